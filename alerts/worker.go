@@ -0,0 +1,299 @@
+// Package alerts re-evaluates saved searches against newly added documents
+// and delivers the matches by webhook.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+	"legal-documents-api/search"
+)
+
+const (
+	// lookback widens every run's window so documents indexed after the
+	// previous run started are not missed; duplicates are dropped by the
+	// unique (saved_search_id, document_id) index
+	lookback = 30 * time.Minute
+	// webhookBatchSize caps how many matches one webhook call carries
+	webhookBatchSize = 100
+)
+
+// Worker periodically evaluates every active saved search
+type Worker struct {
+	client *mongo.Client
+	engine search.SearchEngine
+	http   *http.Client
+}
+
+// NewWorker creates a worker that searches with engine
+func NewWorker(client *mongo.Client, engine search.SearchEngine) *Worker {
+	return &Worker{
+		client: client,
+		engine: engine,
+		http:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Start runs the worker every interval in the background
+func (w *Worker) Start(interval time.Duration) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := EnsureIndexes(ctx, w.client); err != nil {
+			log.Printf("Warning: Failed to create saved search indexes: %v", err)
+		}
+		cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := w.RunOnce(ctx); err != nil {
+				log.Printf("Warning: Saved search run failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// EnsureIndexes creates the indexes the saved search collections rely on
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetSavedSearchMatchesCollection(client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "saved_search_id", Value: 1}, {Key: "document_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "saved_search_id", Value: 1}, {Key: "acknowledged", Value: 1}, {Key: "matched_at", Value: 1}}},
+	})
+	return err
+}
+
+// RunOnce evaluates every active saved search once
+func (w *Worker) RunOnce(ctx context.Context) error {
+	if !w.engine.Ready() {
+		return nil // try again once the index is built
+	}
+
+	cursor, err := config.GetSavedSearchesCollection(w.client).Find(ctx, bson.M{"active": true})
+	if err != nil {
+		return err
+	}
+	var savedSearches []models.SavedSearch
+	if err := cursor.All(ctx, &savedSearches); err != nil {
+		return err
+	}
+
+	for _, saved := range savedSearches {
+		if err := w.run(ctx, saved); err != nil {
+			log.Printf("Warning: Saved search %s (%s) failed: %v", saved.ID.Hex(), saved.Name, err)
+		}
+	}
+	return nil
+}
+
+// run evaluates one saved search, records its new matches and delivers any
+// matches its webhook has not accepted yet
+func (w *Worker) run(ctx context.Context, saved models.SavedSearch) error {
+	startedAt := time.Now().UTC()
+	since := saved.CreatedAt
+	if saved.LastRunAt != nil {
+		since = *saved.LastRunAt
+	}
+	since = since.Add(-lookback)
+
+	matches, err := Evaluate(ctx, w.engine, saved, since)
+	if err != nil {
+		return err
+	}
+	if err := w.record(ctx, matches); err != nil {
+		return err
+	}
+	if saved.WebhookURL != "" {
+		if err := w.deliver(ctx, saved); err != nil {
+			// Undelivered matches are retried on the next run
+			log.Printf("Warning: Webhook for saved search %s failed: %v", saved.ID.Hex(), err)
+		}
+	}
+
+	_, err = config.GetSavedSearchesCollection(w.client).UpdateOne(ctx,
+		bson.M{"_id": saved.ID},
+		bson.M{"$set": bson.M{"last_run_at": startedAt}},
+	)
+	return err
+}
+
+// Evaluate runs a saved search and returns the matching documents published
+// after since. Documents reviewed before publication count from their
+// approval, not their upload.
+func Evaluate(ctx context.Context, engine search.SearchEngine, saved models.SavedSearch, since time.Time) ([]models.SavedSearchMatch, error) {
+	node, err := search.ParseQuery(saved.Query)
+	if err != nil {
+		return nil, err
+	}
+	node = search.ExpandSynonyms(node)
+	filters, err := search.FiltersFromMap(saved.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := engine.Search(ctx, search.Query{Node: node, Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var matches []models.SavedSearchMatch
+	for _, hit := range results.Hits {
+		metadata := hit.Document.Metadata
+		if !publishedAt(metadata).After(since) {
+			continue
+		}
+		matches = append(matches, models.SavedSearchMatch{
+			SavedSearchID:    saved.ID,
+			DocumentID:       metadata.ID,
+			PdfAdi:           metadata.PdfAdi,
+			KurumID:          metadata.KurumID,
+			BelgeTuru:        metadata.BelgeTuru,
+			BelgeYayinTarihi: metadata.BelgeYayinTarihi,
+			URLSlug:          metadata.URLSlug,
+			Score:            hit.Score,
+			MatchedAt:        now,
+		})
+	}
+	return matches, nil
+}
+
+// publishedAt returns when a document became aktif, or its insertion time,
+// which ObjectIDs carry, for documents published before that was recorded
+func publishedAt(metadata models.DocumentMetadata) time.Time {
+	if metadata.PublishedAt != nil {
+		return *metadata.PublishedAt
+	}
+	return metadata.ID.Timestamp()
+}
+
+// record stores new matches, ignoring those recorded by an earlier run
+func (w *Worker) record(ctx context.Context, matches []models.SavedSearchMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(matches))
+	for i := range matches {
+		docs[i] = matches[i]
+	}
+	_, err := config.GetSavedSearchMatchesCollection(w.client).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeyErrors(err) {
+		return err
+	}
+	return nil
+}
+
+func onlyDuplicateKeyErrors(err error) bool {
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookPayload is the JSON body POSTed to a saved search's webhook
+type WebhookPayload struct {
+	SavedSearchID string                    `json:"saved_search_id"`
+	Name          string                    `json:"name"`
+	Query         string                    `json:"query"`
+	Matches       []models.SavedSearchMatch `json:"matches"`
+	SentAt        time.Time                 `json:"sent_at"`
+}
+
+// deliver POSTs undelivered matches to the saved search's webhook in batches
+// and marks them delivered once the webhook answers with a 2xx status
+func (w *Worker) deliver(ctx context.Context, saved models.SavedSearch) error {
+	collection := config.GetSavedSearchMatchesCollection(w.client)
+	for {
+		findOptions := options.Find().SetSort(bson.M{"matched_at": 1}).SetLimit(webhookBatchSize)
+		cursor, err := collection.Find(ctx, bson.M{"saved_search_id": saved.ID, "delivered_at": bson.M{"$exists": false}}, findOptions)
+		if err != nil {
+			return err
+		}
+		var pending []models.SavedSearchMatch
+		if err := cursor.All(ctx, &pending); err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if err := w.post(ctx, saved, pending); err != nil {
+			return err
+		}
+
+		ids := make([]interface{}, len(pending))
+		for i, match := range pending {
+			ids[i] = match.ID
+		}
+		if _, err := collection.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": ids}},
+			bson.M{"$set": bson.M{"delivered_at": time.Now().UTC()}},
+		); err != nil {
+			return err
+		}
+		if len(pending) < webhookBatchSize {
+			return nil
+		}
+	}
+}
+
+// post sends one webhook request. When the saved search has a secret the body
+// is signed with HMAC-SHA256 in the X-Signature-256 header.
+func (w *Worker) post(ctx context.Context, saved models.SavedSearch, matches []models.SavedSearchMatch) error {
+	body, err := json.Marshal(WebhookPayload{
+		SavedSearchID: saved.ID.Hex(),
+		Name:          saved.Name,
+		Query:         saved.Query,
+		Matches:       matches,
+		SentAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, saved.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Legal-Documents-API/1.0 (+saved-search)")
+	if saved.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(saved.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
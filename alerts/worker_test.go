@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"legal-documents-api/models"
+	"legal-documents-api/search"
+)
+
+func TestEvaluate(t *testing.T) {
+	since := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	before, after := since.Add(-time.Hour), since.Add(time.Hour)
+
+	document := func(title, kurumID string, created time.Time, published *time.Time) search.Document {
+		return search.Document{Metadata: models.DocumentMetadata{
+			ID:          primitive.NewObjectIDFromTimestamp(created),
+			PdfAdi:      title,
+			KurumID:     kurumID,
+			PublishedAt: published,
+		}}
+	}
+	idx := search.NewIndex()
+	idx.Index(
+		document("Prim teşviki genelgesi", "sgk", after, nil),
+		document("Prim borçları duyurusu", "sgk", before, nil),
+		// Uploaded earlier, but only approved after since
+		document("Prim affı taslağı", "sgk", before, &after),
+		// Uploaded after since, but published before: not new
+		document("Prim oranları", "sgk", after, &before),
+		document("Prim ödeme süresi", "gib", after, nil),
+		document("Vergi usul genelgesi", "sgk", after, nil),
+	)
+	idx.MarkReady()
+
+	titles := func(saved models.SavedSearch) []string {
+		t.Helper()
+		matches, err := Evaluate(context.Background(), idx, saved, since)
+		if err != nil {
+			t.Fatalf("Evaluate(%q) failed: %v", saved.Query, err)
+		}
+		var titles []string
+		for _, m := range matches {
+			if m.SavedSearchID != saved.ID {
+				t.Errorf("match for %s, want %s", m.SavedSearchID.Hex(), saved.ID.Hex())
+			}
+			titles = append(titles, m.PdfAdi)
+		}
+		sort.Strings(titles)
+		return titles
+	}
+
+	saved := models.SavedSearch{ID: primitive.NewObjectID(), Query: "prim"}
+	if got, want := titles(saved), []string{"Prim affı taslağı", "Prim teşviki genelgesi", "Prim ödeme süresi"}; !equalStrings(got, want) {
+		t.Errorf("new matches = %q, want %q", got, want)
+	}
+	saved.Filters = map[string][]string{"kurum_id": {"gib"}}
+	if got, want := titles(saved), []string{"Prim ödeme süresi"}; !equalStrings(got, want) {
+		t.Errorf("new matches in gib = %q, want %q", got, want)
+	}
+
+	for _, bad := range []models.SavedSearch{
+		{Query: `"prim`},
+		{Query: "prim", Filters: map[string][]string{"renk": {"mavi"}}},
+	} {
+		if _, err := Evaluate(context.Background(), idx, bad, since); err == nil {
+			t.Errorf("Evaluate(%q, %v) succeeded, want an error", bad.Query, bad.Filters)
+		}
+	}
+}
+
+func TestOnlyDuplicateKeyErrors(t *testing.T) {
+	duplicate := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 11000}}
+	other := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 121}}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"duplicates only", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate, duplicate}}, true},
+		{"another write error", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate, other}}, false},
+		{"write concern", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate}, WriteConcernError: &mongo.WriteConcernError{}}, false},
+		{"not a bulk write", errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		if got := onlyDuplicateKeyErrors(tt.err); got != tt.want {
+			t.Errorf("%s: onlyDuplicateKeyErrors = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPopularWithPrefix(t *testing.T) {
+	saved := popularCache.queries
+	defer func() { popularCache.queries = saved }()
+
+	found := func(ids ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	// Sorted by normalized text, as RefreshPopularQueries leaves them
+	popularCache.queries = []PopularQuery{
+		{Query: "İş Kanunu", Normalized: "is kanunu", Searches: 40, kurumIDs: found("csgb")},
+		{Query: "iş sağlığı", Normalized: "is sagligi", Searches: 25, kurumIDs: found("csgb", "sgk")},
+		{Query: "işsizlik", Normalized: "issizlik", Searches: 60, kurumIDs: found("iskur")},
+		{Query: "kıdem", Normalized: "kidem", Searches: 90, kurumIDs: found("csgb")},
+		{Query: "kira", Normalized: "kira", Searches: 10, kurumIDs: found("gib")},
+		{Query: "kira stopajı", Normalized: "kira stopaji", Searches: 10, kurumIDs: found("gib")},
+	}
+
+	tests := []struct {
+		prefix, kurumID string
+		limit           int
+		want            []string
+	}{
+		{"is", "", 0, []string{"issizlik", "is kanunu", "is sagligi"}},
+		{"is ", "", 0, []string{"is kanunu", "is sagligi"}},
+		{"is", "", 2, []string{"issizlik", "is kanunu"}},
+		// The institution filter applies before the limit
+		{"is", "sgk", 1, []string{"is sagligi"}},
+		{"is", "csgb", 1, []string{"is kanunu"}},
+		// Equal counts keep their alphabetical order
+		{"ki", "", 0, []string{"kidem", "kira", "kira stopaji"}},
+		{"ki", "gib", 0, []string{"kira", "kira stopaji"}},
+		{"ki", "yok", 0, nil},
+		{"z", "", 0, nil},
+		{"", "", 1, []string{"kidem"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, q := range PopularWithPrefix(tt.prefix, tt.kurumID, tt.limit) {
+			got = append(got, q.Normalized)
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("PopularWithPrefix(%q, %q, %d) = %q, want %q", tt.prefix, tt.kurumID, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestRecorderDropsWhenFull(t *testing.T) {
+	r := NewRecorder(nil, 2) // not started, so nothing drains the buffer
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			if id := r.Record(Event{Type: EventSearch, Query: "kira"}); id.IsZero() {
+				t.Errorf("Record returned no id")
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked on a full buffer")
+	}
+	if len(r.events) != 2 || r.dropped != 3 {
+		t.Errorf("buffered %d and dropped %d events, want 2 and 3", len(r.events), r.dropped)
+	}
+	if e := <-r.events; e.ID.IsZero() || e.CreatedAt.IsZero() {
+		t.Errorf("queued event without an id or time: %+v", e)
+	}
+
+	// A nil recorder still hands out ids
+	var none *Recorder
+	if id := none.Record(Event{Type: EventClick}); id.IsZero() {
+		t.Errorf("nil Recorder returned no id")
+	}
+}
+
+func TestClickThroughRate(t *testing.T) {
+	tests := []struct {
+		withClick, searches int
+		want                float64
+	}{
+		{0, 0, 0},
+		{3, 0, 0},
+		{0, 10, 0},
+		{1, 4, 0.25},
+		{10, 10, 1},
+	}
+	for _, tt := range tests {
+		if got := clickThroughRate(tt.withClick, tt.searches); got != tt.want {
+			t.Errorf("clickThroughRate(%d, %d) = %v, want %v", tt.withClick, tt.searches, got, tt.want)
+		}
+	}
+}
+
+func TestSearchStagesSince(t *testing.T) {
+	since := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	stages := searchStagesSince("search_events", since, bson.M{"result_count": 0})
+	match, _ := stages[0]["$match"].(bson.M)
+	if match == nil {
+		t.Fatalf("first stage = %v, want $match", stages[0])
+	}
+	if match["type"] != EventSearch || match["result_count"] != 0 {
+		t.Errorf("$match = %v", match)
+	}
+	// $last in groupByQuery needs searches oldest first
+	if sort, ok := stages[1]["$sort"]; !ok || sort.(bson.M)["created_at"] != 1 {
+		t.Errorf("second stage = %v, want $sort by created_at", stages[1])
+	}
+	if !*searchAggregateOptions().AllowDiskUse {
+		t.Errorf("search pipelines may not spill to disk")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
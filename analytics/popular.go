@@ -0,0 +1,108 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// popularWindow is how far back searches count towards popularity
+	popularWindow = 30 * 24 * time.Hour
+	// maxPopularQueries caps the cached popular queries
+	maxPopularQueries = 2000
+	// minPopularSearches is how often a query must be searched to be suggested
+	minPopularSearches = 3
+)
+
+// PopularQuery is a frequently searched query that found documents
+type PopularQuery struct {
+	Query      string
+	Normalized string
+	Searches   int
+	kurumIDs   map[string]bool
+}
+
+// FoundIn reports whether searching the query found documents of the
+// institution kurumID
+func (q PopularQuery) FoundIn(kurumID string) bool {
+	return q.kurumIDs[kurumID]
+}
+
+// popularCache holds popular queries sorted by normalized text for prefix lookups
+var popularCache struct {
+	mu      sync.RWMutex
+	queries []PopularQuery
+}
+
+// RefreshPopularQueries reloads the popular query cache from recent searches
+func RefreshPopularQueries(ctx context.Context, client *mongo.Client) error {
+	stats, err := TopQueries(ctx, client, time.Now().Add(-popularWindow), maxPopularQueries)
+	if err != nil {
+		return err
+	}
+
+	queries := make([]PopularQuery, 0, len(stats))
+	for _, s := range stats {
+		// Queries that never found anything make poor suggestions
+		if s.Normalized == "" || s.Searches < minPopularSearches || s.AvgResults == 0 {
+			continue
+		}
+		kurumIDs := make(map[string]bool, len(s.KurumIDs))
+		for _, id := range s.KurumIDs {
+			kurumIDs[id] = true
+		}
+		queries = append(queries, PopularQuery{Query: s.Query, Normalized: s.Normalized, Searches: s.Searches, kurumIDs: kurumIDs})
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Normalized < queries[j].Normalized })
+
+	popularCache.mu.Lock()
+	popularCache.queries = queries
+	popularCache.mu.Unlock()
+	return nil
+}
+
+// StartPopularRefresher keeps the popular query cache up to date
+func StartPopularRefresher(client *mongo.Client, interval time.Duration) {
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := RefreshPopularQueries(ctx, client); err != nil {
+				log.Printf("Warning: Failed to refresh popular queries: %v", err)
+			}
+			cancel()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// PopularWithPrefix returns cached popular queries whose normalized form
+// starts with prefix, most searched first. With kurumID set only queries that
+// found documents of that institution are returned; the limit applies after
+// this filter.
+func PopularWithPrefix(prefix, kurumID string, limit int) []PopularQuery {
+	popularCache.mu.RLock()
+	defer popularCache.mu.RUnlock()
+
+	queries := popularCache.queries
+	var matches []PopularQuery
+	for i := sort.Search(len(queries), func(i int) bool { return queries[i].Normalized >= prefix }); i < len(queries); i++ {
+		if !strings.HasPrefix(queries[i].Normalized, prefix) {
+			break
+		}
+		if kurumID != "" && !queries[i].FoundIn(kurumID) {
+			continue
+		}
+		matches = append(matches, queries[i])
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Searches > matches[j].Searches })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,154 @@
+// Package analytics records search activity and reports on it
+package analytics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+)
+
+// EventType distinguishes the kinds of recorded events
+type EventType string
+
+const (
+	EventSearch       EventType = "search"
+	EventAutocomplete EventType = "autocomplete"
+	EventClick        EventType = "click"
+)
+
+const (
+	// flushSize and flushInterval bound how long events wait in memory
+	flushSize     = 200
+	flushInterval = 2 * time.Second
+)
+
+// Event is a single search, autocomplete request or result click
+type Event struct {
+	ID          primitive.ObjectID  `bson:"_id"`
+	Type        EventType           `bson:"type"`
+	Query       string              `bson:"query,omitempty"`
+	Normalized  string              `bson:"normalized,omitempty"` // textnorm.Normalize(Query), the grouping key
+	Filters     map[string]string   `bson:"filters,omitempty"`
+	ResultCount int                 `bson:"result_count"`
+	KurumIDs    []string            `bson:"kurum_ids,omitempty"` // searches: institutions of the documents found
+	LatencyMs   float64             `bson:"latency_ms,omitempty"`
+	SearchID    *primitive.ObjectID `bson:"search_id,omitempty"` // clicks: the search the result came from
+	Slug        string              `bson:"slug,omitempty"`
+	Position    int                 `bson:"position,omitempty"` // clicks: 1-based rank of the clicked result
+	CreatedAt   time.Time           `bson:"created_at"`
+}
+
+// Recorder persists events asynchronously so request handlers never wait on
+// MongoDB. Events are dropped, not queued without bound, when the buffer is full.
+type Recorder struct {
+	client  *mongo.Client
+	events  chan Event
+	dropped uint64
+}
+
+// NewRecorder creates a recorder buffering up to bufferSize events
+func NewRecorder(client *mongo.Client, bufferSize int) *Recorder {
+	return &Recorder{
+		client: client,
+		events: make(chan Event, bufferSize),
+	}
+}
+
+// Record queues an event and returns its id. A nil recorder ignores events.
+func (r *Recorder) Record(e Event) primitive.ObjectID {
+	if e.ID.IsZero() {
+		e.ID = primitive.NewObjectID()
+	}
+	if r == nil {
+		return e.ID
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	select {
+	case r.events <- e:
+	default:
+		if n := atomic.AddUint64(&r.dropped, 1); n%1000 == 1 {
+			log.Printf("Warning: Analytics buffer full, %d events dropped so far", n)
+		}
+	}
+	return e.ID
+}
+
+// Start creates the collection indexes, with events expiring after retention,
+// and writes queued events in the background
+func (r *Recorder) Start(retention time.Duration) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := EnsureIndexes(ctx, r.client, retention); err != nil {
+			log.Printf("Warning: Failed to create analytics indexes: %v", err)
+		}
+		cancel()
+
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		batch := make([]interface{}, 0, flushSize)
+		for {
+			select {
+			case e := <-r.events:
+				batch = append(batch, e)
+				if len(batch) < flushSize {
+					continue
+				}
+			case <-ticker.C:
+				if len(batch) == 0 {
+					continue
+				}
+			}
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}()
+}
+
+func (r *Recorder) flush(batch []interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := config.GetSearchEventsCollection(r.client).InsertMany(ctx, batch, options.InsertMany().SetOrdered(false)); err != nil {
+		log.Printf("Warning: Failed to store %d analytics events: %v", len(batch), err)
+	}
+}
+
+// EnsureIndexes creates the report indexes and the TTL index that enforces
+// retention. An existing TTL index with another lifetime is updated in place.
+func EnsureIndexes(ctx context.Context, client *mongo.Client, retention time.Duration) error {
+	collection := config.GetSearchEventsCollection(client)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "search_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return err
+	}
+
+	seconds := int32(retention / time.Second)
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(seconds),
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == 85 || cmdErr.Code == 86) { // IndexOptionsConflict / IndexKeySpecsConflict
+		return collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "keyPattern", Value: bson.D{{Key: "created_at", Value: 1}}},
+				{Key: "expireAfterSeconds", Value: seconds},
+			}},
+		}).Err()
+	}
+	return err
+}
@@ -0,0 +1,183 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+)
+
+// QueryStats summarises every search for one normalized query
+type QueryStats struct {
+	Query             string    `bson:"query" json:"query"` // most recent spelling
+	Normalized        string    `bson:"_id" json:"normalized"`
+	Searches          int       `bson:"searches" json:"searches"`
+	AvgResults        float64   `bson:"avg_results" json:"avg_results"`
+	Clicks            int       `bson:"clicks" json:"clicks"`
+	SearchesWithClick int       `bson:"searches_with_click" json:"searches_with_click"`
+	CTR               float64   `bson:"-" json:"ctr"` // share of searches followed by at least one click
+	LastSeen          time.Time `bson:"last_seen" json:"last_seen"`
+	KurumIDs          []string  `bson:"kurum_ids" json:"-"` // institutions any of the searches found documents in
+}
+
+// ClickThroughReport is the overall click-through rate over a period
+type ClickThroughReport struct {
+	Since              time.Time `json:"since"`
+	Searches           int       `json:"searches"`
+	SearchesWithClick  int       `json:"searches_with_click"`
+	ZeroResultSearches int       `json:"zero_result_searches"`
+	Clicks             int       `json:"clicks"`
+	CTR                float64   `json:"ctr"`
+	AvgClickPosition   float64   `json:"avg_click_position"`
+}
+
+// searchStagesSince returns the pipeline stages selecting searches after since,
+// oldest first, each annotated with the number of clicks that followed it
+func searchStagesSince(collectionName string, since time.Time, extra bson.M) []bson.M {
+	match := bson.M{"type": EventSearch, "created_at": bson.M{"$gte": since}}
+	for key, value := range extra {
+		match[key] = value
+	}
+	return []bson.M{
+		{"$match": match},
+		// Oldest first, so $last in groupByQuery picks the latest spelling
+		{"$sort": bson.M{"created_at": 1}},
+		{"$lookup": bson.M{
+			"from":         collectionName,
+			"localField":   "_id",
+			"foreignField": "search_id",
+			"as":           "click_events",
+		}},
+		{"$addFields": bson.M{"clicks": bson.M{"$size": "$click_events"}}},
+	}
+}
+
+// searchAggregateOptions lets the pipelines of searchStagesSince, which sort
+// and join every search in the window, spill to disk past the 100 MB limit
+// on in-memory stages
+func searchAggregateOptions() *options.AggregateOptions {
+	return options.Aggregate().SetAllowDiskUse(true)
+}
+
+// groupByQuery is the $group stage building QueryStats
+var groupByQuery = bson.M{"$group": bson.M{
+	"_id":                 "$normalized",
+	"query":               bson.M{"$last": "$query"},
+	"searches":            bson.M{"$sum": 1},
+	"avg_results":         bson.M{"$avg": "$result_count"},
+	"clicks":              bson.M{"$sum": "$clicks"},
+	"searches_with_click": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$clicks", 0}}, 1, 0}}},
+	"last_seen":           bson.M{"$max": "$created_at"},
+	"kurum_ids":           bson.M{"$push": bson.M{"$ifNull": bson.A{"$kurum_ids", bson.A{}}}},
+}}
+
+// mergeKurumIDs flattens the institution lists grouped by groupByQuery
+var mergeKurumIDs = bson.M{"$addFields": bson.M{"kurum_ids": bson.M{"$reduce": bson.M{
+	"input":        "$kurum_ids",
+	"initialValue": bson.A{},
+	"in":           bson.M{"$setUnion": bson.A{"$$value", "$$this"}},
+}}}}
+
+// TopQueries returns the most frequent queries since the given time
+func TopQueries(ctx context.Context, client *mongo.Client, since time.Time, limit int) ([]QueryStats, error) {
+	return queryStats(ctx, client, since, nil, limit)
+}
+
+// ZeroResultQueries returns the most frequent queries that found nothing
+func ZeroResultQueries(ctx context.Context, client *mongo.Client, since time.Time, limit int) ([]QueryStats, error) {
+	return queryStats(ctx, client, since, bson.M{"result_count": 0}, limit)
+}
+
+func queryStats(ctx context.Context, client *mongo.Client, since time.Time, extra bson.M, limit int) ([]QueryStats, error) {
+	collection := config.GetSearchEventsCollection(client)
+	pipeline := searchStagesSince(collection.Name(), since, extra)
+	pipeline = append(pipeline,
+		groupByQuery,
+		bson.M{"$sort": bson.D{{Key: "searches", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": limit},
+		mergeKurumIDs,
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline, searchAggregateOptions())
+	if err != nil {
+		return nil, err
+	}
+	stats := []QueryStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].CTR = clickThroughRate(stats[i].SearchesWithClick, stats[i].Searches)
+	}
+	return stats, nil
+}
+
+// ClickThrough computes the overall click-through rate since the given time
+func ClickThrough(ctx context.Context, client *mongo.Client, since time.Time) (ClickThroughReport, error) {
+	report := ClickThroughReport{Since: since}
+	collection := config.GetSearchEventsCollection(client)
+
+	pipeline := searchStagesSince(collection.Name(), since, nil)
+	pipeline = append(pipeline, bson.M{"$group": bson.M{
+		"_id":                  nil,
+		"searches":             bson.M{"$sum": 1},
+		"searches_with_click":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$clicks", 0}}, 1, 0}}},
+		"zero_result_searches": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$result_count", 0}}, 1, 0}}},
+	}})
+	var totals []struct {
+		Searches           int `bson:"searches"`
+		SearchesWithClick  int `bson:"searches_with_click"`
+		ZeroResultSearches int `bson:"zero_result_searches"`
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, searchAggregateOptions())
+	if err != nil {
+		return report, err
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return report, err
+	}
+	if len(totals) > 0 {
+		report.Searches = totals[0].Searches
+		report.SearchesWithClick = totals[0].SearchesWithClick
+		report.ZeroResultSearches = totals[0].ZeroResultSearches
+	}
+
+	var clicks []struct {
+		Clicks      int     `bson:"clicks"`
+		AvgPosition float64 `bson:"avg_position"`
+	}
+	cursor, err = collection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"type": EventClick, "created_at": bson.M{"$gte": since}}},
+		{"$group": bson.M{
+			"_id":          nil,
+			"clicks":       bson.M{"$sum": 1},
+			"avg_position": bson.M{"$avg": "$position"},
+		}},
+	})
+	if err != nil {
+		return report, err
+	}
+	if err := cursor.All(ctx, &clicks); err != nil {
+		return report, err
+	}
+	if len(clicks) > 0 {
+		report.Clicks = clicks[0].Clicks
+		report.AvgClickPosition = clicks[0].AvgPosition
+	}
+
+	report.CTR = clickThroughRate(report.SearchesWithClick, report.Searches)
+	return report, nil
+}
+
+// clickThroughRate is the share of searches followed by a click, 0 without
+// searches
+func clickThroughRate(searchesWithClick, searches int) float64 {
+	if searches <= 0 {
+		return 0
+	}
+	return float64(searchesWithClick) / float64(searches)
+}
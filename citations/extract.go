@@ -0,0 +1,186 @@
+// Package citations finds references to other legislation in document
+// content and resolves them to documents, building the citation graph
+package citations
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"legal-documents-api/textnorm"
+)
+
+// Version is bumped whenever extraction changes, so documents scanned by an
+// older extractor are scanned again
+const Version = 1
+
+// Reference kinds
+const (
+	KindKanun      = "kanun"
+	KindKHK        = "khk" // Kanun Hükmünde Kararname
+	KindCBK        = "cbk" // Cumhurbaşkanlığı Kararnamesi
+	KindKarar      = "karar"
+	KindYonetmelik = "yonetmelik"
+	KindTeblig     = "teblig"
+	KindGenelge    = "genelge"
+)
+
+// legislationKind describes how a kind of legislation is written
+type legislationKind struct {
+	kind     string
+	stem     string // pattern matched with any suffix: "Kanunun", "Yönetmeliğin"
+	prefix   string // literal start of every match of stem
+	bare     string // after a number: "5510 sayılı Kanun"
+	compound string // after a name: "İş Kanunu"
+}
+
+// legislationKinds are tried in order, so longer names win over their prefixes
+var legislationKinds = []legislationKind{
+	{KindKHK, "Kanun Hükmünde Kararname", "Kanun Hükmünde Kararname", "Kanun Hükmünde Kararname", "Kanun Hükmünde Kararname"},
+	{KindCBK, "Cumhurbaşkanlığı Kararname", "Cumhurbaşkanlığı Kararname", "Cumhurbaşkanlığı Kararnamesi", "Cumhurbaşkanlığı Kararnamesi"},
+	{KindKarar, "Cumhurbaşkanı Karar", "Cumhurbaşkanı Karar", "Cumhurbaşkanı Kararı", "Cumhurbaşkanı Kararı"},
+	{KindKarar, "Bakanlar Kurulu Karar", "Bakanlar Kurulu Karar", "Bakanlar Kurulu Kararı", "Bakanlar Kurulu Kararı"},
+	{KindKanun, "Kanun", "Kanun", "Kanun", "Kanunu"},
+	{KindYonetmelik, "Yönetmeli[kğ]", "Yönetmeli", "Yönetmelik", "Yönetmeliği"},
+	{KindTeblig, "Tebliğ", "Tebliğ", "Tebliğ", "Tebliği"}, // not "Tebligat"
+	{KindGenelge, "Genelge", "Genelge", "Genelge", "Genelgesi"},
+}
+
+var (
+	kindPattern = func() string {
+		stems := make([]string, len(legislationKinds))
+		for i, k := range legislationKinds {
+			stems[i] = k.stem
+		}
+		return `(` + strings.Join(stems, "|") + `)\p{L}*(?:['’]\p{L}+)?`
+	}()
+	// articleSuffix matches "... 12 nci maddesi", "4 üncü maddesinin",
+	// "geçici 3 üncü maddesi" and "5/A maddesi" after the legislation
+	articleSuffix = `(?:\s+(?:(geçici|ek)\s+)?(\d{1,4}(?:\s*/\s*\p{Lu})?)\s*(?:['’]?\s*[ıiuü]?nc[ıiuü])?\s+madde)?`
+	// nameWords are capitalised words joined by the conjunctions used in titles
+	nameWords = `((?:\p{Lu}[\p{L}'’-]*\s+(?:(?:ve|ile|veya|ilâ)\s+)?){0,14}?)`
+
+	numberedPattern = regexp.MustCompile(`(\d{1,5}(?:/\d{1,5})?)\s+[sS]ayılı\s+` + nameWords + kindPattern + articleSuffix)
+	namedPattern    = regexp.MustCompile(`(?:^|[^\p{L}\d'’])` + strings.Replace(nameWords, "{0,14}", "{2,14}", 1) + kindPattern + articleSuffix)
+)
+
+// selfWords start references to the citing document itself ("Bu Yönetmeliğin")
+var selfWords = map[string]bool{"bu": true, "isbu": true, "ayni": true, "anilan": true, "soz": true}
+
+// Reference is a citation of other legislation found in a text
+type Reference struct {
+	Kind   string
+	Number string // "5510", "2020/1"; empty when cited by name only
+	Name   string // full name when written out: "İş Sağlığı ve Güvenliği Yönetmeliği"
+	Madde  string // cited article, if any: "12", "5/A", "geçici 3"
+	Text   string // canonical form: "5510 sayılı Kanun", "İş Kanunu m. 18"
+	Count  int    // occurrences in the text
+	Offset int    // byte offset of the first occurrence
+}
+
+// key identifies a reference regardless of how it was inflected
+func (r *Reference) key() string {
+	return r.Kind + "|" + r.Number + "|" + textnorm.Normalize(r.Name) + "|" + textnorm.Normalize(r.Madde)
+}
+
+// Extract finds the references to other legislation in text: numbered ones
+// ("5510 sayılı Kanunun 4 üncü maddesi") and ones by name ("İş Sağlığı ve
+// Güvenliği Yönetmeliğinin 12 nci maddesi"). References to the text itself
+// ("Bu Yönetmeliğin") are skipped. Repeated references are counted once.
+func Extract(text string) []Reference {
+	found := make(map[string]*Reference)
+	var covered [][2]int // byte ranges of numbered references
+
+	add := func(ref Reference, kind legislationKind, start int) {
+		ref.Text = canonicalText(ref, kind)
+		key := ref.key()
+		if existing, ok := found[key]; ok {
+			existing.Count++
+			return
+		}
+		ref.Count = 1
+		ref.Offset = start
+		found[key] = &ref
+	}
+
+	for _, m := range numberedPattern.FindAllStringSubmatchIndex(text, -1) {
+		covered = append(covered, [2]int{m[0], m[1]})
+		kind := kindOf(text[m[6]:m[7]])
+		ref := Reference{Kind: kind.kind, Number: text[m[2]:m[3]], Madde: articleOf(text, m[8:12])}
+		if name := strings.TrimSpace(text[m[4]:m[5]]); name != "" {
+			ref.Name = name + " " + kind.compound
+		}
+		add(ref, kind, m[0])
+	}
+
+	for _, m := range namedPattern.FindAllStringSubmatchIndex(text, -1) {
+		start := m[2]
+		if overlaps(covered, start, m[1]) {
+			continue
+		}
+		words := strings.Fields(text[m[2]:m[3]])
+		if selfWords[textnorm.Normalize(words[0])] {
+			continue
+		}
+		kind := kindOf(text[m[4]:m[5]])
+		add(Reference{
+			Kind:  kind.kind,
+			Name:  strings.Join(words, " ") + " " + kind.compound,
+			Madde: articleOf(text, m[6:10]),
+		}, kind, start)
+	}
+
+	refs := make([]Reference, 0, len(found))
+	for _, ref := range found {
+		refs = append(refs, *ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Offset < refs[j].Offset })
+	return refs
+}
+
+// kindOf returns the legislation kind whose stem starts the matched word
+func kindOf(word string) legislationKind {
+	for _, k := range legislationKinds {
+		if strings.HasPrefix(word, k.prefix) {
+			return k
+		}
+	}
+	return legislationKinds[len(legislationKinds)-1]
+}
+
+// articleOf formats the optional article groups (kind, number) of a match
+func articleOf(text string, groups []int) string {
+	if groups[2] < 0 {
+		return ""
+	}
+	number := strings.Join(strings.Fields(text[groups[2]:groups[3]]), "")
+	if groups[0] >= 0 {
+		return text[groups[0]:groups[1]] + " " + number
+	}
+	return number
+}
+
+// canonicalText writes a reference the way it is cited in the nominative
+func canonicalText(ref Reference, kind legislationKind) string {
+	var b strings.Builder
+	if ref.Number != "" {
+		b.WriteString(ref.Number + " sayılı ")
+		if ref.Name == "" {
+			b.WriteString(kind.bare)
+		}
+	}
+	b.WriteString(ref.Name)
+	if ref.Madde != "" {
+		b.WriteString(" m. " + ref.Madde)
+	}
+	return b.String()
+}
+
+func overlaps(ranges [][2]int, start, end int) bool {
+	for _, r := range ranges {
+		if start < r[1] && end > r[0] {
+			return true
+		}
+	}
+	return false
+}
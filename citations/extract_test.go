@@ -0,0 +1,86 @@
+package citations
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// summary writes the kind, canonical text and count of each reference
+func summary(refs []Reference) string {
+	parts := make([]string, len(refs))
+	for i, r := range refs {
+		parts[i] = fmt.Sprintf("%s:%s×%d", r.Kind, r.Text, r.Count)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			"numbered law with article",
+			"5510 sayılı Kanunun 4 üncü maddesinin birinci fıkrası uyarınca",
+			"kanun:5510 sayılı Kanun m. 4×1",
+		},
+		{
+			"numbered law with its name",
+			"5510 sayılı Sosyal Sigortalar ve Genel Sağlık Sigortası Kanununun 88 inci maddesi",
+			"kanun:5510 sayılı Sosyal Sigortalar ve Genel Sağlık Sigortası Kanunu m. 88×1",
+		},
+		{
+			"temporary and lettered articles",
+			"4857 sayılı Kanunun geçici 3 üncü maddesi ile 193 sayılı Kanunun 5/A maddesi",
+			"kanun:4857 sayılı Kanun m. geçici 3×1; kanun:193 sayılı Kanun m. 5/A×1",
+		},
+		{
+			"regulation by name",
+			"İş Sağlığı ve Güvenliği Yönetmeliğinin 12 nci maddesine göre",
+			"yonetmelik:İş Sağlığı ve Güvenliği Yönetmeliği m. 12×1",
+		},
+		{
+			"decree kinds win over Kanun",
+			"375 sayılı Kanun Hükmünde Kararnamenin ve 1 sayılı Cumhurbaşkanlığı Kararnamesinin",
+			"khk:375 sayılı Kanun Hükmünde Kararname×1; cbk:1 sayılı Cumhurbaşkanlığı Kararnamesi×1",
+		},
+		{
+			"repeated in other inflections",
+			"5510 sayılı Kanunda ve 5510 sayılı Kanuna göre; 5510 sayılı Kanun'un",
+			"kanun:5510 sayılı Kanun×3",
+		},
+		{
+			"references to the text itself",
+			"Bu Yönetmeliğin 3 üncü maddesi ve İşbu Tebliğin hükümleri",
+			"",
+		},
+		{
+			"tebligat is not a communiqué",
+			"Tebligat Kanunu hükümlerine göre yapılan tebligat",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		if got := summary(Extract(tt.text)); got != tt.want {
+			t.Errorf("%s: Extract = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractOffsets(t *testing.T) {
+	const text = "Gelir Vergisi Kanunu ile 213 sayılı Kanun arasında"
+	refs := Extract(text)
+	if len(refs) != 2 {
+		t.Fatalf("Extract = %q, want two references", summary(refs))
+	}
+	for _, r := range refs {
+		if !strings.HasPrefix(text[r.Offset:], strings.Fields(r.Text)[0]) {
+			t.Errorf("%s: offset %d points at %q", r.Text, r.Offset, text[r.Offset:])
+		}
+	}
+	if refs[0].Name != "Gelir Vergisi Kanunu" || refs[1].Number != "213" {
+		t.Errorf("Extract = %q, want the named law before the numbered one", summary(refs))
+	}
+}
@@ -0,0 +1,251 @@
+package citations
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+// scanBatchSize is how many content documents are scanned before their
+// citations are written
+const scanBatchSize = 200
+
+// EnsureIndexes creates the citations indexes used by both directions of the graph
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetCitationsCollection(client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "source_id", Value: 1}}},
+		{Keys: bson.D{{Key: "target_id", Value: 1}}},
+	})
+	return err
+}
+
+// LoadResolver builds a resolver from the titles of active documents and the
+// numbers that already resolved in stored citations
+func LoadResolver(ctx context.Context, client *mongo.Client) (*Resolver, error) {
+	resolver := NewResolver()
+
+	cursor, err := config.GetMetadataCollection(client).Find(ctx, bson.M{"status": editorial.VisibleFilter()},
+		options.Find().SetProjection(bson.M{"_id": 1, "pdf_adi": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var metadata models.DocumentMetadata
+		if err := cursor.Decode(&metadata); err != nil {
+			continue
+		}
+		resolver.AddDocument(metadata.ID, metadata.PdfAdi)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	edges, err := config.GetCitationsCollection(client).Find(ctx,
+		bson.M{"number": bson.M{"$exists": true, "$ne": ""}, "target_id": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"kind": 1, "number": 1, "target_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer edges.Close(ctx)
+	for edges.Next(ctx) {
+		var citation models.Citation
+		if err := edges.Decode(&citation); err != nil || citation.TargetID == nil {
+			continue
+		}
+		resolver.AddNumber(citation.Kind, citation.Number, *citation.TargetID)
+	}
+	return resolver, edges.Err()
+}
+
+// pendingScan is a scanned document whose citations are not stored yet
+type pendingScan struct {
+	content models.DocumentContent
+	refs    []Reference
+}
+
+// Refresh extracts the citations of content documents not scanned by the
+// current extractor (every document with force set), stores them as edges
+// and retries resolving edges that did not resolve before. Writers that
+// change icerik unset "atiflar" so the document is scanned again.
+func Refresh(ctx context.Context, client *mongo.Client, force bool) error {
+	resolver, err := LoadResolver(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"$or": []bson.M{
+		{"atiflar": bson.M{"$exists": false}},
+		{"atiflar.version": bson.M{"$lt": Version}},
+	}}
+	if force {
+		filter = bson.M{}
+	}
+	cursor, err := config.GetContentCollection(client).Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"metadata_id": 1, "icerik": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	scanned, stored := 0, 0
+	var batch []pendingScan
+	flush := func() error {
+		// Learn numbers from the whole batch before resolving any of it
+		for _, scan := range batch {
+			for _, ref := range scan.refs {
+				resolver.Learn(ref)
+			}
+		}
+		for _, scan := range batch {
+			n, err := storeCitations(ctx, client, resolver, scan)
+			if err != nil {
+				return err
+			}
+			stored += n
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var content models.DocumentContent
+		if err := cursor.Decode(&content); err != nil {
+			continue
+		}
+		scanned++
+		batch = append(batch, pendingScan{content: content, refs: Extract(content.Icerik)})
+		if len(batch) >= scanBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	resolved, err := resolveStored(ctx, client, resolver)
+	if err != nil {
+		return err
+	}
+	if scanned > 0 || resolved > 0 {
+		log.Printf("Citations refreshed: %d documents scanned, %d citations stored, %d newly resolved", scanned, stored, resolved)
+	}
+	return nil
+}
+
+// storeCitations replaces the stored citations of a scanned document
+func storeCitations(ctx context.Context, client *mongo.Client, resolver *Resolver, scan pendingScan) (int, error) {
+	source := scan.content.MetadataID
+	now := time.Now().UTC()
+	var rows []interface{}
+	for _, ref := range scan.refs {
+		citation := models.Citation{
+			SourceID:  source,
+			Kind:      ref.Kind,
+			Number:    ref.Number,
+			Name:      ref.Name,
+			Madde:     ref.Madde,
+			Text:      ref.Text,
+			Count:     ref.Count,
+			CreatedAt: now,
+		}
+		if id, name, ok := resolver.Resolve(ref); ok {
+			if id == source {
+				continue // the document naming itself
+			}
+			target := id
+			citation.TargetID = &target
+			citation.Name = name
+			citation.Text = strings.Replace(citation.Text, ref.Name, name, 1)
+		}
+		rows = append(rows, citation)
+	}
+
+	collection := config.GetCitationsCollection(client)
+	if _, err := collection.DeleteMany(ctx, bson.M{"source_id": source}); err != nil {
+		return 0, err
+	}
+	if len(rows) > 0 {
+		if _, err := collection.InsertMany(ctx, rows); err != nil {
+			return 0, err
+		}
+	}
+
+	scanRecord := models.CitationScan{
+		Version:     Version,
+		ContentHash: utils.ContentHash(scan.content.Icerik),
+		Count:       len(rows),
+		ScannedAt:   now,
+	}
+	_, err := config.GetContentCollection(client).UpdateOne(ctx, bson.M{"_id": scan.content.ID}, bson.M{"$set": bson.M{"atiflar": scanRecord}})
+	return len(rows), err
+}
+
+// resolveStored resolves stored citations that had no target, now that more
+// documents or numbers may be known
+func resolveStored(ctx context.Context, client *mongo.Client, resolver *Resolver) (int, error) {
+	collection := config.GetCitationsCollection(client)
+	cursor, err := collection.Find(ctx, bson.M{"target_id": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var citation models.Citation
+		if err := cursor.Decode(&citation); err != nil {
+			continue
+		}
+		id, name, ok := resolver.Resolve(Reference{Kind: citation.Kind, Number: citation.Number, Name: citation.Name})
+		if !ok || id == citation.SourceID {
+			continue
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": citation.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"target_id": id,
+				"name":      name,
+				"text":      strings.Replace(citation.Text, citation.Name, name, 1),
+			}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	_, err = collection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+	return len(updates), err
+}
+
+// StartRefresher periodically scans new documents and resolves citations in
+// the background
+func StartRefresher(client *mongo.Client, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := Refresh(ctx, client, false); err != nil {
+				log.Printf("Warning: Citation refresh failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
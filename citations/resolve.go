@@ -0,0 +1,126 @@
+package citations
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"legal-documents-api/textnorm"
+)
+
+// maxDroppedWords is how many leading words of a name may be dropped while
+// resolving it, since a capitalised word starting the sentence is easily
+// mistaken for part of the name ("Ayrıca İş Kanunu")
+const maxDroppedWords = 3
+
+// Resolver maps references to the documents they cite: names to documents
+// with that title, numbers to documents whose title or citations give the
+// number. Titles shared by several documents resolve to none of them.
+type Resolver struct {
+	titles  map[string]primitive.ObjectID // normalized title -> document, zero when ambiguous
+	sorted  []string                      // normalized titles, for prefix matches
+	numbers map[string]primitive.ObjectID // kind|number -> document
+}
+
+// NewResolver creates an empty resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+		titles:  make(map[string]primitive.ObjectID),
+		numbers: make(map[string]primitive.ObjectID),
+	}
+}
+
+// AddDocument registers a document that references can resolve to. Titles
+// that start with a number ("5510 sayılı ... Kanunu") register the number too.
+func (r *Resolver) AddDocument(id primitive.ObjectID, title string) {
+	key := textnorm.Normalize(title)
+	if key == "" {
+		return
+	}
+	if _, exists := r.titles[key]; exists {
+		r.titles[key] = primitive.NilObjectID
+	} else {
+		r.titles[key] = id
+		r.sorted = nil
+	}
+	for _, ref := range Extract(title) {
+		if ref.Offset == 0 && ref.Number != "" {
+			r.setNumber(ref, id)
+		}
+	}
+}
+
+// AddNumber registers the document a numbered reference is known to cite
+func (r *Resolver) AddNumber(kind, number string, id primitive.ObjectID) {
+	r.setNumber(Reference{Kind: kind, Number: number}, id)
+}
+
+// Learn registers the number of a reference that also gives the name, so
+// "5510 sayılı Sosyal Sigortalar ve Genel Sağlık Sigortası Kanunu" in one
+// document resolves a bare "5510 sayılı Kanun" in another
+func (r *Resolver) Learn(ref Reference) {
+	if ref.Number == "" || ref.Name == "" {
+		return
+	}
+	if id, _, ok := r.resolveName(ref.Name); ok {
+		r.setNumber(ref, id)
+	}
+}
+
+func (r *Resolver) setNumber(ref Reference, id primitive.ObjectID) {
+	key := ref.Kind + "|" + ref.Number
+	if _, exists := r.numbers[key]; !exists {
+		r.numbers[key] = id
+	}
+}
+
+// Resolve returns the document ref cites and its name with any words that
+// turned out not to belong to it dropped
+func (r *Resolver) Resolve(ref Reference) (primitive.ObjectID, string, bool) {
+	if ref.Name != "" {
+		if id, name, ok := r.resolveName(ref.Name); ok {
+			return id, name, true
+		}
+	}
+	if ref.Number != "" {
+		if id, ok := r.numbers[ref.Kind+"|"+ref.Number]; ok && !id.IsZero() {
+			return id, ref.Name, true
+		}
+	}
+	return primitive.NilObjectID, ref.Name, false
+}
+
+// resolveName matches a name against the titles exactly, then as the start
+// of a single longer title, dropping leading words that do not fit
+func (r *Resolver) resolveName(name string) (primitive.ObjectID, string, bool) {
+	if r.sorted == nil {
+		r.sorted = make([]string, 0, len(r.titles))
+		for title := range r.titles {
+			r.sorted = append(r.sorted, title)
+		}
+		sort.Strings(r.sorted)
+	}
+
+	words := strings.Fields(name)
+	for drop := 0; drop <= maxDroppedWords && len(words)-drop >= 2; drop++ {
+		candidate := strings.Join(words[drop:], " ")
+		key := textnorm.Normalize(candidate)
+		if id, ok := r.titles[key]; ok {
+			if id.IsZero() {
+				return id, name, false // ambiguous
+			}
+			return id, candidate, true
+		}
+
+		prefix := key + " "
+		i := sort.SearchStrings(r.sorted, prefix)
+		if i < len(r.sorted) && strings.HasPrefix(r.sorted[i], prefix) &&
+			(i+1 == len(r.sorted) || !strings.HasPrefix(r.sorted[i+1], prefix)) {
+			if id := r.titles[r.sorted[i]]; !id.IsZero() {
+				return id, candidate, true
+			}
+		}
+	}
+	return primitive.NilObjectID, name, false
+}
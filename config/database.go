@@ -76,3 +76,63 @@ func GetLinksCollection(client *mongo.Client) *mongo.Collection {
         db := GetDatabase(client)
         return db.Collection("links") // Links collection name
 }
+
+// GetSavedSearchesCollection returns the saved_searches collection
+func GetSavedSearchesCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("saved_searches") // Saved searches collection name
+}
+
+// GetSavedSearchMatchesCollection returns the saved_search_matches collection
+func GetSavedSearchMatchesCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("saved_search_matches") // Saved search matches collection name
+}
+
+// GetSearchEventsCollection returns the search_events collection
+func GetSearchEventsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("search_events") // Search analytics collection name
+}
+
+// GetSynonymsCollection returns the synonyms collection
+func GetSynonymsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("synonyms") // Synonym dictionary collection name
+}
+
+// GetContentEmbeddingsCollection returns the content_embeddings collection
+func GetContentEmbeddingsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("content_embeddings") // Semantic search vectors collection name
+}
+
+// GetCitationsCollection returns the citations collection
+func GetCitationsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("citations") // Citation graph edges collection name
+}
+
+// GetDocumentVersionsCollection returns the document_versions collection
+func GetDocumentVersionsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("document_versions") // Document version snapshots collection name
+}
+
+// GetJobsCollection returns the jobs collection
+func GetJobsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("jobs") // Background job queue collection name
+}
+
+// GetStatusHistoryCollection returns the status_history collection
+func GetStatusHistoryCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("status_history") // Editorial audit trail collection name
+}
+
+// GetFingerprintsCollection returns the fingerprints collection
+func GetFingerprintsCollection(client *mongo.Client) *mongo.Collection {
+        db := GetDatabase(client)
+        return db.Collection("fingerprints") // Duplicate detection fingerprints collection name
+}
@@ -0,0 +1,211 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+)
+
+// Policies for new documents that duplicate existing ones
+const (
+	PolicyOff   = "off"   // not checked
+	PolicyWarn  = "warn"  // logged and created
+	PolicyBlock = "block" // refused unless the duplicate is allowed
+)
+
+// policy is the current policy, PolicyWarn until SetPolicy is called
+var policy = PolicyWarn
+
+// SetPolicy sets how Check treats duplicates
+func SetPolicy(p string) error {
+	switch p {
+	case PolicyOff, PolicyWarn, PolicyBlock:
+		policy = p
+		return nil
+	}
+	return fmt.Errorf("unknown duplicate policy %q (supported: off, warn, block)", p)
+}
+
+// Policy returns how Check treats duplicates
+func Policy() string {
+	return policy
+}
+
+type allowKey struct{}
+
+// AllowDuplicates returns a context in which Check lets duplicates through,
+// for documents an editor knowingly stores again
+func AllowDuplicates(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowKey{}, true)
+}
+
+// Allowed reports whether ctx lets duplicates through
+func Allowed(ctx context.Context) bool {
+	allow, _ := ctx.Value(allowKey{}).(bool)
+	return allow
+}
+
+// Options are the similarities two documents are duplicates from
+type Options struct {
+	ContentThreshold float64 `json:"content_threshold"` // content similarity that is a duplicate whatever the titles
+	RelatedThreshold float64 `json:"related_threshold"` // content similarity that is a duplicate when titles are alike
+	TitleThreshold   float64 `json:"title_threshold"`   // title similarity of alike titles
+}
+
+// DefaultOptions are the thresholds used by Check
+var DefaultOptions = Options{ContentThreshold: 0.9, RelatedThreshold: 0.7, TitleThreshold: 0.8}
+
+// duplicate reports whether documents with the given similarities are
+// duplicates. Titles alone never are: amendments and regulations of
+// different institutions often share them.
+func (o Options) duplicate(content, title float64, exact bool) bool {
+	return exact || content >= o.ContentThreshold || (content >= o.RelatedThreshold && title >= o.TitleThreshold)
+}
+
+// minContent is the lowest content similarity that can be a duplicate
+func (o Options) minContent() float64 {
+	return math.Min(o.ContentThreshold, o.RelatedThreshold)
+}
+
+// Match is an existing document a new one duplicates
+type Match struct {
+	ID                primitive.ObjectID `json:"id"`
+	URLSlug           string             `json:"url_slug"`
+	PdfAdi            string             `json:"pdf_adi"`
+	KurumID           string             `json:"kurum_id"`
+	Status            string             `json:"status"`
+	ContentSimilarity float64            `json:"content_similarity"`
+	TitleSimilarity   float64            `json:"title_similarity"`
+	Exact             bool               `json:"exact"` // identical content
+}
+
+// DuplicateError is returned by Check when a document duplicates existing ones
+type DuplicateError struct {
+	Matches []Match
+}
+
+func (e *DuplicateError) Error() string {
+	slugs := make([]string, len(e.Matches))
+	for i, m := range e.Matches {
+		slugs[i] = m.URLSlug
+	}
+	return "document duplicates existing documents: " + strings.Join(slugs, ", ")
+}
+
+// Find returns the documents, other than exclude and deleted ones, that a
+// document with the given title and content would duplicate, closest first
+func Find(ctx context.Context, client *mongo.Client, title, icerik string, exclude primitive.ObjectID, opts Options) ([]Match, error) {
+	fp := Build(primitive.NilObjectID, icerik)
+	if fp.Shingles == 0 {
+		return nil, nil
+	}
+	filter := bson.M{
+		"version": Version,
+		"$or":     bson.A{bson.M{"bands": bson.M{"$in": fp.Bands}}, bson.M{"content_hash": fp.ContentHash}},
+	}
+	if !exclude.IsZero() {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+	cursor, err := config.GetFingerprintsCollection(client).Find(ctx, filter, options.Find().SetProjection(bson.M{"bands": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.Fingerprint
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	type score struct {
+		content float64
+		exact   bool
+	}
+	scores := make(map[primitive.ObjectID]score)
+	var ids []primitive.ObjectID
+	for _, candidate := range candidates {
+		s := score{exact: candidate.ContentHash == fp.ContentHash}
+		if s.exact {
+			s.content = 1
+		} else {
+			s.content = contentSimilarity(fp.MinHash, candidate.MinHash)
+		}
+		if s.exact || s.content >= opts.minContent() {
+			scores[candidate.DocumentID] = s
+			ids = append(ids, candidate.DocumentID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err = config.GetMetadataCollection(client).Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "status": bson.M{"$ne": editorial.Silindi}},
+		options.Find().SetProjection(bson.M{"url_slug": 1, "pdf_adi": 1, "kurum_id": 1, "status": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.DocumentMetadata
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, metadata := range existing {
+		s := scores[metadata.ID]
+		titleSim := titleSimilarity(title, metadata.PdfAdi)
+		if !opts.duplicate(s.content, titleSim, s.exact) {
+			continue
+		}
+		matches = append(matches, Match{
+			ID:                metadata.ID,
+			URLSlug:           metadata.URLSlug,
+			PdfAdi:            metadata.PdfAdi,
+			KurumID:           metadata.KurumID,
+			Status:            metadata.Status,
+			ContentSimilarity: round(s.content),
+			TitleSimilarity:   round(titleSim),
+			Exact:             s.exact,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].ContentSimilarity != matches[j].ContentSimilarity {
+			return matches[i].ContentSimilarity > matches[j].ContentSimilarity
+		}
+		return matches[i].TitleSimilarity > matches[j].TitleSimilarity
+	})
+	return matches, nil
+}
+
+// Check applies the duplicate policy to a document about to be created: with
+// PolicyBlock a document that duplicates existing ones fails with a
+// DuplicateError unless ctx allows duplicates; with PolicyWarn it is logged.
+func Check(ctx context.Context, client *mongo.Client, title, icerik string) error {
+	if policy == PolicyOff {
+		return nil
+	}
+	matches, err := Find(ctx, client, title, icerik, primitive.NilObjectID, DefaultOptions)
+	if err != nil || len(matches) == 0 {
+		return err
+	}
+	dupErr := &DuplicateError{Matches: matches}
+	if policy == PolicyBlock && !Allowed(ctx) {
+		return dupErr
+	}
+	log.Printf("Warning: %q is stored anyway: %v", title, dupErr)
+	return nil
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
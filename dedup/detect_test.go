@@ -0,0 +1,82 @@
+package dedup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testID = primitive.NewObjectID()
+
+// The decision combines both similarities the way Find and Report apply it,
+// starting from fingerprints built like the stored ones
+func TestDuplicateDecision(t *testing.T) {
+	text := words("madde", 300)
+	base := strings.Join(text, " ")
+	tests := []struct {
+		name           string
+		titleA, titleB string
+		contentB       string
+		want           bool
+	}{
+		{"reuploaded under another title", "İş Kanunu", "4857 sayılı Kanun", edit(text, 150), true},
+		{"revised text with the same title", "İş Kanunu", "İŞ KANUNU", edit(text, 10, 60, 110, 160, 210, 260), true},
+		{"revised text under another title", "İş Kanunu", "Türk Ticaret Kanunu", strings.Join(append(text[:200:200], words("yeni", 100)...), " "), false},
+		// Amendments share titles; alike titles alone are no duplicate
+		{"same title, other content", "Uygulama Tebliği", "Uygulama Tebliği", strings.Join(words("baska", 300), " "), false},
+	}
+	for _, tt := range tests {
+		a, b := Build(testID, base), Build(testID, tt.contentB)
+		exact := a.ContentHash == b.ContentHash
+		content := contentSimilarity(a.MinHash, b.MinHash)
+		title := titleSimilarity(tt.titleA, tt.titleB)
+		if got := DefaultOptions.duplicate(content, title, exact); got != tt.want {
+			t.Errorf("%s: duplicate(content %.2f, title %.2f) = %v, want %v", tt.name, content, title, got, tt.want)
+		}
+	}
+
+	// Identical content is a duplicate even when nothing else is alike
+	if !DefaultOptions.duplicate(0, 0, true) {
+		t.Errorf("identical content is not a duplicate")
+	}
+	if got := (Options{ContentThreshold: 0.9, RelatedThreshold: 0.6}).minContent(); got != 0.6 {
+		t.Errorf("minContent() = %v, want the lower threshold 0.6", got)
+	}
+}
+
+func TestBuildFingerprint(t *testing.T) {
+	fp := Build(testID, "İŞ KANUNU madde 1")
+	same := Build(testID, "iş kanunu – Madde 1.")
+	if fp.Shingles != 1 || len(fp.MinHash) != numHashes || len(fp.Bands) != numBands {
+		t.Errorf("Build = %d shingles, %d hashes, %d bands", fp.Shingles, len(fp.MinHash), len(fp.Bands))
+	}
+	// Spelling differences leave the signature alone but not the exact hash
+	if contentSimilarity(fp.MinHash, same.MinHash) != 1 || fp.ContentHash == same.ContentHash {
+		t.Errorf("normalized copies: similarity %v, hashes %s and %s", contentSimilarity(fp.MinHash, same.MinHash), fp.ContentHash, same.ContentHash)
+	}
+	if empty := Build(testID, " ... "); empty.Shingles != 0 || empty.MinHash != nil || empty.Bands != nil {
+		t.Errorf("Build without words = %+v, want no signature", empty)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	defer SetPolicy(Policy())
+	for _, p := range []string{PolicyOff, PolicyBlock, PolicyWarn} {
+		if err := SetPolicy(p); err != nil || Policy() != p {
+			t.Errorf("SetPolicy(%q) = %v, policy %q", p, err, Policy())
+		}
+	}
+	if err := SetPolicy("strict"); err == nil || Policy() != PolicyWarn {
+		t.Errorf("SetPolicy(strict) = %v, policy %q; want an error and no change", err, Policy())
+	}
+
+	if Allowed(context.Background()) || !Allowed(AllowDuplicates(context.Background())) {
+		t.Errorf("Allowed does not follow AllowDuplicates")
+	}
+	err := &DuplicateError{Matches: []Match{{URLSlug: "is-kanunu"}, {URLSlug: "is-kanunu-2"}}}
+	if !strings.HasSuffix(err.Error(), "is-kanunu, is-kanunu-2") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
@@ -0,0 +1,152 @@
+package dedup
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+
+	"legal-documents-api/textnorm"
+)
+
+// Version is bumped whenever fingerprinting changes, so stored fingerprints
+// are computed again by the next backfill
+const Version = 1
+
+const (
+	// shingleSize is the number of consecutive words hashed together
+	shingleSize = 5
+	// numHashes is the length of a signature; the similarity estimated from
+	// it is off by about 1/sqrt(numHashes)
+	numHashes = 128
+	// numBands splits the signature for candidate lookup. With 32 bands of 4
+	// rows, documents with similar content of 0.7 share a band with a
+	// probability above 0.999 and those of 0.3 with about 0.23.
+	numBands = 32
+	rows     = numHashes / numBands
+)
+
+// seeds select the hash functions of a signature
+var seeds = func() [numHashes]uint64 {
+	var s [numHashes]uint64
+	state := uint64(0x5eed0fd0c5)
+	for i := range s {
+		state += 0x9e3779b97f4a7c15
+		s[i] = mix(state)
+	}
+	return s
+}()
+
+// mix is the splitmix64 finaliser, scattering the bits of x
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// shingles returns the hashes of every run of shingleSize normalized words
+// of text. Texts shorter than that are a single shingle.
+func shingles(text string) []uint64 {
+	words := textnorm.Tokens(text)
+	if len(words) == 0 {
+		return nil
+	}
+	n := len(words) - shingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+	hashes := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+		h := fnv.New64a()
+		for _, word := range words[i:end] {
+			h.Write([]byte(word))
+			h.Write([]byte{' '})
+		}
+		hashes = append(hashes, h.Sum64())
+	}
+	return hashes
+}
+
+// signature computes the MinHash signature of a set of shingle hashes
+func signature(hashes []uint64) []int64 {
+	var mins [numHashes]uint64
+	for i := range mins {
+		mins[i] = ^uint64(0)
+	}
+	for _, h := range hashes {
+		for i, seed := range seeds {
+			if v := mix(h ^ seed); v < mins[i] {
+				mins[i] = v
+			}
+		}
+	}
+	// BSON has no unsigned integers; the bits are kept as they are
+	sig := make([]int64, numHashes)
+	for i, v := range mins {
+		sig[i] = int64(v)
+	}
+	return sig
+}
+
+// bands hashes each band of a signature, prefixed with its position so equal
+// values in different bands do not match
+func bands(sig []int64) []string {
+	out := make([]string, 0, numBands)
+	buf := make([]byte, 8)
+	for b := 0; b < numBands && (b+1)*rows <= len(sig); b++ {
+		h := fnv.New64a()
+		for _, v := range sig[b*rows : (b+1)*rows] {
+			binary.LittleEndian.PutUint64(buf, uint64(v))
+			h.Write(buf)
+		}
+		out = append(out, fmt.Sprintf("%02d:%016x", b, h.Sum64()))
+	}
+	return out
+}
+
+// contentSimilarity estimates the Jaccard similarity of the shingles of two
+// documents from their signatures
+func contentSimilarity(a, b []int64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// titleSimilarity is the Jaccard similarity of the character trigrams of two
+// normalized titles, so that small changes in wording, numbering or
+// punctuation keep titles close
+func titleSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	runes := []rune(" " + textnorm.Normalize(s) + " ")
+	set := make(map[string]bool, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
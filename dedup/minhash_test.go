@@ -0,0 +1,121 @@
+package dedup
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// words returns n distinct words starting with prefix
+func words(prefix string, n int) []string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = prefix + strconv.Itoa(i)
+	}
+	return w
+}
+
+// edit replaces the words at the given positions
+func edit(w []string, at ...int) string {
+	edited := append([]string(nil), w...)
+	for _, i := range at {
+		edited[i] = "degisti" + strconv.Itoa(i)
+	}
+	return strings.Join(edited, " ")
+}
+
+func TestContentSimilarity(t *testing.T) {
+	text := words("madde", 300)
+	base := strings.Join(text, " ")
+	tests := []struct {
+		name     string
+		a, b     string
+		min, max float64
+	}{
+		{"identical", base, base, 1, 1},
+		{"case and diacritics", "İŞ SAĞLIĞI VE GÜVENLİĞİ KANUNU", "iş sağlığı ve güvenliği kanunu", 1, 1},
+		{"one word changed", base, edit(text, 150), 0.85, 1},
+		{"a few words changed", base, edit(text, 30, 120, 210), 0.75, 0.98},
+		{"half rewritten", base, strings.Join(append(text[:150:150], words("yeni", 150)...), " "), 0.2, 0.5},
+		{"unrelated", base, strings.Join(words("baska", 300), " "), 0, 0.05},
+		{"shorter than a shingle", "iş kanunu", "iş kanunu", 1, 1},
+		{"short and different", "iş kanunu", "vergi usul", 0, 0.05},
+	}
+	for _, tt := range tests {
+		got := contentSimilarity(signature(shingles(tt.a)), signature(shingles(tt.b)))
+		if got < tt.min || got > tt.max {
+			t.Errorf("%s: similarity = %.3f, want between %.2f and %.2f", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestContentSimilarityOfMalformedSignatures(t *testing.T) {
+	sig := signature(shingles("iş kanunu"))
+	tests := []struct {
+		name string
+		a, b []int64
+	}{
+		{"empty", nil, nil},
+		{"different lengths", sig, sig[:numHashes/2]},
+	}
+	for _, tt := range tests {
+		if got := contentSimilarity(tt.a, tt.b); got != 0 {
+			t.Errorf("%s: similarity = %v, want 0", tt.name, got)
+		}
+	}
+}
+
+func TestBands(t *testing.T) {
+	text := words("madde", 300)
+	a := bands(signature(shingles(strings.Join(text, " "))))
+	if len(a) != numBands {
+		t.Fatalf("bands returned %d bands, want %d", len(a), numBands)
+	}
+	tests := []struct {
+		name      string
+		text      string
+		minShared int
+		maxShared int
+	}{
+		{"identical", strings.Join(text, " "), numBands, numBands},
+		{"near duplicate", edit(text, 150), 1, numBands},
+		{"unrelated", strings.Join(words("baska", 300), " "), 0, 0},
+	}
+	for _, tt := range tests {
+		b := bands(signature(shingles(tt.text)))
+		shared := 0
+		for i := range a {
+			if a[i] == b[i] {
+				shared++
+			}
+		}
+		if shared < tt.minShared || shared > tt.maxShared {
+			t.Errorf("%s: %d bands shared, want between %d and %d", tt.name, shared, tt.minShared, tt.maxShared)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"İş Kanunu", "İş Kanunu", 1, 1},
+		{"İŞ KANUNU", "iş kanunu", 1, 1},
+		{"Sözleşmeli Personel Yönetmeliği", "Sozlesmeli Personel Yonetmeligi", 1, 1},
+		{"2023/15 Sayılı Genelge", "2023/16 Sayılı Genelge", 0.7, 0.95},
+		{"Gelir Vergisi Kanunu", "Gelir Vergisi Kanunu Genel Tebliği", 0.4, 0.8},
+		{"İş Kanunu", "Türk Ticaret Kanunu", 0, 0.4},
+		{"İş Kanunu", "", 0, 0},
+		{"", "", 0, 0},
+	}
+	for _, tt := range tests {
+		got := titleSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("titleSimilarity(%q, %q) = %.3f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+		if back := titleSimilarity(tt.b, tt.a); back != got {
+			t.Errorf("titleSimilarity(%q, %q) = %.3f, not symmetric with %.3f", tt.b, tt.a, back, got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package dedup
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+)
+
+// Member is a document of a duplicate cluster
+type Member struct {
+	ID            primitive.ObjectID `json:"id"`
+	URLSlug       string             `json:"url_slug"`
+	PdfAdi        string             `json:"pdf_adi"`
+	KurumID       string             `json:"kurum_id"`
+	Status        string             `json:"status"`
+	YuklemeTarihi string             `json:"yukleme_tarihi"`
+}
+
+// Pair is two documents of a cluster found to be duplicates
+type Pair struct {
+	A                 primitive.ObjectID `json:"a"`
+	B                 primitive.ObjectID `json:"b"`
+	ContentSimilarity float64            `json:"content_similarity"`
+	TitleSimilarity   float64            `json:"title_similarity"`
+	Exact             bool               `json:"exact"`
+}
+
+// Cluster is a group of documents linked by duplicate pairs. Members are
+// ordered oldest first, so the first one is usually the one to keep.
+type Cluster struct {
+	Members       []Member `json:"members"`
+	Pairs         []Pair   `json:"pairs"`
+	MaxSimilarity float64  `json:"max_similarity"`
+}
+
+// ReportStats describes what a report covered
+type ReportStats struct {
+	Documents       int `json:"documents"`       // documents with the requested statuses
+	Compared        int `json:"compared"`        // of those, documents with a current fingerprint
+	Unfingerprinted int `json:"unfingerprinted"` // documents the next backfill fingerprints
+}
+
+// Report groups documents in clusters of duplicates, largest first. Only
+// documents with the given statuses are compared, or all but deleted ones
+// when none are given.
+func Report(ctx context.Context, client *mongo.Client, opts Options, statuses []string) ([]Cluster, ReportStats, error) {
+	var stats ReportStats
+	filter := bson.M{"status": bson.M{"$ne": editorial.Silindi}}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	cursor, err := config.GetMetadataCollection(client).Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"url_slug": 1, "pdf_adi": 1, "kurum_id": 1, "status": 1, "yukleme_tarihi": 1}))
+	if err != nil {
+		return nil, stats, err
+	}
+	members := make(map[primitive.ObjectID]Member)
+	for cursor.Next(ctx) {
+		var metadata models.DocumentMetadata
+		if err := cursor.Decode(&metadata); err != nil {
+			continue
+		}
+		members[metadata.ID] = Member{
+			ID:            metadata.ID,
+			URLSlug:       metadata.URLSlug,
+			PdfAdi:        metadata.PdfAdi,
+			KurumID:       metadata.KurumID,
+			Status:        metadata.Status,
+			YuklemeTarihi: metadata.YuklemeTarihi,
+		}
+	}
+	cursor.Close(ctx)
+	if err := cursor.Err(); err != nil {
+		return nil, stats, err
+	}
+	stats.Documents = len(members)
+
+	cursor, err = config.GetFingerprintsCollection(client).Find(ctx, bson.M{"version": Version})
+	if err != nil {
+		return nil, stats, err
+	}
+	defer cursor.Close(ctx)
+	var fps []models.Fingerprint
+	fingerprinted := 0
+	for cursor.Next(ctx) {
+		var fp models.Fingerprint
+		if err := cursor.Decode(&fp); err != nil {
+			continue
+		}
+		if _, ok := members[fp.DocumentID]; !ok {
+			continue
+		}
+		fingerprinted++
+		if fp.Shingles > 0 {
+			fps = append(fps, fp)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, stats, err
+	}
+	stats.Compared = len(fps)
+	stats.Unfingerprinted = stats.Documents - fingerprinted
+
+	// Documents sharing a band or identical content are candidates
+	buckets := make(map[string][]int)
+	for i, fp := range fps {
+		buckets["hash:"+fp.ContentHash] = append(buckets["hash:"+fp.ContentHash], i)
+		for _, band := range fp.Bands {
+			buckets[band] = append(buckets[band], i)
+		}
+	}
+
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	seen := make(map[[2]int]bool)
+	var pairs []Pair
+	for _, bucket := range buckets {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				i, j := bucket[x], bucket[y]
+				if seen[[2]int{i, j}] {
+					continue
+				}
+				seen[[2]int{i, j}] = true
+
+				a, b := fps[i], fps[j]
+				exact := a.ContentHash == b.ContentHash
+				content := 1.0
+				if !exact {
+					content = contentSimilarity(a.MinHash, b.MinHash)
+					if content < opts.minContent() {
+						continue
+					}
+				}
+				title := titleSimilarity(members[a.DocumentID].PdfAdi, members[b.DocumentID].PdfAdi)
+				if !opts.duplicate(content, title, exact) {
+					continue
+				}
+				pairs = append(pairs, Pair{
+					A:                 a.DocumentID,
+					B:                 b.DocumentID,
+					ContentSimilarity: round(content),
+					TitleSimilarity:   round(title),
+					Exact:             exact,
+				})
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	index := make(map[primitive.ObjectID]int, len(fps))
+	for i, fp := range fps {
+		index[fp.DocumentID] = i
+	}
+	byRoot := make(map[int]*Cluster)
+	for _, pair := range pairs {
+		root := find(index[pair.A])
+		cluster := byRoot[root]
+		if cluster == nil {
+			cluster = &Cluster{}
+			byRoot[root] = cluster
+		}
+		cluster.Pairs = append(cluster.Pairs, pair)
+		if pair.ContentSimilarity > cluster.MaxSimilarity {
+			cluster.MaxSimilarity = pair.ContentSimilarity
+		}
+	}
+	for i, fp := range fps {
+		if cluster := byRoot[find(i)]; cluster != nil {
+			cluster.Members = append(cluster.Members, members[fp.DocumentID])
+		}
+	}
+
+	clusters := make([]Cluster, 0, len(byRoot))
+	for _, cluster := range byRoot {
+		sort.Slice(cluster.Members, func(i, j int) bool {
+			return cluster.Members[i].ID.Hex() < cluster.Members[j].ID.Hex()
+		})
+		sort.Slice(cluster.Pairs, func(i, j int) bool {
+			return cluster.Pairs[i].ContentSimilarity > cluster.Pairs[j].ContentSimilarity
+		})
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Members) != len(clusters[j].Members) {
+			return len(clusters[i].Members) > len(clusters[j].Members)
+		}
+		if clusters[i].MaxSimilarity != clusters[j].MaxSimilarity {
+			return clusters[i].MaxSimilarity > clusters[j].MaxSimilarity
+		}
+		return clusters[i].Members[0].ID.Hex() < clusters[j].Members[0].ID.Hex()
+	})
+	return clusters, stats, nil
+}
@@ -0,0 +1,141 @@
+// Package dedup finds documents stored more than once. The content of every
+// document is fingerprinted with MinHash over word shingles; documents whose
+// fingerprints are close, or whose content is close and titles alike, are
+// reported as duplicates and can be refused when they are created.
+package dedup
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+// backfillBatchSize is how many fingerprints are written per bulk write
+const backfillBatchSize = 100
+
+// Build fingerprints the content of a document
+func Build(id primitive.ObjectID, icerik string) models.Fingerprint {
+	hashes := shingles(icerik)
+	fp := models.Fingerprint{
+		DocumentID:  id,
+		Version:     Version,
+		ContentHash: utils.ContentHash(icerik),
+		Shingles:    len(hashes),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if len(hashes) > 0 {
+		fp.MinHash = signature(hashes)
+		fp.Bands = bands(fp.MinHash)
+	}
+	return fp
+}
+
+// Index stores the fingerprint of the content of a document, replacing the
+// previous one
+func Index(ctx context.Context, client *mongo.Client, id primitive.ObjectID, icerik string) error {
+	fp := Build(id, icerik)
+	_, err := config.GetFingerprintsCollection(client).ReplaceOne(ctx, bson.M{"_id": id}, fp, options.Replace().SetUpsert(true))
+	return err
+}
+
+// EnsureIndexes creates the fingerprints indexes
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetFingerprintsCollection(client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "bands", Value: 1}}},
+		{Keys: bson.D{{Key: "content_hash", Value: 1}}},
+	})
+	return err
+}
+
+// BackfillStats summarises a fingerprint backfill run
+type BackfillStats struct {
+	Scanned       int
+	Fingerprinted int
+	Empty         int // content without words
+}
+
+// Backfill fingerprints every document whose fingerprint is missing, was
+// computed from other content or by an older version, or every document
+// with force set
+func Backfill(ctx context.Context, client *mongo.Client, force bool) (BackfillStats, error) {
+	var stats BackfillStats
+	collection := config.GetFingerprintsCollection(client)
+
+	current := make(map[primitive.ObjectID]string)
+	if !force {
+		cursor, err := collection.Find(ctx, bson.M{"version": Version}, options.Find().SetProjection(bson.M{"content_hash": 1}))
+		if err != nil {
+			return stats, err
+		}
+		for cursor.Next(ctx) {
+			var fp models.Fingerprint
+			if err := cursor.Decode(&fp); err == nil {
+				current[fp.DocumentID] = fp.ContentHash
+			}
+		}
+		cursor.Close(ctx)
+		if err := cursor.Err(); err != nil {
+			return stats, err
+		}
+	}
+
+	cursor, err := config.GetContentCollection(client).Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"metadata_id": 1, "icerik": 1}))
+	if err != nil {
+		return stats, err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		_, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		writes = writes[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var content models.DocumentContent
+		if err := cursor.Decode(&content); err != nil {
+			continue
+		}
+		stats.Scanned++
+		if hash, ok := current[content.MetadataID]; ok && hash == utils.ContentHash(content.Icerik) {
+			continue
+		}
+
+		fp := Build(content.MetadataID, content.Icerik)
+		stats.Fingerprinted++
+		if fp.Shingles == 0 {
+			stats.Empty++
+		}
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": fp.DocumentID}).
+			SetReplacement(fp).
+			SetUpsert(true))
+		if len(writes) >= backfillBatchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return stats, err
+	}
+	if err := flush(); err != nil {
+		return stats, err
+	}
+
+	log.Printf("Fingerprint backfill: %d scanned, %d fingerprinted, %d without text", stats.Scanned, stats.Fingerprinted, stats.Empty)
+	return stats, nil
+}
@@ -0,0 +1,199 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// render writes ops compactly: "=" kept, "-" deleted, "+" inserted text,
+// separated by "|"
+func render(ops []Op) string {
+	signs := map[string]string{OpEqual: "=", OpDelete: "-", OpInsert: "+"}
+	parts := make([]string, len(ops))
+	for i, op := range ops {
+		parts[i] = signs[op.Type] + op.Text
+	}
+	return strings.Join(parts, "|")
+}
+
+// side joins the text of the ops a version is made of
+func side(ops []Op, changed string) string {
+	var b strings.Builder
+	for _, op := range ops {
+		if op.Type == OpEqual || op.Type == changed {
+			b.WriteString(op.Text)
+		}
+	}
+	return b.String()
+}
+
+// canonical is text as the ops spell it: words separated by single spaces,
+// one line per non-empty paragraph
+func canonical(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if words := strings.Fields(line); len(words) > 0 {
+			b.WriteString(strings.Join(words, " ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"unchanged", "a b c", "a b c", "=a b c\n"},
+		{"replaced word", "a b c", "a x c", "=a |-b |+x |=c\n"},
+		{"appended word", "a b c", "a b c d", "=a b |-c\n|+c d\n"},
+		{"added text", "", "a b", "+a b\n"},
+		{"removed text", "a b", "", "-a b\n"},
+		{"removed paragraph", "bir\niki\nüç", "bir\nüç", "=bir\n|-iki\n|=üç\n"},
+		{"joined paragraphs", "bir iki\nüç", "bir iki üç", "=bir |-iki\n|+iki |=üç\n"},
+		{"whitespace only", "a  b\n\n c", "a b\nc", "=a b\nc\n"},
+	}
+	for _, tt := range tests {
+		if got := render(Words(tt.from, tt.to)); got != tt.want {
+			t.Errorf("%s: Words(%q, %q) = %q, want %q", tt.name, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestWordsRebuildsBothVersions(t *testing.T) {
+	long := make([]string, 3000)
+	for i := range long {
+		long[i] = "w" + strconv.Itoa(i)
+	}
+	tests := []struct {
+		name, from, to string
+	}{
+		{"edits", "İşçi ve işveren\nhaklarını korur.\nYürürlük", "İşveren ve işçi\nhaklarını\nkorur.\nYürürlük maddesi"},
+		{"reordered", "a b c d e f", "f e d c b a"},
+		// Too far apart for a shortest diff: replaced outright
+		{"beyond maxEdits", strings.Join(long[:1500], " "), strings.Join(long[1500:], " ")},
+		{"beyond maxEdits in paragraphs", strings.Join(long[:1500], "\n"), strings.Join(long[1500:], "\n")},
+	}
+	for _, tt := range tests {
+		ops := Words(tt.from, tt.to)
+		if got, want := side(ops, OpDelete), canonical(tt.from); got != want {
+			t.Errorf("%s: old version rebuilt as %q, want %q", tt.name, got, want)
+		}
+		if got, want := side(ops, OpInsert), canonical(tt.to); got != want {
+			t.Errorf("%s: new version rebuilt as %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestCompareAlignsArticles(t *testing.T) {
+	type section struct{ anchor, status string }
+	tests := []struct {
+		name, from, to string
+		aligned        bool
+		want           []section
+		stats          Stats
+	}{
+		{
+			name:    "inserted and modified articles",
+			from:    "Amaç\nMADDE 1 – Bu Kanunun amacı işçileri korumaktır.\nMADDE 2 – Kapsam şudur.\nMADDE 3 – Yürürlük tarihi.\n",
+			to:      "Amaç\nMADDE 1 – Bu Kanunun amacı işçileri korumaktır.\nMADDE 2 – Kapsam budur.\nMADDE 2/A – Yeni madde.\nMADDE 3 – Yürürlük tarihi.\n",
+			aligned: true,
+			want: []section{
+				{"madde-1", StatusUnchanged},
+				{"madde-2", StatusModified},
+				{"madde-2a", StatusAdded},
+				{"madde-3", StatusUnchanged},
+			},
+			stats: Stats{WordsInserted: 6, WordsDeleted: 1, Added: 1, Modified: 1, Unchanged: 2},
+		},
+		{
+			name:    "removed article keeps its place",
+			from:    "MADDE 1 – a\nMADDE 2 – b\nMADDE 3 – c\n",
+			to:      "MADDE 1 – a\nMADDE 3 – c\n",
+			aligned: true,
+			want: []section{
+				{"madde-1", StatusUnchanged},
+				{"madde-2", StatusRemoved},
+				{"madde-3", StatusUnchanged},
+			},
+			stats: Stats{WordsDeleted: 4, Removed: 1, Unchanged: 2},
+		},
+		{
+			name:  "text without articles",
+			from:  "Genelge metni bir.",
+			to:    "Genelge metni iki.",
+			want:  []section{{"", StatusModified}},
+			stats: Stats{WordsInserted: 1, WordsDeleted: 1, Modified: 1},
+		},
+	}
+	for _, tt := range tests {
+		result := Compare(tt.from, tt.to)
+		if result.Aligned != tt.aligned {
+			t.Errorf("%s: aligned = %v, want %v", tt.name, result.Aligned, tt.aligned)
+		}
+		var got []section
+		for _, s := range result.Sections {
+			got = append(got, section{s.Anchor, s.Status})
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: sections = %v, want %v", tt.name, got, tt.want)
+		} else {
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("%s: section %d = %v, want %v", tt.name, i, got[i], tt.want[i])
+				}
+			}
+		}
+		if result.Stats != tt.stats {
+			t.Errorf("%s: stats = %+v, want %+v", tt.name, result.Stats, tt.stats)
+		}
+	}
+}
+
+func TestCompareSections(t *testing.T) {
+	from := "KANUN METNİ\n" +
+		"Amaç\nMADDE 1 – Amaç budur.\n" +
+		"MADDE 2 – İkinci.\n" +
+		"GEÇİCİ MADDE 1 – Geçiş hükmü.\n"
+	to := "KANUN METNİ DEĞİŞTİ\n" +
+		"Amaç\nMADDE 1 – Amaç budur.\n" +
+		"GEÇİCİ MADDE 1 – Geçiş hükmü uzatıldı.\n" +
+		"EK MADDE 1 – Ek hüküm.\n" +
+		"MADDE 1 – Başka kanunun maddesi aktarıldı.\n"
+	result := Compare(from, to)
+	if !result.Aligned {
+		t.Fatal("texts with articles were not aligned")
+	}
+
+	want := []Section{
+		// The text before the first article is a section of its own
+		{Anchor: "giris", Status: StatusModified},
+		{Anchor: "madde-1", Type: "madde", Number: "1", Title: "Amaç", Status: StatusUnchanged},
+		// Removed articles stay where they stood in the old version
+		{Anchor: "madde-2", Type: "madde", Number: "2", Status: StatusRemoved},
+		// Article kinds with the same number do not collide
+		{Anchor: "gecici-madde-1", Type: "gecici_madde", Number: "1", Status: StatusModified},
+		{Anchor: "ek-madde-1", Type: "ek_madde", Number: "1", Status: StatusAdded},
+		// A repeated anchor is numbered rather than matched to the first
+		{Anchor: "madde-1-2", Type: "madde", Number: "1", Status: StatusAdded},
+	}
+	if len(result.Sections) != len(want) {
+		var anchors []string
+		for _, s := range result.Sections {
+			anchors = append(anchors, s.Anchor)
+		}
+		t.Fatalf("sections = %v, want %d sections", anchors, len(want))
+	}
+	for i, s := range result.Sections {
+		got := Section{Anchor: s.Anchor, Type: s.Type, Number: s.Number, Title: s.Title, Status: s.Status}
+		if got.Anchor != want[i].Anchor || got.Type != want[i].Type || got.Number != want[i].Number ||
+			got.Title != want[i].Title || got.Status != want[i].Status {
+			t.Errorf("section %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Only the changed words of a modified article are marked
+	if got := render(result.Sections[3].Ops); got != "=GEÇİCİ MADDE 1 – Geçiş |-hükmü.\n|+hükmü uzatıldı.\n" {
+		t.Errorf("gecici-madde-1 ops = %q", got)
+	}
+}
@@ -0,0 +1,198 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"legal-documents-api/models"
+	"legal-documents-api/structure"
+)
+
+// Section statuses
+const (
+	StatusUnchanged = "unchanged"
+	StatusModified  = "modified"
+	StatusAdded     = "added"
+	StatusRemoved   = "removed"
+)
+
+// introAnchor identifies the text before the first article
+const introAnchor = "giris"
+
+// Section is the comparison of one article, or of the text before the first
+// article. An unaligned comparison has a single section without an anchor.
+type Section struct {
+	Anchor string `json:"anchor,omitempty"` // structure anchor, e.g. "madde-5a"
+	Type   string `json:"type,omitempty"`   // madde, gecici_madde, ek_madde, ek_gecici_madde
+	Number string `json:"number,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Status string `json:"status"`
+	Ops    []Op   `json:"ops,omitempty"`
+}
+
+// Stats summarises a comparison
+type Stats struct {
+	WordsInserted int `json:"words_inserted"`
+	WordsDeleted  int `json:"words_deleted"`
+	Added         int `json:"added"` // sections
+	Removed       int `json:"removed"`
+	Modified      int `json:"modified"`
+	Unchanged     int `json:"unchanged"`
+}
+
+// Result is the comparison of two versions of a document
+type Result struct {
+	Aligned  bool      `json:"aligned"` // sections were matched by article
+	Stats    Stats     `json:"stats"`
+	Sections []Section `json:"sections"`
+}
+
+// part is an article of a text, with any headings between it and the next
+// article
+type part struct {
+	key  string
+	node models.StructureNode
+	text string
+}
+
+// Compare compares two versions of a document. When both have articles,
+// articles are matched by number so an inserted or removed article shows as
+// such instead of shifting the text around it; otherwise the texts are
+// compared as a whole.
+func Compare(from, to string) Result {
+	a, b := parts(from), parts(to)
+	if a == nil || b == nil {
+		result := Result{Sections: []Section{newSection(models.StructureNode{}, "", Words(from, to))}}
+		result.count()
+		return result
+	}
+
+	inA := make(map[string]int, len(a))
+	for i, p := range a {
+		inA[p.key] = i
+	}
+	inB := make(map[string]bool, len(b))
+	for _, p := range b {
+		inB[p.key] = true
+	}
+
+	// Articles are listed in the order of the new version, removed ones where
+	// they stood in the old
+	result := Result{Aligned: true}
+	done := make([]bool, len(a))
+	i := 0
+	for _, p := range b {
+		for i < len(a) && (done[i] || !inB[a[i].key]) {
+			if !done[i] {
+				result.Sections = append(result.Sections, newSection(a[i].node, a[i].key, Words(a[i].text, "")))
+			}
+			i++
+		}
+		j, ok := inA[p.key]
+		if !ok {
+			result.Sections = append(result.Sections, newSection(p.node, p.key, Words("", p.text)))
+			continue
+		}
+		done[j] = true
+		result.Sections = append(result.Sections, newSection(p.node, p.key, Words(a[j].text, p.text)))
+	}
+	for ; i < len(a); i++ {
+		if !done[i] {
+			result.Sections = append(result.Sections, newSection(a[i].node, a[i].key, Words(a[i].text, "")))
+		}
+	}
+	result.count()
+	return result
+}
+
+// newSection builds a section from the ops comparing its text
+func newSection(node models.StructureNode, key string, ops []Op) Section {
+	section := Section{Anchor: key, Type: node.Type, Number: node.Number, Title: node.Title, Ops: ops}
+	var kept, inserted, deleted bool
+	for _, op := range ops {
+		switch op.Type {
+		case OpEqual:
+			kept = true
+		case OpInsert:
+			inserted = true
+		case OpDelete:
+			deleted = true
+		}
+	}
+	switch {
+	case inserted && !deleted && !kept:
+		section.Status = StatusAdded
+	case deleted && !inserted && !kept:
+		section.Status = StatusRemoved
+	case inserted || deleted:
+		section.Status = StatusModified
+	default:
+		section.Status = StatusUnchanged
+	}
+	return section
+}
+
+// count fills in the statistics of a result
+func (r *Result) count() {
+	for _, section := range r.Sections {
+		switch section.Status {
+		case StatusAdded:
+			r.Stats.Added++
+		case StatusRemoved:
+			r.Stats.Removed++
+		case StatusModified:
+			r.Stats.Modified++
+		default:
+			r.Stats.Unchanged++
+		}
+		for _, op := range section.Ops {
+			switch op.Type {
+			case OpInsert:
+				r.Stats.WordsInserted += len(strings.Fields(op.Text))
+			case OpDelete:
+				r.Stats.WordsDeleted += len(strings.Fields(op.Text))
+			}
+		}
+	}
+}
+
+// parts splits text into its articles, or returns nil when it has none.
+// Headings between two articles belong to the first, so an article added at
+// the start of a chapter does not take the chapter heading with it.
+func parts(text string) []part {
+	var articles []models.StructureNode
+	var collect func(nodes []models.StructureNode)
+	collect = func(nodes []models.StructureNode) {
+		for _, node := range nodes {
+			switch node.Type {
+			case structure.TypeMadde, structure.TypeGeciciMadde, structure.TypeEkMadde, structure.TypeEkGeciciMadde:
+				articles = append(articles, node)
+			default:
+				collect(node.Children)
+			}
+		}
+	}
+	collect(structure.Parse(text))
+	if len(articles) == 0 {
+		return nil
+	}
+
+	var result []part
+	if intro := text[:articles[0].Start]; strings.TrimSpace(intro) != "" {
+		result = append(result, part{key: introAnchor, text: intro})
+	}
+	seen := make(map[string]int)
+	for i, article := range articles {
+		// Anchors repeat when a text quotes articles of another
+		key := article.Anchor
+		if seen[key]++; seen[key] > 1 {
+			key += "-" + strconv.Itoa(seen[key])
+		}
+		end := len(text)
+		if i+1 < len(articles) {
+			end = articles[i+1].Start
+		}
+		result = append(result, part{key: key, node: article, text: text[article.Start:end]})
+	}
+	return result
+}
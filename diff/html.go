@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"html"
+	"strings"
+
+	"legal-documents-api/structure"
+)
+
+// articleLabels is how each article type is written in a heading
+var articleLabels = map[string]string{
+	structure.TypeMadde:         "Madde",
+	structure.TypeGeciciMadde:   "Geçici Madde",
+	structure.TypeEkMadde:       "Ek Madde",
+	structure.TypeEkGeciciMadde: "Ek Geçici Madde",
+}
+
+// HTML renders a comparison as an HTML fragment: a section per article with
+// the class diff-<status>, deleted text in <del> and inserted text in <ins>
+func HTML(result Result) string {
+	var b strings.Builder
+	b.WriteString(`<div class="diff">` + "\n")
+	for _, section := range result.Sections {
+		b.WriteString(`<section class="diff-section diff-` + section.Status + `"`)
+		if section.Anchor != "" {
+			b.WriteString(` id="` + html.EscapeString(section.Anchor) + `"`)
+		}
+		b.WriteString(">\n")
+		if heading := sectionHeading(section); heading != "" {
+			b.WriteString("<h3>" + html.EscapeString(heading) + "</h3>\n")
+		}
+		b.WriteString(`<div class="diff-text">`)
+		for _, op := range section.Ops {
+			// The space after the last word stays outside the markup
+			trimmed := strings.TrimRight(op.Text, " ")
+			text := strings.ReplaceAll(html.EscapeString(trimmed), "\n", "<br>\n")
+			switch op.Type {
+			case OpInsert:
+				text = "<ins>" + text + "</ins>"
+			case OpDelete:
+				text = "<del>" + text + "</del>"
+			}
+			b.WriteString(text + op.Text[len(trimmed):])
+		}
+		b.WriteString("</div>\n</section>\n")
+	}
+	b.WriteString("</div>\n")
+	return b.String()
+}
+
+// sectionHeading is the title shown above a section: "Madde 5 - Amaç"
+func sectionHeading(section Section) string {
+	if section.Anchor == introAnchor {
+		return "Giriş"
+	}
+	label, ok := articleLabels[section.Type]
+	if !ok {
+		return ""
+	}
+	heading := label + " " + section.Number
+	if section.Title != "" {
+		heading += " - " + section.Title
+	}
+	return heading
+}
@@ -0,0 +1,290 @@
+// Package diff compares versions of a document word by word, article by
+// article where the structure of both versions is known
+package diff
+
+import "strings"
+
+// Op types
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxEdits bounds a single comparison: inputs further apart than this are
+// reported as replaced outright instead of searched for a shortest diff
+const maxEdits = 1000
+
+// Op is a run of text that is kept, inserted or deleted. Words are separated
+// by a space and paragraphs by a newline, so joining the texts of the equal
+// and delete ops gives the old text and of equal and insert ops the new one.
+type Op struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// token is a word with the separator that follows it. Paragraph ends are part
+// of the key, so splitting or joining paragraphs counts as a change.
+type token struct {
+	key  string
+	text string
+}
+
+// paragraph is a line of text and its words
+type paragraph struct {
+	key    string // words joined by single spaces
+	tokens []token
+}
+
+// Words compares two texts paragraph by paragraph, then word by word within
+// the paragraphs that changed. Runs of whitespace are not significant.
+func Words(from, to string) []Op {
+	a, b := paragraphs(from), paragraphs(to)
+	aKeys := make([]string, len(a))
+	for i, p := range a {
+		aKeys[i] = p.key
+	}
+	bKeys := make([]string, len(b))
+	for i, p := range b {
+		bKeys[i] = p.key
+	}
+
+	var w opWriter
+	steps, ok := script(aKeys, bKeys)
+	if !ok {
+		w.words(flatten(a), flatten(b))
+		return w.done()
+	}
+	var deleted, inserted []paragraph
+	flush := func() {
+		w.words(flatten(deleted), flatten(inserted))
+		deleted, inserted = deleted[:0], inserted[:0]
+	}
+	for _, s := range steps {
+		switch s.op {
+		case OpDelete:
+			deleted = append(deleted, a[s.a])
+		case OpInsert:
+			inserted = append(inserted, b[s.b])
+		default:
+			flush()
+			for _, t := range b[s.b].tokens {
+				w.write(OpEqual, t.text)
+			}
+		}
+	}
+	flush()
+	return w.done()
+}
+
+// opWriter collects ops, joining consecutive text of the same type
+type opWriter struct {
+	ops  []Op
+	typ  string
+	text strings.Builder
+}
+
+func (w *opWriter) write(typ, text string) {
+	if typ != w.typ {
+		w.flush()
+		w.typ = typ
+	}
+	w.text.WriteString(text)
+}
+
+func (w *opWriter) flush() {
+	if w.text.Len() > 0 {
+		w.ops = append(w.ops, Op{Type: w.typ, Text: w.text.String()})
+		w.text.Reset()
+	}
+}
+
+func (w *opWriter) done() []Op {
+	w.flush()
+	return w.ops
+}
+
+// words writes the word diff of two token lists
+func (w *opWriter) words(a, b []token) {
+	aKeys := make([]string, len(a))
+	for i, t := range a {
+		aKeys[i] = t.key
+	}
+	bKeys := make([]string, len(b))
+	for i, t := range b {
+		bKeys[i] = t.key
+	}
+
+	steps, ok := script(aKeys, bKeys)
+	if !ok {
+		for _, t := range a {
+			w.write(OpDelete, t.text)
+		}
+		for _, t := range b {
+			w.write(OpInsert, t.text)
+		}
+		return
+	}
+	for _, s := range steps {
+		switch s.op {
+		case OpDelete:
+			w.write(OpDelete, a[s.a].text)
+		case OpInsert:
+			w.write(OpInsert, b[s.b].text)
+		default:
+			w.write(OpEqual, b[s.b].text)
+		}
+	}
+}
+
+// paragraphs splits text into its non-empty lines
+func paragraphs(text string) []paragraph {
+	var result []paragraph
+	for _, l := range strings.Split(text, "\n") {
+		words := strings.Fields(l)
+		if len(words) == 0 {
+			continue
+		}
+		p := paragraph{key: strings.Join(words, " "), tokens: make([]token, len(words))}
+		for i, word := range words {
+			if i == len(words)-1 {
+				p.tokens[i] = token{key: word + "\n", text: word + "\n"}
+			} else {
+				p.tokens[i] = token{key: word, text: word + " "}
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func flatten(ps []paragraph) []token {
+	var tokens []token
+	for _, p := range ps {
+		tokens = append(tokens, p.tokens...)
+	}
+	return tokens
+}
+
+// step is one element of an edit script: a[a] kept as b[b], a[a] deleted or
+// b[b] inserted
+type step struct {
+	op   string
+	a, b int
+}
+
+// script returns the shortest edit script turning a into b (Myers' algorithm),
+// deletions before insertions within a change, or ok false when it takes more
+// than maxEdits edits
+func script(a, b []string) ([]step, bool) {
+	// Common ends take no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	steps := make([]step, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		steps = append(steps, step{op: OpEqual, a: i, b: i})
+	}
+	middle, ok := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		return nil, false
+	}
+	var inserts []step
+	for _, s := range middle {
+		s.a += prefix
+		s.b += prefix
+		switch s.op {
+		case OpInsert:
+			inserts = append(inserts, s)
+		case OpDelete:
+			steps = append(steps, s)
+		default:
+			steps = append(append(steps, inserts...), s)
+			inserts = inserts[:0]
+		}
+	}
+	steps = append(steps, inserts...)
+	for i := suffix; i > 0; i-- {
+		steps = append(steps, step{op: OpEqual, a: len(a) - i, b: len(b) - i})
+	}
+	return steps, true
+}
+
+func myers(a, b []string) ([]step, bool) {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v for diagonals -d-1..d+1 before round d
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down: insert
+			} else {
+				x = v[offset+k-1] + 1 // right: delete
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, d, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack follows the trace of myers back from (n, m) after d edits
+func backtrack(trace [][]int, d, n, m int) []step {
+	var reversed []step
+	x, y := n, m
+	for ; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, step{op: OpEqual, a: x, b: y})
+		}
+		if x == prevX {
+			reversed = append(reversed, step{op: OpInsert, a: x, b: prevY})
+		} else {
+			reversed = append(reversed, step{op: OpDelete, a: prevX, b: y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, step{op: OpEqual, a: x, b: y})
+	}
+
+	steps := make([]step, len(reversed))
+	for i, s := range reversed {
+		steps[len(reversed)-1-i] = s
+	}
+	return steps
+}
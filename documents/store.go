@@ -0,0 +1,289 @@
+// Package documents writes document metadata and content together, keeping
+// slugs unique and the derived data of content (citations, versions,
+// fingerprints) in step
+package documents
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/dedup"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+	"legal-documents-api/textnorm"
+	"legal-documents-api/utils"
+	"legal-documents-api/versions"
+)
+
+const (
+	// timestampLayout is how the string dates of new records are written
+	timestampLayout = "2006-01-02 15:04:05"
+	// slugAttempts bounds how often a generated slug is replaced after
+	// another document took it between the check and the insert
+	slugAttempts = 5
+)
+
+var (
+	// ErrNotFound is returned when no document has the requested id
+	ErrNotFound = errors.New("document not found")
+	// ErrSlugTaken is returned when another document already uses a slug
+	ErrSlugTaken = errors.New("url_slug is already used by another document")
+)
+
+// EnsureIndexes creates the unique slug index that keeps concurrent writes
+// from sharing a slug. Documents without a slug are left out of it.
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetMetadataCollection(client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "url_slug", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"url_slug": bson.M{"$type": "string", "$gt": ""}}),
+	})
+	return err
+}
+
+// SlugAvailable reports whether no document other than exclude uses slug
+func SlugAvailable(ctx context.Context, client *mongo.Client, slug string, exclude primitive.ObjectID) (bool, error) {
+	filter := bson.M{"url_slug": slug}
+	if !exclude.IsZero() {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+	n, err := config.GetMetadataCollection(client).CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n == 0, err
+}
+
+// UniqueSlug returns the slug of title, followed by -2, -3, ... when a
+// document other than exclude already uses it
+func UniqueSlug(ctx context.Context, client *mongo.Client, title string, exclude primitive.ObjectID) (string, error) {
+	base := textnorm.Slug(title)
+	if base == "" {
+		base = "belge"
+	}
+	slug := base
+	for n := 2; ; n++ {
+		available, err := SlugAvailable(ctx, client, slug, exclude)
+		if err != nil || available {
+			return slug, err
+		}
+		slug = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// Get returns the metadata and content of a document in any status. The
+// content is empty when the document has none.
+func Get(ctx context.Context, client *mongo.Client, id primitive.ObjectID) (models.DocumentMetadata, models.DocumentContent, error) {
+	var metadata models.DocumentMetadata
+	var content models.DocumentContent
+	err := config.GetMetadataCollection(client).FindOne(ctx, bson.M{"_id": id}).Decode(&metadata)
+	if err == mongo.ErrNoDocuments {
+		return metadata, content, ErrNotFound
+	}
+	if err != nil {
+		return metadata, content, err
+	}
+	err = config.GetContentCollection(client).FindOne(ctx, bson.M{"metadata_id": id}).Decode(&content)
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	return metadata, content, err
+}
+
+// Create inserts a new document with its content. A slug is generated from
+// the title when metadata has none; a given slug must be free. The metadata
+// is removed again when the content cannot be written, so no document is
+// left without content. A document duplicating existing ones is refused with
+// a *dedup.DuplicateError when the duplicate policy blocks them. Failing to
+// fingerprint or version the new document is logged rather than returned:
+// the document is stored, and the startup backfill and the snapshotter catch
+// up with it.
+func Create(ctx context.Context, client *mongo.Client, metadata *models.DocumentMetadata, icerik, note string) (models.DocumentContent, error) {
+	var content models.DocumentContent
+	if err := dedup.Check(ctx, client, metadata.PdfAdi, icerik); err != nil {
+		return content, err
+	}
+	generated := metadata.URLSlug == ""
+	if err := assignSlug(ctx, client, metadata); err != nil {
+		return content, err
+	}
+
+	now := time.Now().UTC()
+	metadata.ID = primitive.NewObjectID()
+	if metadata.Status == "" {
+		metadata.Status = editorial.Aktif
+	}
+	if metadata.Status == editorial.Aktif {
+		metadata.PublishedAt = &now
+	}
+	if metadata.YuklemeTarihi == "" {
+		metadata.YuklemeTarihi = now.Format(timestampLayout)
+	}
+	if metadata.OlusturulmaTarihi == "" {
+		metadata.OlusturulmaTarihi = now.Format(timestampLayout)
+	}
+	utils.SetParsedDates(metadata)
+
+	if err := insertMetadata(ctx, client, metadata, generated); err != nil {
+		return content, err
+	}
+
+	content = models.DocumentContent{
+		ID:                primitive.NewObjectID(),
+		MetadataID:        metadata.ID,
+		Icerik:            icerik,
+		OlusturulmaTarihi: now.Format(timestampLayout),
+		ContentHash:       utils.ContentHash(icerik),
+	}
+	if _, err := config.GetContentCollection(client).InsertOne(ctx, content); err != nil {
+		config.GetMetadataCollection(client).DeleteOne(ctx, bson.M{"_id": metadata.ID})
+		return content, err
+	}
+
+	if err := dedup.Index(ctx, client, metadata.ID, icerik); err != nil {
+		log.Printf("Warning: Failed to fingerprint document %s: %v", metadata.ID.Hex(), err)
+	}
+	if _, _, err := versions.Capture(ctx, client, *metadata, content, note); err != nil {
+		log.Printf("Warning: Failed to capture version of document %s: %v", metadata.ID.Hex(), err)
+	}
+	return content, nil
+}
+
+// insertMetadata inserts the metadata of a new document. The unique slug
+// index refuses a slug another document took since it was checked; a
+// generated slug is then replaced by the next free one, a given one is
+// reported as taken.
+func insertMetadata(ctx context.Context, client *mongo.Client, metadata *models.DocumentMetadata, generated bool) error {
+	for attempt := 1; ; attempt++ {
+		_, err := config.GetMetadataCollection(client).InsertOne(ctx, metadata)
+		if err == nil || !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		if !generated || attempt == slugAttempts {
+			return ErrSlugTaken
+		}
+		slug, err := UniqueSlug(ctx, client, metadata.PdfAdi, primitive.NilObjectID)
+		if err != nil {
+			return err
+		}
+		metadata.URLSlug = slug
+	}
+}
+
+// Update replaces the metadata of an existing document and, when icerik is
+// not nil, its content. The id and slug of metadata must be set; a changed
+// slug must be free. The status and published_at are owned by the review
+// workflow and left as stored; metadata is refreshed with the stored values,
+// so a status changed since metadata was read is not undone.
+func Update(ctx context.Context, client *mongo.Client, metadata *models.DocumentMetadata, icerik *string, note string) (models.DocumentContent, error) {
+	var content models.DocumentContent
+	available, err := SlugAvailable(ctx, client, metadata.URLSlug, metadata.ID)
+	if err != nil {
+		return content, err
+	}
+	if !available {
+		return content, ErrSlugTaken
+	}
+	utils.SetParsedDates(metadata)
+
+	fields, err := toDocument(metadata)
+	if err != nil {
+		return content, err
+	}
+	delete(fields, "_id")
+	delete(fields, "status")
+	delete(fields, "published_at")
+	// Nil dates are left out of fields and must be cleared explicitly
+	unset := bson.M{}
+	for field, date := range map[string]*time.Time{
+		"belge_yayin_date": metadata.BelgeYayinDate,
+		"yukleme_date":     metadata.YuklemeDate,
+		"olusturulma_date": metadata.OlusturulmaDate,
+	} {
+		if date == nil {
+			unset[field] = ""
+		}
+	}
+	update := bson.M{"$set": fields}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	err = config.GetMetadataCollection(client).FindOneAndUpdate(ctx, bson.M{"_id": metadata.ID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(metadata)
+	if err == mongo.ErrNoDocuments {
+		return content, ErrNotFound
+	}
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return content, ErrSlugTaken
+		}
+		return content, err
+	}
+
+	if icerik != nil {
+		return SetContent(ctx, client, *metadata, *icerik, note)
+	}
+	err = config.GetContentCollection(client).FindOne(ctx, bson.M{"metadata_id": metadata.ID}).Decode(&content)
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	return content, err
+}
+
+// SetContent writes the content of a document, creating it when missing.
+// Stored citations are marked for a new scan, the fingerprint is replaced and
+// a version is captured when the text changed; the structure is parsed again
+// on its next use. As in Create, failing to fingerprint or version the
+// content is logged rather than returned once the content is written: the
+// fingerprint backfill and the snapshotter notice the changed text.
+func SetContent(ctx context.Context, client *mongo.Client, metadata models.DocumentMetadata, icerik, note string) (models.DocumentContent, error) {
+	var content models.DocumentContent
+	update := bson.M{
+		"$set":         bson.M{"icerik": icerik, "content_hash": utils.ContentHash(icerik)},
+		"$unset":       bson.M{"atiflar": ""},
+		"$setOnInsert": bson.M{"olusturulma_tarihi": time.Now().UTC().Format(timestampLayout)},
+	}
+	err := config.GetContentCollection(client).FindOneAndUpdate(ctx, bson.M{"metadata_id": metadata.ID}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&content)
+	if err != nil {
+		return content, err
+	}
+	if err := dedup.Index(ctx, client, metadata.ID, icerik); err != nil {
+		log.Printf("Warning: Failed to fingerprint document %s: %v", metadata.ID.Hex(), err)
+	}
+	if _, _, err := versions.Capture(ctx, client, metadata, content, note); err != nil {
+		log.Printf("Warning: Failed to capture version of document %s: %v", metadata.ID.Hex(), err)
+	}
+	return content, nil
+}
+
+// assignSlug generates the slug of a new document or checks the given one
+func assignSlug(ctx context.Context, client *mongo.Client, metadata *models.DocumentMetadata) error {
+	if metadata.URLSlug == "" {
+		slug, err := UniqueSlug(ctx, client, metadata.PdfAdi, primitive.NilObjectID)
+		metadata.URLSlug = slug
+		return err
+	}
+	available, err := SlugAvailable(ctx, client, metadata.URLSlug, primitive.NilObjectID)
+	if err == nil && !available {
+		err = ErrSlugTaken
+	}
+	return err
+}
+
+// toDocument converts metadata to the fields it is stored with
+func toDocument(metadata *models.DocumentMetadata) (bson.M, error) {
+	raw, err := bson.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.M
+	err = bson.Unmarshal(raw, &fields)
+	return fields, err
+}
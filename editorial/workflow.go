@@ -0,0 +1,239 @@
+// Package editorial is the review workflow of documents: the statuses a
+// document moves through, the transitions allowed between them and the audit
+// trail of every change. Read handlers ask it which statuses they may serve.
+package editorial
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+)
+
+// Document statuses. A document is written as a draft (taslak), reviewed
+// (inceleme) and published (aktif); a published document is later archived
+// (arsiv) or repealed (yururlukten_kalkmis).
+const (
+	Taslak             = "taslak"
+	Inceleme           = "inceleme"
+	Aktif              = "aktif"
+	Arsiv              = "arsiv"
+	YururluktenKalkmis = "yururlukten_kalkmis"
+	Silindi            = "silindi" // soft deleted
+
+	// Statuses written before the workflow existed. Pasif documents were
+	// taken offline by hand; beklemede documents were ingested for review
+	// and are treated as inceleme.
+	Pasif     = "pasif"
+	Beklemede = "beklemede"
+)
+
+// transitions lists the statuses each status may change to
+var transitions = map[string][]string{
+	Taslak:             {Inceleme, Silindi},
+	Inceleme:           {Aktif, Taslak, Silindi},
+	Beklemede:          {Aktif, Taslak, Silindi},
+	Aktif:              {Arsiv, YururluktenKalkmis, Inceleme, Silindi},
+	Arsiv:              {Aktif, Silindi},
+	YururluktenKalkmis: {Aktif, Arsiv, Silindi},
+	Pasif:              {Aktif, Taslak, Arsiv, YururluktenKalkmis, Silindi},
+	Silindi:            {Taslak},
+}
+
+// initialStatuses are the statuses a new document may be created with
+var initialStatuses = []string{Taslak, Inceleme, Aktif}
+
+var (
+	// listed are the statuses of documents in search, listings, sitemaps
+	// and statistics
+	listed = []string{Aktif, YururluktenKalkmis}
+	// visible are the statuses of documents served by slug; archived
+	// documents leave the listings but their links keep working
+	visible = []string{Aktif, YururluktenKalkmis, Arsiv}
+)
+
+var (
+	// ErrNotFound is returned when no document has the requested id
+	ErrNotFound = errors.New("document not found")
+	// ErrInvalidTransition is returned for a change the workflow does not allow
+	ErrInvalidTransition = errors.New("status change not allowed")
+	// ErrConflict is returned when the status changed while it was being
+	// changed by another request
+	ErrConflict = errors.New("status was changed concurrently, reload and try again")
+)
+
+// Listed reports whether documents with the status appear in search results,
+// listings and sitemaps
+func Listed(status string) bool {
+	return contains(listed, status)
+}
+
+// Visible reports whether documents with the status are served by slug
+func Visible(status string) bool {
+	return contains(visible, status)
+}
+
+// ListedFilter is the status condition of queries for listed documents
+func ListedFilter() bson.M {
+	return bson.M{"$in": listed}
+}
+
+// VisibleFilter is the status condition of queries for documents served by
+// slug
+func VisibleFilter() bson.M {
+	return bson.M{"$in": visible}
+}
+
+// Known reports whether status is part of the workflow
+func Known(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// Initial reports whether a document may be created with the status
+func Initial(status string) bool {
+	return contains(initialStatuses, status)
+}
+
+// InitialStatuses returns the statuses a document may be created with
+func InitialStatuses() []string {
+	return append([]string(nil), initialStatuses...)
+}
+
+// Next returns the statuses a document in status may change to
+func Next(status string) []string {
+	next := append([]string(nil), transitions[status]...)
+	sort.Strings(next)
+	return next
+}
+
+// Allowed reports whether a document may change from one status to another
+func Allowed(from, to string) bool {
+	return contains(transitions[from], to)
+}
+
+// Change moves a document to a new status and records the change, stamping
+// published_at when it becomes aktif. The document must still be in the
+// status it was read in; a concurrent change fails with ErrConflict. When the
+// audit entry cannot be written the status is put back, so a document never
+// changes without a history entry and the change can be retried. reviewer is
+// the authenticated user; reviewerName is an optional free-text name.
+func Change(ctx context.Context, client *mongo.Client, id primitive.ObjectID, to, reviewer, reviewerName, comment string) (models.DocumentMetadata, models.StatusChange, error) {
+	var metadata models.DocumentMetadata
+	var change models.StatusChange
+	collection := config.GetMetadataCollection(client)
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&metadata); err != nil {
+		if err == mongo.ErrNoDocuments {
+			err = ErrNotFound
+		}
+		return metadata, change, err
+	}
+	from := metadata.Status
+	if !Allowed(from, to) {
+		return metadata, change, ErrInvalidTransition
+	}
+	previous := metadata
+
+	set := bson.M{"status": to}
+	if to == Aktif {
+		set["published_at"] = time.Now().UTC()
+	}
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&metadata)
+	if err == mongo.ErrNoDocuments {
+		return metadata, change, ErrConflict
+	}
+	if err != nil {
+		return metadata, change, err
+	}
+
+	change, err = record(ctx, client, newChange(id, from, to, reviewer, reviewerName, comment))
+	if err != nil {
+		if rollbackErr := rollback(ctx, collection, previous, to); rollbackErr != nil {
+			log.Printf("editorial: document %s left in status %s without a history entry: %v", id.Hex(), to, rollbackErr)
+		}
+		return previous, change, err
+	}
+	return metadata, change, nil
+}
+
+// rollback puts a document changed to status back the way it was before
+func rollback(ctx context.Context, collection *mongo.Collection, previous models.DocumentMetadata, status string) error {
+	update := bson.M{"$set": bson.M{"status": previous.Status}}
+	if status == Aktif {
+		if previous.PublishedAt != nil {
+			update["$set"].(bson.M)["published_at"] = *previous.PublishedAt
+		} else {
+			update["$unset"] = bson.M{"published_at": ""}
+		}
+	}
+	// A fresh context: the request's may be what made the record fail
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": previous.ID, "status": status}, update)
+	return err
+}
+
+// Record adds an entry to the audit trail of a document. Change records its
+// own entries; Record is for documents created with a status.
+func Record(ctx context.Context, client *mongo.Client, id primitive.ObjectID, from, to, reviewer, comment string) (models.StatusChange, error) {
+	return record(ctx, client, newChange(id, from, to, reviewer, "", comment))
+}
+
+func newChange(id primitive.ObjectID, from, to, reviewer, reviewerName, comment string) models.StatusChange {
+	return models.StatusChange{
+		ID:           primitive.NewObjectID(),
+		DocumentID:   id,
+		From:         from,
+		To:           to,
+		Reviewer:     reviewer,
+		ReviewerName: reviewerName,
+		Comment:      comment,
+		ChangedAt:    time.Now().UTC(),
+	}
+}
+
+func record(ctx context.Context, client *mongo.Client, change models.StatusChange) (models.StatusChange, error) {
+	_, err := config.GetStatusHistoryCollection(client).InsertOne(ctx, change)
+	return change, err
+}
+
+// History returns the audit trail of a document, oldest first
+func History(ctx context.Context, client *mongo.Client, id primitive.ObjectID) ([]models.StatusChange, error) {
+	cursor, err := config.GetStatusHistoryCollection(client).Find(ctx, bson.M{"document_id": id},
+		options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	history := []models.StatusChange{}
+	err = cursor.All(ctx, &history)
+	return history, err
+}
+
+// EnsureIndexes creates the status_history indexes
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetStatusHistoryCollection(client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "document_id", Value: 1}, {Key: "changed_at", Value: 1}}},
+		{Keys: bson.D{{Key: "reviewer", Value: 1}, {Key: "changed_at", Value: -1}}},
+	})
+	return err
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
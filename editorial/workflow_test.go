@@ -0,0 +1,73 @@
+package editorial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransitions(t *testing.T) {
+	statuses := []string{Taslak, Inceleme, Beklemede, Aktif, Arsiv, YururluktenKalkmis, Pasif, Silindi}
+	// allowed lists, for each status, the statuses it may change to
+	allowed := map[string]string{
+		Taslak:             "inceleme silindi",
+		Inceleme:           "aktif silindi taslak",
+		Beklemede:          "aktif silindi taslak", // as inceleme
+		Aktif:              "arsiv inceleme silindi yururlukten_kalkmis",
+		Arsiv:              "aktif silindi",
+		YururluktenKalkmis: "aktif arsiv silindi",
+		Pasif:              "aktif arsiv silindi taslak yururlukten_kalkmis",
+		Silindi:            "taslak",
+	}
+	for _, from := range statuses {
+		if !Known(from) {
+			t.Errorf("Known(%q) = false", from)
+		}
+		if got := strings.Join(Next(from), " "); got != allowed[from] {
+			t.Errorf("Next(%q) = %q, want %q", from, got, allowed[from])
+		}
+		for _, to := range statuses {
+			want := strings.Contains(" "+allowed[from]+" ", " "+to+" ")
+			if Allowed(from, to) != want {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", from, to, !want, want)
+			}
+		}
+	}
+
+	// Legacy statuses can be left but not entered
+	for _, from := range statuses {
+		if Allowed(from, Pasif) || Allowed(from, Beklemede) {
+			t.Errorf("%q may change to a legacy status", from)
+		}
+	}
+	if Known("yayinda") || Allowed("yayinda", Aktif) || len(Next("yayinda")) != 0 {
+		t.Errorf("an unknown status is part of the workflow")
+	}
+}
+
+func TestStatusSets(t *testing.T) {
+	tests := []struct {
+		status                   string
+		initial, listed, visible bool
+	}{
+		{Taslak, true, false, false},
+		{Inceleme, true, false, false},
+		{Beklemede, false, false, false},
+		{Aktif, true, true, true},
+		{Arsiv, false, false, true},
+		{YururluktenKalkmis, false, true, true},
+		{Pasif, false, false, false},
+		{Silindi, false, false, false},
+	}
+	for _, tt := range tests {
+		if Initial(tt.status) != tt.initial || Listed(tt.status) != tt.listed || Visible(tt.status) != tt.visible {
+			t.Errorf("%s: initial %v listed %v visible %v, want %v %v %v", tt.status,
+				Initial(tt.status), Listed(tt.status), Visible(tt.status), tt.initial, tt.listed, tt.visible)
+		}
+	}
+
+	// Callers get a copy
+	InitialStatuses()[0] = Silindi
+	if !Initial(Taslak) || Initial(Silindi) {
+		t.Errorf("InitialStatuses shares its slice")
+	}
+}
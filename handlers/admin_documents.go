@@ -0,0 +1,414 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "errors"
+        "fmt"
+        "io"
+        "log"
+        "net/http"
+        "net/url"
+        "regexp"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+
+        "legal-documents-api/dedup"
+        "legal-documents-api/documents"
+        "legal-documents-api/editorial"
+        "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/semantic"
+        "legal-documents-api/utils"
+)
+
+// slugPattern is the form of a valid url_slug
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// DocumentRequest is the body accepted when creating or changing a document.
+// PATCH bodies may hold any subset of the fields.
+type DocumentRequest struct {
+        PdfAdi           string  `json:"pdf_adi"`
+        KurumID          string  `json:"kurum_id"`
+        BelgeTuru        string  `json:"belge_turu"`
+        BelgeDurumu      string  `json:"belge_durumu"`
+        BelgeYayinTarihi string  `json:"belge_yayin_tarihi"`
+        Etiketler        string  `json:"etiketler"`
+        AnahtarKelimeler string  `json:"anahtar_kelimeler"`
+        Aciklama         string  `json:"aciklama"`
+        URLSlug          string  `json:"url_slug"`
+        Status           string  `json:"status"`
+        SayfaSayisi      int32   `json:"sayfa_sayisi"`
+        DosyaBoyutuMB    float64 `json:"dosya_boyutu_mb"`
+        YuklemeTarihi    string  `json:"yukleme_tarihi"`
+        PdfURL           string  `json:"pdf_url"`
+        Icerik           *string `json:"icerik"`
+        Note             string  `json:"note"`            // recorded with the version when icerik changes
+        AllowDuplicate   bool    `json:"allow_duplicate"` // create even when the duplicate policy blocks it
+}
+
+// newDocumentRequest returns the request that would leave metadata unchanged
+func newDocumentRequest(metadata models.DocumentMetadata) DocumentRequest {
+        return DocumentRequest{
+                PdfAdi:           metadata.PdfAdi,
+                KurumID:          metadata.KurumID,
+                BelgeTuru:        metadata.BelgeTuru,
+                BelgeDurumu:      metadata.BelgeDurumu,
+                BelgeYayinTarihi: metadata.BelgeYayinTarihi,
+                Etiketler:        metadata.Etiketler,
+                AnahtarKelimeler: metadata.AnahtarKelimeler,
+                Aciklama:         metadata.Aciklama,
+                URLSlug:          metadata.URLSlug,
+                Status:           metadata.Status,
+                SayfaSayisi:      metadata.SayfaSayisi,
+                DosyaBoyutuMB:    metadata.DosyaBoyutuMB,
+                YuklemeTarihi:    metadata.YuklemeTarihi,
+                PdfURL:           metadata.PdfURL,
+        }
+}
+
+// validate trims the request and checks its fields. Content is required when
+// creating or replacing a document. Only the fields in present are checked, or
+// all of them when present is nil, so that a PATCH is not refused for stored
+// values it does not touch.
+func (req *DocumentRequest) validate(requireContent bool, present map[string]bool) error {
+        for _, field := range []*string{&req.PdfAdi, &req.KurumID, &req.BelgeTuru, &req.BelgeDurumu, &req.BelgeYayinTarihi,
+                &req.Etiketler, &req.AnahtarKelimeler, &req.Aciklama, &req.URLSlug, &req.Status, &req.YuklemeTarihi, &req.PdfURL, &req.Note} {
+                *field = strings.TrimSpace(*field)
+        }
+        check := func(field string) bool {
+                return present == nil || present[field]
+        }
+
+        if check("pdf_adi") && req.PdfAdi == "" {
+                return fmt.Errorf("'pdf_adi' is required")
+        }
+        if check("kurum_id") {
+                if req.KurumID == "" {
+                        return fmt.Errorf("'kurum_id' is required")
+                }
+                if _, ok := utils.GetKurumByID(req.KurumID); !ok {
+                        return fmt.Errorf("Unknown kurum_id '%s'", req.KurumID)
+                }
+        }
+        if check("status") {
+                if req.Status == "" {
+                        req.Status = editorial.Aktif
+                }
+                if !editorial.Known(req.Status) {
+                        return fmt.Errorf("Invalid status '%s'", req.Status)
+                }
+        }
+        if check("url_slug") && req.URLSlug != "" && (len(req.URLSlug) < 3 || len(req.URLSlug) > 120 || !slugPattern.MatchString(req.URLSlug)) {
+                return fmt.Errorf("Invalid url_slug '%s' (3-120 lowercase letters, digits and single hyphens)", req.URLSlug)
+        }
+        if check("belge_yayin_tarihi") && req.BelgeYayinTarihi != "" {
+                if _, ok := utils.ParseDate(req.BelgeYayinTarihi); !ok {
+                        return fmt.Errorf("Invalid belge_yayin_tarihi '%s'", req.BelgeYayinTarihi)
+                }
+        }
+        if check("yukleme_tarihi") && req.YuklemeTarihi != "" {
+                if _, ok := utils.ParseDate(req.YuklemeTarihi); !ok {
+                        return fmt.Errorf("Invalid yukleme_tarihi '%s'", req.YuklemeTarihi)
+                }
+        }
+        if check("sayfa_sayisi") && req.SayfaSayisi < 0 {
+                return fmt.Errorf("'sayfa_sayisi' cannot be negative")
+        }
+        if check("dosya_boyutu_mb") && req.DosyaBoyutuMB < 0 {
+                return fmt.Errorf("'dosya_boyutu_mb' cannot be negative")
+        }
+        if check("pdf_url") && req.PdfURL != "" {
+                if u, err := url.Parse(req.PdfURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+                        return fmt.Errorf("Invalid pdf_url '%s'", req.PdfURL)
+                }
+        }
+        if requireContent && (req.Icerik == nil || strings.TrimSpace(*req.Icerik) == "") {
+                return fmt.Errorf("'icerik' is required")
+        }
+        return nil
+}
+
+// apply copies the fields of the request to metadata
+func (req *DocumentRequest) apply(metadata *models.DocumentMetadata) {
+        metadata.PdfAdi = req.PdfAdi
+        metadata.KurumID = req.KurumID
+        metadata.BelgeTuru = req.BelgeTuru
+        metadata.BelgeDurumu = req.BelgeDurumu
+        metadata.BelgeYayinTarihi = req.BelgeYayinTarihi
+        metadata.Etiketler = req.Etiketler
+        metadata.AnahtarKelimeler = req.AnahtarKelimeler
+        metadata.Aciklama = req.Aciklama
+        if req.URLSlug != "" {
+                metadata.URLSlug = req.URLSlug
+        }
+        metadata.Status = req.Status
+        metadata.SayfaSayisi = req.SayfaSayisi
+        metadata.DosyaBoyutuMB = req.DosyaBoyutuMB
+        if req.YuklemeTarihi != "" {
+                metadata.YuklemeTarihi = req.YuklemeTarihi
+        }
+        metadata.PdfURL = req.PdfURL
+}
+
+// CreateDocument adds a document with its content
+func CreateDocument(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        var req DocumentRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        if err := req.validate(true, nil); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
+        }
+        if !editorial.Initial(req.Status) {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "New documents start as one of: "+strings.Join(editorial.InitialStatuses(), ", "))
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+        if req.AllowDuplicate {
+                ctx = dedup.AllowDuplicates(ctx)
+        }
+
+        var metadata models.DocumentMetadata
+        req.apply(&metadata)
+        content, err := documents.Create(ctx, mongoClient, &metadata, *req.Icerik, req.Note)
+        if err != nil {
+                sendDocumentError(w, "create document", err)
+                return
+        }
+        if _, err := editorial.Record(ctx, mongoClient, metadata.ID, "", metadata.Status, reviewerOf(r), req.Note); err != nil {
+                log.Printf("Warning: Failed to record status of %s: %v", metadata.ID.Hex(), err)
+        }
+        syncDocumentIndexes(ctx, metadata, content, true)
+
+        utils.SendCreatedResponse(w, newDocumentDetails(metadata, content), "Belge oluşturuldu")
+}
+
+// GetAdminDocument returns a document with its content in any status
+func GetAdminDocument(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := documentID(w, r)
+        if !ok {
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, content, err := documents.Get(ctx, mongoClient, id)
+        if err != nil {
+                sendDocumentError(w, "fetch document", err)
+                return
+        }
+
+        utils.SendSuccessResponse(w, newDocumentDetails(metadata, content), "Document details fetched successfully")
+}
+
+// ReplaceDocument replaces the metadata and content of a document. The slug
+// and status are kept unless the body gives new ones.
+func ReplaceDocument(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := documentID(w, r)
+        if !ok {
+                return
+        }
+
+        var req DocumentRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+
+        updateDocument(w, id, true, nil, func(metadata models.DocumentMetadata) DocumentRequest {
+                if strings.TrimSpace(req.Status) == "" {
+                        req.Status = metadata.Status
+                }
+                return req
+        })
+}
+
+// PatchDocument changes the fields of a document given in the body, leaving
+// the others as they are. Content is only written when icerik is given.
+func PatchDocument(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := documentID(w, r)
+        if !ok {
+                return
+        }
+
+        body, err := io.ReadAll(r.Body)
+        var probe DocumentRequest
+        var fields map[string]json.RawMessage
+        if err == nil {
+                err = json.Unmarshal(body, &probe)
+        }
+        if err == nil {
+                err = json.Unmarshal(body, &fields)
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        present := make(map[string]bool, len(fields))
+        for field := range fields {
+                present[strings.ToLower(field)] = true // as json matches them
+        }
+
+        updateDocument(w, id, false, present, func(metadata models.DocumentMetadata) DocumentRequest {
+                // Fields missing from the body keep their current values
+                req := newDocumentRequest(metadata)
+                json.Unmarshal(body, &req)
+                return req
+        })
+}
+
+// updateDocument loads a document, builds the request to apply to it and
+// writes the result, validating the fields in present (all when nil). The
+// status is changed through the review workflow only.
+func updateDocument(w http.ResponseWriter, id primitive.ObjectID, requireContent bool, present map[string]bool, build func(models.DocumentMetadata) DocumentRequest) {
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, _, err := documents.Get(ctx, mongoClient, id)
+        if err != nil {
+                sendDocumentError(w, "fetch document", err)
+                return
+        }
+        req := build(metadata)
+        if err := req.validate(requireContent, present); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
+        }
+        if req.Status != metadata.Status {
+                utils.SendErrorResponse(w, http.StatusConflict, "Status is changed through POST /api/v1/admin/documents/{id}/status")
+                return
+        }
+
+        req.apply(&metadata)
+        content, err := documents.Update(ctx, mongoClient, &metadata, req.Icerik, req.Note)
+        if err != nil {
+                sendDocumentError(w, "update document", err)
+                return
+        }
+        syncDocumentIndexes(ctx, metadata, content, req.Icerik != nil)
+
+        utils.SendSuccessResponse(w, newDocumentDetails(metadata, content), "Belge güncellendi")
+}
+
+// DeleteDocument soft deletes a document: it is kept with status "silindi"
+// and no longer served. The deletion is recorded in the audit trail with the
+// optional comment query parameter.
+func DeleteDocument(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := documentID(w, r)
+        if !ok {
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, _, err := editorial.Change(ctx, mongoClient, id, editorial.Silindi, reviewerOf(r), "", r.URL.Query().Get("comment"))
+        if err != nil {
+                sendDocumentError(w, "delete document", err)
+                return
+        }
+        syncDocumentIndexes(ctx, metadata, models.DocumentContent{}, false)
+
+        utils.SendSuccessResponse(w, metadata, "Belge silindi")
+}
+
+// syncDocumentIndexes brings the in-memory indexes in line with a written
+// document instead of waiting for their next refresh
+func syncDocumentIndexes(ctx context.Context, metadata models.DocumentMetadata, content models.DocumentContent, contentChanged bool) {
+        if searchEngine != nil {
+                if editorial.Listed(metadata.Status) {
+                        searchEngine.Index(search.Document{
+                                Metadata: metadata,
+                                KurumAdi: utils.GetKurumAdiByID(metadata.KurumID),
+                                Content:  content.Icerik,
+                        })
+                } else {
+                        searchEngine.Remove(metadata.ID.Hex())
+                }
+        }
+        if semanticIndex != nil && contentChanged {
+                if err := semantic.Forget(ctx, mongoClient, semanticIndex, metadata.ID); err != nil {
+                        log.Printf("Warning: Failed to drop vectors of %s: %v", metadata.ID.Hex(), err)
+                }
+        }
+}
+
+// newDocumentDetails combines a document with its institution
+func newDocumentDetails(metadata models.DocumentMetadata, content models.DocumentContent) models.DocumentDetails {
+        return models.DocumentDetails{
+                Metadata:      metadata,
+                Content:       content,
+                KurumAdi:      utils.GetKurumAdiByID(metadata.KurumID),
+                KurumLogo:     utils.GetKurumLogoByID(metadata.KurumID),
+                KurumAciklama: utils.GetKurumAciklamaByID(metadata.KurumID),
+        }
+}
+
+// sendDocumentError writes the response for an error of the documents store.
+// Refused duplicates are listed in the data of the response.
+func sendDocumentError(w http.ResponseWriter, action string, err error) {
+        var dupErr *dedup.DuplicateError
+        if errors.As(err, &dupErr) {
+                w.Header().Set("Content-Type", "application/json")
+                w.WriteHeader(http.StatusConflict)
+                json.NewEncoder(w).Encode(models.APIResponse{
+                        Success: false,
+                        Data:    dupErr.Matches,
+                        Count:   len(dupErr.Matches),
+                        Error:   err.Error() + " (set allow_duplicate to store it anyway)",
+                })
+                return
+        }
+
+        switch err {
+        case documents.ErrNotFound, editorial.ErrNotFound:
+                utils.SendErrorResponse(w, http.StatusNotFound, "Document not found")
+        case documents.ErrSlugTaken, editorial.ErrInvalidTransition, editorial.ErrConflict:
+                utils.SendErrorResponse(w, http.StatusConflict, err.Error())
+        default:
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to "+action+": "+err.Error())
+        }
+}
+
+func documentID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+        id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid document id")
+                return id, false
+        }
+        return id, true
+}
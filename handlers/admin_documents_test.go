@@ -0,0 +1,62 @@
+package handlers
+
+import (
+        "strings"
+        "testing"
+
+        "legal-documents-api/editorial"
+)
+
+func TestDocumentRequestValidate(t *testing.T) {
+        content := "Madde 1 - Metin"
+        // A stored document with values that no longer validate
+        stored := DocumentRequest{
+                PdfAdi:           "  Genelge  ",
+                KurumID:          "silinmis-kurum",
+                BelgeYayinTarihi: "belirsiz",
+                URLSlug:          "Eski Slug",
+                PdfURL:           "ftp://eski",
+        }
+
+        tests := []struct {
+                name    string
+                change  func(*DocumentRequest)
+                present map[string]bool
+                want    string // part of the error, empty when valid
+        }{
+                {"untouched stored values are not checked", func(*DocumentRequest) {}, map[string]bool{"aciklama": true}, ""},
+                {"an empty patch", func(*DocumentRequest) {}, map[string]bool{}, ""},
+                {"a patched invalid slug", func(r *DocumentRequest) {}, map[string]bool{"url_slug": true}, "Invalid url_slug"},
+                {"a patched valid slug", func(r *DocumentRequest) { r.URLSlug = "yeni-slug" }, map[string]bool{"url_slug": true}, ""},
+                {"a patched unknown institution", func(*DocumentRequest) {}, map[string]bool{"kurum_id": true}, "Unknown kurum_id"},
+                {"a patched empty title", func(r *DocumentRequest) { r.PdfAdi = "   " }, map[string]bool{"pdf_adi": true}, "'pdf_adi' is required"},
+                {"a patched date", func(r *DocumentRequest) { r.BelgeYayinTarihi = "23 Eylül 2025" }, map[string]bool{"belge_yayin_tarihi": true}, ""},
+                {"a patched bad date", func(*DocumentRequest) {}, map[string]bool{"belge_yayin_tarihi": true}, "Invalid belge_yayin_tarihi"},
+                {"a patched negative page count", func(r *DocumentRequest) { r.SayfaSayisi = -1 }, map[string]bool{"sayfa_sayisi": true}, "cannot be negative"},
+                {"a patched bad url", func(*DocumentRequest) {}, map[string]bool{"pdf_url": true}, "Invalid pdf_url"},
+                {"a patched unknown status", func(r *DocumentRequest) { r.Status = "yayinda" }, map[string]bool{"status": true}, "Invalid status"},
+                {"every field without present", func(*DocumentRequest) {}, nil, "Unknown kurum_id"},
+        }
+        for _, tt := range tests {
+                req := stored
+                req.Icerik = &content
+                tt.change(&req)
+                err := req.validate(false, tt.present)
+                if tt.want == "" && err != nil {
+                        t.Errorf("%s: validate = %v, want no error", tt.name, err)
+                }
+                if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+                        t.Errorf("%s: validate = %v, want %q", tt.name, err, tt.want)
+                }
+        }
+
+        // Fields are trimmed and an empty status becomes the default
+        req := DocumentRequest{PdfAdi: "  Genelge  "}
+        if err := req.validate(false, map[string]bool{"pdf_adi": true, "status": true}); err != nil || req.PdfAdi != "Genelge" || req.Status != editorial.Aktif {
+                t.Errorf("validate = %v, request %q %q, want trimmed title and status %q", err, req.PdfAdi, req.Status, editorial.Aktif)
+        }
+        // Content is required when creating or replacing
+        if err := (&DocumentRequest{PdfAdi: "Genelge"}).validate(true, map[string]bool{}); err == nil || !strings.Contains(err.Error(), "'icerik' is required") {
+                t.Errorf("validate without content = %v, want 'icerik' is required", err)
+        }
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+
+        "legal-documents-api/analytics"
+        "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/textnorm"
+        "legal-documents-api/utils"
+)
+
+// analyticsRecorder stores search events; nil disables recording
+var analyticsRecorder *analytics.Recorder
+
+// SetAnalyticsRecorder sets the recorder used by the search handlers
+func SetAnalyticsRecorder(recorder *analytics.Recorder) {
+        analyticsRecorder = recorder
+}
+
+// recordedFilters are the search parameters stored with each search event
+var recordedFilters = []string{"kurum", "kurum_id", "belge_turu", "belge_durumu", "yil", "from", "to", "date_field", "sort"}
+
+// ClickRequest reports that a user opened a search result
+type ClickRequest struct {
+        SearchID string `json:"search_id"`
+        Slug     string `json:"slug"`
+        Position int    `json:"position"` // 1-based rank of the result
+}
+
+// recordSearch queues a search event and returns its id for click attribution.
+// kurumIDs are the institutions of the documents found, so that popular
+// queries can be suggested under an institution filter without searching.
+func recordSearch(r *http.Request, query string, resultCount int, kurumIDs []string, started time.Time) string {
+        filters := make(map[string]string)
+        for _, name := range recordedFilters {
+                if value := r.URL.Query().Get(name); value != "" {
+                        filters[name] = value
+                }
+        }
+
+        id := analyticsRecorder.Record(analytics.Event{
+                Type:        analytics.EventSearch,
+                Query:       query,
+                Normalized:  textnorm.Normalize(query),
+                Filters:     filters,
+                ResultCount: resultCount,
+                KurumIDs:    kurumIDs,
+                LatencyMs:   float64(time.Since(started).Microseconds()) / 1000,
+        })
+        return id.Hex()
+}
+
+// hitKurumIDs returns the distinct institutions of search hits
+func hitKurumIDs(hits []search.Hit) []string {
+        seen := make(map[string]bool)
+        var ids []string
+        for _, hit := range hits {
+                if id := hit.Document.Metadata.KurumID; id != "" && !seen[id] {
+                        seen[id] = true
+                        ids = append(ids, id)
+                }
+        }
+        return ids
+}
+
+// recordAutocomplete queues an autocomplete event
+func recordAutocomplete(query string, resultCount int, started time.Time) {
+        analyticsRecorder.Record(analytics.Event{
+                Type:        analytics.EventAutocomplete,
+                Query:       query,
+                Normalized:  textnorm.Normalize(query),
+                ResultCount: resultCount,
+                LatencyMs:   float64(time.Since(started).Microseconds()) / 1000,
+        })
+}
+
+// RecordSearchClick records a click on a search result. The body may be sent
+// with navigator.sendBeacon, so the content type is not checked.
+func RecordSearchClick(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        var req ClickRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+
+        searchID, err := primitive.ObjectIDFromHex(req.SearchID)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid search_id")
+                return
+        }
+        req.Slug = strings.TrimSpace(req.Slug)
+        if req.Slug == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'slug' is required")
+                return
+        }
+
+        analyticsRecorder.Record(analytics.Event{
+                Type:     analytics.EventClick,
+                SearchID: &searchID,
+                Slug:     req.Slug,
+                Position: req.Position,
+        })
+
+        w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTopQueries reports the most frequent search queries
+func GetTopQueries(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        since, limit := reportRange(r)
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        stats, err := analytics.TopQueries(ctx, mongoClient, since, limit)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to build top queries report: "+err.Error())
+                return
+        }
+        sendReport(w, stats, len(stats))
+}
+
+// GetZeroResultQueries reports the most frequent searches that found nothing
+func GetZeroResultQueries(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        since, limit := reportRange(r)
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        stats, err := analytics.ZeroResultQueries(ctx, mongoClient, since, limit)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to build zero result report: "+err.Error())
+                return
+        }
+        sendReport(w, stats, len(stats))
+}
+
+// GetClickThroughRate reports the overall click-through rate of searches
+func GetClickThroughRate(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        since, _ := reportRange(r)
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        report, err := analytics.ClickThrough(ctx, mongoClient, since)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to build click-through report: "+err.Error())
+                return
+        }
+        sendReport(w, report, 0)
+}
+
+// reportRange reads the days (default 30) and limit (default 50) report parameters
+func reportRange(r *http.Request) (time.Time, int) {
+        days := 30
+        if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+                if parsedDays, err := strconv.Atoi(daysStr); err == nil && parsedDays > 0 && parsedDays <= 365 {
+                        days = parsedDays
+                }
+        }
+        limit := 50
+        if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+                if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 500 {
+                        limit = parsedLimit
+                }
+        }
+        return time.Now().UTC().AddDate(0, 0, -days), limit
+}
+
+func sendReport(w http.ResponseWriter, data interface{}, count int) {
+        response := models.APIResponse{
+                Success: true,
+                Data:    data,
+                Count:   count,
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
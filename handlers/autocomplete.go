@@ -4,27 +4,26 @@ import (
         "context"
         "encoding/json"
         "net/http"
-        "regexp"
         "sort"
         "strconv"
         "strings"
         "time"
+        "unicode/utf8"
 
-        "go.mongodb.org/mongo-driver/bson"
-        "go.mongodb.org/mongo-driver/bson/primitive"
-        "go.mongodb.org/mongo-driver/mongo"
-        "go.mongodb.org/mongo-driver/mongo/options"
-
-        "legal-documents-api/config"
+        "legal-documents-api/analytics"
         "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/textnorm"
         "legal-documents-api/utils"
 )
 
 // SuggestionItem represents an autocomplete suggestion
 type SuggestionItem struct {
-        Text  string `json:"text"`
-        Count int    `json:"count"`
-        Type  string `json:"type"` // "title", "keyword", "tag", "institution"
+        Text       string `json:"text"`
+        Count      int    `json:"count"`
+        Type       string `json:"type"`                 // "title", "synonym", "keyword", "tag", "institution", "popular"
+        Fuzzy      bool   `json:"fuzzy,omitempty"`      // completes a corrected spelling of the query
+        Popularity int    `json:"popularity,omitempty"` // recent searches for this exact text
 }
 
 // AutocompleteResponse represents the response structure for autocomplete
@@ -38,6 +37,7 @@ func Autocomplete(w http.ResponseWriter, r *http.Request) {
                 w.WriteHeader(http.StatusOK)
                 return
         }
+        started := time.Now()
 
         // Get query parameter
         query := r.URL.Query().Get("q")
@@ -48,7 +48,7 @@ func Autocomplete(w http.ResponseWriter, r *http.Request) {
 
         // Clean and prepare query
         query = strings.TrimSpace(query)
-        if len(query) < 2 {
+        if utf8.RuneCountInString(textnorm.Normalize(query)) < 2 {
                 utils.SendErrorResponse(w, http.StatusBadRequest, "Query must be at least 2 characters long")
                 return
         }
@@ -68,12 +68,13 @@ func Autocomplete(w http.ResponseWriter, r *http.Request) {
         ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
         defer cancel()
 
-        // Get suggestions from database
+        // Get suggestions from the search engine
         suggestions, err := getSuggestions(ctx, query, institution, limit)
         if err != nil {
                 utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to get suggestions: "+err.Error())
                 return
         }
+        recordAutocomplete(query, len(suggestions), started)
 
         response := models.APIResponse{
                 Success: true,
@@ -88,436 +89,162 @@ func Autocomplete(w http.ResponseWriter, r *http.Request) {
         json.NewEncoder(w).Encode(response)
 }
 
-// getSuggestions retrieves word suggestions from MongoDB
+// suggestionTypes maps search engine fields to the suggestion types exposed by the API
+var suggestionTypes = map[search.Field]string{
+        search.FieldTitle:       "title",
+        search.FieldInstitution: "institution",
+        search.FieldKeywords:    "keyword",
+        search.FieldTags:        "tag",
+}
+
+// getSuggestions retrieves completions from the search engine's suggestion
+// dictionary, merged with synonyms and popular queries
 func getSuggestions(ctx context.Context, query string, institution string, limit int) ([]SuggestionItem, error) {
-        collection := config.GetMetadataCollection(mongoClient)
-        
-        // Create case-insensitive regex pattern for multi-word support
-        // Handle both single words and phrases
-        var searchRegex bson.M
-        if strings.Contains(query, " ") {
-                // Multi-word query: escape each word and join with flexible spacing
-                words := strings.Fields(query)
-                escapedWords := make([]string, len(words))
-                for i, word := range words {
-                        escapedWords[i] = regexp.QuoteMeta(word)
-                }
-                // Allow flexible spacing between words and partial match on last word
-                if len(escapedWords) > 1 {
-                        lastWord := escapedWords[len(escapedWords)-1]
-                        prefix := strings.Join(escapedWords[:len(escapedWords)-1], `\s+`)
-                        pattern := "(?i)" + prefix + `\s+\w*` + lastWord
-                        searchRegex = bson.M{"$regex": primitive.Regex{Pattern: pattern, Options: "i"}}
-                } else {
-                        pattern := "(?i)" + regexp.QuoteMeta(query)
-                        searchRegex = bson.M{"$regex": primitive.Regex{Pattern: pattern, Options: "i"}}
-                }
-        } else {
-                // Single word query
-                pattern := "(?i)" + regexp.QuoteMeta(query)
-                searchRegex = bson.M{"$regex": primitive.Regex{Pattern: pattern, Options: "i"}}
-        }
-        
-        // Build base filter
-        baseFilter := bson.M{"status": "aktif"}
-        
         // Add institution filter if specified (using kurum_id from cache)
+        var kurumID string
         if institution != "" {
                 // Find kurum_id by kurum_adi from cache
-                var kurumID string
-                allKurumlar := utils.GetAllKurumlar()
-                for _, kurum := range allKurumlar {
-                        if strings.Contains(strings.ToLower(kurum.KurumAdi), strings.ToLower(institution)) {
-                                kurumID = kurum.ID.Hex()
-                                break
-                        }
-                }
+                kurumID = utils.FindKurumIDByName(institution)
                 
-                if kurumID != "" {
-                        baseFilter["kurum_id"] = kurumID
-                } else {
-                        // Institution not found, return empty filter to get no results
-                        baseFilter["_id"] = bson.M{"$exists": false}
+                if kurumID == "" {
+                        // Institution not found, no suggestions can match
+                        return []SuggestionItem{}, nil
                 }
         }
 
         // Map to store unique suggestions with their counts
         suggestionMap := make(map[string]*SuggestionItem)
 
-        // Search in titles (highest priority)
-        titleFilter := bson.M{"pdf_adi": searchRegex}
-        for k, v := range baseFilter {
-                titleFilter[k] = v
-        }
-        
-        if err := extractSuggestions(ctx, collection, titleFilter, "pdf_adi", "title", query, suggestionMap); err != nil {
-                return nil, err
-        }
-
-        // Search in keywords
-        keywordFilter := bson.M{"anahtar_kelimeler": searchRegex}
-        for k, v := range baseFilter {
-                keywordFilter[k] = v
-        }
-        
-        if err := extractSuggestions(ctx, collection, keywordFilter, "anahtar_kelimeler", "keyword", query, suggestionMap); err != nil {
-                return nil, err
-        }
-
-        // Search in tags
-        tagFilter := bson.M{"etiketler": searchRegex}
-        for k, v := range baseFilter {
-                tagFilter[k] = v
-        }
-        
-        if err := extractSuggestions(ctx, collection, tagFilter, "etiketler", "tag", query, suggestionMap); err != nil {
-                return nil, err
-        }
-
-        // TODO: Content search temporarily disabled for performance optimization
-        // Will re-enable with better indexing and optimization
-        // if err := extractContentSuggestions(contentCtx, query, baseFilter, suggestionMap); err != nil {
-        //     // Log but don't fail - content search is optional and may timeout
-        // }
-
-        // Search in institution names (from cache, not database field)
-        // Since kurum_adi is no longer in metadata, we'll add institution suggestions from cache
-        allKurumlar := utils.GetAllKurumlar()
-        queryLower := strings.ToLower(query)
-        for _, kurum := range allKurumlar {
-                kurumAdiLower := strings.ToLower(kurum.KurumAdi)
-                if strings.Contains(kurumAdiLower, queryLower) {
-                        // Extract words from institution name
-                        words := extractRelevantWords(kurum.KurumAdi, queryLower)
-                        for _, word := range words {
-                                wordLower := strings.ToLower(word)
-                                if !strings.Contains(wordLower, queryLower) {
-                                        continue
-                                }
-                                
-                                if existing, exists := suggestionMap[wordLower]; exists {
-                                        existing.Count++
-                                        if getTypePriority("institution") < getTypePriority(existing.Type) {
-                                                existing.Type = "institution"
-                                        }
-                                } else {
-                                        suggestionMap[wordLower] = &SuggestionItem{
-                                                Text:  word,
-                                                Count: 1,
-                                                Type:  "institution",
-                                        }
-                                }
+        // Completions from titles, keywords, tags and institution names. While
+        // the index is still being built only institution names are available.
+        queryNormalized := textnorm.Normalize(query)
+        if searchEngine != nil && searchEngine.Ready() {
+                engineSuggestions, err := searchEngine.Suggest(ctx, search.SuggestQuery{
+                        Text:    query,
+                        KurumID: kurumID,
+                        Limit:   limit,
+                })
+                if err != nil {
+                        return nil, err
+                }
+                for _, suggestion := range engineSuggestions {
+                        suggestionMap[textnorm.Normalize(suggestion.Text)] = &SuggestionItem{
+                                Text:  suggestion.Text,
+                                Count: suggestion.Count,
+                                Type:  suggestionTypes[suggestion.Field],
+                                Fuzzy: suggestion.Fuzzy,
                         }
                 }
-        }
 
-        // Convert map to slice and sort by relevance
-        suggestions := make([]SuggestionItem, 0, len(suggestionMap))
-        for _, suggestion := range suggestionMap {
-                suggestions = append(suggestions, *suggestion)
-        }
-
-        // Sort by count (descending) and then by type priority
-        sort.Slice(suggestions, func(i, j int) bool {
-                if suggestions[i].Count == suggestions[j].Count {
-                        return getTypePriority(suggestions[i].Type) < getTypePriority(suggestions[j].Type)
-                }
-                return suggestions[i].Count > suggestions[j].Count
-        })
-
-        // Limit results
-        if len(suggestions) > limit {
-                suggestions = suggestions[:limit]
-        }
-
-        return suggestions, nil
-}
-
-// extractSuggestions extracts word suggestions from a specific field
-func extractSuggestions(ctx context.Context, collection *mongo.Collection, filter bson.M, field string, suggestionType string, query string, suggestionMap map[string]*SuggestionItem) error {
-        findOptions := options.Find()
-        findOptions.SetLimit(100) // Limit documents to process
-        findOptions.SetProjection(bson.M{field: 1})
-
-        cursor, err := collection.Find(ctx, filter, findOptions)
-        if err != nil {
-                return err
-        }
-        defer cursor.Close(ctx)
-
-        queryLower := strings.ToLower(query)
-        
-        for cursor.Next(ctx) {
-                var doc bson.M
-                if err := cursor.Decode(&doc); err != nil {
-                        continue
-                }
-
-                fieldValue, ok := doc[field].(string)
-                if !ok {
-                        continue
-                }
-
-                // Extract words that start with or contain the query
-                words := extractRelevantWords(fieldValue, queryLower)
-                
-                for _, word := range words {
-                        wordLower := strings.ToLower(word)
-                        
-                        // Skip if word doesn't contain the query
-                        if !strings.Contains(wordLower, queryLower) {
+                // Dictionary forms of the whole query ("kvkk" -> "Kişisel Verilerin
+                // Korunması Kanunu"), counted from the suggestion dictionary
+                for _, synonym := range utils.GetSynonyms(query) {
+                        key := textnorm.Normalize(synonym)
+                        if _, exists := suggestionMap[key]; exists {
                                 continue
                         }
-
-                        // Add to suggestion map or increase count
-                        if existing, exists := suggestionMap[wordLower]; exists {
-                                existing.Count++
-                                // Prefer higher priority types
-                                if getTypePriority(suggestionType) < getTypePriority(existing.Type) {
-                                        existing.Type = suggestionType
-                                }
-                        } else {
-                                suggestionMap[wordLower] = &SuggestionItem{
-                                        Text:  word,
-                                        Count: 1,
-                                        Type:  suggestionType,
+                        if count := searchEngine.SuggestionCount(key, kurumID); count > 0 {
+                                suggestionMap[key] = &SuggestionItem{
+                                        Text:  synonym,
+                                        Count: count,
+                                        Type:  "synonym",
                                 }
                         }
                 }
-        }
-
-        return cursor.Err()
-}
-
-// extractRelevantWords extracts words and phrases from text that are relevant to the query
-func extractRelevantWords(text, queryLower string) []string {
-        var relevantWords []string
-        
-        // Handle multi-word queries (phrases)
-        if strings.Contains(queryLower, " ") {
-                queryWords := strings.Fields(queryLower)
-                if len(queryWords) > 1 {
-                        // Look for phrases that start with the query words
-                        lastWord := queryWords[len(queryWords)-1]
-                        
-                        // Find phrases in text that contain the prefix and have words starting with lastWord
-                        words := strings.Fields(text)
-                        for i := 0; i < len(words); i++ {
-                                // Try to match starting from each position
-                                if i+len(queryWords) <= len(words) {
-                                        // Check if we can form a phrase starting here
-                                        phraseWords := make([]string, 0)
-                                        matchCount := 0
-                                        
-                                        // Check prefix words
-                                        for j, qWord := range queryWords[:len(queryWords)-1] {
-                                                if i+j < len(words) {
-                                                        cleanWord := regexp.MustCompile(`[^\p{L}\p{N}]`).ReplaceAllString(words[i+j], "")
-                                                        if strings.Contains(strings.ToLower(cleanWord), qWord) {
-                                                                phraseWords = append(phraseWords, cleanWord)
-                                                                matchCount++
-                                                        }
-                                                }
-                                        }
-                                        
-                                        // Check if we found a phrase candidate and look for words that start with lastWord
-                                        if matchCount == len(queryWords)-1 {
-                                                for k := i + len(queryWords) - 1; k < len(words) && k < i+len(queryWords)+2; k++ {
-                                                        cleanWord := regexp.MustCompile(`[^\p{L}\p{N}]`).ReplaceAllString(words[k], "")
-                                                        if len(cleanWord) >= len(lastWord) && strings.HasPrefix(strings.ToLower(cleanWord), lastWord) {
-                                                                fullPhrase := strings.Join(phraseWords, " ") + " " + cleanWord
-                                                                relevantWords = append(relevantWords, fullPhrase)
-                                                                break
-                                                        }
-                                                }
-                                        }
+        } else if kurumID == "" {
+                for _, kurum := range utils.GetAllKurumlar() {
+                        if textnorm.Contains(kurum.KurumAdi, queryNormalized) {
+                                suggestionMap[textnorm.Normalize(kurum.KurumAdi)] = &SuggestionItem{
+                                        Text: kurum.KurumAdi,
+                                        Type: "institution",
                                 }
                         }
                 }
         }
-        
-        // Also extract individual words that contain the query (for single word or fallback)
-        words := strings.Fields(text)
-        for _, word := range words {
-                // Clean word (remove punctuation, etc.)
-                cleanWord := regexp.MustCompile(`[^\p{L}\p{N}]`).ReplaceAllString(word, "")
-                if len(cleanWord) < 2 {
+
+        // Queries other users searched often. Popularity only breaks ties
+        // between completions and fills the list after them, so repeated
+        // searches cannot push arbitrary text to the top. With an institution
+        // filter a popular query must have found documents of that
+        // institution when it was searched.
+        for _, popular := range analytics.PopularWithPrefix(queryNormalized, kurumID, limit) {
+                if existing, exists := suggestionMap[popular.Normalized]; exists {
+                        existing.Popularity = popular.Searches
                         continue
                 }
-                
-                // Check if word contains the query
-                if strings.Contains(strings.ToLower(cleanWord), queryLower) {
-                        relevantWords = append(relevantWords, cleanWord)
+                suggestionMap[popular.Normalized] = &SuggestionItem{
+                        Text:       popular.Query,
+                        Count:      popular.Searches,
+                        Type:       "popular",
+                        Popularity: popular.Searches,
                 }
         }
-        
-        return relevantWords
-}
 
-// getTypePriority returns priority order for suggestion types (lower = higher priority)
-// extractContentSuggestions searches in content collection for both words and phrases
-func extractContentSuggestions(ctx context.Context, query string, baseFilter bson.M, suggestionMap map[string]*SuggestionItem) error {
-        contentCollection := config.GetContentCollection(mongoClient)
-        
-        // Create regex for content search
-        queryLower := strings.ToLower(query)
-        searchRegex := bson.M{"$regex": primitive.Regex{Pattern: query, Options: "i"}}
-        
-        // Build content filter - we need to match metadata_id to kurum_id filter
-        contentFilter := bson.M{"icerik": searchRegex}
-        
-        // If we have institution filter, we need to join with metadata to get kurum_id
-        if kurumID, exists := baseFilter["kurum_id"]; exists {
-                // First get metadata_ids for this kurum_id
-                metadataCollection := config.GetMetadataCollection(mongoClient)
-                metadataFilter := bson.M{
-                        "kurum_id": kurumID,
-                        "status":   "aktif",
-                }
-                
-                cursor, err := metadataCollection.Find(ctx, metadataFilter, options.Find().SetProjection(bson.M{"_id": 1}))
-                if err != nil {
-                        return err
-                }
-                defer cursor.Close(ctx)
-                
-                var metadataIDs []interface{}
-                for cursor.Next(ctx) {
-                        var doc bson.M
-                        if err := cursor.Decode(&doc); err != nil {
-                                continue
-                        }
-                        if id, ok := doc["_id"]; ok {
-                                metadataIDs = append(metadataIDs, id)
-                        }
-                }
-                
-                if len(metadataIDs) == 0 {
-                        return nil // No metadata for this institution
-                }
-                
-                contentFilter["metadata_id"] = bson.M{"$in": metadataIDs}
-        }
-        
-        // Limit content search to prevent performance issues
-        findOptions := options.Find()
-        findOptions.SetLimit(5) // Very low limit for content search to improve performance
-        findOptions.SetProjection(bson.M{"icerik": 1})
-        
-        cursor, err := contentCollection.Find(ctx, contentFilter, findOptions)
-        if err != nil {
-                return err
-        }
-        defer cursor.Close(ctx)
-        
-        for cursor.Next(ctx) {
-                var doc bson.M
-                if err := cursor.Decode(&doc); err != nil {
-                        continue
-                }
-                
-                content, ok := doc["icerik"].(string)
-                if !ok || content == "" {
-                        continue
-                }
-                
-                // Extract individual words (skip phrases for performance)
-                extractContentWords(content, queryLower, suggestionMap)
-                // Skip phrase extraction for now to improve performance
-                // extractContentPhrases(content, queryLower, suggestionMap)
+        // Convert map to slice and sort by relevance
+        suggestions := make([]SuggestionItem, 0, len(suggestionMap))
+        for _, suggestion := range suggestionMap {
+                suggestions = append(suggestions, *suggestion)
         }
-        
-        return cursor.Err()
-}
 
-// extractContentWords extracts individual words from content
-func extractContentWords(content, query string, suggestionMap map[string]*SuggestionItem) {
-        words := extractRelevantWords(content, query)
-        for _, word := range words {
-                wordLower := strings.ToLower(word)
-                if !strings.Contains(wordLower, query) || len(word) < 3 {
-                        continue
+        // Sort exact completions before popular queries and corrections, then
+        // by count (descending), popularity, type priority and text so the
+        // order does not depend on map iteration
+        sort.Slice(suggestions, func(i, j int) bool {
+                if ri, rj := suggestionRank(suggestions[i]), suggestionRank(suggestions[j]); ri != rj {
+                        return ri < rj
                 }
-                
-                if existing, exists := suggestionMap[wordLower]; exists {
-                        existing.Count++
-                        if getTypePriority("content") < getTypePriority(existing.Type) {
-                                existing.Type = "content"
-                        }
-                } else {
-                        suggestionMap[wordLower] = &SuggestionItem{
-                                Text:  word,
-                                Count: 1,
-                                Type:  "content",
-                        }
+                if suggestions[i].Count != suggestions[j].Count {
+                        return suggestions[i].Count > suggestions[j].Count
                 }
-        }
-}
-
-// extractContentPhrases extracts short phrases (2-4 words) from content
-func extractContentPhrases(content, query string, suggestionMap map[string]*SuggestionItem) {
-        // Split content into sentences
-        sentences := strings.FieldsFunc(content, func(r rune) bool {
-                return r == '.' || r == '!' || r == '?' || r == '\n'
-        })
-        
-        for _, sentence := range sentences {
-                sentence = strings.TrimSpace(sentence)
-                if len(sentence) < 10 || !strings.Contains(strings.ToLower(sentence), query) {
-                        continue
+                if suggestions[i].Popularity != suggestions[j].Popularity {
+                        return suggestions[i].Popularity > suggestions[j].Popularity
                 }
-                
-                // Extract phrases around the query term
-                words := strings.Fields(sentence)
-                for i, word := range words {
-                        if strings.Contains(strings.ToLower(word), query) {
-                                // Extract 2-4 word phrases around this position
-                                for phraseLen := 2; phraseLen <= 4; phraseLen++ {
-                                        for start := max(0, i-phraseLen+1); start <= i && start+phraseLen <= len(words); start++ {
-                                                phrase := strings.Join(words[start:start+phraseLen], " ")
-                                                phraseLower := strings.ToLower(phrase)
-                                                
-                                                if strings.Contains(phraseLower, query) && len(phrase) > len(query)+5 {
-                                                        if existing, exists := suggestionMap[phraseLower]; exists {
-                                                                existing.Count++
-                                                        } else {
-                                                                suggestionMap[phraseLower] = &SuggestionItem{
-                                                                        Text:  phrase,
-                                                                        Count: 1,
-                                                                        Type:  "phrase",
-                                                                }
-                                                        }
-                                                }
-                                        }
-                                }
-                        }
+                if pi, pj := getTypePriority(suggestions[i].Type), getTypePriority(suggestions[j].Type); pi != pj {
+                        return pi < pj
                 }
+                return suggestions[i].Text < suggestions[j].Text
+        })
+
+        // Limit results
+        if len(suggestions) > limit {
+                suggestions = suggestions[:limit]
         }
+
+        return suggestions, nil
 }
 
-// max helper function
-func max(a, b int) int {
-        if a > b {
-                return a
+// suggestionRank groups suggestions: completions of the query, then popular
+// queries found only in search history, then completions of a correction
+func suggestionRank(suggestion SuggestionItem) int {
+        switch {
+        case suggestion.Fuzzy:
+                return 2
+        case suggestion.Type == "popular":
+                return 1
         }
-        return b
+        return 0
 }
 
+// getTypePriority returns priority order for suggestion types (lower = higher priority)
 func getTypePriority(suggestionType string) int {
         switch suggestionType {
         case "title":
                 return 1
-        case "phrase":
+        case "synonym":
                 return 2
-        case "keyword":
+        case "phrase":
                 return 3
-        case "tag":
+        case "keyword":
                 return 4
-        case "content":
+        case "tag":
                 return 5
-        case "institution":
+        case "content":
                 return 6
-        default:
+        case "institution":
                 return 7
+        case "popular":
+                return 8
+        default:
+                return 9
         }
 }
\ No newline at end of file
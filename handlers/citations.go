@@ -0,0 +1,262 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "sort"
+        "strconv"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+        "go.mongodb.org/mongo-driver/mongo"
+
+        "legal-documents-api/config"
+        "legal-documents-api/editorial"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+)
+
+// ReferenceItem is a piece of legislation a document cites
+type ReferenceItem struct {
+        Text   string                  `json:"text"` // "5510 sayılı Kanun m. 4"
+        Kind   string                  `json:"kind"`
+        Number string                  `json:"number,omitempty"`
+        Name   string                  `json:"name,omitempty"`
+        Madde  string                  `json:"madde,omitempty"`
+        Count  int                     `json:"count"`
+        Target *models.DocumentSummary `json:"target,omitempty"` // the cited document, when known
+}
+
+// CitedByItem is a document citing the requested one
+type CitedByItem struct {
+        Document models.DocumentSummary `json:"document"`
+        Maddeler []string               `json:"maddeler,omitempty"` // cited articles of the requested document
+        Count    int                    `json:"count"`              // citations in the citing document
+}
+
+// GetDocumentReferences lists the legislation cited by a document, with the
+// cited document where the citation could be resolved. resolved=true leaves
+// out citations of documents that are not in the database.
+func GetDocumentReferences(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        filter := bson.M{"source_id": metadata.ID}
+        if r.URL.Query().Get("resolved") == "true" {
+                filter["target_id"] = bson.M{"$exists": true}
+        }
+        cursor, err := config.GetCitationsCollection(mongoClient).Find(ctx, filter)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch references: "+err.Error())
+                return
+        }
+        var citations []models.Citation
+        if err := cursor.All(ctx, &citations); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode references: "+err.Error())
+                return
+        }
+
+        var targetIDs []primitive.ObjectID
+        for _, citation := range citations {
+                if citation.TargetID != nil {
+                        targetIDs = append(targetIDs, *citation.TargetID)
+                }
+        }
+        targets, err := activeSummaries(ctx, targetIDs)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch cited documents: "+err.Error())
+                return
+        }
+
+        references := make([]ReferenceItem, 0, len(citations))
+        for _, citation := range citations {
+                item := ReferenceItem{
+                        Text:   citation.Text,
+                        Kind:   citation.Kind,
+                        Number: citation.Number,
+                        Name:   citation.Name,
+                        Madde:  citation.Madde,
+                        Count:  citation.Count,
+                }
+                if citation.TargetID != nil {
+                        if target, ok := targets[*citation.TargetID]; ok {
+                                item.Target = &target
+                        }
+                }
+                references = append(references, item)
+        }
+        // Resolved citations first, then the most frequent
+        sort.SliceStable(references, func(i, j int) bool {
+                if (references[i].Target != nil) != (references[j].Target != nil) {
+                        return references[i].Target != nil
+                }
+                if references[i].Count != references[j].Count {
+                        return references[i].Count > references[j].Count
+                }
+                return references[i].Text < references[j].Text
+        })
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    references,
+                Count:   len(references),
+                Message: "References fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// GetDocumentCitedBy lists the active documents citing a document, most
+// citations first, with the articles of it they cite
+func GetDocumentCitedBy(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        limit := 50
+        if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+                if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 200 {
+                        limit = parsedLimit
+                }
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        pipeline := []bson.M{
+                {"$match": bson.M{"target_id": metadata.ID}},
+                {"$group": bson.M{
+                        "_id":      "$source_id",
+                        "count":    bson.M{"$sum": "$count"},
+                        "maddeler": bson.M{"$addToSet": "$madde"},
+                }},
+        }
+        cursor, err := config.GetCitationsCollection(mongoClient).Aggregate(ctx, pipeline)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch citing documents: "+err.Error())
+                return
+        }
+        var groups []struct {
+                SourceID primitive.ObjectID `bson:"_id"`
+                Count    int                `bson:"count"`
+                Maddeler []string           `bson:"maddeler"`
+        }
+        if err := cursor.All(ctx, &groups); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode citing documents: "+err.Error())
+                return
+        }
+
+        sourceIDs := make([]primitive.ObjectID, len(groups))
+        for i, group := range groups {
+                sourceIDs[i] = group.SourceID
+        }
+        sources, err := activeSummaries(ctx, sourceIDs)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch citing documents: "+err.Error())
+                return
+        }
+
+        citedBy := make([]CitedByItem, 0, len(groups))
+        for _, group := range groups {
+                source, ok := sources[group.SourceID]
+                if !ok {
+                        continue // no longer active
+                }
+                item := CitedByItem{Document: source, Count: group.Count}
+                for _, madde := range group.Maddeler {
+                        if madde != "" {
+                                item.Maddeler = append(item.Maddeler, madde)
+                        }
+                }
+                sort.Strings(item.Maddeler)
+                citedBy = append(citedBy, item)
+        }
+        sort.Slice(citedBy, func(i, j int) bool {
+                if citedBy[i].Count != citedBy[j].Count {
+                        return citedBy[i].Count > citedBy[j].Count
+                }
+                return citedBy[i].Document.PdfAdi < citedBy[j].Document.PdfAdi
+        })
+        total := len(citedBy)
+        if len(citedBy) > limit {
+                citedBy = citedBy[:limit]
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    citedBy,
+                Count:   len(citedBy),
+                Meta:    map[string]int{"total": total},
+                Message: "Citing documents fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// findActiveDocument fetches the published document with the slug in the
+// request. Errors are written to w and reported with ok false.
+func findActiveDocument(ctx context.Context, w http.ResponseWriter, r *http.Request) (models.DocumentMetadata, bool) {
+        var metadata models.DocumentMetadata
+        slug := strings.TrimSpace(mux.Vars(r)["slug"])
+        if len(slug) < 3 {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid document slug")
+                return metadata, false
+        }
+
+        err := config.GetMetadataCollection(mongoClient).FindOne(ctx, bson.M{"url_slug": slug, "status": editorial.VisibleFilter()}).Decode(&metadata)
+        if err == mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Document not found")
+                return metadata, false
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document metadata: "+err.Error())
+                return metadata, false
+        }
+        return metadata, true
+}
+
+// activeSummaries returns the summaries of the published documents among ids
+func activeSummaries(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.DocumentSummary, error) {
+        summaries := make(map[primitive.ObjectID]models.DocumentSummary, len(ids))
+        if len(ids) == 0 {
+                return summaries, nil
+        }
+        cursor, err := config.GetMetadataCollection(mongoClient).Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "status": editorial.VisibleFilter()})
+        if err != nil {
+                return nil, err
+        }
+        defer cursor.Close(ctx)
+
+        for cursor.Next(ctx) {
+                var metadata models.DocumentMetadata
+                if err := cursor.Decode(&metadata); err != nil {
+                        continue
+                }
+                summaries[metadata.ID] = newDocumentSummary(metadata)
+        }
+        return summaries, cursor.Err()
+}
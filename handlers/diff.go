@@ -0,0 +1,142 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "fmt"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+
+        "legal-documents-api/diff"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+        "legal-documents-api/versions"
+)
+
+// DiffResponse is the comparison of two versions of a document
+type DiffResponse struct {
+        URLSlug string                 `json:"url_slug"`
+        PdfAdi  string                 `json:"pdf_adi"`
+        From    models.DocumentVersion `json:"from"`
+        To      models.DocumentVersion `json:"to"`
+        diff.Result
+        HTML string `json:"html"`
+}
+
+// GetDocumentDiff compares two versions of a document, article by article
+// where both have a recognisable structure. from and to take a version number
+// or a date (the version in force on it); to defaults to the current version
+// and from to the one before to. Unchanged articles are listed without their
+// text unless full=true; format=html returns only the HTML rendering.
+func GetDocumentDiff(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        query := r.URL.Query()
+        format := query.Get("format")
+        if format != "" && format != "json" && format != "html" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid format '%s' (supported: json, html)", format))
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+        list, err := versions.List(ctx, mongoClient, metadata.ID)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document versions: "+err.Error())
+                return
+        }
+
+        toStr := strings.TrimSpace(query.Get("to"))
+        if toStr == "" && len(list) > 0 {
+                toStr = strconv.Itoa(list[len(list)-1].Version)
+        }
+        to, ok := resolveVersion(ctx, w, metadata.ID, "to", toStr)
+        if !ok {
+                return
+        }
+        fromStr := strings.TrimSpace(query.Get("from"))
+        if fromStr == "" {
+                if to.Version <= 1 {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Document has no earlier version to compare with")
+                        return
+                }
+                fromStr = strconv.Itoa(to.Version - 1)
+        }
+        from, ok := resolveVersion(ctx, w, metadata.ID, "from", fromStr)
+        if !ok {
+                return
+        }
+
+        result := diff.Compare(from.Icerik, to.Icerik)
+        rendering := diff.HTML(result)
+        if format == "html" {
+                w.Header().Set("Content-Type", "text/html; charset=utf-8")
+                w.WriteHeader(http.StatusOK)
+                w.Write([]byte(rendering))
+                return
+        }
+
+        if query.Get("full") != "true" {
+                for i := range result.Sections {
+                        if result.Sections[i].Status == diff.StatusUnchanged {
+                                result.Sections[i].Ops = nil
+                        }
+                }
+        }
+        from.Icerik, to.Icerik = "", ""
+
+        response := models.APIResponse{
+                Success: true,
+                Data: DiffResponse{
+                        URLSlug: metadata.URLSlug,
+                        PdfAdi:  metadata.PdfAdi,
+                        From:    *from,
+                        To:      *to,
+                        Result:  result,
+                        HTML:    rendering,
+                },
+                Message: "Document diff computed successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// resolveVersion fetches the version a from/to parameter names: a version
+// number, or a date for the version in force on it. Errors are written to w
+// and reported with ok false.
+func resolveVersion(ctx context.Context, w http.ResponseWriter, documentID primitive.ObjectID, name, value string) (*models.DocumentVersion, bool) {
+        var version *models.DocumentVersion
+        var err error
+        if n, convErr := strconv.Atoi(value); convErr == nil {
+                version, err = versions.Get(ctx, mongoClient, documentID, n)
+        } else if date, ok := utils.ParseDate(value); ok {
+                version, err = versions.AsOf(ctx, mongoClient, documentID, endOfDay(date))
+        } else {
+                utils.SendErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s '%s' (a version number or a date)", name, value))
+                return nil, false
+        }
+
+        if err == versions.ErrNotFound {
+                utils.SendErrorResponse(w, http.StatusNotFound, fmt.Sprintf("No version of the document matches %s '%s'", name, value))
+                return nil, false
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document version: "+err.Error())
+                return nil, false
+        }
+        return version, true
+}
@@ -14,7 +14,9 @@ import (
         "go.mongodb.org/mongo-driver/mongo/options"
 
         "legal-documents-api/config"
+        "legal-documents-api/editorial"
         "legal-documents-api/models"
+        "legal-documents-api/textnorm"
         "legal-documents-api/utils"
 )
 
@@ -60,7 +62,7 @@ func GetDocumentsByInstitution(w http.ResponseWriter, r *http.Request) {
         // Build filter directly with kurum_id
         filter := bson.M{
                 "kurum_id": kurumID,
-                "status":   "aktif",
+                "status":   editorial.ListedFilter(),
         }
 
         // Additional filters
@@ -77,7 +79,7 @@ func GetDocumentsByInstitution(w http.ResponseWriter, r *http.Request) {
         // Search in title or description
         search := r.URL.Query().Get("search")
         if search != "" {
-                searchRegex := bson.M{"$regex": primitive.Regex{Pattern: search, Options: "i"}}
+                searchRegex := bson.M{"$regex": primitive.Regex{Pattern: textnorm.RegexPattern(search), Options: "i"}}
                 filter["$or"] = []bson.M{
                         {"pdf_adi": searchRegex},
                         {"aciklama": searchRegex},
@@ -204,7 +206,7 @@ func GetDocumentBySlug(w http.ResponseWriter, r *http.Request) {
         var metadata models.DocumentMetadata
         filter := bson.M{
                 "url_slug": slug,
-                "status":   "aktif",
+                "status":   editorial.VisibleFilter(),
         }
 
         if err := metadataCollection.FindOne(ctx, filter).Decode(&metadata); err != nil {
@@ -316,7 +318,7 @@ func GetDocumentsByInstitutionSlug(w http.ResponseWriter, r *http.Request) {
         // Build filter directly with kurum_id
         filter := bson.M{
                 "kurum_id": kurumID,
-                "status":   "aktif",
+                "status":   editorial.ListedFilter(),
         }
 
         // Additional filters from query parameters
@@ -333,7 +335,7 @@ func GetDocumentsByInstitutionSlug(w http.ResponseWriter, r *http.Request) {
         // Search in title or description
         search := r.URL.Query().Get("search")
         if search != "" {
-                searchRegex := bson.M{"$regex": primitive.Regex{Pattern: search, Options: "i"}}
+                searchRegex := bson.M{"$regex": primitive.Regex{Pattern: textnorm.RegexPattern(search), Options: "i"}}
                 filter["$or"] = []bson.M{
                         {"pdf_adi": searchRegex},
                         {"aciklama": searchRegex},
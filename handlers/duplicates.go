@@ -0,0 +1,177 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+
+        "legal-documents-api/dedup"
+        "legal-documents-api/editorial"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+)
+
+// DuplicateCheckRequest is the body accepted when checking a document for
+// duplicates before writing it
+type DuplicateCheckRequest struct {
+        PdfAdi    string `json:"pdf_adi"`
+        Icerik    string `json:"icerik"`
+        ExcludeID string `json:"exclude_id"` // the document itself when checking an existing one
+}
+
+// GetDuplicateReport lists clusters of duplicate documents, largest first
+func GetDuplicateReport(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        opts, ok := duplicateOptions(w, r)
+        if !ok {
+                return
+        }
+        var statuses []string
+        if value := r.URL.Query().Get("status"); value != "" {
+                for _, status := range strings.Split(value, ",") {
+                        status = strings.TrimSpace(status)
+                        if !editorial.Known(status) {
+                                utils.SendErrorResponse(w, http.StatusBadRequest, "Unknown status '"+status+"'")
+                                return
+                        }
+                        statuses = append(statuses, status)
+                }
+        }
+        limit := 50
+        offset := 0
+        if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 && parsed <= 200 {
+                limit = parsed
+        }
+        if parsed, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsed >= 0 {
+                offset = parsed
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+        defer cancel()
+
+        clusters, stats, err := dedup.Report(ctx, mongoClient, opts, statuses)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to build duplicate report: "+err.Error())
+                return
+        }
+        total := len(clusters)
+        duplicates := 0
+        for _, cluster := range clusters {
+                duplicates += len(cluster.Members) - 1
+        }
+        if offset > len(clusters) {
+                offset = len(clusters)
+        }
+        clusters = clusters[offset:]
+        if len(clusters) > limit {
+                clusters = clusters[:limit]
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    clusters,
+                Count:   len(clusters),
+                Meta: map[string]interface{}{
+                        "total":      total,
+                        "duplicates": duplicates,
+                        "stats":      stats,
+                        "options":    opts,
+                        "policy":     dedup.Policy(),
+                        "limit":      limit,
+                        "offset":     offset,
+                },
+                Message: "Duplicate report built successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// CheckDuplicates lists the documents a title and content would duplicate,
+// without writing anything
+func CheckDuplicates(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        opts, ok := duplicateOptions(w, r)
+        if !ok {
+                return
+        }
+        var req DuplicateCheckRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        if strings.TrimSpace(req.Icerik) == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'icerik' is required")
+                return
+        }
+        var exclude primitive.ObjectID
+        if req.ExcludeID != "" {
+                id, err := primitive.ObjectIDFromHex(req.ExcludeID)
+                if err != nil {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid exclude_id")
+                        return
+                }
+                exclude = id
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        matches, err := dedup.Find(ctx, mongoClient, req.PdfAdi, req.Icerik, exclude, opts)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to check duplicates: "+err.Error())
+                return
+        }
+        if matches == nil {
+                matches = []dedup.Match{}
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    matches,
+                Count:   len(matches),
+                Meta:    map[string]interface{}{"options": opts, "policy": dedup.Policy()},
+                Message: "Duplicate check completed",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// duplicateOptions reads the similarity thresholds of the request, keeping
+// the defaults for those not given
+func duplicateOptions(w http.ResponseWriter, r *http.Request) (dedup.Options, bool) {
+        opts := dedup.DefaultOptions
+        for param, threshold := range map[string]*float64{
+                "content_threshold": &opts.ContentThreshold,
+                "related_threshold": &opts.RelatedThreshold,
+                "title_threshold":   &opts.TitleThreshold,
+        } {
+                value := r.URL.Query().Get(param)
+                if value == "" {
+                        continue
+                }
+                parsed, err := strconv.ParseFloat(value, 64)
+                if err != nil || parsed <= 0 || parsed > 1 {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "'"+param+"' must be a number in (0, 1]")
+                        return opts, false
+                }
+                *threshold = parsed
+        }
+        return opts, true
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strings"
+        "time"
+
+        "legal-documents-api/documents"
+        "legal-documents-api/editorial"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+)
+
+// StatusChangeRequest is the body accepted when changing the status of a
+// document
+type StatusChangeRequest struct {
+        Status       string `json:"status"`
+        ReviewerName string `json:"reviewer_name"` // optional, recorded next to the authenticated user
+        Comment      string `json:"comment"`
+}
+
+// ChangeDocumentStatus moves a document through the review workflow and
+// records who made the change in its audit trail
+func ChangeDocumentStatus(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := documentID(w, r)
+        if !ok {
+                return
+        }
+        var req StatusChangeRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        req.Status = strings.TrimSpace(req.Status)
+        if !editorial.Known(req.Status) || req.Status == editorial.Pasif || req.Status == editorial.Beklemede {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid status '"+req.Status+"' (supported: taslak, inceleme, aktif, arsiv, yururlukten_kalkmis, silindi)")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, change, err := editorial.Change(ctx, mongoClient, id, req.Status, reviewerOf(r), strings.TrimSpace(req.ReviewerName), strings.TrimSpace(req.Comment))
+        if err == editorial.ErrInvalidTransition {
+                utils.SendErrorResponse(w, http.StatusConflict, "Status cannot change from '"+metadata.Status+"' to '"+req.Status+
+                        "' (allowed: "+strings.Join(editorial.Next(metadata.Status), ", ")+")")
+                return
+        }
+        if err != nil {
+                sendDocumentError(w, "change status", err)
+                return
+        }
+
+        _, content, err := documents.Get(ctx, mongoClient, id)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document content: "+err.Error())
+                return
+        }
+        syncDocumentIndexes(ctx, metadata, content, false)
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    newDocumentDetails(metadata, content),
+                Meta:    map[string]interface{}{"change": change, "next": editorial.Next(metadata.Status)},
+                Message: "Belge durumu güncellendi: " + change.From + " → " + change.To,
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// GetDocumentStatusHistory returns the audit trail of a document, oldest
+// first, with the statuses it may change to next
+func GetDocumentStatusHistory(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := documentID(w, r)
+        if !ok {
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, _, err := documents.Get(ctx, mongoClient, id)
+        if err != nil {
+                sendDocumentError(w, "fetch document", err)
+                return
+        }
+        history, err := editorial.History(ctx, mongoClient, id)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch status history: "+err.Error())
+                return
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    history,
+                Count:   len(history),
+                Meta:    map[string]interface{}{"status": metadata.Status, "next": editorial.Next(metadata.Status)},
+                Message: "Status history fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// reviewerOf names who made a change: the user the request was
+// authenticated as
+func reviewerOf(r *http.Request) string {
+        if user, _, ok := r.BasicAuth(); ok && user != "" {
+                return user
+        }
+        return "admin"
+}
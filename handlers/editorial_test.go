@@ -0,0 +1,22 @@
+package handlers
+
+import (
+        "net/http"
+        "net/http/httptest"
+        "strings"
+        "testing"
+)
+
+func TestReviewerOf(t *testing.T) {
+        // The reviewer is always the authenticated user, whatever the body says
+        r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/documents/x/status", strings.NewReader(`{"status":"aktif","reviewer_name":"Başka Biri"}`))
+        r.SetBasicAuth("editor", "secret")
+        if got := reviewerOf(r); got != "editor" {
+                t.Errorf("reviewerOf = %q, want editor", got)
+        }
+
+        r = httptest.NewRequest(http.MethodPost, "/api/v1/admin/documents/x/status", nil)
+        if got := reviewerOf(r); got != "admin" {
+                t.Errorf("reviewerOf without credentials = %q, want admin", got)
+        }
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "io"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+        "go.mongodb.org/mongo-driver/mongo/options"
+
+        "legal-documents-api/config"
+        "legal-documents-api/dedup"
+        "legal-documents-api/editorial"
+        "legal-documents-api/ingest"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+)
+
+// maxUploadSize bounds the size of an uploaded PDF
+const maxUploadSize = 64 << 20
+
+// IngestDocument creates a document awaiting review from an uploaded PDF.
+// The multipart form carries the file in "file" and optionally the metadata
+// fields of DocumentRequest; the title, page count, size and slug are taken
+// from the file when not given.
+func IngestDocument(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+        if err := r.ParseMultipartForm(8 << 20); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid upload (multipart form up to 64 MB expected): "+err.Error())
+                return
+        }
+        upload, header, err := r.FormFile("file")
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'file' is required")
+                return
+        }
+        defer upload.Close()
+        data, err := io.ReadAll(upload)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Failed to read upload: "+err.Error())
+                return
+        }
+
+        file, err := ingest.Read(header.Filename, data)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusUnprocessableEntity, "Failed to read PDF: "+err.Error())
+                return
+        }
+
+        req := DocumentRequest{
+                PdfAdi:           r.FormValue("pdf_adi"),
+                KurumID:          r.FormValue("kurum_id"),
+                BelgeTuru:        r.FormValue("belge_turu"),
+                BelgeDurumu:      r.FormValue("belge_durumu"),
+                BelgeYayinTarihi: r.FormValue("belge_yayin_tarihi"),
+                Etiketler:        r.FormValue("etiketler"),
+                AnahtarKelimeler: r.FormValue("anahtar_kelimeler"),
+                Aciklama:         r.FormValue("aciklama"),
+                URLSlug:          r.FormValue("url_slug"),
+                Status:           editorial.Inceleme,
+                PdfURL:           r.FormValue("pdf_url"),
+                Note:             r.FormValue("note"),
+                AllowDuplicate:   r.FormValue("allow_duplicate") == "true",
+        }
+        if strings.TrimSpace(req.PdfAdi) == "" {
+                req.PdfAdi = file.Title
+        }
+        if err := req.validate(false, nil); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+        if req.AllowDuplicate {
+                ctx = dedup.AllowDuplicates(ctx)
+        }
+
+        var metadata models.DocumentMetadata
+        req.apply(&metadata)
+        content, err := ingest.Create(ctx, mongoClient, file, &metadata, req.Note, reviewerOf(r))
+        if err != nil {
+                sendDocumentError(w, "ingest document", err)
+                return
+        }
+
+        utils.SendCreatedResponse(w, newDocumentDetails(metadata, content), "Belge incelemeye alındı")
+}
+
+// GetPendingDocuments lists the documents awaiting review, oldest first.
+// They are published through the status endpoint.
+func GetPendingDocuments(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        limit := int64(50)
+        offset := int64(0)
+        if parsed, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64); err == nil && parsed > 0 && parsed <= 100 {
+                limit = parsed
+        }
+        if parsed, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64); err == nil && parsed >= 0 {
+                offset = parsed
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        collection := config.GetMetadataCollection(mongoClient)
+        filter := bson.M{"status": bson.M{"$in": []string{editorial.Inceleme, editorial.Beklemede}}}
+        total, err := collection.CountDocuments(ctx, filter)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to count pending documents: "+err.Error())
+                return
+        }
+        cursor, err := collection.Find(ctx, filter, options.Find().
+                SetSort(bson.D{primitive.E{Key: "_id", Value: 1}}).
+                SetSkip(offset).
+                SetLimit(limit))
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch pending documents: "+err.Error())
+                return
+        }
+        defer cursor.Close(ctx)
+
+        pending := []models.DocumentMetadata{}
+        if err := cursor.All(ctx, &pending); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode pending documents: "+err.Error())
+                return
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    pending,
+                Count:   len(pending),
+                Meta:    map[string]int64{"total": total, "limit": limit, "offset": offset},
+                Message: "Pending documents fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
@@ -11,6 +11,7 @@ import (
         "go.mongodb.org/mongo-driver/mongo"
 
         "legal-documents-api/config"
+        "legal-documents-api/editorial"
         "legal-documents-api/models"
         "legal-documents-api/utils"
 )
@@ -49,7 +50,7 @@ func GetInstitutions(w http.ResponseWriter, r *http.Request) {
         pipeline := []bson.M{
                 {
                         "$match": bson.M{
-                                "status": "aktif", // Only active documents
+                                "status": editorial.ListedFilter(), // Only published documents
                         },
                 },
                 {
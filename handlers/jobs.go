@@ -0,0 +1,193 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+        "go.mongodb.org/mongo-driver/mongo"
+
+        "legal-documents-api/jobs"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+)
+
+// jobPool runs background jobs; nil when the queue is disabled
+var jobPool *jobs.Pool
+
+// SetJobPool sets the pool the job handlers enqueue to
+func SetJobPool(pool *jobs.Pool) {
+        jobPool = pool
+}
+
+// EnqueueJobRequest is the body accepted when enqueuing a job
+type EnqueueJobRequest struct {
+        Type        string                 `json:"type"`
+        Payload     map[string]interface{} `json:"payload"`
+        Key         string                 `json:"key"`
+        MaxAttempts int                    `json:"max_attempts"`
+        DelaySecs   int                    `json:"delay_seconds"`
+}
+
+// GetJobs lists jobs, newest first, with the number of jobs in each status
+func GetJobs(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        filter := jobs.Filter{
+                Status: r.URL.Query().Get("status"),
+                Type:   r.URL.Query().Get("type"),
+                Limit:  50,
+        }
+        if parsed, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64); err == nil && parsed > 0 && parsed <= 200 {
+                filter.Limit = parsed
+        }
+        if parsed, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64); err == nil && parsed >= 0 {
+                filter.Offset = parsed
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        list, total, err := jobs.List(ctx, mongoClient, filter)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch jobs: "+err.Error())
+                return
+        }
+        counts, err := jobs.Counts(ctx, mongoClient)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to count jobs: "+err.Error())
+                return
+        }
+
+        meta := map[string]interface{}{"total": total, "statuses": counts}
+        if jobPool != nil {
+                meta["types"] = jobPool.Types()
+        }
+        response := models.APIResponse{
+                Success: true,
+                Data:    list,
+                Count:   len(list),
+                Meta:    meta,
+                Message: "Jobs fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// EnqueueJob adds a job of a registered type. A job with the key of a queued
+// or running job is not added again; the existing job is returned instead.
+func EnqueueJob(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+        if jobPool == nil {
+                utils.SendErrorResponse(w, http.StatusServiceUnavailable, "Job queue is not running")
+                return
+        }
+
+        var req EnqueueJobRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        req.Type = strings.TrimSpace(req.Type)
+        if req.Type == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'type' is required (one of: "+strings.Join(jobPool.Types(), ", ")+")")
+                return
+        }
+        if req.MaxAttempts < 0 || req.MaxAttempts > 20 || req.DelaySecs < 0 {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'max_attempts' must be between 0 and 20 and 'delay_seconds' cannot be negative")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        var payload interface{}
+        if len(req.Payload) > 0 {
+                payload = req.Payload
+        }
+        job, created, err := jobPool.Enqueue(ctx, req.Type, payload, jobs.Options{
+                Key:         strings.TrimSpace(req.Key),
+                MaxAttempts: req.MaxAttempts,
+                Delay:       time.Duration(req.DelaySecs) * time.Second,
+        })
+        if err == jobs.ErrUnknownType {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Unknown job type '"+req.Type+"' (one of: "+strings.Join(jobPool.Types(), ", ")+")")
+                return
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to enqueue job: "+err.Error())
+                return
+        }
+
+        if !created {
+                utils.SendSuccessResponse(w, job, "A job with the same key is already queued or running")
+                return
+        }
+        utils.SendCreatedResponse(w, job, "Job queued")
+}
+
+// GetJob returns a job with its recent errors
+func GetJob(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+        jobAction(w, r, "fetch job", "Job fetched successfully", jobs.Get)
+}
+
+// RetryJob queues a dead or cancelled job again
+func RetryJob(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+        jobAction(w, r, "retry job", "Job queued again", jobs.Retry)
+}
+
+// CancelJob stops a queued or running job
+func CancelJob(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+        jobAction(w, r, "cancel job", "Job cancelled", jobs.Cancel)
+}
+
+// jobAction runs an operation of the queue on the job in the request and
+// writes the result
+func jobAction(w http.ResponseWriter, r *http.Request, action, message string,
+        op func(context.Context, *mongo.Client, primitive.ObjectID) (models.Job, error)) {
+        id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid job id")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        job, err := op(ctx, mongoClient, id)
+        switch err {
+        case nil:
+                utils.SendSuccessResponse(w, job, message)
+        case jobs.ErrNotFound:
+                utils.SendErrorResponse(w, http.StatusNotFound, "Job not found")
+        case jobs.ErrInvalidState, jobs.ErrDuplicate:
+                utils.SendErrorResponse(w, http.StatusConflict, err.Error())
+        default:
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to "+action+": "+err.Error())
+        }
+}
@@ -4,22 +4,40 @@ import (
         "context"
         "encoding/json"
         "fmt"
-        "html"
-        "io"
+        "log"
         "net/http"
-        "regexp"
+        "net/url"
         "strings"
         "time"
 
+        "github.com/gorilla/mux"
         "go.mongodb.org/mongo-driver/bson"
-        "golang.org/x/net/html/charset"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+        "go.mongodb.org/mongo-driver/mongo"
+        "go.mongodb.org/mongo-driver/mongo/options"
 
         "legal-documents-api/config"
+        "legal-documents-api/jobs"
         "legal-documents-api/models"
+        "legal-documents-api/scraper"
         "legal-documents-api/utils"
 )
 
-// GetKurumDuyuru scrapes announcements from institution's website
+// duyuruMaxAge is how long scraped announcements are served before the page
+// is scraped again
+const duyuruMaxAge = time.Hour
+
+// ScrapeAnnouncementsJob is the job type that runs RefreshAnnouncements
+const ScrapeAnnouncementsJob = "scrape-announcements"
+
+// AnnouncementsPayload limits a scrape-announcements job to one institution
+type AnnouncementsPayload struct {
+        KurumID string `bson:"kurum_id"`
+}
+
+// GetKurumDuyuru serves the announcements last scraped from an institution's
+// website. Stale announcements are still served while a scrape-announcements
+// job refreshes them.
 func GetKurumDuyuru(w http.ResponseWriter, r *http.Request) {
         // Handle CORS preflight
         if r.Method == "OPTIONS" {
@@ -27,7 +45,7 @@ func GetKurumDuyuru(w http.ResponseWriter, r *http.Request) {
                 return
         }
 
-        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
         defer cancel()
 
         // Get query parameters
@@ -53,11 +71,20 @@ func GetKurumDuyuru(w http.ResponseWriter, r *http.Request) {
                 return
         }
 
-        // Scrape the website based on domain
-        duyurular, err := scrapeDuyurular(kurumDuyuru.DuyuruLinki)
-        if err != nil {
-                utils.SendErrorResponse(w, http.StatusInternalServerError, "Duyuru sayfası çekilemedi: "+err.Error())
-                return
+        // Serve the announcements of the last scrape and queue a new scrape
+        // of the website when they are stale; the job key keeps one scrape
+        // per institution in flight
+        duyurular := kurumDuyuru.Duyurular
+        if duyurular == nil {
+                duyurular = []models.DuyuruItem{}
+        }
+        stale := kurumDuyuru.TaranmaTarihi == nil || time.Since(*kurumDuyuru.TaranmaTarihi) > duyuruMaxAge
+        if stale && jobPool != nil {
+                _, _, err := jobPool.Enqueue(ctx, ScrapeAnnouncementsJob, AnnouncementsPayload{KurumID: kurumID},
+                        jobs.Options{Key: ScrapeAnnouncementsJob + ":" + kurumID})
+                if err != nil {
+                        log.Printf("Warning: Failed to queue announcement scrape of %s: %v", kurumID, err)
+                }
         }
 
         // Prepare response
@@ -65,6 +92,7 @@ func GetKurumDuyuru(w http.ResponseWriter, r *http.Request) {
                 Success: true,
                 Data:    duyurular,
                 Count:   len(duyurular),
+                Meta:    map[string]interface{}{"taranma_tarihi": kurumDuyuru.TaranmaTarihi, "yenileniyor": stale},
                 Message: "Kurum duyuruları başarıyla çekildi",
         }
 
@@ -73,552 +101,196 @@ func GetKurumDuyuru(w http.ResponseWriter, r *http.Request) {
         json.NewEncoder(w).Encode(response)
 }
 
-// scrapeDuyurular routes to appropriate scraper based on domain
-func scrapeDuyurular(url string) ([]models.DuyuruItem, error) {
-        if strings.Contains(url, "yargitay.gov.tr") {
-                return scrapeYargitayDuyuru(url)
-        } else if strings.Contains(url, "sgk.gov.tr") {
-                return scrapeSGKDuyuru(url)
-        } else if strings.Contains(url, "iskur.gov.tr") {
-                return scrapeIskurDuyuru(url)
-        } else {
-                // Default to generic scraping
-                return scrapeYargitayDuyuru(url)
-        }
-}
-
-// scrapeYargitayDuyuru scrapes announcements from Yargıtay website
-func scrapeYargitayDuyuru(url string) ([]models.DuyuruItem, error) {
-        // Create HTTP client with timeout
-        client := &http.Client{
-                Timeout: 15 * time.Second,
-        }
-
-        // Make GET request
-        resp, err := client.Get(url)
-        if err != nil {
-                return nil, fmt.Errorf("HTTP request failed: %v", err)
-        }
-        defer resp.Body.Close()
-
-        if resp.StatusCode != http.StatusOK {
-                return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+// RefreshAnnouncements scrapes the announcement pages of every institution,
+// or of one when kurumID is set, and stores the announcements found. It fails
+// only when no page could be scraped.
+func RefreshAnnouncements(ctx context.Context, kurumID string) (string, error) {
+        filter := bson.M{"duyuru_linki": bson.M{"$ne": ""}}
+        if kurumID != "" {
+                filter["kurum_id"] = kurumID
         }
-
-        // Read response body with charset conversion
-        reader, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
-        if err != nil {
-                reader = resp.Body // Fallback to original body if charset detection fails
-        }
-        body, err := io.ReadAll(reader)
+        cursor, err := config.GetKurumDuyuruCollection(mongoClient).Find(ctx, filter)
         if err != nil {
-                return nil, fmt.Errorf("Failed to read response: %v", err)
+                return "", err
         }
-
-        // Parse HTML with regex patterns (simpler approach)
-        duyurular := extractDuyurularWithRegex(string(body), url)
-
-        // Limit to 5 results
-        if len(duyurular) > 5 {
-                duyurular = duyurular[:5]
+        var pages []models.KurumDuyuru
+        if err := cursor.All(ctx, &pages); err != nil {
+                return "", err
         }
 
-        return duyurular, nil
-}
-
-// extractDuyurularWithRegex extracts announcements using regex patterns
-func extractDuyurularWithRegex(htmlContent, baseURL string) []models.DuyuruItem {
-        var duyurular []models.DuyuruItem
-        seenLinks := make(map[string]bool) // Deduplication
-
-        // Primary regex: Yargıtay item links + keyword-based links with nested HTML support
-        linkPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*(?:/item/\d+/[^"']*|(?:duyuru|haber|news|announcement)[^"']*))["'][^>]*>([\s\S]*?)</a>`)
-        matches := linkPattern.FindAllStringSubmatch(htmlContent, -1)
-        
-        for _, match := range matches {
-                if len(match) >= 3 {
-                        href := strings.TrimSpace(match[1])
-                        innerHTML := strings.TrimSpace(match[2])
-                        
-                        // Clean title from HTML entities and extract text
-                        title := cleanHTML(innerHTML)
-                        
-                        // Normalize link for deduplication
-                        normalizedLink := makeAbsoluteURL(href, baseURL)
-                        
-                        if len(title) > 10 && href != "" && !seenLinks[normalizedLink] {
-                                seenLinks[normalizedLink] = true
-                                duyuru := models.DuyuruItem{
-                                        Baslik: title,
-                                        Link:   normalizedLink,
-                                        Tarih:  extractDateFromHTML(htmlContent, title),
-                                }
-                                duyurular = append(duyurular, duyuru)
-                        }
+        found, failed := 0, 0
+        var lastErr error
+        for _, page := range pages {
+                if ctx.Err() != nil {
+                        return "", ctx.Err()
                 }
-        }
-        
-        // Secondary pass: if we have fewer than 5 items, try general item links
-        if len(duyurular) < 5 {
-                itemLinkPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/item/\d+/[^"']*)["'][^>]*>([\s\S]*?)</a>`)
-                itemMatches := itemLinkPattern.FindAllStringSubmatch(htmlContent, -1)
-                
-                for _, match := range itemMatches {
-                        if len(duyurular) >= 5 {
-                                break
-                        }
-                        
-                        if len(match) >= 3 {
-                                href := strings.TrimSpace(match[1])
-                                innerHTML := strings.TrimSpace(match[2])
-                                title := cleanHTML(innerHTML)
-                                
-                                normalizedLink := makeAbsoluteURL(href, baseURL)
-                                
-                                if len(title) > 10 && !seenLinks[normalizedLink] && !isNavigationLink(title) {
-                                        seenLinks[normalizedLink] = true
-                                        duyuru := models.DuyuruItem{
-                                                Baslik: title,
-                                                Link:   normalizedLink,
-                                                Tarih:  extractDateFromHTML(htmlContent, title),
-                                        }
-                                        duyurular = append(duyurular, duyuru)
-                                }
-                        }
+                duyurular, err := scrapeDuyurular(ctx, page)
+                if err != nil {
+                        log.Printf("Warning: Failed to scrape announcements of %s: %v", page.KurumID, err)
+                        failed++
+                        lastErr = err
+                        continue
                 }
+                storeDuyurular(ctx, page.ID, duyurular)
+                found += len(duyurular)
         }
-        
-        // Final fallback: general links if still not enough
-        if len(duyurular) < 5 {
-                generalLinkPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*)["'][^>]*>([\s\S]{15,}?)</a>`)
-                generalMatches := generalLinkPattern.FindAllStringSubmatch(htmlContent, -1)
-                
-                for _, match := range generalMatches {
-                        if len(duyurular) >= 5 {
-                                break
-                        }
-                        
-                        if len(match) >= 3 {
-                                href := strings.TrimSpace(match[1])
-                                innerHTML := strings.TrimSpace(match[2])
-                                title := cleanHTML(innerHTML)
-                                
-                                normalizedLink := makeAbsoluteURL(href, baseURL)
-                                
-                                if len(title) > 15 && !seenLinks[normalizedLink] && !isNavigationLink(title) {
-                                        seenLinks[normalizedLink] = true
-                                        duyuru := models.DuyuruItem{
-                                                Baslik: title,
-                                                Link:   normalizedLink,
-                                                Tarih:  extractDateFromHTML(htmlContent, title),
-                                        }
-                                        duyurular = append(duyurular, duyuru)
-                                }
-                        }
-                        }
+        if failed > 0 && failed == len(pages) {
+                return "", fmt.Errorf("all %d announcement pages failed, last error: %w", failed, lastErr)
         }
-        
-        return duyurular
-}
-
-// cleanHTML removes HTML entities and cleans text
-func cleanHTML(text string) string {
-        // Remove HTML tags
-        text = regexp.MustCompile(`<[^>]*>`).ReplaceAllString(text, "")
-        
-        // Decode all HTML entities comprehensively (handles Turkish chars and more)
-        text = html.UnescapeString(text)
-        
-        // Clean extra spaces
-        text = regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
-        
-        return strings.TrimSpace(text)
+        return fmt.Sprintf("%d pages scraped, %d announcements, %d failed", len(pages)-failed, found, failed), nil
 }
 
-// isNavigationLink checks if the text looks like a navigation item
-func isNavigationLink(text string) bool {
-        navKeywords := []string{
-                "ana sayfa", "anasayfa", "home", "menü", "menu",
-                "hakkımızda", "iletişim", "contact", "about",
-                "giriş", "login", "kayıt", "register", "çıkış", "logout",
-                "ara", "search", "site haritası", "sitemap",
-        }
-        
-        lowerText := strings.ToLower(text)
-        for _, keyword := range navKeywords {
-                if strings.Contains(lowerText, keyword) {
-                        return true
-                }
+// storeDuyurular saves the announcements of a scrape on its kurum_duyuru record
+func storeDuyurular(ctx context.Context, id primitive.ObjectID, duyurular []models.DuyuruItem) {
+        _, err := config.GetKurumDuyuruCollection(mongoClient).UpdateOne(ctx, bson.M{"_id": id},
+                bson.M{"$set": bson.M{"duyurular": duyurular, "taranma_tarihi": time.Now().UTC()}})
+        if err != nil {
+                log.Printf("Warning: Failed to store announcements: %v", err)
         }
-        
-        // Check for very short texts (likely navigation)
-        return len(strings.TrimSpace(text)) < 15
 }
 
-// extractDateFromHTML tries to find date near the announcement title
-func extractDateFromHTML(htmlContent, title string) string {
-        // Look for date patterns near the title
-        titleIndex := strings.Index(htmlContent, title)
-        if titleIndex == -1 {
-                return time.Now().Format("02.01.2006")
-        }
-        
-        // Search in surrounding text (500 characters before and after)
-        start := titleIndex - 500
-        if start < 0 {
-                start = 0
-        }
-        end := titleIndex + len(title) + 500
-        if end > len(htmlContent) {
-                end = len(htmlContent)
-        }
-        
-        surroundingText := htmlContent[start:end]
-        if date := extractDateFromText(surroundingText); date != "" {
-                return date
+// scrapeDuyurular reads the announcements of a page with the scraper its
+// record configures, or the one registered for its host
+func scrapeDuyurular(ctx context.Context, page models.KurumDuyuru) ([]models.DuyuruItem, error) {
+        s, err := scraper.For(page)
+        if err != nil {
+                return nil, err
         }
-        
-        return time.Now().Format("02.01.2006")
+        return s.Scrape(ctx, page.DuyuruLinki)
 }
 
-func makeAbsoluteURL(href, baseURL string) string {
-        if strings.HasPrefix(href, "http") {
-                return href
-        }
-        if strings.HasPrefix(href, "/") {
-                // Extract base domain from baseURL
-                if idx := strings.Index(baseURL[8:], "/"); idx > 0 {
-                        return baseURL[:8+idx] + href
-                }
-                return baseURL + href
-        }
-        return baseURL + "/" + href
+// KurumDuyuruConfigRequest is the body accepted when configuring how the
+// announcements of an institution are scraped
+type KurumDuyuruConfigRequest struct {
+        DuyuruLinki string                `json:"duyuru_linki"`
+        Scraper     *models.ScraperConfig `json:"scraper"` // the scraper of the host when null
 }
 
-func extractDateFromText(text string) string {
-        // Common Turkish date patterns
-        datePatterns := []string{
-                `\d{1,2}[./]\d{1,2}[./]\d{4}`,        // 01.01.2024 or 01/01/2024
-                `\d{1,2}[./]\d{1,2}[./]\d{2}`,         // 01.01.24
-                `\d{4}[.-]\d{1,2}[.-]\d{1,2}`,         // 2024-01-01
+// validate checks the page address and that the scraper can be built
+func (req *KurumDuyuruConfigRequest) validate() error {
+        req.DuyuruLinki = strings.TrimSpace(req.DuyuruLinki)
+        if u, err := url.Parse(req.DuyuruLinki); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+                return fmt.Errorf("'duyuru_linki' must be an http(s) address")
         }
-        
-        for _, pattern := range datePatterns {
-                re := regexp.MustCompile(pattern)
-                if match := re.FindString(text); match != "" {
-                        return match
-                }
+        if req.Scraper != nil && *req.Scraper == (models.ScraperConfig{}) {
+                req.Scraper = nil
         }
-        
-        return ""
+        _, err := scraper.For(models.KurumDuyuru{DuyuruLinki: req.DuyuruLinki, Scraper: req.Scraper})
+        return err
 }
 
-// scrapeSGKDuyuru scrapes announcements from SGK website
-func scrapeSGKDuyuru(url string) ([]models.DuyuruItem, error) {
-        // Create HTTP client with timeout
-        client := &http.Client{
-                Timeout: 15 * time.Second,
-        }
-
-        // Make GET request
-        resp, err := client.Get(url)
-        if err != nil {
-                return nil, fmt.Errorf("HTTP request failed: %v", err)
-        }
-        defer resp.Body.Close()
-
-        if resp.StatusCode != http.StatusOK {
-                return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
-        }
-
-        // Read response body with charset conversion
-        reader, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
-        if err != nil {
-                reader = resp.Body // Fallback to original body if charset detection fails
-        }
-        body, err := io.ReadAll(reader)
-        if err != nil {
-                return nil, fmt.Errorf("Failed to read response: %v", err)
-        }
-
-        // Parse HTML with SGK-specific regex patterns
-        duyurular := extractSGKDuyurularWithRegex(string(body), url)
-
-        // Limit to 5 results
-        if len(duyurular) > 5 {
-                duyurular = duyurular[:5]
+// GetScrapers lists the registered scrapers with the hosts they serve
+func GetScrapers(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
         }
-
-        return duyurular, nil
+        utils.SendSuccessResponse(w, scraper.Registered(), "Scrapers fetched successfully")
 }
 
-// extractSGKDuyurularWithRegex extracts SGK announcements using regex patterns
-func extractSGKDuyurularWithRegex(htmlContent, baseURL string) []models.DuyuruItem {
-        var duyurular []models.DuyuruItem
-        seenLinks := make(map[string]bool) // Deduplication
-
-        // Primary regex: SGK /Duyuru/Detay/ links
-        linkPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/Duyuru/Detay/[^"']*)["'][^>]*>([\s\S]*?)</a>`)
-        matches := linkPattern.FindAllStringSubmatch(htmlContent, -1)
-        
-        for _, match := range matches {
-                if len(match) >= 3 {
-                        href := strings.TrimSpace(match[1])
-                        innerHTML := strings.TrimSpace(match[2])
-                        
-                        // Clean title from HTML entities and extract text
-                        title := cleanHTML(innerHTML)
-                        
-                        // Normalize link for deduplication
-                        normalizedLink := makeAbsoluteURL(href, baseURL)
-                        
-                        if len(title) > 10 && href != "" && !seenLinks[normalizedLink] {
-                                seenLinks[normalizedLink] = true
-                                duyuru := models.DuyuruItem{
-                                        Baslik: title,
-                                        Link:   normalizedLink,
-                                        Tarih:  extractSGKDateFromHTML(htmlContent, title),
-                                }
-                                duyurular = append(duyurular, duyuru)
-                        }
-                }
-        }
-        
-        // Secondary pass: if we have fewer than 5 items, try general duyuru links
-        if len(duyurular) < 5 {
-                generalPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*(?:duyuru|Duyuru)[^"']*)["'][^>]*>([\s\S]*?)</a>`)
-                generalMatches := generalPattern.FindAllStringSubmatch(htmlContent, -1)
-                
-                for _, match := range generalMatches {
-                        if len(duyurular) >= 5 {
-                                break
-                        }
-                        
-                        if len(match) >= 3 {
-                                href := strings.TrimSpace(match[1])
-                                innerHTML := strings.TrimSpace(match[2])
-                                title := cleanHTML(innerHTML)
-                                
-                                normalizedLink := makeAbsoluteURL(href, baseURL)
-                                
-                                if len(title) > 15 && !seenLinks[normalizedLink] && !isNavigationLink(title) {
-                                        seenLinks[normalizedLink] = true
-                                        duyuru := models.DuyuruItem{
-                                                Baslik: title,
-                                                Link:   normalizedLink,
-                                                Tarih:  extractSGKDateFromHTML(htmlContent, title),
-                                        }
-                                        duyurular = append(duyurular, duyuru)
-                                }
-                        }
-                }
+// UpdateKurumDuyuruConfig sets the announcement page of an institution and
+// how it is scraped. The next request scrapes the page again.
+func UpdateKurumDuyuruConfig(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
         }
-        
-        return duyurular
-}
 
-// extractSGKDateFromHTML tries to find SGK-specific date patterns
-func extractSGKDateFromHTML(htmlContent, title string) string {
-        // Look for Turkish date patterns near the title
-        titleIndex := strings.Index(htmlContent, title)
-        if titleIndex == -1 {
-                return time.Now().Format("02.01.2006")
-        }
-        
-        // Search in surrounding text (1000 characters before and after)
-        start := titleIndex - 1000
-        if start < 0 {
-                start = 0
+        kurumID := mux.Vars(r)["kurum_id"]
+        if _, ok := utils.GetKurumByID(kurumID); !ok {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Unknown kurum_id '"+kurumID+"'")
+                return
         }
-        end := titleIndex + len(title) + 1000
-        if end > len(htmlContent) {
-                end = len(htmlContent)
+        var req KurumDuyuruConfigRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
         }
-        
-        surroundingText := htmlContent[start:end]
-        if date := extractSGKDateFromText(surroundingText); date != "" {
-                return date
+        if err := req.validate(); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid scraper configuration: "+err.Error())
+                return
         }
-        
-        return time.Now().Format("02.01.2006")
-}
 
-// extractSGKDateFromText extracts SGK-specific date formats
-func extractSGKDateFromText(text string) string {
-        // SGK uses Turkish month names: "23 Eylül 2025 Salı"
-        turkishMonths := map[string]string{
-                "Ocak": "01", "Şubat": "02", "Mart": "03", "Nisan": "04",
-                "Mayıs": "05", "Haziran": "06", "Temmuz": "07", "Ağustos": "08",
-                "Eylül": "09", "Ekim": "10", "Kasım": "11", "Aralık": "12",
-        }
-        
-        // Pattern: "23 Eylül 2025"
-        pattern := regexp.MustCompile(`(\d{1,2})\s+(Ocak|Şubat|Mart|Nisan|Mayıs|Haziran|Temmuz|Ağustos|Eylül|Ekim|Kasım|Aralık)\s+(\d{4})`)
-        if match := pattern.FindStringSubmatch(text); len(match) >= 4 {
-                day := match[1]
-                month := turkishMonths[match[2]]
-                year := match[3]
-                if len(day) == 1 {
-                        day = "0" + day
-                }
-                return day + "." + month + "." + year
-        }
-        
-        // Fallback to standard date patterns
-        return extractDateFromText(text)
-}
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
 
-// scrapeIskurDuyuru scrapes announcements from İşkur website
-func scrapeIskurDuyuru(url string) ([]models.DuyuruItem, error) {
-        // Create HTTP client with timeout
-        client := &http.Client{
-                Timeout: 15 * time.Second,
+        set := bson.M{"duyuru_linki": req.DuyuruLinki}
+        unset := bson.M{"taranma_tarihi": ""}
+        if req.Scraper != nil {
+                set["scraper"] = req.Scraper
+        } else {
+                unset["scraper"] = ""
         }
-
-        // Make GET request
-        resp, err := client.Get(url)
+        var kurumDuyuru models.KurumDuyuru
+        err := config.GetKurumDuyuruCollection(mongoClient).FindOneAndUpdate(ctx,
+                bson.M{"kurum_id": kurumID},
+                bson.M{"$set": set, "$unset": unset, "$setOnInsert": bson.M{"_id": primitive.NewObjectID()}},
+                options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&kurumDuyuru)
         if err != nil {
-                return nil, fmt.Errorf("HTTP request failed: %v", err)
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to save scraper configuration: "+err.Error())
+                return
         }
-        defer resp.Body.Close()
 
-        if resp.StatusCode != http.StatusOK {
-                return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
-        }
+        utils.SendSuccessResponse(w, kurumDuyuru, "Duyuru ayarları güncellendi")
+}
 
-        // Read response body with charset conversion
-        reader, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
-        if err != nil {
-                reader = resp.Body // Fallback to original body if charset detection fails
-        }
-        body, err := io.ReadAll(reader)
-        if err != nil {
-                return nil, fmt.Errorf("Failed to read response: %v", err)
+// PreviewKurumDuyuru scrapes the announcement page of an institution without
+// storing the result. A body with duyuru_linki and scraper tries a
+// configuration before it is saved.
+func PreviewKurumDuyuru(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
         }
 
-        // Parse HTML with İşkur-specific regex patterns
-        duyurular := extractIskurDuyurularWithRegex(string(body), url)
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
 
-        // Limit to 5 results
-        if len(duyurular) > 5 {
-                duyurular = duyurular[:5]
+        kurumID := mux.Vars(r)["kurum_id"]
+        var page models.KurumDuyuru
+        err := config.GetKurumDuyuruCollection(mongoClient).FindOne(ctx, bson.M{"kurum_id": kurumID}).Decode(&page)
+        if err != nil && err != mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch announcement settings: "+err.Error())
+                return
         }
 
-        return duyurular, nil
-}
-
-// extractIskurDuyurularWithRegex extracts İşkur announcements using regex patterns
-func extractIskurDuyurularWithRegex(htmlContent, baseURL string) []models.DuyuruItem {
-        var duyurular []models.DuyuruItem
-        seenLinks := make(map[string]bool) // Deduplication
-
-        // Primary regex: İşkur /duyurular/ links
-        linkPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/duyurular/[^"']+)["'][^>]*title=["']([^"']+)["']`)
-        matches := linkPattern.FindAllStringSubmatch(htmlContent, -1)
-        
-        for _, match := range matches {
-                if len(match) >= 3 {
-                        href := strings.TrimSpace(match[1])
-                        title := strings.TrimSpace(match[2])
-                        
-                        // Clean title from HTML entities and extract text
-                        title = cleanHTML(title)
-                        
-                        // Normalize link for deduplication
-                        normalizedLink := makeAbsoluteURL(href, baseURL)
-                        
-                        if len(title) > 10 && href != "" && !seenLinks[normalizedLink] {
-                                seenLinks[normalizedLink] = true
-                                duyuru := models.DuyuruItem{
-                                        Baslik: title,
-                                        Link:   normalizedLink,
-                                        Tarih:  extractIskurDateFromHTML(htmlContent, title),
-                                }
-                                duyurular = append(duyurular, duyuru)
-                        }
-                }
-        }
-        
-        // Secondary pass: if we have fewer than 5 items, try general duyuru links without title attribute
-        if len(duyurular) < 5 {
-                generalPattern := regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/duyurular/[^"']*)["'][^>]*>([\s\S]*?)</a>`)
-                generalMatches := generalPattern.FindAllStringSubmatch(htmlContent, -1)
-                
-                for _, match := range generalMatches {
-                        if len(duyurular) >= 5 {
-                                break
-                        }
-                        
-                        if len(match) >= 3 {
-                                href := strings.TrimSpace(match[1])
-                                innerHTML := strings.TrimSpace(match[2])
-                                title := cleanHTML(innerHTML)
-                                
-                                normalizedLink := makeAbsoluteURL(href, baseURL)
-                                
-                                if len(title) > 15 && !seenLinks[normalizedLink] && !isNavigationLink(title) {
-                                        seenLinks[normalizedLink] = true
-                                        duyuru := models.DuyuruItem{
-                                                Baslik: title,
-                                                Link:   normalizedLink,
-                                                Tarih:  extractIskurDateFromHTML(htmlContent, title),
-                                        }
-                                        duyurular = append(duyurular, duyuru)
-                                }
-                        }
+        var req KurumDuyuruConfigRequest
+        if r.ContentLength != 0 {
+                if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                        return
                 }
         }
-        
-        return duyurular
-}
-
-// extractIskurDateFromHTML tries to find İşkur-specific date patterns
-func extractIskurDateFromHTML(htmlContent, title string) string {
-        // Look for Turkish date patterns near the title
-        titleIndex := strings.Index(htmlContent, title)
-        if titleIndex == -1 {
-                return time.Now().Format("02.01.2006")
+        if req.DuyuruLinki == "" {
+                req.DuyuruLinki = page.DuyuruLinki
         }
-        
-        // Search in surrounding text (1500 characters before and after)
-        start := titleIndex - 1500
-        if start < 0 {
-                start = 0
+        if req.Scraper == nil {
+                req.Scraper = page.Scraper
         }
-        end := titleIndex + len(title) + 1500
-        if end > len(htmlContent) {
-                end = len(htmlContent)
+        if req.DuyuruLinki == "" {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Kurum için duyuru linki tanımlanmamış")
+                return
         }
-        
-        surroundingText := htmlContent[start:end]
-        if date := extractIskurDateFromText(surroundingText); date != "" {
-                return date
+        if err := req.validate(); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid scraper configuration: "+err.Error())
+                return
         }
-        
-        return time.Now().Format("02.01.2006")
-}
 
-// extractIskurDateFromText extracts İşkur-specific date formats
-func extractIskurDateFromText(text string) string {
-        // İşkur uses Turkish abbreviated month names: "11 Ağu 2025", "27 Haz 2025"
-        turkishMonths := map[string]string{
-                "Oca": "01", "Şub": "02", "Mar": "03", "Nis": "04", "May": "05", "Haz": "06",
-                "Tem": "07", "Ağu": "08", "Eyl": "09", "Eki": "10", "Kas": "11", "Ara": "12",
+        duyurular, err := scrapeDuyurular(ctx, models.KurumDuyuru{KurumID: kurumID, DuyuruLinki: req.DuyuruLinki, Scraper: req.Scraper})
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadGateway, "Duyuru sayfası çekilemedi: "+err.Error())
+                return
         }
-        
-        // Pattern: "11 Ağu 2025"
-        pattern := regexp.MustCompile(`(\d{1,2})\s+(Oca|Şub|Mar|Nis|May|Haz|Tem|Ağu|Eyl|Eki|Kas|Ara)\s+(\d{4})`)
-        if match := pattern.FindStringSubmatch(text); len(match) >= 4 {
-                day := match[1]
-                month := turkishMonths[match[2]]
-                year := match[3]
-                if len(day) == 1 {
-                        day = "0" + day
-                }
-                return day + "." + month + "." + year
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    duyurular,
+                Count:   len(duyurular),
+                Meta:    map[string]interface{}{"duyuru_linki": req.DuyuruLinki, "scraper": req.Scraper},
+                Message: "Duyurular önizlendi",
         }
-        
-        // Fallback to standard date patterns
-        return extractDateFromText(text)
-}
 
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
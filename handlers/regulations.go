@@ -11,10 +11,18 @@ import (
         "go.mongodb.org/mongo-driver/bson/primitive"
 
         "legal-documents-api/config"
+        "legal-documents-api/editorial"
         "legal-documents-api/models"
         "legal-documents-api/utils"
 )
 
+// typedDateFields maps the string date fields to their parsed BSON date fields
+var typedDateFields = map[string]string{
+        "belge_yayin_tarihi": "belge_yayin_date",
+        "yukleme_tarihi":     "yukleme_date",
+        "olusturulma_tarihi": "olusturulma_date",
+}
+
 // GetRecentRegulations returns the most recently published regulations
 func GetRecentRegulations(w http.ResponseWriter, r *http.Request) {
         // Handle CORS preflight
@@ -53,6 +61,13 @@ func GetRecentRegulations(w http.ResponseWriter, r *http.Request) {
                 sortOrder = 1
         }
 
+        // Date fields sort on their typed BSON date; the string is only a
+        // tie-breaker for documents the date migration could not parse
+        sortSpec := bson.D{{Key: sortBy, Value: sortOrder}}
+        if typedField, ok := typedDateFields[sortBy]; ok {
+                sortSpec = bson.D{{Key: typedField, Value: sortOrder}, {Key: sortBy, Value: sortOrder}}
+        }
+
         // Get metadata collection
         metadataCollection := config.GetMetadataCollection(mongoClient)
 
@@ -61,14 +76,12 @@ func GetRecentRegulations(w http.ResponseWriter, r *http.Request) {
                 // Match only active documents
                 {
                         "$match": bson.M{
-                                "status": "aktif",
+                                "status": editorial.ListedFilter(),
                         },
                 },
                 // Sort by the specified field
                 {
-                        "$sort": bson.M{
-                                sortBy: sortOrder,
-                        },
+                        "$sort": sortSpec,
                 },
                 // Limit results
                 {
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/mongo"
+
+        "legal-documents-api/config"
+        "legal-documents-api/editorial"
+        "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/utils"
+)
+
+// GetRelatedDocuments returns the documents most similar to the document with
+// the given slug: similar content, shared tags and keywords, and the same
+// institution and document type
+func GetRelatedDocuments(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        slug := strings.TrimSpace(mux.Vars(r)["slug"])
+        if len(slug) < 3 {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid document slug")
+                return
+        }
+
+        limit := 10
+        if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+                if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 50 {
+                        limit = parsedLimit
+                }
+        }
+
+        if searchEngine == nil || !searchEngine.Ready() {
+                utils.SendErrorResponse(w, http.StatusServiceUnavailable, "Search index is still being built, please retry shortly")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        var metadata models.DocumentMetadata
+        err := config.GetMetadataCollection(mongoClient).FindOne(ctx, bson.M{"url_slug": slug, "status": editorial.VisibleFilter()}).Decode(&metadata)
+        if err == mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Document not found")
+                return
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document metadata: "+err.Error())
+                return
+        }
+
+        // Without content the metadata signals still find related documents
+        var content models.DocumentContent
+        err = config.GetContentCollection(mongoClient).FindOne(ctx, bson.M{"metadata_id": metadata.ID}).Decode(&content)
+        if err != nil && err != mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document content: "+err.Error())
+                return
+        }
+
+        hits, err := searchEngine.Related(ctx, search.Document{
+                Metadata: metadata,
+                KurumAdi: utils.GetKurumAdiByID(metadata.KurumID),
+                Content:  content.Icerik,
+        }, limit)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to find related documents: "+err.Error())
+                return
+        }
+
+        summaries := make([]models.DocumentSummary, 0, len(hits))
+        for _, hit := range hits {
+                summaries = append(summaries, newDocumentSummary(hit.Document.Metadata))
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    summaries,
+                Count:   len(summaries),
+                Message: "Related documents fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// newDocumentSummary builds the listing form of a document, with institution
+// details from the cache and the description truncated
+func newDocumentSummary(doc models.DocumentMetadata) models.DocumentSummary {
+        return models.DocumentSummary{
+                ID:               doc.ID.Hex(),
+                KurumAdi:         utils.GetKurumAdiByID(doc.KurumID),
+                KurumLogo:        utils.GetKurumLogoByID(doc.KurumID),
+                KurumAciklama:    utils.GetKurumAciklamaByID(doc.KurumID),
+                PdfAdi:           doc.PdfAdi,
+                BelgeTuru:        doc.BelgeTuru,
+                Etiketler:        doc.Etiketler,
+                BelgeYayinTarihi: doc.BelgeYayinTarihi,
+                BelgeDurumu:      doc.BelgeDurumu,
+                Aciklama:         truncateText(doc.Aciklama, 200),
+                URLSlug:          doc.URLSlug,
+        }
+}
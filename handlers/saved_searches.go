@@ -0,0 +1,308 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "net/url"
+        "strconv"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+        "go.mongodb.org/mongo-driver/mongo"
+        "go.mongodb.org/mongo-driver/mongo/options"
+
+        "legal-documents-api/config"
+        "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/utils"
+)
+
+// SavedSearchRequest is the body accepted when registering a saved search
+type SavedSearchRequest struct {
+        Name       string              `json:"name"`
+        Query      string              `json:"query"`
+        Filters    map[string][]string `json:"filters"`
+        WebhookURL string              `json:"webhook_url"`
+        Secret     string              `json:"secret"` // optional, signs webhook payloads (X-Signature-256)
+}
+
+// AcknowledgeRequest lists the matches a client has processed
+type AcknowledgeRequest struct {
+        IDs []string `json:"ids"` // empty acknowledges every pending match
+}
+
+// CreateSavedSearch registers a query to be re-run against new documents
+func CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        var req SavedSearchRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+
+        req.Name = strings.TrimSpace(req.Name)
+        req.Query = strings.TrimSpace(req.Query)
+        if req.Query == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'query' is required")
+                return
+        }
+        if _, err := search.ParseQuery(req.Query); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid search query: "+err.Error())
+                return
+        }
+        if _, err := search.FiltersFromMap(req.Filters); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid filters: "+err.Error())
+                return
+        }
+        if req.WebhookURL != "" {
+                parsed, err := url.Parse(req.WebhookURL)
+                if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "'webhook_url' must be an absolute http(s) URL")
+                        return
+                }
+        }
+        if req.Name == "" {
+                req.Name = req.Query
+        }
+
+        savedSearch := models.SavedSearch{
+                ID:         primitive.NewObjectID(),
+                Name:       req.Name,
+                Query:      req.Query,
+                Filters:    req.Filters,
+                WebhookURL: req.WebhookURL,
+                Secret:     req.Secret,
+                Active:     true,
+                CreatedAt:  time.Now().UTC(),
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        if _, err := config.GetSavedSearchesCollection(mongoClient).InsertOne(ctx, savedSearch); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to save search: "+err.Error())
+                return
+        }
+
+        utils.SendCreatedResponse(w, savedSearch, "Kayıtlı arama oluşturuldu")
+}
+
+// ListSavedSearches returns every saved search
+func ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        findOptions := options.Find().SetSort(bson.M{"created_at": -1})
+        cursor, err := config.GetSavedSearchesCollection(mongoClient).Find(ctx, bson.M{}, findOptions)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch saved searches: "+err.Error())
+                return
+        }
+
+        savedSearches := []models.SavedSearch{}
+        if err := cursor.All(ctx, &savedSearches); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode saved searches: "+err.Error())
+                return
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    savedSearches,
+                Count:   len(savedSearches),
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// GetSavedSearch returns a single saved search
+func GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := savedSearchID(w, r)
+        if !ok {
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        var savedSearch models.SavedSearch
+        err := config.GetSavedSearchesCollection(mongoClient).FindOne(ctx, bson.M{"_id": id}).Decode(&savedSearch)
+        if err == mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Saved search not found")
+                return
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch saved search: "+err.Error())
+                return
+        }
+
+        utils.SendSuccessResponse(w, savedSearch, "")
+}
+
+// DeleteSavedSearch removes a saved search together with its matches
+func DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := savedSearchID(w, r)
+        if !ok {
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        result, err := config.GetSavedSearchesCollection(mongoClient).DeleteOne(ctx, bson.M{"_id": id})
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to delete saved search: "+err.Error())
+                return
+        }
+        if result.DeletedCount == 0 {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Saved search not found")
+                return
+        }
+        if _, err := config.GetSavedSearchMatchesCollection(mongoClient).DeleteMany(ctx, bson.M{"saved_search_id": id}); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to delete saved search matches: "+err.Error())
+                return
+        }
+
+        utils.SendSuccessResponse(w, nil, "Kayıtlı arama silindi")
+}
+
+// GetSavedSearchMatches returns the matches of a saved search the client has
+// not acknowledged yet, oldest first (all=true includes acknowledged ones)
+func GetSavedSearchMatches(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := savedSearchID(w, r)
+        if !ok {
+                return
+        }
+
+        limit := int64(100)
+        if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+                if parsedLimit, err := strconv.ParseInt(limitStr, 10, 64); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
+                        limit = parsedLimit
+                }
+        }
+
+        filter := bson.M{"saved_search_id": id}
+        if r.URL.Query().Get("all") != "true" {
+                filter["acknowledged"] = false
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        collection := config.GetSavedSearchMatchesCollection(mongoClient)
+        total, err := collection.CountDocuments(ctx, filter)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to count matches: "+err.Error())
+                return
+        }
+
+        findOptions := options.Find().SetSort(bson.M{"matched_at": 1}).SetLimit(limit)
+        cursor, err := collection.Find(ctx, filter, findOptions)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch matches: "+err.Error())
+                return
+        }
+
+        matches := []models.SavedSearchMatch{}
+        if err := cursor.All(ctx, &matches); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode matches: "+err.Error())
+                return
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    matches,
+                Count:   len(matches),
+        }
+
+        w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// AcknowledgeSavedSearchMatches marks matches as processed so they no longer
+// appear among the pending matches
+func AcknowledgeSavedSearchMatches(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := savedSearchID(w, r)
+        if !ok {
+                return
+        }
+
+        var req AcknowledgeRequest
+        if r.ContentLength != 0 {
+                if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                        return
+                }
+        }
+
+        filter := bson.M{"saved_search_id": id, "acknowledged": false}
+        if len(req.IDs) > 0 {
+                matchIDs := make([]primitive.ObjectID, 0, len(req.IDs))
+                for _, hex := range req.IDs {
+                        matchID, err := primitive.ObjectIDFromHex(hex)
+                        if err != nil {
+                                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid match id: "+hex)
+                                return
+                        }
+                        matchIDs = append(matchIDs, matchID)
+                }
+                filter["_id"] = bson.M{"$in": matchIDs}
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        result, err := config.GetSavedSearchMatchesCollection(mongoClient).UpdateMany(ctx, filter, bson.M{"$set": bson.M{"acknowledged": true}})
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to acknowledge matches: "+err.Error())
+                return
+        }
+
+        utils.SendSuccessResponse(w, map[string]int64{"acknowledged": result.ModifiedCount}, "Eşleşmeler onaylandı")
+}
+
+// savedSearchID parses the {id} path variable, answering 400 when it is invalid
+func savedSearchID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+        id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid saved search id")
+                return id, false
+        }
+        return id, true
+}
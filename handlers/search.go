@@ -3,10 +3,13 @@ package handlers
 import (
         "context"
         "encoding/json"
+        "fmt"
+        "math"
         "net/http"
         "strconv"
         "strings"
         "time"
+        "unicode/utf8"
 
         "go.mongodb.org/mongo-driver/bson"
         "go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,26 +17,69 @@ import (
 
         "legal-documents-api/config"
         "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/textnorm"
         "legal-documents-api/utils"
 )
 
 // SearchResult represents a search result
 type SearchResult struct {
-        ID                   string  `json:"id"`
-        PdfAdi               string  `json:"pdf_adi"`
-        KurumAdi             string  `json:"kurum_adi"`
-        KurumLogo            string  `json:"kurum_logo"`
-        BelgeTuru            string  `json:"belge_turu"`
-        BelgeDurumu          string  `json:"belge_durumu"`
-        BelgeYayinTarihi     string  `json:"belge_yayin_tarihi"`
-        Etiketler            string  `json:"etiketler"`
-        Aciklama             string  `json:"aciklama"`
-        URLSlug              string  `json:"url_slug"`
-        MatchType            string  `json:"match_type"`    // "title", "content", "tags", "institution"
-        ContentPreview       string  `json:"content_preview,omitempty"`
-        RelevanceScore       float64 `json:"relevance_score"`
-        RelevancePercentage  int     `json:"relevance_percentage"`
-        MatchCount           int     `json:"match_count"`
+        ID                   string      `json:"id"`
+        PdfAdi               string      `json:"pdf_adi"`
+        KurumAdi             string      `json:"kurum_adi"`
+        KurumLogo            string      `json:"kurum_logo"`
+        BelgeTuru            string      `json:"belge_turu"`
+        BelgeDurumu          string      `json:"belge_durumu"`
+        BelgeYayinTarihi     string      `json:"belge_yayin_tarihi"`
+        Etiketler            string      `json:"etiketler"`
+        Aciklama             string      `json:"aciklama"`
+        URLSlug              string      `json:"url_slug"`
+        MatchType            string      `json:"match_type"`    // "title", "content", "tags", "institution"
+        ContentPreview       string      `json:"content_preview,omitempty"`
+        RelevanceScore       float64     `json:"relevance_score"`
+        RelevancePercentage  int         `json:"relevance_percentage"`
+        MatchCount           int         `json:"match_count"`
+        Highlights           []Highlight `json:"highlights,omitempty"`
+}
+
+// Highlight holds the best matching fragments of one field
+type Highlight struct {
+        Field     string            `json:"field"` // "title", "description" or "content"
+        Fragments []search.Fragment `json:"fragments"`
+}
+
+// highlightOptions controls how GlobalSearch renders highlights
+type highlightOptions struct {
+        Mode         string // "tags", "offsets" or "none"
+        PreTag       string
+        PostTag      string
+        Fragments    int // per field
+        FragmentSize int // in characters
+}
+
+// highlightFields are the fields highlighted for every result, in display order
+var highlightFields = []search.Field{search.FieldTitle, search.FieldDescription, search.FieldContent}
+
+// SearchMeta carries pagination details for a search response
+type SearchMeta struct {
+        Total      int                                   `json:"total"`                 // exact number of matching documents
+        Limit      int64                                 `json:"limit"`
+        Offset     int64                                 `json:"offset"`
+        NextCursor string                                `json:"next_cursor,omitempty"` // pass as ?cursor= to fetch the next page
+        Facets     map[search.Facet][]search.FacetBucket `json:"facets,omitempty"`      // counts over all matches, when requested
+        DidYouMean string                                `json:"did_you_mean,omitempty"` // corrected query when few documents matched
+        SearchID   string                                `json:"search_id,omitempty"`    // send with /search/click to attribute result clicks
+}
+
+// didYouMeanThreshold is the hit count below which a spelling correction is suggested
+const didYouMeanThreshold = 5
+
+// searchEngine ranks documents for GlobalSearch and Autocomplete
+var searchEngine search.SearchEngine
+
+// SetSearchEngine sets the engine used by the search handlers
+func SetSearchEngine(engine search.SearchEngine) {
+        searchEngine = engine
 }
 
 // GlobalSearch performs comprehensive search across titles, content, tags, and institutions
@@ -42,6 +88,7 @@ func GlobalSearch(w http.ResponseWriter, r *http.Request) {
                 w.WriteHeader(http.StatusOK)
                 return
         }
+        started := time.Now()
 
         // Get search query
         query := r.URL.Query().Get("q")
@@ -54,9 +101,10 @@ func GlobalSearch(w http.ResponseWriter, r *http.Request) {
         institution := r.URL.Query().Get("kurum")         // Institution name filter
         institutionID := r.URL.Query().Get("kurum_id")    // Institution ID filter (more efficient)
 
-        // Clean and prepare query
+        // Clean and prepare query (length is measured on the normalized form so
+        // Turkish characters count once and punctuation-only queries are rejected)
         query = strings.TrimSpace(query)
-        if len(query) < 2 {
+        if utf8.RuneCountInString(textnorm.Normalize(query)) < 2 {
                 utils.SendErrorResponse(w, http.StatusBadRequest, "Search query must be at least 2 characters long")
                 return
         }
@@ -80,479 +128,425 @@ func GlobalSearch(w http.ResponseWriter, r *http.Request) {
                 }
         }
 
-        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-        defer cancel()
+        // Parse the query syntax: "phrases", AND/OR/NOT, -exclusion, field:scopes and prefix*
+        parsedQuery, err := search.ParseQuery(query)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid search query: "+err.Error())
+                return
+        }
 
-        // Search in multiple phases and combine results
-        var allResults []SearchResult
+        // Expand abbreviations and synonyms from the dictionary unless synonyms=false
+        if r.URL.Query().Get("synonyms") != "false" {
+                parsedQuery = search.ExpandSynonyms(parsedQuery)
+        }
 
-        // Phase 1: Search in metadata (titles, descriptions, tags, institutions)
-        metadataResults, err := searchInMetadata(ctx, query, institution, institutionID, limit*2) // Get more results to filter later
+        // Highlighting: highlight=tags (default, <mark> or the element named by
+        // pre_tag/post_tag),
+        // offsets (plain text with match ranges) or none
+        highlight, err := parseHighlightOptions(r)
         if err != nil {
-                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to search metadata: "+err.Error())
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
                 return
         }
-        allResults = append(allResults, metadataResults...)
 
-        // Phase 2: Search in content
-        contentResults, err := searchInContent(ctx, query, institution, institutionID, limit*2)
+        // Requested facet counts: facets=true for all, or a comma separated list
+        facets, err := parseRequestedFacets(r.URL.Query().Get("facets"))
         if err != nil {
-                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to search content: "+err.Error())
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
                 return
         }
 
-        // Merge content results with metadata, avoiding duplicates
-        allResults = mergeResults(allResults, contentResults)
-
-        // Filter out results with zero relevance score
-        var filteredResults []SearchResult
-        for _, result := range allResults {
-                if result.RelevanceScore > 0 {
-                        filteredResults = append(filteredResults, result)
-                }
+        // Date range and ordering: from/to bound belge_yayin_tarihi unless
+        // date_field=yukleme_tarihi, sort=relevance|date_desc|date_asc|title
+        dateQuery, err := parseDateQuery(r)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
         }
 
-        // Sort by relevance score (higher is better)
-        sortResultsByRelevance(filteredResults)
-
-        // Apply pagination
-        totalResults := len(filteredResults)
-        start := int(offset)
-        end := int(offset + limit)
-
-        if start > totalResults {
-                start = totalResults
+        // Cursor pagination (search_after): takes precedence over offset
+        var cursor *search.Cursor
+        cursorStr := r.URL.Query().Get("cursor")
+        if cursorStr == "" {
+                cursorStr = r.URL.Query().Get("search_after")
         }
-        if end > totalResults {
-                end = totalResults
+        if cursorStr != "" {
+                parsedCursor, err := search.DecodeCursor(cursorStr)
+                if err != nil {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid cursor parameter")
+                        return
+                }
+                if !parsedCursor.Matches(dateQuery.Sort) {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Cursor was issued for a different sort order")
+                        return
+                }
+                cursor = &parsedCursor
         }
 
-        paginatedResults := filteredResults[start:end]
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
 
-        response := models.APIResponse{
-                Success: true,
-                Data:    paginatedResults,
-                Count:   len(paginatedResults),
-                Message: "Search completed successfully",
+        if searchEngine == nil || !searchEngine.Ready() {
+                utils.SendErrorResponse(w, http.StatusServiceUnavailable, "Search index is still being built, please retry shortly")
+                return
         }
 
-        // Add pagination metadata
-        w.Header().Set("X-Total-Count", strconv.Itoa(totalResults))
-        w.Header().Set("X-Limit", strconv.FormatInt(limit, 10))
-        w.Header().Set("X-Offset", strconv.FormatInt(offset, 10))
-        w.Header().Set("Content-Type", "application/json")
-        w.WriteHeader(http.StatusOK)
-        json.NewEncoder(w).Encode(response)
-}
+        // Facet filters: kurum_id, belge_turu, belge_durumu and yil accept
+        // repeated or comma separated values
+        filters := parseSearchFilters(r)
 
-// searchInMetadata searches in document metadata (titles, descriptions, tags, institutions)
-func searchInMetadata(ctx context.Context, query string, institution string, institutionID string, limit int64) ([]SearchResult, error) {
-        collection := config.GetMetadataCollection(mongoClient)
-        
-        // Create regex for case-insensitive search
-        searchRegex := bson.M{"$regex": primitive.Regex{Pattern: query, Options: "i"}}
-        
-        // Build base search conditions (removed kurum_adi since it's now a reference)
-        searchConditions := []bson.M{
-                {"pdf_adi": searchRegex},           // Search in title
-                {"aciklama": searchRegex},          // Search in description  
-                {"anahtar_kelimeler": searchRegex}, // Search in keywords
-                {"etiketler": searchRegex},         // Search in tags
-        }
-        
-        // Build comprehensive search filter with optional institution filter
-        var filter bson.M
-        var kurumID string
-        
         // Priority: kurum_id > kurum (institution name)
-        if institutionID != "" {
-                // Direct kurum_id filter (most efficient)
-                kurumID = institutionID
-        } else if institution != "" {
-                // Find kurum_id by kurum_adi from cache
-                allKurumlar := utils.GetAllKurumlar()
-                for _, kurum := range allKurumlar {
-                        if strings.Contains(strings.ToLower(kurum.KurumAdi), strings.ToLower(institution)) {
-                                kurumID = kurum.ID.Hex()
-                                break
+        if institutionID == "" && institution != "" {
+                kurumID := utils.FindKurumIDByName(institution)
+                if kurumID == "" {
+                        // Institution specified but not found, nothing can match
+                        meta := SearchMeta{Limit: limit, Offset: offset}
+                        if cursor == nil && offset == 0 {
+                                meta.SearchID = recordSearch(r, query, 0, nil, started)
                         }
+                        sendSearchResponse(w, []SearchResult{}, meta)
+                        return
                 }
-        }
-        
-        if kurumID != "" {
-                filter = bson.M{
-                        "status": "aktif",
-                        "$and": []bson.M{
-                                {"kurum_id": kurumID},
-                                {"$or": searchConditions},
-                        },
-                }
-        } else if institution != "" || institutionID != "" {
-                // Institution specified but not found, return empty filter
-                filter = bson.M{"status": "aktif", "_id": bson.M{"$exists": false}}
-        } else {
-                // No institution filter
-                filter = bson.M{
-                        "status": "aktif",
-                        "$or": searchConditions,
-                }
+                filters[search.FacetKurum] = []string{kurumID}
         }
 
-        findOptions := options.Find()
-        findOptions.SetLimit(limit)
-        findOptions.SetSort(bson.M{"belge_yayin_tarihi": -1})
+        // Typo tolerance is on unless fuzzy=false
+        fuzzy := r.URL.Query().Get("fuzzy") != "false"
 
-        cursor, err := collection.Find(ctx, filter, findOptions)
+        // Rank every matching document with the search engine
+        searchQuery := dateQuery
+        searchQuery.Node = parsedQuery
+        searchQuery.Filters = filters
+        searchQuery.Facets = facets
+        searchQuery.Fuzzy = fuzzy
+        results, err := searchEngine.Search(ctx, searchQuery)
         if err != nil {
-                return nil, err
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to search documents: "+err.Error())
+                return
         }
-        defer cursor.Close(ctx)
 
-        var documents []models.DocumentMetadata
-        if err := cursor.All(ctx, &documents); err != nil {
-                return nil, err
+        // Apply pagination over the full ranked result set
+        totalResults := results.Total
+        start := int(offset)
+        if cursor != nil {
+                start = results.After(*cursor)
+                offset = int64(start)
         }
+        end := start + int(limit)
 
-        var results []SearchResult
-        for _, doc := range documents {
-                // Get kurum info from cache
-                kurumAdi := utils.GetKurumAdiByID(doc.KurumID)
-                kurumLogo := utils.GetKurumLogoByID(doc.KurumID)
+        if start > totalResults {
+                start = totalResults
+        }
+        if end > totalResults {
+                end = totalResults
+        }
 
-                result := SearchResult{
-                        ID:                   doc.ID.Hex(),
-                        PdfAdi:               doc.PdfAdi,
-                        KurumAdi:             kurumAdi,
-                        KurumLogo:            kurumLogo,
-                        BelgeTuru:            doc.BelgeTuru,
-                        BelgeDurumu:          doc.BelgeDurumu,
-                        BelgeYayinTarihi:     doc.BelgeYayinTarihi,
-                        Etiketler:            doc.Etiketler,
-                        Aciklama:             truncateText(doc.Aciklama, 200),
-                        URLSlug:              doc.URLSlug,
-                        RelevanceScore:       calculateMetadataRelevance(doc, query),
+        pageHits := results.Hits[start:end]
+        paginatedResults, err := buildSearchResults(ctx, pageHits, search.NewHighlighter(parsedQuery, results.Fuzzy), highlight, results.MaxScore)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to load content previews: "+err.Error())
+                return
+        }
+
+        meta := SearchMeta{Total: totalResults, Limit: limit, Offset: offset, Facets: labelFacets(results.Facets)}
+        if results.Fuzzy || totalResults < didYouMeanThreshold {
+                if corrected, ok := searchEngine.DidYouMean(ctx, query); ok {
+                        meta.DidYouMean = corrected
                 }
+        }
+        if end < totalResults && len(pageHits) > 0 {
+                meta.NextCursor = search.CursorFor(pageHits[len(pageHits)-1], results.Sort).Encode()
+        }
 
-                // Determine match type based on where the query was found
-                result.MatchType = determineMatchType(doc, query)
-                result.RelevancePercentage = calculatePercentage(result.RelevanceScore)
-                result.MatchCount = countMatches(doc, query)
-                results = append(results, result)
+        // Only the first page counts as a search; later pages reuse its search_id
+        if cursor == nil && offset == 0 {
+                meta.SearchID = recordSearch(r, query, totalResults, hitKurumIDs(results.Hits), started)
         }
 
-        return results, nil
+        sendSearchResponse(w, paginatedResults, meta)
 }
 
-// searchInContent searches in document content
-func searchInContent(ctx context.Context, query string, institution string, institutionID string, limit int64) ([]SearchResult, error) {
-        contentCollection := config.GetContentCollection(mongoClient)
-        metadataCollection := config.GetMetadataCollection(mongoClient)
-        
-        // Search in content
-        searchRegex := bson.M{"$regex": primitive.Regex{Pattern: query, Options: "i"}}
-        contentFilter := bson.M{
-                "icerik": searchRegex,
-        }
+// parseDateQuery reads the date range and sort parameters into a query
+func parseDateQuery(r *http.Request) (search.Query, error) {
+        var q search.Query
 
-        findOptions := options.Find()
-        findOptions.SetLimit(limit)
+        if dateField := r.URL.Query().Get("date_field"); dateField != "" {
+                switch search.DateField(dateField) {
+                case search.DatePublished, search.DateUploaded:
+                        q.DateField = search.DateField(dateField)
+                default:
+                        return q, fmt.Errorf("Invalid date_field '%s' (supported: belge_yayin_tarihi, yukleme_tarihi)", dateField)
+                }
+        }
 
-        cursor, err := contentCollection.Find(ctx, contentFilter, findOptions)
-        if err != nil {
-                return nil, err
+        if from := r.URL.Query().Get("from"); from != "" {
+                parsed, ok := utils.ParseDate(from)
+                if !ok {
+                        return q, fmt.Errorf("Invalid 'from' date '%s' (use YYYY-MM-DD or DD.MM.YYYY)", from)
+                }
+                q.From = parsed
+        }
+        if to := r.URL.Query().Get("to"); to != "" {
+                parsed, ok := utils.ParseDate(to)
+                if !ok {
+                        return q, fmt.Errorf("Invalid 'to' date '%s' (use YYYY-MM-DD or DD.MM.YYYY)", to)
+                }
+                q.To = parsed
+        }
+        if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+                return q, fmt.Errorf("'to' date must not be before 'from' date")
         }
-        defer cursor.Close(ctx)
 
-        var contents []models.DocumentContent
-        if err := cursor.All(ctx, &contents); err != nil {
-                return nil, err
+        q.Sort = search.SortRelevance
+        if sortParam := r.URL.Query().Get("sort"); sortParam != "" {
+                valid := false
+                for _, order := range search.SortOrders {
+                        if string(order) == sortParam {
+                                q.Sort = order
+                                valid = true
+                                break
+                        }
+                }
+                if !valid {
+                        return q, fmt.Errorf("Invalid sort '%s' (supported: relevance, date_desc, date_asc, title)", sortParam)
+                }
         }
+        return q, nil
+}
 
-        var results []SearchResult
-        for _, content := range contents {
-                // Get corresponding metadata
-                var metadata models.DocumentMetadata
-                metadataFilter := bson.M{
-                        "_id": content.MetadataID,
-                        "status": "aktif",
+// parseHighlightOptions reads the highlighting parameters
+func parseHighlightOptions(r *http.Request) (highlightOptions, error) {
+        opts := highlightOptions{Mode: "tags", PreTag: "<mark>", PostTag: "</mark>", Fragments: 3, FragmentSize: 160}
+
+        if mode := strings.ToLower(r.URL.Query().Get("highlight")); mode != "" {
+                switch mode {
+                case "tags", "true":
+                        opts.Mode = "tags"
+                case "offsets":
+                        opts.Mode = "offsets"
+                case "none", "false":
+                        opts.Mode = "none"
+                default:
+                        return opts, fmt.Errorf("Invalid highlight parameter '%s' (supported: tags, offsets, none)", mode)
                 }
-                
-                // Add institution filter if specified
-                var kurumID string
-                if institutionID != "" {
-                        // Direct kurum_id filter (most efficient)
-                        kurumID = institutionID
-                } else if institution != "" {
-                        // Find kurum_id by kurum_adi from cache
-                        allKurumlar := utils.GetAllKurumlar()
-                        for _, kurum := range allKurumlar {
-                                if strings.Contains(strings.ToLower(kurum.KurumAdi), strings.ToLower(institution)) {
-                                        kurumID = kurum.ID.Hex()
-                                        break
-                                }
-                        }
+        }
+        // Tags are rebuilt from a whitelisted element name so nothing from the
+        // query string is written into the HTML unescaped
+        tag := ""
+        for _, param := range []string{"pre_tag", "post_tag"} {
+                raw := r.URL.Query().Get(param)
+                if raw == "" {
+                        continue
                 }
-                
-                if kurumID != "" {
-                        metadataFilter["kurum_id"] = kurumID
+                name, ok := highlightTagName(raw)
+                if !ok {
+                        return opts, fmt.Errorf("Invalid %s '%s' (supported: mark, em, b, strong, span)", param, raw)
                 }
-                
-                if err := metadataCollection.FindOne(ctx, metadataFilter).Decode(&metadata); err != nil {
-                        continue // Skip if metadata not found or not active
+                if tag != "" && name != tag {
+                        return opts, fmt.Errorf("pre_tag and post_tag must name the same element")
                 }
+                tag = name
+        }
+        if tag != "" {
+                opts.PreTag = "<" + tag + ">"
+                opts.PostTag = "</" + tag + ">"
+        }
+        if fragments, err := strconv.Atoi(r.URL.Query().Get("fragments")); err == nil && fragments > 0 && fragments <= 10 {
+                opts.Fragments = fragments
+        }
+        if size, err := strconv.Atoi(r.URL.Query().Get("fragment_size")); err == nil && size >= 40 && size <= 500 {
+                opts.FragmentSize = size
+        }
+        return opts, nil
+}
 
-                // Get kurum info from cache
-                kurumAdi := utils.GetKurumAdiByID(metadata.KurumID)
-                kurumLogo := utils.GetKurumLogoByID(metadata.KurumID)
-
-                result := SearchResult{
-                        ID:                   metadata.ID.Hex(),
-                        PdfAdi:               metadata.PdfAdi,
-                        KurumAdi:             kurumAdi,
-                        KurumLogo:            kurumLogo,
-                        BelgeTuru:            metadata.BelgeTuru,
-                        BelgeDurumu:          metadata.BelgeDurumu,
-                        BelgeYayinTarihi:     metadata.BelgeYayinTarihi,
-                        Etiketler:            metadata.Etiketler,
-                        Aciklama:             truncateText(metadata.Aciklama, 200),
-                        URLSlug:              metadata.URLSlug,
-                        MatchType:            "content",
-                        ContentPreview:       extractContentPreview(content.Icerik, query),
-                        RelevanceScore:       calculateContentRelevance(content.Icerik, query),
-                }
+// highlightTags are the elements pre_tag and post_tag may name
+var highlightTags = map[string]bool{"mark": true, "em": true, "b": true, "strong": true, "span": true}
 
-                result.RelevancePercentage = calculatePercentage(result.RelevanceScore)
-                result.MatchCount = countContentMatches(content.Icerik, query)
-                results = append(results, result)
+// highlightTagName extracts the element name from a pre_tag or post_tag value,
+// accepting "mark", "<mark>" or "</mark>" for whitelisted elements only
+func highlightTagName(raw string) (string, bool) {
+        name := strings.TrimSpace(raw)
+        if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+                name = strings.TrimPrefix(strings.TrimSuffix(name[1:], ">"), "/")
         }
-
-        return results, nil
+        name = strings.ToLower(name)
+        return name, highlightTags[name]
 }
 
-// mergeResults combines metadata and content results, removing duplicates
-func mergeResults(metadataResults, contentResults []SearchResult) []SearchResult {
-        resultMap := make(map[string]SearchResult)
-        
-        // Add metadata results
-        for _, result := range metadataResults {
-                resultMap[result.ID] = result
-        }
-        
-        // Add content results, but merge with existing metadata results if duplicate
-        for _, contentResult := range contentResults {
-                if existing, exists := resultMap[contentResult.ID]; exists {
-                        // Merge: keep higher relevance score and combine match types
-                        if contentResult.RelevanceScore > existing.RelevanceScore {
-                                existing.RelevanceScore = contentResult.RelevanceScore
-                                existing.RelevancePercentage = contentResult.RelevancePercentage
+// parseSearchFilters reads the facet filters from the query string
+func parseSearchFilters(r *http.Request) search.Filters {
+        filters := make(search.Filters)
+        for _, facet := range search.AllFacets {
+                var values []string
+                for _, raw := range r.URL.Query()[string(facet)] {
+                        for _, value := range strings.Split(raw, ",") {
+                                if value = strings.TrimSpace(value); value != "" {
+                                        values = append(values, value)
+                                }
                         }
-                        existing.MatchType = existing.MatchType + "+content"
-                        existing.ContentPreview = contentResult.ContentPreview
-                        existing.MatchCount += contentResult.MatchCount // Add content matches to metadata matches
-                        resultMap[contentResult.ID] = existing
-                } else {
-                        resultMap[contentResult.ID] = contentResult
+                }
+                if len(values) > 0 {
+                        filters[facet] = values
                 }
         }
-        
-        // Convert map back to slice
-        var mergedResults []SearchResult
-        for _, result := range resultMap {
-                mergedResults = append(mergedResults, result)
-        }
-        
-        return mergedResults
+        return filters
 }
 
-// Helper functions
-func calculateMetadataRelevance(doc models.DocumentMetadata, query string) float64 {
-        score := 0.0
-        queryLower := strings.ToLower(query)
-        
-        // Title match (highest weight)
-        if strings.Contains(strings.ToLower(doc.PdfAdi), queryLower) {
-                score += 10.0
-        }
-        
-        // Institution match - get from cache
-        kurumAdi := utils.GetKurumAdiByID(doc.KurumID)
-        if strings.Contains(strings.ToLower(kurumAdi), queryLower) {
-                score += 5.0
-        }
-        
-        // Tags match
-        if strings.Contains(strings.ToLower(doc.Etiketler), queryLower) {
-                score += 3.0
-        }
-        
-        // Keywords match
-        if strings.Contains(strings.ToLower(doc.AnahtarKelimeler), queryLower) {
-                score += 2.0
-        }
-        
-        // Description match
-        if strings.Contains(strings.ToLower(doc.Aciklama), queryLower) {
-                score += 1.0
+// parseRequestedFacets parses the facets parameter
+func parseRequestedFacets(param string) ([]search.Facet, error) {
+        param = strings.TrimSpace(param)
+        switch strings.ToLower(param) {
+        case "", "false", "0":
+                return nil, nil
+        case "true", "1", "all":
+                return search.AllFacets, nil
+        }
+
+        var facets []search.Facet
+        for _, name := range strings.Split(param, ",") {
+                name = strings.TrimSpace(name)
+                if name == "" {
+                        continue
+                }
+                facet, ok := search.ParseFacet(name)
+                if !ok {
+                        return nil, fmt.Errorf("Unknown facet '%s' (supported: kurum_id, belge_turu, belge_durumu, yil)", name)
+                }
+                facets = append(facets, facet)
         }
-        
-        return score
+        return facets, nil
 }
 
-func calculateContentRelevance(content, query string) float64 {
-        queryLower := strings.ToLower(query)
-        contentLower := strings.ToLower(content)
-        
-        // Count occurrences
-        occurrences := strings.Count(contentLower, queryLower)
-        
-        // Base score from occurrences
-        score := float64(occurrences) * 0.5
-        
-        // Bonus for content length (shorter content with matches is more relevant)
-        if len(content) > 0 {
-                density := float64(len(query)*occurrences) / float64(len(content))
-                score += density * 100
+// labelFacets adds institution names to kurum_id buckets
+func labelFacets(facets map[search.Facet][]search.FacetBucket) map[search.Facet][]search.FacetBucket {
+        for i, bucket := range facets[search.FacetKurum] {
+                facets[search.FacetKurum][i].Label = utils.GetKurumAdiByID(bucket.Value)
         }
-        
-        return score
+        return facets
 }
 
-func determineMatchType(doc models.DocumentMetadata, query string) string {
-        queryLower := strings.ToLower(query)
-        
-        if strings.Contains(strings.ToLower(doc.PdfAdi), queryLower) {
-                return "title"
-        }
-        // Check institution name from cache
-        kurumAdi := utils.GetKurumAdiByID(doc.KurumID)
-        if strings.Contains(strings.ToLower(kurumAdi), queryLower) {
-                return "institution"
-        }
-        if strings.Contains(strings.ToLower(doc.Etiketler), queryLower) {
-                return "tags"
+// sendSearchResponse writes search results with pagination headers
+func sendSearchResponse(w http.ResponseWriter, results []SearchResult, meta SearchMeta) {
+        response := models.APIResponse{
+                Success: true,
+                Data:    results,
+                Count:   len(results),
+                Message: "Search completed successfully",
+                Meta:    meta,
         }
-        if strings.Contains(strings.ToLower(doc.AnahtarKelimeler), queryLower) {
-                return "keywords"
+
+        // Add pagination metadata
+        w.Header().Set("X-Total-Count", strconv.Itoa(meta.Total))
+        w.Header().Set("X-Limit", strconv.FormatInt(meta.Limit, 10))
+        w.Header().Set("X-Offset", strconv.FormatInt(meta.Offset, 10))
+        if meta.NextCursor != "" {
+                w.Header().Set("X-Next-Cursor", meta.NextCursor)
         }
-        return "description"
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
 }
 
-func extractContentPreview(content, query string) string {
-        queryLower := strings.ToLower(query)
-        contentLower := strings.ToLower(content)
-        
-        // Find the first occurrence of the query
-        index := strings.Index(contentLower, queryLower)
-        if index == -1 {
-                return truncateText(content, 150)
-        }
-        
-        // Extract surrounding context
-        start := index - 75
-        if start < 0 {
-                start = 0
-        }
-        
-        end := index + len(query) + 75
-        if end > len(content) {
-                end = len(content)
-        }
-        
-        preview := content[start:end]
-        if start > 0 {
-                preview = "..." + preview
-        }
-        if end < len(content) {
-                preview = preview + "..."
+// buildSearchResults converts engine hits into API results, loading content
+// for the hits that matched in the document body to build previews and highlights
+func buildSearchResults(ctx context.Context, hits []search.Hit, highlighter *search.Highlighter, opts highlightOptions, maxScore float64) ([]SearchResult, error) {
+        var contentIDs []primitive.ObjectID
+        for _, hit := range hits {
+                if hit.MatchedIn(search.FieldContent) {
+                        contentIDs = append(contentIDs, hit.Document.Metadata.ID)
+                }
         }
-        
-        return preview
-}
 
-func truncateText(text string, maxLength int) string {
-        if len(text) <= maxLength {
-                return text
+        contents := make(map[primitive.ObjectID]string)
+        if len(contentIDs) > 0 {
+                contentCollection := config.GetContentCollection(mongoClient)
+                findOptions := options.Find().SetProjection(bson.M{"metadata_id": 1, "icerik": 1})
+                cursor, err := contentCollection.Find(ctx, bson.M{"metadata_id": bson.M{"$in": contentIDs}}, findOptions)
+                if err != nil {
+                        return nil, err
+                }
+                defer cursor.Close(ctx)
+
+                for cursor.Next(ctx) {
+                        var content models.DocumentContent
+                        if err := cursor.Decode(&content); err != nil {
+                                continue
+                        }
+                        contents[content.MetadataID] = content.Icerik
+                }
+                if err := cursor.Err(); err != nil {
+                        return nil, err
+                }
         }
-        return text[:maxLength] + "..."
-}
 
-func sortResultsByRelevance(results []SearchResult) {
-        // Simple bubble sort by relevance score (descending)
-        n := len(results)
-        for i := 0; i < n-1; i++ {
-                for j := 0; j < n-i-1; j++ {
-                        if results[j].RelevanceScore < results[j+1].RelevanceScore {
-                                results[j], results[j+1] = results[j+1], results[j]
+        results := make([]SearchResult, 0, len(hits))
+        for _, hit := range hits {
+                doc := hit.Document.Metadata
+
+                percentage := 0
+                if maxScore > 0 {
+                        percentage = int(math.Round(hit.Score / maxScore * 100))
+                }
+
+                result := SearchResult{
+                        ID:                   doc.ID.Hex(),
+                        PdfAdi:               doc.PdfAdi,
+                        KurumAdi:             hit.Document.KurumAdi,
+                        KurumLogo:            utils.GetKurumLogoByID(doc.KurumID),
+                        BelgeTuru:            doc.BelgeTuru,
+                        BelgeDurumu:          doc.BelgeDurumu,
+                        BelgeYayinTarihi:     doc.BelgeYayinTarihi,
+                        Etiketler:            doc.Etiketler,
+                        Aciklama:             truncateText(doc.Aciklama, 200),
+                        URLSlug:              doc.URLSlug,
+                        MatchType:            hit.MatchType(),
+                        RelevanceScore:       hit.Score,
+                        RelevancePercentage:  percentage,
+                        MatchCount:           hit.MatchCount,
+                }
+
+                fieldTexts := map[search.Field]string{
+                        search.FieldTitle:       doc.PdfAdi,
+                        search.FieldDescription: doc.Aciklama,
+                        search.FieldContent:     contents[doc.ID],
+                }
+                for _, field := range highlightFields {
+                        fragments := highlighter.Fragments(field, fieldTexts[field], opts.Fragments, opts.FragmentSize)
+                        if field == search.FieldContent && fieldTexts[field] != "" {
+                                // The best content fragment doubles as the plain preview
+                                if len(fragments) > 0 {
+                                        result.ContentPreview = fragments[0].Text
+                                } else {
+                                        result.ContentPreview = truncateText(fieldTexts[field], 150)
+                                }
+                        }
+                        if len(fragments) == 0 || opts.Mode == "none" {
+                                continue
                         }
+                        if opts.Mode == "tags" {
+                                for i := range fragments {
+                                        fragments[i].Text = fragments[i].Marked(opts.PreTag, opts.PostTag)
+                                        fragments[i].Matches = nil
+                                }
+                        }
+                        result.Highlights = append(result.Highlights, Highlight{Field: field.String(), Fragments: fragments})
                 }
+
+                results = append(results, result)
         }
+
+        return results, nil
 }
 
-// calculatePercentage converts relevance score to percentage (0-100)
-func calculatePercentage(score float64) int {
-        if score <= 0 {
-                return 0
-        }
-        
-        // Normalize different score ranges
-        var percentage float64
-        
-        if score >= 1000 {
-                // Very high relevance (title + content matches)
-                percentage = 95 + (score-1000)/10000*5  // 95-100%
-        } else if score >= 100 {
-                // High relevance (multiple matches)
-                percentage = 80 + (score-100)/900*15     // 80-95%
-        } else if score >= 50 {
-                // Medium-high relevance
-                percentage = 60 + (score-50)/50*20       // 60-80%
-        } else if score >= 10 {
-                // Medium relevance
-                percentage = 30 + (score-10)/40*30       // 30-60%
-        } else if score >= 1 {
-                // Low relevance
-                percentage = 10 + (score-1)/9*20         // 10-30%
-        } else {
-                // Very low relevance
-                percentage = score * 10                  // 0-10%
+func truncateText(text string, maxLength int) string {
+        if len(text) <= maxLength {
+                return text
         }
-        
-        // Cap at 100%
-        if percentage > 100 {
-                percentage = 100
+        // Step back to a rune boundary so Turkish characters are not mangled
+        cut := maxLength
+        for cut > 0 && !utf8.RuneStart(text[cut]) {
+                cut--
         }
-        
-        return int(percentage)
-}
-
-// countMatches counts how many times the query appears in metadata fields
-func countMatches(doc models.DocumentMetadata, query string) int {
-        count := 0
-        queryLower := strings.ToLower(query)
-        
-        // Count in title
-        count += strings.Count(strings.ToLower(doc.PdfAdi), queryLower)
-        
-        // Count in institution name from cache
-        kurumAdi := utils.GetKurumAdiByID(doc.KurumID)
-        count += strings.Count(strings.ToLower(kurumAdi), queryLower)
-        
-        // Count in tags
-        count += strings.Count(strings.ToLower(doc.Etiketler), queryLower)
-        
-        // Count in keywords
-        count += strings.Count(strings.ToLower(doc.AnahtarKelimeler), queryLower)
-        
-        // Count in description
-        count += strings.Count(strings.ToLower(doc.Aciklama), queryLower)
-        
-        return count
+        return text[:cut] + "..."
 }
-
-// countContentMatches counts how many times the query appears in content
-func countContentMatches(content, query string) int {
-        queryLower := strings.ToLower(query)
-        contentLower := strings.ToLower(content)
-        
-        return strings.Count(contentLower, queryLower)
-}
\ No newline at end of file
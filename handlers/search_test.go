@@ -0,0 +1,70 @@
+package handlers
+
+import (
+        "encoding/json"
+        "net/http"
+        "net/http/httptest"
+        "strings"
+        "testing"
+
+        "legal-documents-api/models"
+)
+
+func TestGlobalSearchRejectsMalformedCursor(t *testing.T) {
+        tests := []struct {
+                url, message string
+        }{
+                {"/api/v1/search?q=kira&cursor=not-a-cursor", "Invalid cursor"},
+                {"/api/v1/search?q=kira&search_after=e30", "Invalid cursor"},
+                // eyJzIjoxLCJpZCI6IngifQ is {"s":1,"id":"x"}, a relevance cursor
+                {"/api/v1/search?q=kira&sort=title&cursor=eyJzIjoxLCJpZCI6IngifQ", "different sort order"},
+        }
+        for _, tt := range tests {
+                recorder := httptest.NewRecorder()
+                GlobalSearch(recorder, httptest.NewRequest(http.MethodGet, tt.url, nil))
+                if recorder.Code != http.StatusBadRequest {
+                        t.Errorf("GET %s: status %d, want 400", tt.url, recorder.Code)
+                        continue
+                }
+                var response models.APIResponse
+                if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil || !strings.Contains(response.Error, tt.message) {
+                        t.Errorf("GET %s: error %q (%v), want %q", tt.url, response.Error, err, tt.message)
+                }
+        }
+}
+
+func TestParseHighlightOptionsTags(t *testing.T) {
+        tests := []struct {
+                query     string
+                pre, post string
+                err       bool
+        }{
+                {"", "<mark>", "</mark>", false},
+                {"pre_tag=%3Cem%3E&post_tag=%3C%2Fem%3E", "<em>", "</em>", false},
+                {"pre_tag=STRONG", "<strong>", "</strong>", false},
+                {"post_tag=%3C%2Fb%3E", "<b>", "</b>", false},
+                {"pre_tag=%3Cscript%3E&post_tag=%3C%2Fscript%3E", "", "", true},
+                {"pre_tag=%3Cspan%20onmouseover%3Dalert(1)%3E&post_tag=%3C%2Fspan%3E", "", "", true},
+                {"pre_tag=%3Cmark%3E%3Cimg%20src%3Dx%3E", "", "", true},
+                {"pre_tag=%3Cem%3E&post_tag=%3C%2Fb%3E", "", "", true},
+        }
+        for _, tt := range tests {
+                opts, err := parseHighlightOptions(httptest.NewRequest(http.MethodGet, "/api/v1/search?q=kira&"+tt.query, nil))
+                if tt.err {
+                        if err == nil {
+                                t.Errorf("%s: tags %q %q accepted", tt.query, opts.PreTag, opts.PostTag)
+                        }
+                        continue
+                }
+                if err != nil || opts.PreTag != tt.pre || opts.PostTag != tt.post {
+                        t.Errorf("%s: tags %q %q (%v), want %q %q", tt.query, opts.PreTag, opts.PostTag, err, tt.pre, tt.post)
+                }
+        }
+
+        // Rejected tags are a 400
+        recorder := httptest.NewRecorder()
+        GlobalSearch(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=kira&pre_tag=%3Cscript%3E", nil))
+        if recorder.Code != http.StatusBadRequest {
+                t.Errorf("GET with a script tag: status %d, want 400", recorder.Code)
+        }
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "fmt"
+        "math"
+        "net/http"
+        "sort"
+        "strconv"
+        "strings"
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+
+        "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/semantic"
+        "legal-documents-api/utils"
+)
+
+// semanticIndex answers /search/semantic; nil until SetSemanticIndex is called
+var semanticIndex *semantic.Index
+
+// SetSemanticIndex sets the vector index used by SemanticSearch
+func SetSemanticIndex(idx *semantic.Index) {
+        semanticIndex = idx
+}
+
+// semanticCandidates is how many documents each ranking contributes per
+// requested result, leaving room for filters and for blending
+const semanticCandidates = 4
+
+// SemanticResult is a document whose content is close in meaning to the query
+type SemanticResult struct {
+        ID               string  `json:"id"`
+        PdfAdi           string  `json:"pdf_adi"`
+        KurumAdi         string  `json:"kurum_adi"`
+        KurumLogo        string  `json:"kurum_logo"`
+        BelgeTuru        string  `json:"belge_turu"`
+        BelgeDurumu      string  `json:"belge_durumu"`
+        BelgeYayinTarihi string  `json:"belge_yayin_tarihi"`
+        Aciklama         string  `json:"aciklama"`
+        URLSlug          string  `json:"url_slug"`
+        Passage          string  `json:"passage,omitempty"` // best matching passage of the content
+        Score            float64 `json:"score"`
+        SemanticScore    float64 `json:"semantic_score"`          // cosine similarity of the best passage
+        KeywordScore     float64 `json:"keyword_score,omitempty"` // keyword relevance relative to the best keyword match (hybrid only)
+}
+
+// SemanticMeta describes how semantic results were ranked
+type SemanticMeta struct {
+        Mode  string  `json:"mode"`  // "semantic" or "hybrid"
+        Alpha float64 `json:"alpha"` // weight of the semantic score, 1 in semantic mode
+        Model string  `json:"model"`
+}
+
+// semanticScores holds both relevance signals of one document
+type semanticScores struct {
+        semantic    float64
+        keyword     float64
+        embeddingID primitive.ObjectID
+}
+
+// SemanticSearch finds documents whose content is close in meaning to a
+// natural-language query. mode=hybrid blends the similarity with keyword
+// relevance: score = alpha*semantic + (1-alpha)*keyword.
+func SemanticSearch(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        query := strings.TrimSpace(r.URL.Query().Get("q"))
+        if query == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Query parameter 'q' is required")
+                return
+        }
+
+        limit := 10
+        if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+                if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 50 {
+                        limit = parsedLimit
+                }
+        }
+
+        meta, err := parseSemanticMode(r)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
+        }
+
+        if semanticIndex == nil || !semanticIndex.Ready() || searchEngine == nil || !searchEngine.Ready() {
+                utils.SendErrorResponse(w, http.StatusServiceUnavailable, "Search index is still being built, please retry shortly")
+                return
+        }
+        meta.Model = semanticIndex.Embedder().Name()
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        filters := parseSearchFilters(r)
+        // Filtered out documents are skipped while the neighbours are
+        // collected, so a selective filter still fills the page
+        keep := func(id string) bool {
+                doc, ok := searchEngine.Document(id)
+                return ok && filters.Matches(doc)
+        }
+        scores := make(map[string]*semanticScores)
+        for _, match := range semanticIndex.Search(query, limit*semanticCandidates, keep) {
+                scores[match.DocumentID] = &semanticScores{
+                        semantic:    math.Max(match.Score, 0),
+                        embeddingID: match.EmbeddingID,
+                }
+        }
+
+        if meta.Mode == "hybrid" {
+                parsedQuery, err := search.ParseQuery(query)
+                if err != nil {
+                        utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid search query: "+err.Error())
+                        return
+                }
+                results, err := searchEngine.Search(ctx, search.Query{
+                        Node:    search.AnyOf(search.ExpandSynonyms(parsedQuery)),
+                        Filters: filters,
+                        Fuzzy:   true,
+                })
+                if err != nil {
+                        utils.SendErrorResponse(w, http.StatusInternalServerError, "Search failed: "+err.Error())
+                        return
+                }
+                hits := results.Hits
+                if len(hits) > limit*semanticCandidates {
+                        hits = hits[:limit*semanticCandidates]
+                }
+                for _, hit := range hits {
+                        if results.MaxScore <= 0 {
+                                break
+                        }
+                        id := hit.Document.ID()
+                        if scores[id] == nil {
+                                scores[id] = &semanticScores{}
+                        }
+                        scores[id].keyword = hit.Score / results.MaxScore
+                }
+        }
+
+        type ranked struct {
+                doc    *search.Document
+                scores *semanticScores
+                score  float64
+        }
+        var ranking []ranked
+        for id, s := range scores {
+                doc, ok := searchEngine.Document(id)
+                if !ok || !filters.Matches(doc) {
+                        continue // no longer active, or filtered out
+                }
+                score := meta.Alpha*s.semantic + (1-meta.Alpha)*s.keyword
+                ranking = append(ranking, ranked{doc: doc, scores: s, score: score})
+        }
+        sort.Slice(ranking, func(i, j int) bool {
+                if ranking[i].score != ranking[j].score {
+                        return ranking[i].score > ranking[j].score
+                }
+                return ranking[i].doc.ID() < ranking[j].doc.ID()
+        })
+        if len(ranking) > limit {
+                ranking = ranking[:limit]
+        }
+
+        var passageIDs []primitive.ObjectID
+        for _, item := range ranking {
+                if !item.scores.embeddingID.IsZero() {
+                        passageIDs = append(passageIDs, item.scores.embeddingID)
+                }
+        }
+        passages, err := semantic.Passages(ctx, mongoClient, passageIDs)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch passages: "+err.Error())
+                return
+        }
+
+        results := make([]SemanticResult, 0, len(ranking))
+        for _, item := range ranking {
+                doc := item.doc.Metadata
+                results = append(results, SemanticResult{
+                        ID:               doc.ID.Hex(),
+                        PdfAdi:           doc.PdfAdi,
+                        KurumAdi:         item.doc.KurumAdi,
+                        KurumLogo:        utils.GetKurumLogoByID(doc.KurumID),
+                        BelgeTuru:        doc.BelgeTuru,
+                        BelgeDurumu:      doc.BelgeDurumu,
+                        BelgeYayinTarihi: doc.BelgeYayinTarihi,
+                        Aciklama:         truncateText(doc.Aciklama, 200),
+                        URLSlug:          doc.URLSlug,
+                        Passage:          truncateText(passages[item.scores.embeddingID], 400),
+                        Score:            item.score,
+                        SemanticScore:    item.scores.semantic,
+                        KeywordScore:     item.scores.keyword,
+                })
+        }
+
+        sendSemanticResponse(w, results, meta)
+}
+
+// parseSemanticMode reads mode (semantic or hybrid) and alpha (0-1, default 0.5)
+func parseSemanticMode(r *http.Request) (SemanticMeta, error) {
+        meta := SemanticMeta{Mode: "semantic", Alpha: 1}
+        switch mode := strings.ToLower(r.URL.Query().Get("mode")); mode {
+        case "", "semantic":
+        case "hybrid":
+                meta.Mode = mode
+                meta.Alpha = 0.5
+                if alphaStr := r.URL.Query().Get("alpha"); alphaStr != "" {
+                        alpha, err := strconv.ParseFloat(alphaStr, 64)
+                        if err != nil || alpha < 0 || alpha > 1 {
+                                return meta, fmt.Errorf("Invalid alpha '%s' (must be between 0 and 1)", alphaStr)
+                        }
+                        meta.Alpha = alpha
+                }
+        default:
+                return meta, fmt.Errorf("Invalid mode '%s' (supported: semantic, hybrid)", mode)
+        }
+        return meta, nil
+}
+
+func sendSemanticResponse(w http.ResponseWriter, results []SemanticResult, meta SemanticMeta) {
+        response := models.APIResponse{
+                Success: true,
+                Data:    results,
+                Count:   len(results),
+                Meta:    meta,
+                Message: "Semantic search completed successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
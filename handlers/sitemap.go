@@ -12,6 +12,7 @@ import (
         "go.mongodb.org/mongo-driver/mongo/options"
 
         "legal-documents-api/config"
+        "legal-documents-api/editorial"
         "legal-documents-api/models"
         "legal-documents-api/utils"
 )
@@ -27,11 +28,34 @@ type SitemapInstitution struct {
 
 // SitemapDocument represents document data for sitemap
 type SitemapDocument struct {
-        URLSlug              string `json:"url_slug" bson:"url_slug"`
-        PdfAdi               string `json:"pdf_adi" bson:"pdf_adi"`
-        KurumAdi             string `json:"kurum_adi" bson:"kurum_adi"`
-        BelgeYayinTarihi     string `json:"belge_yayin_tarihi" bson:"belge_yayin_tarihi"`
-        OlusturulmaTarihi    string `json:"olusturulma_tarihi" bson:"olusturulma_tarihi"`
+        URLSlug              string     `json:"url_slug" bson:"url_slug"`
+        PdfAdi               string     `json:"pdf_adi" bson:"pdf_adi"`
+        KurumAdi             string     `json:"kurum_adi" bson:"kurum_adi"`
+        BelgeYayinTarihi     string     `json:"belge_yayin_tarihi" bson:"belge_yayin_tarihi"`
+        OlusturulmaTarihi    string     `json:"olusturulma_tarihi" bson:"olusturulma_tarihi"`
+        BelgeYayinDate       *time.Time `json:"-" bson:"belge_yayin_date,omitempty"`
+        OlusturulmaDate      *time.Time `json:"-" bson:"olusturulma_date,omitempty"`
+}
+
+// lastModified returns the sitemap lastmod of a document in W3C date format,
+// or "" when neither date can be determined
+func (doc SitemapDocument) lastModified() string {
+        candidates := []struct {
+                typed *time.Time
+                raw   string
+        }{
+                {doc.OlusturulmaDate, doc.OlusturulmaTarihi},
+                {doc.BelgeYayinDate, doc.BelgeYayinTarihi},
+        }
+        for _, candidate := range candidates {
+                if candidate.typed != nil {
+                        return candidate.typed.UTC().Format("2006-01-02")
+                }
+                if parsed, ok := utils.ParseDate(candidate.raw); ok {
+                        return parsed.UTC().Format("2006-01-02")
+                }
+        }
+        return ""
 }
 
 // GetSitemapInstitutions returns all institutions for sitemap
@@ -50,7 +74,7 @@ func GetSitemapInstitutions(w http.ResponseWriter, r *http.Request) {
         pipeline := []bson.M{
                 {
                         "$match": bson.M{
-                                "status": "aktif",
+                                "status": editorial.ListedFilter(),
                         },
                 },
                 {
@@ -130,7 +154,7 @@ func GetSitemapDocumentsByInstitution(w http.ResponseWriter, r *http.Request) {
 
         filter := bson.M{
                 "kurum_id": kurumID,
-                "status":   "aktif",
+                "status":   editorial.ListedFilter(),
         }
 
         findOptions := options.Find()
@@ -196,7 +220,7 @@ func GetSitemapAllDocuments(w http.ResponseWriter, r *http.Request) {
         collection := config.GetMetadataCollection(mongoClient)
 
         filter := bson.M{
-                "status": "aktif",
+                "status": editorial.ListedFilter(),
         }
 
         findOptions := options.Find()
@@ -262,13 +286,15 @@ func GetSitemapXML(w http.ResponseWriter, r *http.Request) {
         collection := config.GetMetadataCollection(mongoClient)
 
         // Get all active documents
-        filter := bson.M{"status": "aktif"}
+        filter := bson.M{"status": editorial.ListedFilter()}
         findOptions := options.Find()
-        findOptions.SetSort(bson.M{"belge_yayin_tarihi": -1})
+        findOptions.SetSort(bson.D{{Key: "belge_yayin_date", Value: -1}, {Key: "belge_yayin_tarihi", Value: -1}})
         findOptions.SetProjection(bson.M{
                 "url_slug":           1,
                 "belge_yayin_tarihi": 1,
                 "olusturulma_tarihi": 1,
+                "belge_yayin_date":   1,
+                "olusturulma_date":   1,
         })
 
         cursor, err := collection.Find(ctx, filter, findOptions)
@@ -299,11 +325,11 @@ func GetSitemapXML(w http.ResponseWriter, r *http.Request) {
         // Add document pages
         for _, doc := range documents {
                 if doc.URLSlug != "" {
-                        lastmod := doc.BelgeYayinTarihi
-                        if doc.OlusturulmaTarihi != "" {
-                                lastmod = doc.OlusturulmaTarihi
+                        lastmod := ""
+                        if date := doc.lastModified(); date != "" {
+                                lastmod = "<lastmod>" + date + "</lastmod>"
                         }
-                        fmt.Fprintf(w, `<url><loc>%s/belge/%s</loc><lastmod>%s</lastmod><changefreq>monthly</changefreq><priority>0.9</priority></url>`, 
+                        fmt.Fprintf(w, `<url><loc>%s/belge/%s</loc>%s<changefreq>monthly</changefreq><priority>0.9</priority></url>`, 
                                 DOMAIN, doc.URLSlug, lastmod)
                 }
         }
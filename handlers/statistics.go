@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 
 	"legal-documents-api/config"
+	"legal-documents-api/editorial"
 	"legal-documents-api/models"
 	"legal-documents-api/utils"
 )
@@ -49,9 +50,11 @@ func GetStatistics(w http.ResponseWriter, r *http.Request) {
 	}
 	totalKurumlar := int64(len(allKurumlar))
 
-	// 2. Get total documents count from metadata collection
+	// 2. Get total documents count from metadata collection, counting
+	// published documents only
 	metadataCollection := config.GetMetadataCollection(mongoClient)
-	totalBelgeler, err := metadataCollection.CountDocuments(ctx, bson.M{})
+	listed := bson.M{"status": editorial.ListedFilter()}
+	totalBelgeler, err := metadataCollection.CountDocuments(ctx, listed)
 	if err != nil {
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to count documents: "+err.Error())
 		return
@@ -59,6 +62,9 @@ func GetStatistics(w http.ResponseWriter, r *http.Request) {
 
 	// 3. Get document counts grouped by belge_turu
 	pipeline := []bson.M{
+		{
+			"$match": listed,
+		},
 		{
 			"$group": bson.M{
 				"_id":   "$belge_turu",
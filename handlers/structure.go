@@ -0,0 +1,247 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/mongo"
+
+        "legal-documents-api/config"
+        "legal-documents-api/models"
+        "legal-documents-api/structure"
+        "legal-documents-api/textnorm"
+        "legal-documents-api/utils"
+)
+
+// StructureResponse is the table of contents of a document: its kısım, bölüm
+// and madde headings
+type StructureResponse struct {
+        URLSlug     string                 `json:"url_slug"`
+        PdfAdi      string                 `json:"pdf_adi"`
+        MaddeSayisi int                    `json:"madde_sayisi"`
+        Nodes       []models.StructureNode `json:"nodes"`
+        ParsedAt    time.Time              `json:"parsed_at"`
+}
+
+// StructureText is a division of a document together with its text
+type StructureText struct {
+        Type     string          `json:"type"`
+        Number   string          `json:"number,omitempty"`
+        Title    string          `json:"title,omitempty"`
+        Anchor   string          `json:"anchor"`
+        Text     string          `json:"text"`
+        Children []StructureText `json:"children,omitempty"` // fıkra of a madde, bent of a fıkra
+}
+
+// MaddeResponse is a single article with its paragraphs and the anchors of
+// the articles around it
+type MaddeResponse struct {
+        URLSlug string        `json:"url_slug"`
+        PdfAdi  string        `json:"pdf_adi"`
+        Madde   StructureText `json:"madde"`
+        Onceki  string        `json:"onceki,omitempty"`  // anchor of the previous article
+        Sonraki string        `json:"sonraki,omitempty"` // anchor of the next article
+}
+
+// articleKinds maps the words before an article number in /madde/{n} to the
+// article type: "5", "gecici-1", "ek-3", "ek-gecici-2"
+var articleKinds = map[string]string{
+        "":          structure.TypeMadde,
+        "gecici":    structure.TypeGeciciMadde,
+        "ek":        structure.TypeEkMadde,
+        "ek gecici": structure.TypeEkGeciciMadde,
+}
+
+// GetDocumentStructure returns the table of contents of a legislative
+// document, parsing its content on first use
+func GetDocumentStructure(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, _, yapi, ok := loadDocumentStructure(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        articles := 0
+        var toc func(nodes []models.StructureNode) []models.StructureNode
+        toc = func(nodes []models.StructureNode) []models.StructureNode {
+                result := make([]models.StructureNode, 0, len(nodes))
+                for _, node := range nodes {
+                        switch node.Type {
+                        case structure.TypeFikra, structure.TypeBent:
+                                continue // listed by /madde/{n}
+                        case structure.TypeKisim, structure.TypeBolum:
+                                node.Children = toc(node.Children)
+                        default:
+                                articles++
+                                node.Children = nil
+                        }
+                        result = append(result, node)
+                }
+                return result
+        }
+        nodes := toc(yapi.Nodes)
+
+        response := models.APIResponse{
+                Success: true,
+                Data: StructureResponse{
+                        URLSlug:     metadata.URLSlug,
+                        PdfAdi:      metadata.PdfAdi,
+                        MaddeSayisi: articles,
+                        Nodes:       nodes,
+                        ParsedAt:    yapi.ParsedAt,
+                },
+                Count:   articles,
+                Message: "Document structure fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// GetDocumentMadde returns the text of one article of a document with its
+// fıkra and bent. {n} is the article number ("5", "5a"), optionally preceded
+// by its kind ("gecici-1", "ek-3", "ek-gecici-2").
+func GetDocumentMadde(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        kind, number := parseArticleNumber(mux.Vars(r)["n"])
+        articleType, ok := articleKinds[kind]
+        if !ok || number == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid article number '"+mux.Vars(r)["n"]+"' (examples: 5, 5a, gecici-1, ek-3)")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, content, yapi, ok := loadDocumentStructure(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        var articles []models.StructureNode
+        var collect func(nodes []models.StructureNode)
+        collect = func(nodes []models.StructureNode) {
+                for _, node := range nodes {
+                        switch node.Type {
+                        case structure.TypeKisim, structure.TypeBolum:
+                                collect(node.Children)
+                        case structure.TypeFikra, structure.TypeBent:
+                        default:
+                                articles = append(articles, node)
+                        }
+                }
+        }
+        collect(yapi.Nodes)
+
+        for i, article := range articles {
+                if article.Type != articleType || strings.ReplaceAll(textnorm.Normalize(article.Number), " ", "") != number {
+                        continue
+                }
+                data := MaddeResponse{
+                        URLSlug: metadata.URLSlug,
+                        PdfAdi:  metadata.PdfAdi,
+                        Madde:   structureText(content.Icerik, article),
+                }
+                if i > 0 {
+                        data.Onceki = articles[i-1].Anchor
+                }
+                if i+1 < len(articles) {
+                        data.Sonraki = articles[i+1].Anchor
+                }
+
+                response := models.APIResponse{
+                        Success: true,
+                        Data:    data,
+                        Message: "Article fetched successfully",
+                }
+                w.Header().Set("Content-Type", "application/json")
+                w.WriteHeader(http.StatusOK)
+                json.NewEncoder(w).Encode(response)
+                return
+        }
+
+        utils.SendErrorResponse(w, http.StatusNotFound, "Article not found")
+}
+
+// loadDocumentStructure fetches the active document with the slug in the
+// request and its structure, parsing and storing the structure when missing
+// or outdated. Errors are written to w and reported with ok false.
+func loadDocumentStructure(ctx context.Context, w http.ResponseWriter, r *http.Request) (models.DocumentMetadata, models.DocumentContent, *models.DocumentStructure, bool) {
+        var content models.DocumentContent
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return metadata, content, nil, false
+        }
+
+        err := config.GetContentCollection(mongoClient).FindOne(ctx, bson.M{"metadata_id": metadata.ID}).Decode(&content)
+        if err == mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Document content not found")
+                return metadata, content, nil, false
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document content: "+err.Error())
+                return metadata, content, nil, false
+        }
+
+        yapi, err := structure.Ensure(ctx, mongoClient, &content)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to store document structure: "+err.Error())
+                return metadata, content, nil, false
+        }
+        return metadata, content, yapi, true
+}
+
+// parseArticleNumber splits an article reference such as "gecici-1" or
+// "Ek Madde 5/A" into its kind ("gecici", "ek") and normalized number ("5a")
+func parseArticleNumber(n string) (string, string) {
+        var kind []string
+        number := ""
+        for _, token := range textnorm.Tokens(n) {
+                switch token {
+                case "madde":
+                case "ek", "gecici":
+                        if number == "" {
+                                kind = append(kind, token)
+                        }
+                default:
+                        number += token
+                }
+        }
+        return strings.Join(kind, " "), number
+}
+
+// structureText cuts the text of node and its children out of icerik
+func structureText(icerik string, node models.StructureNode) StructureText {
+        start, end := node.Start, node.End
+        if start < 0 || end > len(icerik) || start > end {
+                start, end = 0, 0 // the content changed since it was parsed
+        }
+        text := StructureText{
+                Type:   node.Type,
+                Number: node.Number,
+                Title:  node.Title,
+                Anchor: node.Anchor,
+                Text:   strings.TrimSpace(icerik[start:end]),
+        }
+        for _, child := range node.Children {
+                text.Children = append(text.Children, structureText(icerik, child))
+        }
+        return text
+}
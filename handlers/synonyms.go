@@ -0,0 +1,247 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "fmt"
+        "log"
+        "net/http"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/bson/primitive"
+        "go.mongodb.org/mongo-driver/mongo"
+        "go.mongodb.org/mongo-driver/mongo/options"
+
+        "legal-documents-api/config"
+        "legal-documents-api/models"
+        "legal-documents-api/textnorm"
+        "legal-documents-api/utils"
+)
+
+// SynonymRequest is the body accepted when creating or replacing a synonym entry
+type SynonymRequest struct {
+        Term     string   `json:"term"`
+        Synonyms []string `json:"synonyms"`
+        OneWay   bool     `json:"one_way"`
+}
+
+// validate trims the request and checks that it describes at least two distinct forms
+func (req *SynonymRequest) validate() error {
+        req.Term = strings.TrimSpace(req.Term)
+        if textnorm.Normalize(req.Term) == "" {
+                return fmt.Errorf("'term' is required")
+        }
+
+        seen := map[string]bool{textnorm.Normalize(req.Term): true}
+        synonyms := make([]string, 0, len(req.Synonyms))
+        for _, synonym := range req.Synonyms {
+                synonym = strings.TrimSpace(synonym)
+                key := textnorm.Normalize(synonym)
+                if key == "" || seen[key] {
+                        continue
+                }
+                seen[key] = true
+                synonyms = append(synonyms, synonym)
+        }
+        if len(synonyms) == 0 {
+                return fmt.Errorf("'synonyms' must contain at least one form different from the term")
+        }
+        req.Synonyms = synonyms
+        return nil
+}
+
+// CreateSynonym adds an entry to the synonym dictionary
+func CreateSynonym(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        var req SynonymRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        if err := req.validate(); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
+        }
+
+        now := time.Now().UTC()
+        synonym := models.Synonym{
+                ID:        primitive.NewObjectID(),
+                Term:      req.Term,
+                Synonyms:  req.Synonyms,
+                OneWay:    req.OneWay,
+                CreatedAt: now,
+                UpdatedAt: now,
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        if _, err := config.GetSynonymsCollection(mongoClient).InsertOne(ctx, synonym); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to save synonym: "+err.Error())
+                return
+        }
+        reloadSynonyms()
+
+        utils.SendCreatedResponse(w, synonym, "Eş anlamlı kayıt oluşturuldu")
+}
+
+// ListSynonyms returns the entries of the synonym dictionary ordered by term,
+// optionally only those with a form containing q
+func ListSynonyms(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        filter := bson.M{}
+        if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+                searchRegex := bson.M{"$regex": primitive.Regex{Pattern: textnorm.RegexPattern(q), Options: "i"}}
+                filter["$or"] = []bson.M{
+                        {"term": searchRegex},
+                        {"synonyms": searchRegex},
+                }
+        }
+
+        findOptions := options.Find().SetSort(bson.M{"term": 1})
+        cursor, err := config.GetSynonymsCollection(mongoClient).Find(ctx, filter, findOptions)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch synonyms: "+err.Error())
+                return
+        }
+
+        synonyms := []models.Synonym{}
+        if err := cursor.All(ctx, &synonyms); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode synonyms: "+err.Error())
+                return
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    synonyms,
+                Count:   len(synonyms),
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// UpdateSynonym replaces the forms of a synonym entry
+func UpdateSynonym(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := synonymID(w, r)
+        if !ok {
+                return
+        }
+
+        var req SynonymRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        if err := req.validate(); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        update := bson.M{"$set": bson.M{
+                "term":       req.Term,
+                "synonyms":   req.Synonyms,
+                "one_way":    req.OneWay,
+                "updated_at": time.Now().UTC(),
+        }}
+        var synonym models.Synonym
+        err := config.GetSynonymsCollection(mongoClient).FindOneAndUpdate(ctx, bson.M{"_id": id}, update,
+                options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&synonym)
+        if err == mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Synonym not found")
+                return
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to update synonym: "+err.Error())
+                return
+        }
+        reloadSynonyms()
+
+        utils.SendSuccessResponse(w, synonym, "Eş anlamlı kayıt güncellendi")
+}
+
+// DeleteSynonym removes an entry from the synonym dictionary
+func DeleteSynonym(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        id, ok := synonymID(w, r)
+        if !ok {
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+
+        result, err := config.GetSynonymsCollection(mongoClient).DeleteOne(ctx, bson.M{"_id": id})
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to delete synonym: "+err.Error())
+                return
+        }
+        if result.DeletedCount == 0 {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Synonym not found")
+                return
+        }
+        reloadSynonyms()
+
+        utils.SendSuccessResponse(w, nil, "Eş anlamlı kayıt silindi")
+}
+
+// ReloadSynonyms reloads the synonym dictionary from the database, picking up
+// entries written directly to the collection
+func ReloadSynonyms(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        if err := utils.RefreshSynonymsCache(mongoClient); err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to reload synonyms: "+err.Error())
+                return
+        }
+
+        utils.SendSuccessResponse(w, nil, "Eş anlamlı sözlüğü yeniden yüklendi")
+}
+
+// reloadSynonyms refreshes the cache after a write; a failure only delays the
+// change until the next reload
+func reloadSynonyms() {
+        if err := utils.RefreshSynonymsCache(mongoClient); err != nil {
+                log.Printf("Warning: Failed to reload synonyms: %v", err)
+        }
+}
+
+// synonymID parses the {id} path variable, answering 400 when it is invalid
+func synonymID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+        id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid synonym id")
+                return id, false
+        }
+        return id, true
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+        "context"
+        "encoding/json"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "github.com/gorilla/mux"
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/mongo"
+
+        "legal-documents-api/config"
+        "legal-documents-api/models"
+        "legal-documents-api/utils"
+        "legal-documents-api/versions"
+)
+
+// LinkVersionRequest is the body accepted when linking a document to the
+// record of its previous version
+type LinkVersionRequest struct {
+        PreviousSlug string `json:"previous_slug"`
+        Note         string `json:"note"`
+}
+
+// GetDocumentVersions lists the versions of a document, oldest first, without
+// their content. Documents without history list none until the snapshotter
+// captures their first version.
+func GetDocumentVersions(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        list, err := versions.List(ctx, mongoClient, metadata.ID)
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document versions: "+err.Error())
+                return
+        }
+
+        current := 0
+        if len(list) > 0 {
+                current = list[len(list)-1].Version
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    list,
+                Count:   len(list),
+                Meta:    map[string]int{"current": current},
+                Message: "Document versions fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// GetDocumentVersion returns one version of a document with its content
+func GetDocumentVersion(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        n, err := strconv.Atoi(mux.Vars(r)["version"])
+        if err != nil || n < 1 {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid version number")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        version, err := versions.Get(ctx, mongoClient, metadata.ID, n)
+        sendVersion(w, version, err)
+}
+
+// GetDocumentAsOf returns the version of a document in force on a date
+func GetDocumentAsOf(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        dateStr := strings.TrimSpace(r.URL.Query().Get("date"))
+        date, ok := utils.ParseDate(dateStr)
+        if !ok {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid or missing 'date' (e.g. 2023-05-01 or 01.05.2023)")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        version, err := versions.AsOf(ctx, mongoClient, metadata.ID, endOfDay(date))
+        if err == versions.ErrNotFound {
+                utils.SendErrorResponse(w, http.StatusNotFound, "No version of the document was in force on "+dateStr)
+                return
+        }
+        sendVersion(w, version, err)
+}
+
+// LinkDocumentVersion makes a separately created document the previous
+// version of the document in the request, moving its history over
+func LinkDocumentVersion(w http.ResponseWriter, r *http.Request) {
+        if r.Method == "OPTIONS" {
+                w.WriteHeader(http.StatusOK)
+                return
+        }
+
+        var req LinkVersionRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+                return
+        }
+        req.PreviousSlug = strings.TrimSpace(req.PreviousSlug)
+        if req.PreviousSlug == "" {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "'previous_slug' is required")
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        metadata, ok := findActiveDocument(ctx, w, r)
+        if !ok {
+                return
+        }
+
+        // The previous version may no longer be active
+        var previous models.DocumentMetadata
+        err := config.GetMetadataCollection(mongoClient).FindOne(ctx, bson.M{"url_slug": req.PreviousSlug}).Decode(&previous)
+        if err == mongo.ErrNoDocuments {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Previous document not found")
+                return
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch previous document: "+err.Error())
+                return
+        }
+
+        list, err := versions.Link(ctx, mongoClient, previous, metadata, strings.TrimSpace(req.Note))
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusBadRequest, "Failed to link versions: "+err.Error())
+                return
+        }
+        for i := range list {
+                list[i].Icerik = ""
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    list,
+                Count:   len(list),
+                Message: "Document versions linked successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
+
+// endOfDay extends a date without a time to cover the whole day
+func endOfDay(date time.Time) time.Time {
+        if date.Equal(date.Truncate(24 * time.Hour)) {
+                return date.Add(24*time.Hour - time.Nanosecond)
+        }
+        return date
+}
+
+// sendVersion writes a single version, or the error looking it up
+func sendVersion(w http.ResponseWriter, version *models.DocumentVersion, err error) {
+        if err == versions.ErrNotFound {
+                utils.SendErrorResponse(w, http.StatusNotFound, "Version not found")
+                return
+        }
+        if err != nil {
+                utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch document version: "+err.Error())
+                return
+        }
+
+        response := models.APIResponse{
+                Success: true,
+                Data:    version,
+                Message: "Document version fetched successfully",
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(response)
+}
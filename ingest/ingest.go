@@ -0,0 +1,193 @@
+// Package ingest turns PDF files into documents awaiting review. The text of
+// the file becomes the content of the document; its page count, size, title
+// and slug fill the metadata the uploader leaves empty.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"legal-documents-api/config"
+	"legal-documents-api/documents"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+	"legal-documents-api/pdftext"
+)
+
+// maxTitleLength bounds a title taken from the text of a file
+const maxTitleLength = 200
+
+// ErrNoText is returned for files without extractable text, such as scans
+var ErrNoText = errors.New("no text could be extracted from the PDF (scanned files need OCR first)")
+
+// File is a PDF file with the text and metadata read from it
+type File struct {
+	Name   string // original file name
+	Data   []byte
+	Text   string
+	Title  string // from the document information, the first line or the file name
+	Pages  int32
+	SizeMB float64
+}
+
+// Read extracts the text of a PDF file
+func Read(name string, data []byte) (*File, error) {
+	doc, err := pdftext.Extract(data)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(doc.Text)
+	if text == "" {
+		return nil, ErrNoText
+	}
+	return &File{
+		Name:   name,
+		Data:   data,
+		Text:   text,
+		Title:  title(doc.Title, text, name),
+		Pages:  int32(doc.Pages),
+		SizeMB: math.Round(float64(len(data))/(1<<20)*100) / 100,
+	}, nil
+}
+
+// Create adds the file as a document awaiting review (status inceleme) and
+// records who uploaded it in the audit trail. Fields of metadata that are set
+// are kept; the title, page count, size and slug are filled from the file
+// otherwise. When PDF_STORAGE_DIR and PDF_BASE_URL are set the file is
+// stored there and its address becomes the pdf_url. A file that cannot be
+// moved into place after the document is inserted is logged and the pdf_url
+// cleared, and so is a failure to record the upload: the document itself
+// was created.
+func Create(ctx context.Context, client *mongo.Client, f *File, metadata *models.DocumentMetadata, note, uploader string) (models.DocumentContent, error) {
+	if metadata.PdfAdi == "" {
+		metadata.PdfAdi = f.Title
+	}
+	if metadata.SayfaSayisi == 0 {
+		metadata.SayfaSayisi = f.Pages
+	}
+	if metadata.DosyaBoyutuMB == 0 {
+		metadata.DosyaBoyutuMB = f.SizeMB
+	}
+	metadata.Status = editorial.Inceleme
+	if note == "" {
+		note = "PDF yüklendi: " + f.Name
+	}
+
+	// The slug names the stored file, so it is chosen, and a given one
+	// checked, before anything is written
+	if metadata.URLSlug == "" {
+		slug, err := documents.UniqueSlug(ctx, client, metadata.PdfAdi, primitive.NilObjectID)
+		if err != nil {
+			return models.DocumentContent{}, err
+		}
+		metadata.URLSlug = slug
+	} else if available, err := documents.SlugAvailable(ctx, client, metadata.URLSlug, primitive.NilObjectID); err != nil {
+		return models.DocumentContent{}, err
+	} else if !available {
+		return models.DocumentContent{}, documents.ErrSlugTaken
+	}
+	staged, err := stage(f, metadata)
+	if err != nil {
+		return models.DocumentContent{}, err
+	}
+
+	content, err := documents.Create(ctx, client, metadata, f.Text, note)
+	if err != nil {
+		if staged != nil {
+			os.Remove(staged.temp)
+		}
+		return content, err
+	}
+	if staged != nil {
+		if err := os.Rename(staged.temp, staged.path); err != nil {
+			os.Remove(staged.temp)
+			log.Printf("Warning: Failed to store PDF of document %s: %v", metadata.ID.Hex(), err)
+			metadata.PdfURL = ""
+			if _, err := config.GetMetadataCollection(client).UpdateOne(ctx, bson.M{"_id": metadata.ID}, bson.M{"$set": bson.M{"pdf_url": ""}}); err != nil {
+				log.Printf("Warning: Failed to clear pdf_url of document %s: %v", metadata.ID.Hex(), err)
+			}
+		}
+	}
+	if _, err := editorial.Record(ctx, client, metadata.ID, "", metadata.Status, uploader, "PDF yüklendi: "+f.Name); err != nil {
+		log.Printf("Warning: Failed to record status of %s: %v", metadata.ID.Hex(), err)
+	}
+	return content, nil
+}
+
+// stagedFile is a PDF written under a temporary name until its document is
+// inserted, so a failed insert never replaces the file of another document
+type stagedFile struct {
+	temp string
+	path string
+}
+
+// stage writes the file to PDF_STORAGE_DIR under a temporary name and sets
+// the pdf_url of metadata to its final name when it has none. It returns nil
+// when no file is stored.
+func stage(f *File, metadata *models.DocumentMetadata) (*stagedFile, error) {
+	dir, baseURL := os.Getenv("PDF_STORAGE_DIR"), os.Getenv("PDF_BASE_URL")
+	if dir == "" || baseURL == "" || metadata.PdfURL != "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create PDF storage directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*.pdf")
+	if err != nil {
+		return nil, fmt.Errorf("store PDF: %w", err)
+	}
+	_, err = tmp.Write(f.Data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("store PDF: %w", err)
+	}
+	name := metadata.URLSlug + ".pdf"
+	metadata.PdfURL = strings.TrimRight(baseURL, "/") + "/" + name
+	return &stagedFile{temp: tmp.Name(), path: filepath.Join(dir, name)}, nil
+}
+
+// title picks the title of a document: the one recorded in the file, else
+// its first line of text, else the file name
+func title(info, text, fileName string) string {
+	if info = strings.TrimSpace(info); info != "" && !strings.EqualFold(info, "untitled") {
+		return info
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			return truncate(line, maxTitleLength)
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+}
+
+// truncate shortens s to at most n bytes at a word boundary
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	if i := strings.LastIndexByte(s[:cut], ' '); i > n/2 {
+		cut = i
+	}
+	return strings.TrimSpace(s[:cut])
+}
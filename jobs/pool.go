@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"legal-documents-api/models"
+)
+
+const (
+	// leaseDuration is how long a job is held by a worker without renewal;
+	// running jobs renew it every third of that
+	leaseDuration = 2 * time.Minute
+	// pollInterval is how often idle workers look for due jobs
+	pollInterval = 5 * time.Second
+)
+
+// ErrUnknownType is returned when enqueuing a job no handler is registered for
+var ErrUnknownType = errors.New("unknown job type")
+
+// Handler runs a job, returning a short description of the result. An error
+// fails the attempt; the job is retried while it has attempts left unless
+// the error is wrapped with Permanent.
+type Handler func(ctx context.Context, job models.Job) (string, error)
+
+type registration struct {
+	handler Handler
+	timeout time.Duration
+}
+
+// Pool runs the jobs of the registered types with a fixed number of workers
+type Pool struct {
+	client   *mongo.Client
+	workers  int
+	id       string // identifies this process in leases
+	handlers map[string]registration
+	wake     chan struct{}
+	started  bool
+	mu       sync.RWMutex
+}
+
+// NewPool creates a pool of workers goroutines
+func NewPool(client *mongo.Client, workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	host, _ := os.Hostname()
+	return &Pool{
+		client:   client,
+		workers:  workers,
+		id:       fmt.Sprintf("%s-%d-%s", host, os.Getpid(), primitive.NewObjectID().Hex()[18:]),
+		handlers: make(map[string]registration),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler of a job type. Each attempt may run for at most
+// timeout. Types must be registered before Start.
+func (p *Pool) Register(jobType string, timeout time.Duration, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[jobType] = registration{handler: handler, timeout: timeout}
+}
+
+// Types returns the registered job types
+func (p *Pool) Types() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	types := make([]string, 0, len(p.handlers))
+	for t := range p.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Enqueue adds a job of a registered type and wakes an idle worker
+func (p *Pool) Enqueue(ctx context.Context, jobType string, payload interface{}, opts Options) (models.Job, bool, error) {
+	p.mu.RLock()
+	_, ok := p.handlers[jobType]
+	p.mu.RUnlock()
+	if !ok {
+		return models.Job{}, false, ErrUnknownType
+	}
+	job, created, err := Enqueue(ctx, p.client, jobType, payload, opts)
+	if created && opts.Delay <= 0 {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+	return job, created, err
+}
+
+// Start runs the workers in the background, along with the recovery of jobs
+// whose worker stopped
+func (p *Pool) Start() {
+	p.mu.Lock()
+	if p.started {
+		p.mu.Unlock()
+		return
+	}
+	p.started = true
+	p.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := EnsureIndexes(ctx, p.client); err != nil {
+			log.Printf("Warning: Failed to create job indexes: %v", err)
+		}
+		cancel()
+
+		for n := 1; n <= p.workers; n++ {
+			go p.work(p.id + "/" + strconv.Itoa(n))
+		}
+
+		ticker := time.NewTicker(leaseDuration / 2)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if n, err := recoverExpired(ctx, p.client); err != nil {
+				log.Printf("Warning: Failed to recover expired jobs: %v", err)
+			} else if n > 0 {
+				log.Printf("Recovered %d jobs with expired leases", n)
+			}
+			cancel()
+		}
+	}()
+	log.Printf("Job queue started with %d workers for %v", p.workers, p.Types())
+}
+
+// Every enqueues a job of the given type every interval. The jobs share a
+// key, so a run is skipped while the previous one is still queued or running,
+// also when several processes schedule it.
+func (p *Pool) Every(interval time.Duration, jobType string, payload interface{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			if _, _, err := p.Enqueue(ctx, jobType, payload, Options{Key: "schedule:" + jobType}); err != nil {
+				log.Printf("Warning: Failed to schedule %s job: %v", jobType, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// work leases and runs jobs until the process exits
+func (p *Pool) work(worker string) {
+	types := p.Types()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		job, err := lease(ctx, p.client, types, worker, leaseDuration)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Failed to lease job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-p.wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		p.run(job, worker)
+	}
+}
+
+// run runs one attempt of a job, renewing its lease meanwhile, and records
+// the outcome
+func (p *Pool) run(job *models.Job, worker string) {
+	p.mu.RLock()
+	reg := p.handlers[job.Type]
+	p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), reg.timeout)
+	defer cancel()
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				renewCtx, renewCancel := context.WithTimeout(context.Background(), 15*time.Second)
+				held, err := renew(renewCtx, p.client, job, worker, leaseDuration)
+				renewCancel()
+				if err != nil {
+					log.Printf("Warning: Failed to renew lease of job %s: %v", job.ID.Hex(), err)
+				}
+				if !held {
+					close(lost)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	start := time.Now()
+	result, err := call(ctx, reg.handler, *job)
+	close(done)
+
+	select {
+	case <-lost:
+		log.Printf("Job %s (%s) stopped: cancelled or lease lost", job.ID.Hex(), job.Type)
+		return
+	default:
+	}
+
+	recordCtx, recordCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer recordCancel()
+	if err == nil {
+		if err := complete(recordCtx, p.client, job, worker, result); err != nil {
+			log.Printf("Warning: Failed to record completion of job %s: %v", job.ID.Hex(), err)
+		}
+		log.Printf("Job %s (%s) succeeded in %s: %s", job.ID.Hex(), job.Type, time.Since(start).Round(time.Millisecond), result)
+		return
+	}
+
+	dead, recordErr := fail(recordCtx, p.client, job, worker, err)
+	if recordErr != nil {
+		log.Printf("Warning: Failed to record failure of job %s: %v", job.ID.Hex(), recordErr)
+	}
+	if dead {
+		log.Printf("Job %s (%s) dead-lettered after attempt %d: %v", job.ID.Hex(), job.Type, job.Attempts, err)
+	} else {
+		log.Printf("Job %s (%s) failed (attempt %d/%d), will retry: %v", job.ID.Hex(), job.Type, job.Attempts, job.MaxAttempts, err)
+	}
+}
+
+// call runs a handler, turning a panic into an error
+func call(ctx context.Context, handler Handler, job models.Job) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	if handler == nil {
+		return "", ErrUnknownType
+	}
+	return handler(ctx, job)
+}
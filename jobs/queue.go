@@ -0,0 +1,374 @@
+// Package jobs is a durable background job queue kept in the jobs collection.
+// Jobs are leased by workers for a limited time and the lease is renewed
+// while they run, so the jobs of a crashed process are picked up again once
+// their lease expires. Failed jobs are retried with exponential backoff and
+// dead-lettered after their last attempt.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+)
+
+// Job statuses. Jobs waiting for a retry are queued with a later run_at.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead" // failed on every attempt
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts of a job enqueued without one
+	DefaultMaxAttempts = 5
+	// baseBackoff is the delay before the first retry; it doubles with every
+	// further attempt up to maxBackoff
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+	// keptErrors is how many failures are kept on a job
+	keptErrors = 10
+)
+
+var (
+	// ErrNotFound is returned when no job has the requested id
+	ErrNotFound = errors.New("job not found")
+	// ErrInvalidState is returned when a job cannot make the requested change
+	// in its current status
+	ErrInvalidState = errors.New("job cannot be changed in its current status")
+	// ErrDuplicate is returned when a job with the same key is already queued
+	// or running
+	ErrDuplicate = errors.New("a job with the same key is already queued or running")
+)
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// Permanent wraps the error of a job that should be dead-lettered right away
+// instead of retried, such as invalid input
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Options control how a job is enqueued
+type Options struct {
+	Key         string        // at most one queued or running job has a key; empty for none
+	MaxAttempts int           // DefaultMaxAttempts when zero
+	Delay       time.Duration // the job is not run before this has passed
+}
+
+// EnsureIndexes creates the indexes the queue relies on
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetJobsCollection(client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "active_key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+	})
+	return err
+}
+
+// Enqueue adds a job of the given type. The payload is stored as a document
+// and read back by the handler with Decode. When a job with the same key is
+// already queued or running that job is returned with created false.
+func Enqueue(ctx context.Context, client *mongo.Client, jobType string, payload interface{}, opts Options) (models.Job, bool, error) {
+	now := time.Now().UTC()
+	job := models.Job{
+		ID:          primitive.NewObjectID(),
+		Type:        jobType,
+		Key:         opts.Key,
+		ActiveKey:   opts.Key,
+		Status:      StatusQueued,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       now.Add(opts.Delay),
+		CreatedAt:   now,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if payload != nil {
+		raw, err := bson.Marshal(payload)
+		if err != nil {
+			return job, false, err
+		}
+		if err := bson.Unmarshal(raw, &job.Payload); err != nil {
+			return job, false, err
+		}
+	}
+
+	collection := config.GetJobsCollection(client)
+	_, err := collection.InsertOne(ctx, job)
+	if err != nil && opts.Key != "" && mongo.IsDuplicateKeyError(err) {
+		var existing models.Job
+		if findErr := collection.FindOne(ctx, bson.M{"active_key": opts.Key}).Decode(&existing); findErr == nil {
+			return existing, false, nil
+		}
+	}
+	return job, err == nil, err
+}
+
+// Decode reads the payload of a job into v
+func Decode(job models.Job, v interface{}) error {
+	if job.Payload == nil {
+		return nil
+	}
+	raw, err := bson.Marshal(job.Payload)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, v)
+}
+
+// Get returns a job by id
+func Get(ctx context.Context, client *mongo.Client, id primitive.ObjectID) (models.Job, error) {
+	var job models.Job
+	err := config.GetJobsCollection(client).FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		err = ErrNotFound
+	}
+	return job, err
+}
+
+// Filter selects jobs in List
+type Filter struct {
+	Status string
+	Type   string
+	Limit  int64
+	Offset int64
+}
+
+// List returns jobs matching the filter, newest first, with their total count
+func List(ctx context.Context, client *mongo.Client, filter Filter) ([]models.Job, int64, error) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	collection := config.GetJobsCollection(client)
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(filter.Offset).
+		SetLimit(filter.Limit)
+	cursor, err := collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	list := []models.Job{}
+	err = cursor.All(ctx, &list)
+	return list, total, err
+}
+
+// Counts returns the number of jobs in each status
+func Counts(ctx context.Context, client *mongo.Client) (map[string]int64, error) {
+	cursor, err := config.GetJobsCollection(client).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := map[string]int64{StatusQueued: 0, StatusRunning: 0, StatusSucceeded: 0, StatusDead: 0, StatusCancelled: 0}
+	for _, g := range groups {
+		counts[g.Status] = g.Count
+	}
+	return counts, nil
+}
+
+// Retry queues a dead or cancelled job again with a fresh set of attempts
+func Retry(ctx context.Context, client *mongo.Client, id primitive.ObjectID) (models.Job, error) {
+	job, err := Get(ctx, client, id)
+	if err != nil {
+		return job, err
+	}
+	if job.Status != StatusDead && job.Status != StatusCancelled {
+		return job, ErrInvalidState
+	}
+	set := bson.M{"status": StatusQueued, "attempts": 0, "run_at": time.Now().UTC()}
+	if job.Key != "" {
+		set["active_key"] = job.Key
+	}
+	var retried models.Job
+	err = config.GetJobsCollection(client).FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": job.Status},
+		bson.M{"$set": set, "$unset": bson.M{"finished_at": "", "leased_by": "", "lease_until": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&retried)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		err = ErrInvalidState
+	case mongo.IsDuplicateKeyError(err):
+		err = ErrDuplicate
+	}
+	return retried, err
+}
+
+// Cancel stops a queued or running job. A running job notices when it next
+// renews its lease and its context is cancelled.
+func Cancel(ctx context.Context, client *mongo.Client, id primitive.ObjectID) (models.Job, error) {
+	var job models.Job
+	err := config.GetJobsCollection(client).FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": []string{StatusQueued, StatusRunning}}},
+		bson.M{
+			"$set":   bson.M{"status": StatusCancelled, "finished_at": time.Now().UTC()},
+			"$unset": bson.M{"active_key": "", "lease_until": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		if _, getErr := Get(ctx, client, id); getErr != nil {
+			return job, getErr
+		}
+		return job, ErrInvalidState
+	}
+	return job, err
+}
+
+// lease takes the queued job of one of types that is due first, marking it
+// running for worker until the lease ends. It returns nil when no job is due.
+func lease(ctx context.Context, client *mongo.Client, types []string, worker string, leaseFor time.Duration) (*models.Job, error) {
+	now := time.Now().UTC()
+	var job models.Job
+	err := config.GetJobsCollection(client).FindOneAndUpdate(ctx,
+		bson.M{"status": StatusQueued, "run_at": bson.M{"$lte": now}, "type": bson.M{"$in": types}},
+		bson.M{
+			"$set": bson.M{"status": StatusRunning, "leased_by": worker, "lease_until": now.Add(leaseFor), "started_at": now},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "run_at", Value: 1}}).
+			SetReturnDocument(options.After)).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// renew extends the lease of a running job, reporting false when the worker
+// no longer holds it because the job was cancelled or taken over
+func renew(ctx context.Context, client *mongo.Client, job *models.Job, worker string, leaseFor time.Duration) (bool, error) {
+	result, err := config.GetJobsCollection(client).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": StatusRunning, "leased_by": worker},
+		bson.M{"$set": bson.M{"lease_until": time.Now().UTC().Add(leaseFor)}})
+	if err != nil {
+		return true, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// complete records the success of a job
+func complete(ctx context.Context, client *mongo.Client, job *models.Job, worker, result string) error {
+	_, err := config.GetJobsCollection(client).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": StatusRunning, "leased_by": worker},
+		bson.M{
+			"$set":   bson.M{"status": StatusSucceeded, "result": result, "finished_at": time.Now().UTC()},
+			"$unset": bson.M{"active_key": "", "lease_until": ""},
+		})
+	return err
+}
+
+// fail records a failed attempt, queueing the job for a retry after a backoff
+// or dead-lettering it when it has no attempts left. It reports whether the
+// job was dead-lettered.
+func fail(ctx context.Context, client *mongo.Client, job *models.Job, worker string, cause error) (bool, error) {
+	now := time.Now().UTC()
+	var permanent permanentError
+	dead := job.Attempts >= job.MaxAttempts || errors.As(cause, &permanent)
+	set := bson.M{"last_error": cause.Error()}
+	unset := bson.M{"lease_until": ""}
+	if dead {
+		set["status"] = StatusDead
+		set["finished_at"] = now
+		unset["active_key"] = ""
+	} else {
+		set["status"] = StatusQueued
+		set["run_at"] = now.Add(backoff(job.Attempts))
+	}
+	_, err := config.GetJobsCollection(client).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": StatusRunning, "leased_by": worker},
+		bson.M{
+			"$set":   set,
+			"$unset": unset,
+			"$push": bson.M{"errors": bson.M{
+				"$each":  []models.JobError{{Attempt: job.Attempts, Error: cause.Error(), At: now}},
+				"$slice": -keptErrors,
+			}},
+		})
+	return dead, err
+}
+
+// recoverExpired returns running jobs whose lease ran out, because their
+// worker stopped, to the queue, or dead-letters them when that was their
+// last attempt
+func recoverExpired(ctx context.Context, client *mongo.Client) (int64, error) {
+	collection := config.GetJobsCollection(client)
+	now := time.Now().UTC()
+	expired := bson.M{"status": StatusRunning, "lease_until": bson.M{"$lt": now}}
+	lostError := bson.M{"$each": []models.JobError{{Error: "lease expired", At: now}}, "$slice": -keptErrors}
+
+	retried := bson.M{"$expr": bson.M{"$lt": bson.A{"$attempts", "$max_attempts"}}}
+	for k, v := range expired {
+		retried[k] = v
+	}
+	requeued, err := collection.UpdateMany(ctx, retried, bson.M{
+		"$set":   bson.M{"status": StatusQueued, "run_at": now, "last_error": "lease expired"},
+		"$unset": bson.M{"lease_until": ""},
+		"$push":  bson.M{"errors": lostError},
+	})
+	if err != nil {
+		return 0, err
+	}
+	dead, err := collection.UpdateMany(ctx, expired, bson.M{
+		"$set":   bson.M{"status": StatusDead, "finished_at": now, "last_error": "lease expired"},
+		"$unset": bson.M{"active_key": "", "lease_until": ""},
+		"$push":  bson.M{"errors": lostError},
+	})
+	if err != nil {
+		return requeued.ModifiedCount, err
+	}
+	return requeued.ModifiedCount + dead.ModifiedCount, nil
+}
+
+// backoff is the delay before retrying a job that failed attempt n, with
+// some jitter so failing jobs do not retry in lockstep
+func backoff(n int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < n && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d - time.Duration(rand.Int63n(int64(d)/5+1))
+}
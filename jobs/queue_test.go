@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"legal-documents-api/models"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration // before jitter
+	}{
+		{0, baseBackoff},
+		{1, baseBackoff},
+		{2, 2 * baseBackoff},
+		{3, 4 * baseBackoff},
+		{7, 64 * baseBackoff},
+		{8, maxBackoff}, // 128 × 30s is over the hour
+		{50, maxBackoff},
+	}
+	for _, tt := range tests {
+		// Jitter takes off at most a fifth of the delay
+		for i := 0; i < 100; i++ {
+			if got := backoff(tt.attempt); got > tt.want || got < tt.want-tt.want/5 {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.want-tt.want/5, tt.want)
+			}
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	job := models.Job{Type: "test"}
+	failure := errors.New("failed")
+	tests := []struct {
+		name    string
+		handler Handler
+		result  string
+		err     string
+	}{
+		{"success", func(context.Context, models.Job) (string, error) { return "done", nil }, "done", ""},
+		{"error", func(context.Context, models.Job) (string, error) { return "", failure }, "", "failed"},
+		{"panic", func(context.Context, models.Job) (string, error) { panic("boom") }, "", "panic: boom"},
+		{"no handler", nil, "", ErrUnknownType.Error()},
+	}
+	for _, tt := range tests {
+		result, err := call(context.Background(), tt.handler, job)
+		if result != tt.result {
+			t.Errorf("%s: result %q, want %q", tt.name, result, tt.result)
+		}
+		if (err == nil) != (tt.err == "") || (err != nil && !strings.HasPrefix(err.Error(), tt.err)) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
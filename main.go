@@ -2,20 +2,39 @@ package main
 
 import (
         "context"
+        "errors"
+        "flag"
         "fmt"
         "log"
         "net/http"
         "os"
+        "path/filepath"
+        "strconv"
+        "strings"
         "time"
 
         "github.com/gorilla/mux"
         "github.com/joho/godotenv"
         "go.mongodb.org/mongo-driver/mongo"
 
+        "legal-documents-api/alerts"
+        "legal-documents-api/analytics"
+        "legal-documents-api/citations"
         "legal-documents-api/config"
+        "legal-documents-api/dedup"
+        "legal-documents-api/documents"
+        "legal-documents-api/editorial"
         "legal-documents-api/handlers"
+        "legal-documents-api/ingest"
+        "legal-documents-api/jobs"
         "legal-documents-api/middleware"
+        "legal-documents-api/migrations"
+        "legal-documents-api/models"
+        "legal-documents-api/search"
+        "legal-documents-api/semantic"
+        "legal-documents-api/structure"
         "legal-documents-api/utils"
+        "legal-documents-api/versions"
 )
 
 var mongoClient *mongo.Client
@@ -49,6 +68,28 @@ func main() {
         }
         log.Println("Successfully connected to MongoDB Atlas")
 
+        // How documents duplicating existing ones are treated when created
+        if value := os.Getenv("DUPLICATE_POLICY"); value != "" {
+                if err := dedup.SetPolicy(value); err != nil {
+                        log.Printf("Warning: %v, using %s", err, dedup.Policy())
+                }
+        }
+
+        // Keep slugs unique when documents are created concurrently
+        indexCtx, indexCancel := context.WithTimeout(context.Background(), 30*time.Second)
+        if err := documents.EnsureIndexes(indexCtx, mongoClient); err != nil {
+                log.Printf("Warning: Failed to create unique slug index (duplicate slugs must be resolved first): %v", err)
+        }
+        indexCancel()
+
+        // One-shot maintenance commands, e.g. "go run . migrate-dates -dry-run"
+        if len(os.Args) > 1 {
+                if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+                        log.Fatalf("%s failed: %v", os.Args[1], err)
+                }
+                return
+        }
+
         // Initialize handlers with MongoDB client
         handlers.InitHandlers(mongoClient)
 
@@ -57,6 +98,91 @@ func main() {
                 log.Printf("Warning: Failed to load kurumlar cache: %v", err)
         }
 
+        // Load the synonym dictionary used for query expansion
+        if err := utils.LoadSynonymsToCache(mongoClient); err != nil {
+                log.Printf("Warning: Failed to load synonyms cache: %v", err)
+        }
+
+        // Build the search index in the background and keep it in sync
+        searchIndex := search.NewIndex()
+        handlers.SetSearchEngine(searchIndex)
+        go func() {
+                buildCtx, buildCancel := context.WithTimeout(context.Background(), 10*time.Minute)
+                defer buildCancel()
+                if err := search.LoadFromMongo(buildCtx, mongoClient, searchIndex); err != nil {
+                        log.Printf("Warning: Failed to build search index: %v", err)
+                }
+                search.StartRefresher(mongoClient, searchIndex, getEnvDuration("SEARCH_INDEX_REFRESH_INTERVAL", 5*time.Minute))
+        }()
+
+        // Load content vectors for semantic search, embedding new documents
+        semanticIndex := semantic.NewIndex(newEmbedder())
+        handlers.SetSemanticIndex(semanticIndex)
+        go func() {
+                buildCtx, buildCancel := context.WithTimeout(context.Background(), 30*time.Minute)
+                defer buildCancel()
+                if err := semantic.LoadFromMongo(buildCtx, mongoClient, semanticIndex); err != nil {
+                        log.Printf("Warning: Failed to build semantic index: %v", err)
+                }
+                semantic.StartRefresher(mongoClient, semanticIndex, getEnvDuration("SEMANTIC_INDEX_REFRESH_INTERVAL", 10*time.Minute))
+        }()
+
+        // Extract citations between documents and keep the graph up to date
+        go func() {
+                scanCtx, scanCancel := context.WithTimeout(context.Background(), 30*time.Minute)
+                defer scanCancel()
+                if err := citations.EnsureIndexes(scanCtx, mongoClient); err != nil {
+                        log.Printf("Warning: Failed to create citation indexes: %v", err)
+                }
+                if err := citations.Refresh(scanCtx, mongoClient, false); err != nil {
+                        log.Printf("Warning: Failed to extract citations: %v", err)
+                }
+                citations.StartRefresher(mongoClient, getEnvDuration("CITATION_REFRESH_INTERVAL", 15*time.Minute))
+        }()
+
+        // Keep the version history of documents changed outside the API
+        go func() {
+                snapshotCtx, snapshotCancel := context.WithTimeout(context.Background(), 30*time.Minute)
+                defer snapshotCancel()
+                if err := versions.EnsureIndexes(snapshotCtx, mongoClient); err != nil {
+                        log.Printf("Warning: Failed to create version indexes: %v", err)
+                }
+                if err := editorial.EnsureIndexes(snapshotCtx, mongoClient); err != nil {
+                        log.Printf("Warning: Failed to create status history indexes: %v", err)
+                }
+                if err := versions.Snapshot(snapshotCtx, mongoClient); err != nil {
+                        log.Printf("Warning: Failed to snapshot document versions: %v", err)
+                }
+                versions.StartSnapshotter(mongoClient, getEnvDuration("VERSION_SNAPSHOT_INTERVAL", 6*time.Hour))
+        }()
+
+        // Fingerprint documents written outside the API for duplicate detection
+        go func() {
+                fingerprintCtx, fingerprintCancel := context.WithTimeout(context.Background(), time.Hour)
+                defer fingerprintCancel()
+                if err := dedup.EnsureIndexes(fingerprintCtx, mongoClient); err != nil {
+                        log.Printf("Warning: Failed to create fingerprint indexes: %v", err)
+                }
+                if _, err := dedup.Backfill(fingerprintCtx, mongoClient, false); err != nil {
+                        log.Printf("Warning: Failed to fingerprint documents: %v", err)
+                }
+        }()
+
+        // Re-run saved searches against new documents and deliver matches
+        alerts.NewWorker(mongoClient, searchIndex).Start(getEnvDuration("SAVED_SEARCH_INTERVAL", 15*time.Minute))
+
+        // Record search activity and feed popular queries back into autocomplete
+        recorder := analytics.NewRecorder(mongoClient, 10000)
+        recorder.Start(getEnvDuration("ANALYTICS_RETENTION", 90*24*time.Hour))
+        handlers.SetAnalyticsRecorder(recorder)
+        analytics.StartPopularRefresher(mongoClient, getEnvDuration("POPULAR_QUERIES_INTERVAL", 10*time.Minute))
+
+        // Run parsing, index rebuilds and scraping out of band on the job queue
+        jobPool := newJobPool(searchIndex, semanticIndex)
+        handlers.SetJobPool(jobPool)
+        jobPool.Start()
+        jobPool.Every(getEnvDuration("ANNOUNCEMENT_SCRAPE_INTERVAL", 30*time.Minute), jobScrapeAnnouncements, nil)
+
         // Setup routes
         router := setupRoutes()
 
@@ -70,6 +196,353 @@ func main() {
         log.Fatal(http.ListenAndServe("0.0.0.0:"+port, router))
 }
 
+// runCommand executes a command line subcommand instead of starting the server
+func runCommand(name string, args []string) error {
+        switch name {
+        case "migrate-dates":
+                flags := flag.NewFlagSet(name, flag.ExitOnError)
+                dryRun := flags.Bool("dry-run", false, "report changes without writing them")
+                flags.Parse(args)
+
+                ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+                defer cancel()
+                stats, err := migrations.BackfillDates(ctx, mongoClient, *dryRun)
+                migrations.LogBackfillStats(stats, *dryRun)
+                return err
+        case "embed":
+                flags := flag.NewFlagSet(name, flag.ExitOnError)
+                rebuild := flags.Bool("rebuild", false, "discard stored vectors and embed every document again")
+                flags.Parse(args)
+
+                ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+                defer cancel()
+                idx := semantic.NewIndex(newEmbedder())
+                if *rebuild {
+                        if err := semantic.EnsureIndexes(ctx, mongoClient); err != nil {
+                                return err
+                        }
+                        if err := semantic.Rebuild(ctx, mongoClient, idx); err != nil {
+                                return err
+                        }
+                        log.Printf("Semantic index rebuilt with %d chunks", idx.Len())
+                        return nil
+                }
+                return semantic.LoadFromMongo(ctx, mongoClient, idx)
+        case "extract-citations":
+                flags := flag.NewFlagSet(name, flag.ExitOnError)
+                force := flags.Bool("force", false, "scan every document again, not only new ones")
+                flags.Parse(args)
+
+                ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+                defer cancel()
+                if err := citations.EnsureIndexes(ctx, mongoClient); err != nil {
+                        return err
+                }
+                return citations.Refresh(ctx, mongoClient, *force)
+        case "parse-structure":
+                flags := flag.NewFlagSet(name, flag.ExitOnError)
+                force := flags.Bool("force", false, "parse every document, not only those without a current structure")
+                flags.Parse(args)
+
+                ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+                defer cancel()
+                _, err := structure.Backfill(ctx, mongoClient, *force)
+                return err
+        case "snapshot-versions":
+                ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+                defer cancel()
+                if err := versions.EnsureIndexes(ctx, mongoClient); err != nil {
+                        return err
+                }
+                return versions.Snapshot(ctx, mongoClient)
+        case "fingerprint":
+                flags := flag.NewFlagSet(name, flag.ExitOnError)
+                force := flags.Bool("force", false, "fingerprint every document, not only new and changed ones")
+                flags.Parse(args)
+
+                ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+                defer cancel()
+                if err := dedup.EnsureIndexes(ctx, mongoClient); err != nil {
+                        return err
+                }
+                _, err := dedup.Backfill(ctx, mongoClient, *force)
+                return err
+        case "ingest":
+                flags := flag.NewFlagSet(name, flag.ExitOnError)
+                var metadata models.DocumentMetadata
+                flags.StringVar(&metadata.KurumID, "kurum", "", "id of the issuing institution (required)")
+                flags.StringVar(&metadata.PdfAdi, "title", "", "document title; read from the PDF when empty")
+                flags.StringVar(&metadata.BelgeTuru, "type", "", "document type (belge_turu)")
+                flags.StringVar(&metadata.BelgeYayinTarihi, "date", "", "publication date (belge_yayin_tarihi)")
+                flags.StringVar(&metadata.PdfURL, "url", "", "public address of the PDF")
+                note := flags.String("note", "", "note recorded with the first version")
+                queue := flags.Bool("queue", false, "leave the files, which must be inside INGEST_DIR, to the job queue of the server instead of ingesting them now")
+                allowDuplicates := flags.Bool("allow-duplicates", false, "ingest files even when DUPLICATE_POLICY=block refuses them")
+                flags.Parse(args)
+                if flags.NArg() == 0 {
+                        return fmt.Errorf("usage: ingest -kurum <id> [flags] <file.pdf>...")
+                }
+                if err := utils.LoadKurumlarToCache(mongoClient); err != nil {
+                        return err
+                }
+                if _, ok := utils.GetKurumByID(metadata.KurumID); !ok {
+                        return fmt.Errorf("unknown institution %q", metadata.KurumID)
+                }
+                if flags.NArg() > 1 && (metadata.PdfAdi != "" || metadata.PdfURL != "") {
+                        return fmt.Errorf("-title and -url apply to a single file")
+                }
+
+                ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+                defer cancel()
+                if *allowDuplicates {
+                        ctx = dedup.AllowDuplicates(ctx)
+                }
+                ingestOne := ingestFile
+                if *queue {
+                        ingestOne = queueIngest
+                }
+                for _, path := range flags.Args() {
+                        if err := ingestOne(ctx, path, metadata, *note); err != nil {
+                                return fmt.Errorf("%s: %w", path, err)
+                        }
+                }
+                return nil
+        default:
+                return fmt.Errorf("unknown command %q (available: migrate-dates, embed, parse-structure, extract-citations, snapshot-versions, fingerprint, ingest)", name)
+        }
+}
+
+// Background job types, registered in newJobPool
+const (
+        jobParseStructure       = "parse-structure"
+        jobExtractCitations     = "extract-citations"
+        jobRebuildSearchIndex   = "rebuild-search-index"
+        jobRebuildSemanticIndex = "rebuild-semantic-index"
+        jobSnapshotVersions     = "snapshot-versions"
+        jobScrapeAnnouncements  = handlers.ScrapeAnnouncementsJob
+        jobIngestPDF            = "ingest-pdf"
+        jobFingerprintDocuments = "fingerprint-documents"
+)
+
+// forcePayload is the payload of jobs that can redo work already done
+type forcePayload struct {
+        Force bool `bson:"force"`
+}
+
+// ingestPayload is the payload of an ingest-pdf job: a PDF file inside
+// INGEST_DIR on the server with the metadata given for it
+type ingestPayload struct {
+        Path             string `bson:"path"`
+        KurumID          string `bson:"kurum_id"`
+        PdfAdi           string `bson:"pdf_adi,omitempty"`
+        BelgeTuru        string `bson:"belge_turu,omitempty"`
+        BelgeYayinTarihi string `bson:"belge_yayin_tarihi,omitempty"`
+        PdfURL           string `bson:"pdf_url,omitempty"`
+        Note             string `bson:"note,omitempty"`
+        Uploader         string `bson:"uploader,omitempty"` // recorded in the audit trail
+        AllowDuplicate   bool   `bson:"allow_duplicate,omitempty"`
+}
+
+// newJobPool creates the job queue workers with the handlers of every job type
+func newJobPool(searchIndex *search.Index, semanticIndex *semantic.Index) *jobs.Pool {
+        workers := 2
+        if value := os.Getenv("JOB_WORKERS"); value != "" {
+                if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+                        workers = parsed
+                } else {
+                        log.Printf("Warning: Invalid JOB_WORKERS value %q, using %d", value, workers)
+                }
+        }
+        pool := jobs.NewPool(mongoClient, workers)
+
+        pool.Register(jobParseStructure, time.Hour, func(ctx context.Context, job models.Job) (string, error) {
+                var payload forcePayload
+                if err := jobs.Decode(job, &payload); err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                stats, err := structure.Backfill(ctx, mongoClient, payload.Force)
+                return fmt.Sprintf("%d scanned, %d parsed, %d without articles", stats.Scanned, stats.Parsed, stats.Unstructured), err
+        })
+        pool.Register(jobExtractCitations, 2*time.Hour, func(ctx context.Context, job models.Job) (string, error) {
+                var payload forcePayload
+                if err := jobs.Decode(job, &payload); err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                return "citations refreshed", citations.Refresh(ctx, mongoClient, payload.Force)
+        })
+        pool.Register(jobRebuildSearchIndex, time.Hour, func(ctx context.Context, job models.Job) (string, error) {
+                if err := search.Rebuild(ctx, mongoClient, searchIndex); err != nil {
+                        return "", err
+                }
+                return fmt.Sprintf("%d documents indexed", searchIndex.Len()), nil
+        })
+        pool.Register(jobRebuildSemanticIndex, 2*time.Hour, func(ctx context.Context, job models.Job) (string, error) {
+                if err := semantic.Rebuild(ctx, mongoClient, semanticIndex); err != nil {
+                        return "", err
+                }
+                return fmt.Sprintf("%d chunks embedded", semanticIndex.Len()), nil
+        })
+        pool.Register(jobSnapshotVersions, time.Hour, func(ctx context.Context, job models.Job) (string, error) {
+                return "versions snapshotted", versions.Snapshot(ctx, mongoClient)
+        })
+        pool.Register(jobScrapeAnnouncements, 15*time.Minute, func(ctx context.Context, job models.Job) (string, error) {
+                var payload handlers.AnnouncementsPayload
+                if err := jobs.Decode(job, &payload); err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                return handlers.RefreshAnnouncements(ctx, payload.KurumID)
+        })
+        pool.Register(jobIngestPDF, 10*time.Minute, func(ctx context.Context, job models.Job) (string, error) {
+                var payload ingestPayload
+                if err := jobs.Decode(job, &payload); err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                if _, ok := utils.GetKurumByID(payload.KurumID); !ok {
+                        return "", jobs.Permanent(fmt.Errorf("unknown institution %q", payload.KurumID))
+                }
+                metadata := models.DocumentMetadata{
+                        KurumID:          payload.KurumID,
+                        PdfAdi:           payload.PdfAdi,
+                        BelgeTuru:        payload.BelgeTuru,
+                        BelgeYayinTarihi: payload.BelgeYayinTarihi,
+                        PdfURL:           payload.PdfURL,
+                }
+                path, err := ingestPath(payload.Path)
+                if err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                data, err := os.ReadFile(path)
+                if err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                file, err := ingest.Read(filepath.Base(path), data)
+                if err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                uploader := payload.Uploader
+                if uploader == "" {
+                        uploader = "job:" + job.ID.Hex()
+                }
+                if payload.AllowDuplicate {
+                        ctx = dedup.AllowDuplicates(ctx)
+                }
+                if _, err := ingest.Create(ctx, mongoClient, file, &metadata, payload.Note, uploader); err != nil {
+                        var dupErr *dedup.DuplicateError
+                        if errors.As(err, &dupErr) {
+                                return "", jobs.Permanent(err)
+                        }
+                        return "", err
+                }
+                return fmt.Sprintf("%s ingested as %s, awaiting review", payload.Path, metadata.URLSlug), nil
+        })
+        pool.Register(jobFingerprintDocuments, time.Hour, func(ctx context.Context, job models.Job) (string, error) {
+                var payload forcePayload
+                if err := jobs.Decode(job, &payload); err != nil {
+                        return "", jobs.Permanent(err)
+                }
+                stats, err := dedup.Backfill(ctx, mongoClient, payload.Force)
+                return fmt.Sprintf("%d scanned, %d fingerprinted, %d without text", stats.Scanned, stats.Fingerprinted, stats.Empty), err
+        })
+        return pool
+}
+
+// ingestPath resolves the file of an ingest-pdf job. It must lie inside
+// INGEST_DIR, so that a job cannot read any other file of the server.
+func ingestPath(path string) (string, error) {
+        dir := os.Getenv("INGEST_DIR")
+        if dir == "" {
+                return "", fmt.Errorf("INGEST_DIR is not set, files cannot be ingested by job")
+        }
+        dir, err := filepath.Abs(dir)
+        if err == nil {
+                dir, err = filepath.EvalSymlinks(dir)
+        }
+        if err != nil {
+                return "", fmt.Errorf("INGEST_DIR: %w", err)
+        }
+        if !filepath.IsAbs(path) {
+                return "", fmt.Errorf("path %q is not absolute", path)
+        }
+        resolved, err := filepath.EvalSymlinks(path)
+        if err != nil {
+                return "", err
+        }
+        rel, err := filepath.Rel(dir, resolved)
+        if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+                return "", fmt.Errorf("path %q is outside INGEST_DIR", path)
+        }
+        return resolved, nil
+}
+
+// queueIngest enqueues an ingest-pdf job for a file, to be run by the server
+func queueIngest(ctx context.Context, path string, metadata models.DocumentMetadata, note string) error {
+        abs, err := filepath.Abs(path)
+        if err != nil {
+                return err
+        }
+        if abs, err = ingestPath(abs); err != nil {
+                return err
+        }
+        job, _, err := jobs.Enqueue(ctx, mongoClient, jobIngestPDF, ingestPayload{
+                Path:             abs,
+                KurumID:          metadata.KurumID,
+                PdfAdi:           metadata.PdfAdi,
+                BelgeTuru:        metadata.BelgeTuru,
+                BelgeYayinTarihi: metadata.BelgeYayinTarihi,
+                PdfURL:           metadata.PdfURL,
+                Note:             note,
+                Uploader:         "cli",
+                AllowDuplicate:   dedup.Allowed(ctx),
+        }, jobs.Options{})
+        if err != nil {
+                return err
+        }
+        log.Printf("Queued %s as job %s", abs, job.ID.Hex())
+        return nil
+}
+
+// ingestFile reads a PDF file and adds it as a document awaiting review
+func ingestFile(ctx context.Context, path string, metadata models.DocumentMetadata, note string) error {
+        data, err := os.ReadFile(path)
+        if err != nil {
+                return err
+        }
+        file, err := ingest.Read(filepath.Base(path), data)
+        if err != nil {
+                return err
+        }
+        if _, err := ingest.Create(ctx, mongoClient, file, &metadata, note, "cli"); err != nil {
+                return err
+        }
+        log.Printf("Ingested %s as %q (%s, %d pages), awaiting review", path, metadata.PdfAdi, metadata.URLSlug, metadata.SayfaSayisi)
+        return nil
+}
+
+// newEmbedder returns the embedder for semantic search. The hashed n-gram
+// embedder runs offline; EMBEDDING_DIMENSIONS sets its vector size.
+func newEmbedder() semantic.Embedder {
+        dims := 256
+        if value := os.Getenv("EMBEDDING_DIMENSIONS"); value != "" {
+                if parsed, err := strconv.Atoi(value); err == nil && parsed >= 32 && parsed <= 4096 {
+                        dims = parsed
+                } else {
+                        log.Printf("Warning: Invalid EMBEDDING_DIMENSIONS value %q, using %d", value, dims)
+                }
+        }
+        return semantic.NewHashEmbedder(dims)
+}
+
+// getEnvDuration reads a duration such as "5m" from the environment, falling back to def
+func getEnvDuration(key string, def time.Duration) time.Duration {
+        if value := os.Getenv(key); value != "" {
+                if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+                        return parsed
+                }
+                log.Printf("Warning: Invalid %s value %q, using %s", key, value, def)
+        }
+        return def
+}
+
 func setupRoutes() *mux.Router {
         router := mux.NewRouter()
 
@@ -85,6 +558,15 @@ func setupRoutes() *mux.Router {
         // Document endpoints
         api.HandleFunc("/documents", handlers.GetDocumentsByInstitution).Methods("GET", "OPTIONS")
         api.HandleFunc("/documents/{slug}", handlers.GetDocumentBySlug).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/related", handlers.GetRelatedDocuments).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/structure", handlers.GetDocumentStructure).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/madde/{n}", handlers.GetDocumentMadde).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/references", handlers.GetDocumentReferences).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/cited-by", handlers.GetDocumentCitedBy).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/versions", handlers.GetDocumentVersions).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/versions/{version}", handlers.GetDocumentVersion).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/as-of", handlers.GetDocumentAsOf).Methods("GET", "OPTIONS")
+        api.HandleFunc("/documents/{slug}/diff", handlers.GetDocumentDiff).Methods("GET", "OPTIONS")
         
         // Institution-based routing (alternative endpoint)
         api.HandleFunc("/kurum/{kurum_slug}", handlers.GetDocumentsByInstitutionSlug).Methods("GET", "OPTIONS")
@@ -99,8 +581,58 @@ func setupRoutes() *mux.Router {
 
         // Search endpoints
         api.HandleFunc("/search", handlers.GlobalSearch).Methods("GET", "OPTIONS")
+        api.HandleFunc("/search/semantic", handlers.SemanticSearch).Methods("GET", "OPTIONS")
+        api.HandleFunc("/search/click", handlers.RecordSearchClick).Methods("POST", "OPTIONS")
         api.HandleFunc("/autocomplete", handlers.Autocomplete).Methods("GET", "OPTIONS")
 
+        // Search analytics reports (with basic authentication)
+        api.HandleFunc("/admin/analytics/top-queries", middleware.BasicAuth(handlers.GetTopQueries)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/analytics/zero-results", middleware.BasicAuth(handlers.GetZeroResultQueries)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/analytics/ctr", middleware.BasicAuth(handlers.GetClickThroughRate)).Methods("GET", "OPTIONS")
+
+        // Saved search endpoints (with basic authentication)
+        api.HandleFunc("/saved-searches", middleware.BasicAuth(handlers.CreateSavedSearch)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/saved-searches", middleware.BasicAuth(handlers.ListSavedSearches)).Methods("GET")
+        api.HandleFunc("/saved-searches/{id}", middleware.BasicAuth(handlers.GetSavedSearch)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/saved-searches/{id}", middleware.BasicAuth(handlers.DeleteSavedSearch)).Methods("DELETE")
+        api.HandleFunc("/saved-searches/{id}/matches", middleware.BasicAuth(handlers.GetSavedSearchMatches)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/saved-searches/{id}/matches/ack", middleware.BasicAuth(handlers.AcknowledgeSavedSearchMatches)).Methods("POST", "OPTIONS")
+
+        // Synonym dictionary endpoints (with basic authentication)
+        api.HandleFunc("/admin/synonyms", middleware.BasicAuth(handlers.CreateSynonym)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/synonyms", middleware.BasicAuth(handlers.ListSynonyms)).Methods("GET")
+        api.HandleFunc("/admin/synonyms/reload", middleware.BasicAuth(handlers.ReloadSynonyms)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/synonyms/{id}", middleware.BasicAuth(handlers.UpdateSynonym)).Methods("PUT", "OPTIONS")
+        api.HandleFunc("/admin/synonyms/{id}", middleware.BasicAuth(handlers.DeleteSynonym)).Methods("DELETE")
+
+        // Document administration (requires authentication)
+        api.HandleFunc("/admin/documents", middleware.BasicAuth(handlers.CreateDocument)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/documents/{id}", middleware.BasicAuth(handlers.GetAdminDocument)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/documents/{id}", middleware.BasicAuth(handlers.ReplaceDocument)).Methods("PUT")
+        api.HandleFunc("/admin/documents/{id}", middleware.BasicAuth(handlers.PatchDocument)).Methods("PATCH")
+        api.HandleFunc("/admin/documents/{id}", middleware.BasicAuth(handlers.DeleteDocument)).Methods("DELETE")
+        api.HandleFunc("/admin/documents/{id}/status", middleware.BasicAuth(handlers.ChangeDocumentStatus)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/documents/{id}/status-history", middleware.BasicAuth(handlers.GetDocumentStatusHistory)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/documents/{slug}/versions/link", middleware.BasicAuth(handlers.LinkDocumentVersion)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/ingest", middleware.BasicAuth(handlers.IngestDocument)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/ingest/pending", middleware.BasicAuth(handlers.GetPendingDocuments)).Methods("GET", "OPTIONS")
+
+        // Duplicate detection reports (requires authentication)
+        api.HandleFunc("/admin/duplicates", middleware.BasicAuth(handlers.GetDuplicateReport)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/duplicates/check", middleware.BasicAuth(handlers.CheckDuplicates)).Methods("POST", "OPTIONS")
+
+        // Background jobs (requires authentication)
+        api.HandleFunc("/admin/jobs", middleware.BasicAuth(handlers.GetJobs)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/jobs", middleware.BasicAuth(handlers.EnqueueJob)).Methods("POST")
+        api.HandleFunc("/admin/jobs/{id}", middleware.BasicAuth(handlers.GetJob)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/jobs/{id}/retry", middleware.BasicAuth(handlers.RetryJob)).Methods("POST", "OPTIONS")
+        api.HandleFunc("/admin/jobs/{id}/cancel", middleware.BasicAuth(handlers.CancelJob)).Methods("POST", "OPTIONS")
+
+        // Announcement scraper settings (requires authentication)
+        api.HandleFunc("/admin/scrapers", middleware.BasicAuth(handlers.GetScrapers)).Methods("GET", "OPTIONS")
+        api.HandleFunc("/admin/kurum-duyuru/{kurum_id}", middleware.BasicAuth(handlers.UpdateKurumDuyuruConfig)).Methods("PUT", "OPTIONS")
+        api.HandleFunc("/admin/kurum-duyuru/{kurum_id}/preview", middleware.BasicAuth(handlers.PreviewKurumDuyuru)).Methods("POST", "OPTIONS")
+
         // Kurum duyuru endpoint
         api.HandleFunc("/kurum-duyuru", handlers.GetKurumDuyuru).Methods("GET", "OPTIONS")
         
@@ -138,17 +670,54 @@ func setupRoutes() *mux.Router {
     "/api/v1/documents?kurum_adi={name}": "GET - Get documents by institution (query param)",
     "/api/v1/kurum/{kurum_slug}": "GET - Get documents by institution (URL path)",
     "/api/v1/documents/{slug}": "GET - Get document details with content",
+    "/api/v1/documents/{slug}/related?limit={limit}": "GET - Related documents by content similarity, shared tags/keywords, institution and type",
+    "/api/v1/documents/{slug}/structure": "GET - Table of contents: kısım, bölüm and madde headings with anchors",
+    "/api/v1/documents/{slug}/madde/{n}": "GET - Text of one article with its fıkra and bent (n: 5, 5a, gecici-1, ek-3)",
+    "/api/v1/documents/{slug}/references?resolved={true|false}": "GET - Legislation cited by the document, linked to the cited documents where known",
+    "/api/v1/documents/{slug}/cited-by?limit={limit}": "GET - Documents citing the document, with the cited articles",
+    "/api/v1/documents/{slug}/versions": "GET - Version history of the document, oldest first, with validity periods",
+    "/api/v1/documents/{slug}/versions/{version}": "GET - One version of the document with its content",
+    "/api/v1/documents/{slug}/as-of?date={date}": "GET - The version of the document in force on a date",
+    "/api/v1/documents/{slug}/diff?from={version|date}&to={version|date}&full={true|false}&format={json|html}": "GET - Word-level diff between two versions, aligned by article, as ops and HTML",
     "/api/v1/sitemap/institutions": "GET - Sitemap: All institutions",
     "/api/v1/sitemap/documents?kurum_id={id}": "GET - Sitemap: Documents by institution",
     "/api/v1/sitemap/all-documents": "GET - Sitemap: All documents",
-    "/api/v1/search?q={query}&limit={limit}&offset={offset}&kurum={institution}&kurum_id={id}": "GET - Global search in titles, content, tags, institutions",
+    "/api/v1/search?q={query}&limit={limit}&offset={offset}&cursor={next_cursor}&kurum={institution}&kurum_id={id}&belge_turu={type}&belge_durumu={status}&yil={year}&facets={true|kurum_id,belge_turu,belge_durumu,yil}&from={date}&to={date}&date_field={belge_yayin_tarihi|yukleme_tarihi}&sort={relevance|date_desc|date_asc|title}&fuzzy={true|false}&synonyms={true|false}&highlight={tags|offsets|none}&fragments={n}&fragment_size={chars}&pre_tag={mark|em|b|strong|span}&post_tag={same}": "GET - Global search in titles, content, tags, institutions (supports \"phrases\", AND/OR/NOT, -exclusion, baslik:/etiket:/kurum: scopes and prefix*; filters take comma separated values)",
+    "/api/v1/search/semantic?q={question}&limit={limit}&mode={semantic|hybrid}&alpha={0-1}&kurum_id={id}&belge_turu={type}&belge_durumu={status}&yil={year}": "GET - Natural-language search over content passages; hybrid blends in keyword relevance (alpha = semantic weight)",
+    "/api/v1/search/click": "POST - Record a result click {search_id, slug, position}",
     "/api/v1/autocomplete?q={partial_query}&limit={limit}&kurum={institution}": "GET - Autocomplete suggestions for search",
+    "/api/v1/admin/analytics/top-queries?days={days}&limit={limit}": "GET - Most frequent search queries with click-through rates (auth)",
+    "/api/v1/admin/analytics/zero-results?days={days}&limit={limit}": "GET - Most frequent searches without results (auth)",
+    "/api/v1/admin/analytics/ctr?days={days}": "GET - Overall search click-through rate (auth)",
+    "/api/v1/admin/synonyms?q={text}": "POST - Add synonym/abbreviation entry {term, synonyms, one_way} / GET - List dictionary entries (auth)",
+    "/api/v1/admin/synonyms/{id}": "PUT - Replace entry {term, synonyms, one_way} / DELETE - Remove entry (auth)",
+    "/api/v1/admin/synonyms/reload": "POST - Reload the synonym dictionary from the database (auth)",
+    "/api/v1/admin/documents": "POST - Create a document with its content {pdf_adi, kurum_id, belge_turu, belge_durumu, belge_yayin_tarihi, etiketler, anahtar_kelimeler, aciklama, url_slug, status, sayfa_sayisi, dosya_boyutu_mb, yukleme_tarihi, pdf_url, icerik, note, allow_duplicate}; url_slug is generated when empty; status is taslak, inceleme or aktif (default) (auth)",
+    "/api/v1/admin/documents/{id}": "GET - Document with content in any status / PUT - Replace metadata and content / PATCH - Change the given fields; status is changed through /status only / DELETE - Soft delete (status silindi, ?comment= recorded) (auth)",
+    "/api/v1/admin/documents/{id}/status": "POST - Change the status {status, reviewer_name, comment}; taslak -> inceleme -> aktif -> arsiv | yururlukten_kalkmis, any -> silindi; the authenticated user is recorded as reviewer, reviewer_name is an optional free-text name (auth)",
+    "/api/v1/admin/documents/{id}/status-history": "GET - Audit trail of status changes with reviewer, time and comment, and the statuses allowed next (auth)",
+    "/api/v1/admin/documents/{slug}/versions/link": "POST - Make another record the previous version of the document {previous_slug, note} (auth)",
+    "/api/v1/admin/ingest": "POST - Upload a PDF (multipart: file, optional document fields, allow_duplicate); its text becomes the content and the document awaits review with status inceleme (auth)",
+    "/api/v1/admin/ingest/pending?limit={limit}&offset={offset}": "GET - Documents awaiting review, oldest first; publish through /api/v1/admin/documents/{id}/status (auth)",
+    "/api/v1/admin/duplicates?status={status,...}&content_threshold={0.9}&related_threshold={0.7}&title_threshold={0.8}&limit={limit}&offset={offset}": "GET - Clusters of duplicate documents, largest first: content similar by MinHash fingerprints, or similar with alike titles (auth)",
+    "/api/v1/admin/duplicates/check": "POST - Documents a title and content would duplicate {pdf_adi, icerik, exclude_id}; with DUPLICATE_POLICY=block such documents are refused unless allow_duplicate is set (auth)",
+    "/api/v1/admin/jobs?status={queued|running|succeeded|dead|cancelled}&type={type}&limit={limit}&offset={offset}": "GET - Background jobs, newest first, with counts per status / POST - Enqueue a job {type, payload, key, max_attempts, delay_seconds}; types: parse-structure, extract-citations, rebuild-search-index, rebuild-semantic-index, snapshot-versions, scrape-announcements, ingest-pdf (path inside INGEST_DIR), fingerprint-documents (auth)",
+    "/api/v1/admin/jobs/{id}": "GET - Job with its status, attempts and recent errors (auth)",
+    "/api/v1/admin/jobs/{id}/retry": "POST - Queue a dead or cancelled job again (auth)",
+    "/api/v1/admin/jobs/{id}/cancel": "POST - Cancel a queued or running job (auth)",
+    "/api/v1/admin/scrapers": "GET - Registered announcement scrapers with the hosts they serve (auth)",
+    "/api/v1/admin/kurum-duyuru/{kurum_id}": "PUT - Set the announcement page of an institution and how it is scraped {duyuru_linki, scraper: {name} or {item_selector, title_selector, link_selector, date_selector, date_format, limit}}; selectors are CSS, \"sel@attr\" reads an attribute (auth)",
+    "/api/v1/admin/kurum-duyuru/{kurum_id}/preview": "POST - Scrape the announcement page without storing, with the saved settings or {duyuru_linki, scraper} from the body (auth)",
+    "/api/v1/saved-searches": "POST - Register a saved search {name, query, filters, webhook_url, secret} / GET - List saved searches (auth)",
+    "/api/v1/saved-searches/{id}": "GET - Saved search details / DELETE - Remove saved search (auth)",
+    "/api/v1/saved-searches/{id}/matches?all={true|false}&limit={limit}": "GET - New documents matching a saved search, pending acknowledgement (auth)",
+    "/api/v1/saved-searches/{id}/matches/ack": "POST - Acknowledge matches {ids} (all pending when empty) (auth)",
     "/api/v1/statistics": "GET - Get statistics (total institutions, total documents, document types)",
     "/api/v1/health": "GET - Health check"
   },
   "database": "Connected to MongoDB Atlas",
   "timestamp": "` + time.Now().UTC().Format(time.RFC3339) + `",
-  "auth": "Basic authentication required for this page only"
+  "auth": "Basic authentication required for this page and the endpoints marked (auth): /api/v1/admin/analytics/*, /api/v1/admin/synonyms/*, /api/v1/admin/documents/*, /api/v1/admin/ingest/*, /api/v1/admin/duplicates/*, /api/v1/admin/jobs/*, /api/v1/admin/scrapers, /api/v1/admin/kurum-duyuru/* and /api/v1/saved-searches/*"
 }`
                 fmt.Fprint(w, response)
         })).Methods("GET", "OPTIONS")
@@ -0,0 +1,158 @@
+// Package migrations contains one-shot data migrations run from the command line
+package migrations
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+// backfillBatchSize is how many updates are sent per bulk write
+const backfillBatchSize = 500
+
+// dateFields pairs each free-form date string with its typed BSON field
+var dateFields = []struct {
+	source string
+	target string
+	value  func(m *models.DocumentMetadata) (string, *time.Time)
+}{
+	{"belge_yayin_tarihi", "belge_yayin_date", func(m *models.DocumentMetadata) (string, *time.Time) {
+		return m.BelgeYayinTarihi, m.BelgeYayinDate
+	}},
+	{"yukleme_tarihi", "yukleme_date", func(m *models.DocumentMetadata) (string, *time.Time) {
+		return m.YuklemeTarihi, m.YuklemeDate
+	}},
+	{"olusturulma_tarihi", "olusturulma_date", func(m *models.DocumentMetadata) (string, *time.Time) {
+		return m.OlusturulmaTarihi, m.OlusturulmaDate
+	}},
+}
+
+// BackfillStats summarises a date backfill run
+type BackfillStats struct {
+	Scanned     int
+	Updated     int
+	Unparsable  map[string]int      // per source field
+	Samples     map[string][]string // a few unparsable values per source field
+	SkippedDocs int                 // documents that could not be decoded
+}
+
+// BackfillDates parses the string date fields of every metadata document and
+// stores them as BSON dates (belge_yayin_date, yukleme_date, olusturulma_date).
+// Documents whose typed dates already match are left untouched, so the
+// migration can be re-run safely. With dryRun set nothing is written.
+func BackfillDates(ctx context.Context, client *mongo.Client, dryRun bool) (BackfillStats, error) {
+	stats := BackfillStats{
+		Unparsable: make(map[string]int),
+		Samples:    make(map[string][]string),
+	}
+	collection := config.GetMetadataCollection(client)
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return stats, err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	flush := func() error {
+		if len(updates) == 0 || dryRun {
+			updates = updates[:0]
+			return nil
+		}
+		_, err := collection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+		updates = updates[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var metadata models.DocumentMetadata
+		if err := cursor.Decode(&metadata); err != nil {
+			stats.SkippedDocs++
+			continue
+		}
+		stats.Scanned++
+
+		set := bson.M{}
+		unset := bson.M{}
+		for _, field := range dateFields {
+			raw, current := field.value(&metadata)
+			parsed := utils.ParseDatePtr(raw)
+			if parsed == nil && raw != "" {
+				stats.Unparsable[field.source]++
+				if len(stats.Samples[field.source]) < 5 {
+					stats.Samples[field.source] = append(stats.Samples[field.source], raw)
+				}
+			}
+
+			switch {
+			case parsed != nil && (current == nil || !current.Equal(*parsed)):
+				set[field.target] = *parsed
+			case parsed == nil && current != nil:
+				unset[field.target] = ""
+			}
+		}
+		if len(set) == 0 && len(unset) == 0 {
+			continue
+		}
+
+		update := bson.M{}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": metadata.ID}).SetUpdate(update))
+		stats.Updated++
+
+		if len(updates) >= backfillBatchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return stats, err
+	}
+	if err := flush(); err != nil {
+		return stats, err
+	}
+
+	if !dryRun {
+		if err := ensureDateIndexes(ctx, collection); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// ensureDateIndexes indexes the typed dates used for sorting recent documents
+func ensureDateIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "belge_yayin_date", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "yukleme_date", Value: -1}}},
+	})
+	return err
+}
+
+// LogBackfillStats prints a human readable summary of a backfill run
+func LogBackfillStats(stats BackfillStats, dryRun bool) {
+	verb := "updated"
+	if dryRun {
+		verb = "would update"
+	}
+	log.Printf("Date backfill: scanned %d documents, %s %d, skipped %d undecodable", stats.Scanned, verb, stats.Updated, stats.SkippedDocs)
+	for _, field := range dateFields {
+		if count := stats.Unparsable[field.source]; count > 0 {
+			log.Printf("  %s: %d unparsable values, e.g. %q", field.source, count, stats.Samples[field.source])
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Citation is an edge of the citation graph: a document referring to another
+// piece of legislation. TargetID is set once the reference is resolved to a
+// document in metadata.
+type Citation struct {
+        ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+        SourceID  primitive.ObjectID  `bson:"source_id" json:"source_id"`
+        TargetID  *primitive.ObjectID `bson:"target_id,omitempty" json:"target_id,omitempty"`
+        Kind      string              `bson:"kind" json:"kind"`                         // kanun, khk, cbk, yonetmelik, teblig, genelge, karar
+        Number    string              `bson:"number,omitempty" json:"number,omitempty"` // "5510", "2020/1"; empty when cited by name
+        Name      string              `bson:"name,omitempty" json:"name,omitempty"`     // "İş Sağlığı ve Güvenliği Yönetmeliği"
+        Madde     string              `bson:"madde,omitempty" json:"madde,omitempty"`   // cited article: "12", "5/A", "geçici 3"
+        Text      string              `bson:"text" json:"text"`                         // the citation as it would be written
+        Count     int                 `bson:"count" json:"count"`                       // occurrences in the source
+        CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// CitationScan records that the citations of a content document were extracted
+type CitationScan struct {
+        Version     int       `bson:"version"`
+        ContentHash string    `bson:"content_hash"`
+        Count       int       `bson:"count"`
+        ScannedAt   time.Time `bson:"scanned_at"`
+}
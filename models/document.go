@@ -1,6 +1,8 @@
 package models
 
 import (
+        "time"
+
         "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -31,6 +33,20 @@ type DocumentMetadata struct {
         YuklemeTarihi     string             `bson:"yukleme_tarihi" json:"yukleme_tarihi"`
         OlusturulmaTarihi string             `bson:"olusturulma_tarihi" json:"olusturulma_tarihi"`
         PdfURL            string             `bson:"pdf_url" json:"pdf_url"`
+
+        // Parsed counterparts of the date strings above, filled by the
+        // migrate-dates command and on write. Nil when the string is unparsable.
+        BelgeYayinDate    *time.Time         `bson:"belge_yayin_date,omitempty" json:"belge_yayin_date,omitempty"`
+        YuklemeDate       *time.Time         `bson:"yukleme_date,omitempty" json:"yukleme_date,omitempty"`
+        OlusturulmaDate   *time.Time         `bson:"olusturulma_date,omitempty" json:"olusturulma_date,omitempty"`
+
+        // Set on a record superseded by a newer, separately created record of
+        // the same document; its versions continue under that record
+        GuncelSurumID     *primitive.ObjectID `bson:"guncel_surum_id,omitempty" json:"guncel_surum_id,omitempty"`
+
+        // When the document last became aktif. Nil for documents published
+        // before it was recorded; their insertion time stands in for it.
+        PublishedAt       *time.Time         `bson:"published_at,omitempty" json:"published_at,omitempty"`
 }
 
 // DocumentContent represents the content collection structure
@@ -39,6 +55,16 @@ type DocumentContent struct {
         MetadataID        primitive.ObjectID `bson:"metadata_id" json:"metadata_id"`
         Icerik            string             `bson:"icerik" json:"icerik"`
         OlusturulmaTarihi string             `bson:"olusturulma_tarihi" json:"olusturulma_tarihi"`
+
+        // utils.ContentHash of Icerik, so the search index can tell edited
+        // content apart without reading it. Empty for content stored before.
+        ContentHash       string             `bson:"content_hash,omitempty" json:"-"`
+
+        // Parsed article structure of Icerik, served by /documents/{slug}/structure
+        Yapi              *DocumentStructure `bson:"yapi,omitempty" json:"-"`
+
+        // Set once the citations of Icerik are stored in the citations collection
+        Atiflar           *CitationScan      `bson:"atiflar,omitempty" json:"-"`
 }
 
 // DocumentSummary represents a simplified document structure for listing
@@ -80,13 +106,35 @@ type KurumDuyuru struct {
         ID          primitive.ObjectID `bson:"_id" json:"id"`
         KurumID     string             `bson:"kurum_id" json:"kurum_id"`
         DuyuruLinki string             `bson:"duyuru_linki" json:"duyuru_linki"`
+
+        // How the announcement page is read; the scraper registered for
+        // its host when nil
+        Scraper *ScraperConfig `bson:"scraper,omitempty" json:"scraper,omitempty"`
+
+        // Announcements found by the last scrape and when it ran
+        Duyurular     []DuyuruItem `bson:"duyurular,omitempty" json:"duyurular,omitempty"`
+        TaranmaTarihi *time.Time   `bson:"taranma_tarihi,omitempty" json:"taranma_tarihi,omitempty"`
+}
+
+// ScraperConfig describes how the announcements of an institution are read
+// from its page. With an item selector the page is read with CSS selectors
+// relative to each item; a selector ending in @attr reads that attribute
+// instead of the text. Without one the named scraper is used.
+type ScraperConfig struct {
+        Name          string `bson:"name,omitempty" json:"name,omitempty"`
+        ItemSelector  string `bson:"item_selector,omitempty" json:"item_selector,omitempty"`
+        TitleSelector string `bson:"title_selector,omitempty" json:"title_selector,omitempty"` // text of the item when empty
+        LinkSelector  string `bson:"link_selector,omitempty" json:"link_selector,omitempty"`   // first link of the item when empty
+        DateSelector  string `bson:"date_selector,omitempty" json:"date_selector,omitempty"`
+        DateFormat    string `bson:"date_format,omitempty" json:"date_format,omitempty"` // Go layout; Turkish month names allowed, e.g. "2 Ocak 2006"
+        Limit         int    `bson:"limit,omitempty" json:"limit,omitempty"`             // 5 when zero
 }
 
 // DuyuruItem represents a single announcement item from web scraping
 type DuyuruItem struct {
-        Baslik string `json:"baslik"`
-        Link   string `json:"link"`
-        Tarih  string `json:"tarih"`
+        Baslik string `bson:"baslik" json:"baslik"`
+        Link   string `bson:"link" json:"link"`
+        Tarih  string `bson:"tarih" json:"tarih"`
 }
 
 // Link represents institution service links data from links collection
@@ -106,4 +154,5 @@ type APIResponse struct {
         Error   string      `json:"error,omitempty"`
         Message string      `json:"message,omitempty"`
         Count   int         `json:"count,omitempty"`
+        Meta    interface{} `json:"meta,omitempty"`
 }
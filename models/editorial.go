@@ -0,0 +1,21 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StatusChange is an entry of the audit trail of a document: who moved it
+// from one status to another, when and why. From is empty for the status a
+// document was created with.
+type StatusChange struct {
+        ID           primitive.ObjectID `bson:"_id" json:"id"`
+        DocumentID   primitive.ObjectID `bson:"document_id" json:"document_id"`
+        From         string             `bson:"from" json:"from"`
+        To           string             `bson:"to" json:"to"`
+        Reviewer     string             `bson:"reviewer" json:"reviewer"`                               // the authenticated user
+        ReviewerName string             `bson:"reviewer_name,omitempty" json:"reviewer_name,omitempty"` // free text, for reviewers sharing an account
+        Comment      string             `bson:"comment,omitempty" json:"comment,omitempty"`
+        ChangedAt    time.Time          `bson:"changed_at" json:"changed_at"`
+}
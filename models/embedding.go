@@ -0,0 +1,18 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContentEmbedding is the vector of one passage (chunk) of a document's content
+type ContentEmbedding struct {
+        ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+        MetadataID primitive.ObjectID `bson:"metadata_id" json:"metadata_id"`
+        Chunk      int                `bson:"chunk" json:"chunk"` // position of the passage in the content
+        Text       string             `bson:"text" json:"text"`
+        Model      string             `bson:"model" json:"model"` // embedder that produced the vector
+        Vector     []float32          `bson:"vector" json:"-"`
+        CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fingerprint is the MinHash signature of the content of a document, used to
+// find documents stored more than once. Bands are hashes of slices of the
+// signature; documents sharing a band are compared.
+type Fingerprint struct {
+        DocumentID  primitive.ObjectID `bson:"_id" json:"document_id"`
+        Version     int                `bson:"version" json:"version"`
+        ContentHash string             `bson:"content_hash" json:"content_hash"`
+        Shingles    int                `bson:"shingles" json:"shingles"`
+        MinHash     []int64            `bson:"minhash" json:"-"`
+        Bands       []string           `bson:"bands" json:"-"`
+        UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
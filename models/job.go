@@ -0,0 +1,37 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job is a unit of background work in the jobs collection. A job is leased by
+// one worker at a time; failed attempts are retried with backoff until
+// MaxAttempts is reached and the job is dead-lettered.
+type Job struct {
+        ID          primitive.ObjectID     `bson:"_id" json:"id"`
+        Type        string                 `bson:"type" json:"type"`
+        Payload     map[string]interface{} `bson:"payload,omitempty" json:"payload,omitempty"`
+        Key         string                 `bson:"key,omitempty" json:"key,omitempty"` // at most one queued or running job per key
+        ActiveKey   string                 `bson:"active_key,omitempty" json:"-"`      // Key while queued or running, unique
+        Status      string                 `bson:"status" json:"status"`               // queued, running, succeeded, dead, cancelled
+        Attempts    int                    `bson:"attempts" json:"attempts"`
+        MaxAttempts int                    `bson:"max_attempts" json:"max_attempts"`
+        RunAt       time.Time              `bson:"run_at" json:"run_at"` // not leased before
+        LeasedBy    string                 `bson:"leased_by,omitempty" json:"leased_by,omitempty"`
+        LeaseUntil  *time.Time             `bson:"lease_until,omitempty" json:"lease_until,omitempty"` // renewed while the job runs
+        Result      string                 `bson:"result,omitempty" json:"result,omitempty"`
+        LastError   string                 `bson:"last_error,omitempty" json:"last_error,omitempty"`
+        Errors      []JobError             `bson:"errors,omitempty" json:"errors,omitempty"` // the most recent failures
+        CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
+        StartedAt   *time.Time             `bson:"started_at,omitempty" json:"started_at,omitempty"`
+        FinishedAt  *time.Time             `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// JobError records a failed attempt of a job
+type JobError struct {
+        Attempt int       `bson:"attempt" json:"attempt"`
+        Error   string    `bson:"error" json:"error"`
+        At      time.Time `bson:"at" json:"at"`
+}
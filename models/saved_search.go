@@ -0,0 +1,36 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearch is a search query registered for periodic re-evaluation
+type SavedSearch struct {
+        ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+        Name       string              `bson:"name" json:"name"`
+        Query      string              `bson:"query" json:"query"`                         // same syntax as /api/v1/search?q=
+        Filters    map[string][]string `bson:"filters,omitempty" json:"filters,omitempty"` // kurum_id, belge_turu, belge_durumu, yil
+        WebhookURL string              `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+        Secret     string              `bson:"secret,omitempty" json:"-"` // signs webhook payloads
+        Active     bool                `bson:"active" json:"active"`
+        LastRunAt  *time.Time          `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+        CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// SavedSearchMatch is a document that newly matched a saved search
+type SavedSearchMatch struct {
+        ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+        SavedSearchID    primitive.ObjectID `bson:"saved_search_id" json:"saved_search_id"`
+        DocumentID       primitive.ObjectID `bson:"document_id" json:"document_id"`
+        PdfAdi           string             `bson:"pdf_adi" json:"pdf_adi"`
+        KurumID          string             `bson:"kurum_id" json:"kurum_id"`
+        BelgeTuru        string             `bson:"belge_turu" json:"belge_turu"`
+        BelgeYayinTarihi string             `bson:"belge_yayin_tarihi" json:"belge_yayin_tarihi"`
+        URLSlug          string             `bson:"url_slug" json:"url_slug"`
+        Score            float64            `bson:"score" json:"score"`
+        MatchedAt        time.Time          `bson:"matched_at" json:"matched_at"`
+        DeliveredAt      *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"` // webhook accepted the match
+        Acknowledged     bool               `bson:"acknowledged" json:"acknowledged"`                   // client confirmed it has seen the match
+}
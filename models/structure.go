@@ -0,0 +1,26 @@
+package models
+
+import (
+        "time"
+)
+
+// DocumentStructure is the division of a legislative text into parts (kısım),
+// chapters (bölüm), articles (madde), paragraphs (fıkra) and subparagraphs
+// (bent), stored with the content it was parsed from
+type DocumentStructure struct {
+        Version     int             `bson:"version" json:"version"`   // parser version, older structures are parsed again
+        ContentHash string          `bson:"content_hash" json:"-"`    // hash of the icerik parsed, to notice edits
+        Nodes       []StructureNode `bson:"nodes" json:"nodes"`
+        ParsedAt    time.Time       `bson:"parsed_at" json:"parsed_at"`
+}
+
+// StructureNode is one division of a legislative text
+type StructureNode struct {
+        Type     string          `bson:"type" json:"type"`                         // kisim, bolum, madde, gecici_madde, ek_madde, ek_gecici_madde, fikra, bent
+        Number   string          `bson:"number,omitempty" json:"number,omitempty"` // as written: "BİRİNCİ", "5/A", "2", "ç"
+        Title    string          `bson:"title,omitempty" json:"title,omitempty"`   // heading, e.g. "Amaç"
+        Anchor   string          `bson:"anchor" json:"anchor"`                     // stable id for deep links, e.g. "madde-5a"
+        Start    int             `bson:"start" json:"-"`                           // byte offsets into icerik
+        End      int             `bson:"end" json:"-"`
+        Children []StructureNode `bson:"children,omitempty" json:"children,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Synonym is a dictionary entry of interchangeable spellings, such as a law
+// and its abbreviation ("Kişisel Verilerin Korunması Kanunu" / "KVKK")
+type Synonym struct {
+        ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+        Term      string             `bson:"term" json:"term"`         // canonical form
+        Synonyms  []string           `bson:"synonyms" json:"synonyms"` // abbreviations and alternative forms
+        OneWay    bool               `bson:"one_way" json:"one_way"`   // synonyms expand to term, but term is not expanded to synonyms ("yön." -> "yönetmelik")
+        CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+        UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
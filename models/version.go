@@ -0,0 +1,26 @@
+package models
+
+import (
+        "time"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DocumentVersion is a snapshot of a document as it read during a period.
+// Versions of one document share DocumentID, the metadata id of its current
+// record, and are numbered from 1 in order of ValidFrom.
+type DocumentVersion struct {
+        ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+        DocumentID       primitive.ObjectID `bson:"document_id" json:"document_id"`
+        SourceID         primitive.ObjectID `bson:"source_id" json:"source_id"` // metadata record the snapshot was taken from
+        Version          int                `bson:"version" json:"version"`
+        PdfAdi           string             `bson:"pdf_adi" json:"pdf_adi"`
+        BelgeDurumu      string             `bson:"belge_durumu" json:"belge_durumu"`
+        BelgeYayinTarihi string             `bson:"belge_yayin_tarihi" json:"belge_yayin_tarihi"`
+        Icerik           string             `bson:"icerik" json:"icerik,omitempty"`
+        ContentHash      string             `bson:"content_hash" json:"content_hash"`
+        ValidFrom        time.Time          `bson:"valid_from" json:"valid_from"`
+        ValidTo          *time.Time         `bson:"valid_to,omitempty" json:"valid_to,omitempty"` // nil for the version in force
+        Note             string             `bson:"note,omitempty" json:"note,omitempty"`         // what changed, e.g. the amending regulation
+        CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+}
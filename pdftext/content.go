@@ -0,0 +1,440 @@
+package pdftext
+
+import (
+	"bytes"
+	"math"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maxFormDepth bounds the nesting of form XObjects drawn by a page
+const maxFormDepth = 8
+
+// wordGap is the TJ adjustment, in thousandths of a text space unit, taken as
+// a space between words
+const wordGap = 200
+
+// font decodes the strings shown with a font
+type font struct {
+	cmap      *cmap     // ToUnicode map, when the font has one
+	composite bool      // Type0 font with multi-byte codes
+	encoding  [256]rune // codes of a simple font, 0 when unknown
+}
+
+// codespace is a range of codes of one length
+type codespace struct {
+	low, high []byte
+}
+
+// cmap is a parsed ToUnicode CMap
+type cmap struct {
+	codespaces []codespace
+	chars      map[string]string // code bytes -> text
+}
+
+// lineTolerance is how far, in user space units, the baseline may move
+// before text counts as being on a new line
+const lineTolerance = 1.0
+
+// textState tracks the vertical position of the text being shown, enough to
+// tell when a new line starts
+type textState struct {
+	y, scale float64 // baseline of the text matrix and its vertical scale
+	lastY    float64 // baseline of the last text written
+	written  bool
+}
+
+// moveTo sets the baseline, starting a new line or a new word
+func (s *textState) moveTo(w *textWriter, y float64) {
+	s.y = y
+	if !s.written {
+		return
+	}
+	if math.Abs(s.y-s.lastY) > lineTolerance {
+		w.newLine()
+	} else {
+		w.space()
+	}
+}
+
+// show writes text at the current baseline
+func (s *textState) show(w *textWriter, text string) {
+	if text == "" {
+		return
+	}
+	w.write(text)
+	s.lastY, s.written = s.y, true
+}
+
+// runContent interprets a content stream, writing the text it shows
+func (f *file) runContent(w *textWriter, content []byte, resources dict, depth int) {
+	l := &lexer{data: content}
+	var operands []interface{}
+	var current *font
+	state := &textState{scale: 1}
+
+	for !l.eof() {
+		obj := l.object()
+		op, isOp := obj.(keyword)
+		if !isOp {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			state.y, state.scale = 0, 1
+		case "Tf":
+			if len(operands) >= 2 {
+				if fontName, ok := operands[len(operands)-2].(name); ok {
+					current = f.font(resources, fontName)
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				ty, _ := operands[len(operands)-1].(float64)
+				state.moveTo(w, state.y+ty*state.scale)
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				d, _ := operands[len(operands)-3].(float64)
+				y, _ := operands[len(operands)-1].(float64)
+				state.scale = d
+				state.moveTo(w, y)
+			}
+		case "T*":
+			w.newLine()
+		case "Tj":
+			if len(operands) >= 1 {
+				state.show(w, current.decode(operands[len(operands)-1]))
+			}
+		case "'", "\"":
+			w.newLine()
+			if len(operands) >= 1 {
+				state.show(w, current.decode(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				items, _ := operands[len(operands)-1].(array)
+				for _, item := range items {
+					if gap, ok := item.(float64); ok {
+						if -gap > wordGap {
+							w.space()
+						}
+						continue
+					}
+					state.show(w, current.decode(item))
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < maxFormDepth {
+				if xobjectName, ok := operands[len(operands)-1].(name); ok {
+					f.runForm(w, resources, xobjectName, depth)
+				}
+			}
+		case "BI":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+}
+
+// runForm draws the text of a form XObject
+func (f *file) runForm(w *textWriter, resources dict, xobjectName name, depth int) {
+	xobjects := f.dictOf(resources["XObject"])
+	if xobjects == nil {
+		return
+	}
+	form, ok := f.resolve(xobjects[xobjectName]).(*stream)
+	if !ok || form.dict["Subtype"] != name("Form") {
+		return
+	}
+	data, err := decode(form)
+	if err != nil {
+		return
+	}
+	formResources := f.dictOf(form.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	f.runContent(w, data, formResources, depth+1)
+}
+
+// skipInlineImage moves past the data of an inline image, up to EI
+func skipInlineImage(l *lexer) {
+	i := bytes.Index(l.data[l.pos:], []byte("ID"))
+	if i < 0 {
+		l.pos = len(l.data)
+		return
+	}
+	l.pos += i + 2
+	for l.pos < len(l.data) {
+		j := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if j < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		l.pos += j + 2
+		if isSpace(l.data[l.pos-3]) && (l.pos == len(l.data) || isSpace(l.data[l.pos])) {
+			return
+		}
+	}
+}
+
+// font returns the font a resource name refers to, loading it once
+func (f *file) font(resources dict, fontName name) *font {
+	fonts := f.dictOf(resources["Font"])
+	if fonts == nil {
+		return nil
+	}
+	key, isRef := fonts[fontName].(ref)
+	if isRef {
+		if cached, ok := f.fonts[key]; ok {
+			return cached
+		}
+	}
+	d := f.dictOf(fonts[fontName])
+	if d == nil {
+		return nil
+	}
+
+	fn := &font{composite: d["Subtype"] == name("Type0")}
+	if s, ok := f.resolve(d["ToUnicode"]).(*stream); ok {
+		if data, err := decode(s); err == nil {
+			fn.cmap = parseCMap(data)
+		}
+	}
+	if !fn.composite {
+		fn.encoding = f.simpleEncoding(d["Encoding"])
+	}
+	if isRef {
+		f.fonts[key] = fn
+	}
+	return fn
+}
+
+// simpleEncoding builds the code table of a simple font from its base
+// encoding and differences
+func (f *file) simpleEncoding(obj interface{}) [256]rune {
+	var table [256]rune
+	base := charmap.Windows1252
+	var differences array
+	switch v := f.resolve(obj).(type) {
+	case name:
+		if v == "MacRomanEncoding" {
+			base = charmap.Macintosh
+		}
+	case dict:
+		if v["BaseEncoding"] == name("MacRomanEncoding") {
+			base = charmap.Macintosh
+		}
+		differences, _ = f.resolve(v["Differences"]).(array)
+	}
+	for c := 32; c < 256; c++ {
+		if r := base.DecodeByte(byte(c)); r != '�' {
+			table[c] = r
+		}
+	}
+	code := 0
+	for _, item := range differences {
+		switch v := item.(type) {
+		case float64:
+			code = int(v)
+		case name:
+			if code >= 0 && code < 256 {
+				if r, ok := glyphRune(string(v)); ok {
+					table[code] = r
+				}
+			}
+			code++
+		}
+	}
+	return table
+}
+
+// decode converts a string shown with the font to text
+func (fn *font) decode(obj interface{}) string {
+	s, ok := obj.([]byte)
+	if !ok {
+		return ""
+	}
+	if fn == nil {
+		return textString(s)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		n := fn.codeLength(s[i:])
+		code := s[i:min(len(s), i+n)]
+		i += n
+		if fn.cmap != nil {
+			if text, ok := fn.cmap.chars[string(code)]; ok {
+				b.WriteString(text)
+				continue
+			}
+		}
+		if !fn.composite && len(code) == 1 && fn.encoding[code[0]] != 0 {
+			b.WriteRune(fn.encoding[code[0]])
+		}
+	}
+	return b.String()
+}
+
+// codeLength is the length of the code starting s
+func (fn *font) codeLength(s []byte) int {
+	if fn.cmap != nil {
+		for _, cs := range fn.cmap.codespaces {
+			n := len(cs.low)
+			if n == 0 || n > len(s) {
+				continue
+			}
+			if bytes.Compare(s[:n], cs.low) >= 0 && bytes.Compare(s[:n], cs.high) <= 0 {
+				return n
+			}
+		}
+	}
+	if fn.composite {
+		return 2
+	}
+	return 1
+}
+
+// parseCMap reads the code space and character mappings of a ToUnicode CMap
+func parseCMap(data []byte) *cmap {
+	cm := &cmap{chars: make(map[string]string)}
+	l := &lexer{data: data}
+	for !l.eof() {
+		op, ok := l.object().(keyword)
+		if !ok {
+			continue
+		}
+		switch op {
+		case "begincodespacerange":
+			for {
+				low, ok1 := l.object().([]byte)
+				if !ok1 {
+					break
+				}
+				high, ok2 := l.object().([]byte)
+				if !ok2 {
+					break
+				}
+				cm.codespaces = append(cm.codespaces, codespace{low: low, high: high})
+			}
+		case "beginbfchar":
+			for {
+				src, ok := l.object().([]byte)
+				if !ok {
+					break
+				}
+				if dst, ok := l.object().([]byte); ok {
+					cm.chars[string(src)] = utf16BE(dst)
+				}
+			}
+		case "beginbfrange":
+			for {
+				low, ok1 := l.object().([]byte)
+				if !ok1 {
+					break
+				}
+				high, ok2 := l.object().([]byte)
+				dst := l.object()
+				if !ok2 || len(low) != len(high) {
+					continue
+				}
+				cm.addRange(low, high, dst)
+			}
+		}
+	}
+	// Longer codes are tried first, single byte fonts fall through quickly
+	for i := 1; i < len(cm.codespaces); i++ {
+		for j := i; j > 0 && len(cm.codespaces[j].low) > len(cm.codespaces[j-1].low); j-- {
+			cm.codespaces[j], cm.codespaces[j-1] = cm.codespaces[j-1], cm.codespaces[j]
+		}
+	}
+	return cm
+}
+
+// maxRangeCodes bounds the codes mapped by a single bfrange entry
+const maxRangeCodes = 1 << 16
+
+// addRange maps the codes low..high to consecutive characters starting at
+// dst, or to the strings of the array dst
+func (cm *cmap) addRange(low, high []byte, dst interface{}) {
+	code := append([]byte(nil), low...)
+	for i := 0; i < maxRangeCodes && bytes.Compare(code, high) <= 0; i++ {
+		switch v := dst.(type) {
+		case []byte:
+			target := append([]byte(nil), v...)
+			if n := len(target); n > 0 {
+				// The last byte of the destination counts up with the code
+				carry := i
+				for k := n - 1; k >= 0 && carry > 0; k-- {
+					sum := int(target[k]) + carry
+					target[k] = byte(sum)
+					carry = sum >> 8
+				}
+			}
+			cm.chars[string(code)] = utf16BE(target)
+		case array:
+			if i >= len(v) {
+				return
+			}
+			if s, ok := v[i].([]byte); ok {
+				cm.chars[string(code)] = utf16BE(s)
+			}
+		default:
+			return
+		}
+		if !increment(code) {
+			return
+		}
+	}
+}
+
+// increment adds one to a big-endian code, reporting false on overflow
+func increment(code []byte) bool {
+	for k := len(code) - 1; k >= 0; k-- {
+		code[k]++
+		if code[k] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// textWriter collects text, turning position changes into spaces and line
+// breaks
+type textWriter struct {
+	b              strings.Builder
+	pendingSpace   bool
+	pendingNewLine bool
+}
+
+func (w *textWriter) space() {
+	w.pendingSpace = true
+}
+
+func (w *textWriter) newLine() {
+	w.pendingNewLine = true
+}
+
+func (w *textWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.b.Len() > 0 {
+		if w.pendingNewLine {
+			w.b.WriteByte('\n')
+		} else if w.pendingSpace && !strings.HasPrefix(s, " ") && !strings.HasSuffix(w.b.String(), " ") {
+			w.b.WriteByte(' ')
+		}
+	}
+	w.pendingSpace, w.pendingNewLine = false, false
+	w.b.WriteString(s)
+}
+
+func (w *textWriter) String() string {
+	return w.b.String()
+}
@@ -0,0 +1,421 @@
+// Package pdftext extracts the text, page count and title of PDF files. It
+// reads objects by scanning the file rather than through the cross-reference
+// table, so damaged or incrementally updated files still yield their text.
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var (
+	// ErrNotPDF is returned for data that does not start like a PDF file
+	ErrNotPDF = errors.New("not a PDF file")
+	// ErrEncrypted is returned for encrypted files, whose text cannot be read
+	ErrEncrypted = errors.New("PDF is encrypted")
+	// ErrNoPages is returned when no page can be found
+	ErrNoPages = errors.New("PDF has no pages")
+	// ErrTooDeep is returned for files nesting arrays or dictionaries deeper
+	// than maxNesting
+	ErrTooDeep = errors.New("PDF objects are nested too deeply")
+	// ErrTooLarge is returned for streams decoding to more than maxDecodedSize
+	ErrTooLarge = errors.New("PDF stream decodes to too much data")
+)
+
+const (
+	// maxPageTreeDepth bounds the page tree walk against malformed files
+	maxPageTreeDepth = 64
+	// maxDecodedSize bounds the data a stream may decode to, so that a small
+	// compressed stream cannot exhaust memory
+	maxDecodedSize = 256 << 20
+)
+
+var (
+	objectPattern  = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	trailerPattern = regexp.MustCompile(`trailer\s*<<`)
+)
+
+// Document is the text of a PDF file
+type Document struct {
+	Text  string // one line per text line, pages separated by a blank line
+	Pages int
+	Title string // from the document information dictionary, if any
+}
+
+// file holds the objects of a PDF file by number
+type file struct {
+	objects  map[int]interface{}
+	trailers []dict
+	fonts    map[ref]*font
+}
+
+// Extract reads the text of every page of a PDF file
+func Extract(data []byte) (*Document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], " \r\n\t\x00"), []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+
+	f := &file{objects: make(map[int]interface{}), fonts: make(map[ref]*font)}
+	if err := f.scan(data); err != nil {
+		return nil, err
+	}
+	for _, trailer := range f.trailers {
+		if _, ok := trailer["Encrypt"]; ok {
+			return nil, ErrEncrypted
+		}
+	}
+
+	pages := f.pages()
+	if len(pages) == 0 {
+		return nil, ErrNoPages
+	}
+
+	doc := &Document{Pages: len(pages), Title: f.title()}
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		w := &textWriter{}
+		f.runContent(w, f.contents(page.dict), page.resources, 0)
+		if text := strings.TrimSpace(w.String()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	doc.Text = strings.Join(texts, "\n\n")
+	return doc, nil
+}
+
+// scan reads every "n g obj" in the file, later definitions replacing
+// earlier ones, then the objects packed in object streams
+func (f *file) scan(data []byte) error {
+	var objectStreams []*stream
+	pos := 0
+	for pos < len(data) {
+		loc := objectPattern.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num := atoi(data[pos+loc[2] : pos+loc[3]])
+		l := &lexer{data: data, pos: pos + loc[1]}
+		obj := l.object()
+		if l.err != nil {
+			return l.err
+		}
+		if d, ok := obj.(dict); ok {
+			if s, end := readStream(data, l.pos, d); s != nil {
+				obj = s
+				l.pos = end
+				switch d["Type"] {
+				case name("ObjStm"):
+					objectStreams = append(objectStreams, s)
+				case name("XRef"):
+					f.trailers = append(f.trailers, d)
+				}
+			}
+		}
+		f.objects[num] = obj
+		pos = l.pos
+	}
+
+	for _, loc := range trailerPattern.FindAllIndex(data, -1) {
+		l := &lexer{data: data, pos: loc[1] - 2}
+		if d, ok := l.object().(dict); ok {
+			f.trailers = append(f.trailers, d)
+		}
+		if l.err != nil {
+			return l.err
+		}
+	}
+
+	for _, s := range objectStreams {
+		if err := f.unpack(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readStream reads the stream following the dictionary d ending at pos, if any
+func readStream(data []byte, pos int, d dict) (*stream, int) {
+	l := &lexer{data: data, pos: pos}
+	l.skipSpace()
+	if !bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+		return nil, pos
+	}
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+
+	// Trust /Length only when endstream follows it
+	if length, ok := d["Length"].(float64); ok && length >= 0 && start+int(length) <= len(data) {
+		end := start + int(length)
+		rest := bytes.TrimLeft(data[end:min(len(data), end+32)], " \r\n\t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return &stream{dict: d, data: data[start:end]}, end
+		}
+	}
+	i := bytes.Index(data[start:], []byte("endstream"))
+	if i < 0 {
+		return &stream{dict: d, data: data[start:]}, len(data)
+	}
+	end := start + i
+	raw := bytes.TrimRight(data[start:end], "\r\n")
+	return &stream{dict: d, data: raw}, end + len("endstream")
+}
+
+// unpack adds the objects of an object stream not defined directly. Damaged
+// streams are skipped; only files built to exhaust the parser are an error.
+func (f *file) unpack(s *stream) error {
+	data, err := decode(s)
+	if err == ErrTooLarge {
+		return err
+	}
+	if err != nil {
+		return nil
+	}
+	n, _ := s.dict["N"].(float64)
+	first, _ := s.dict["First"].(float64)
+	if first < 0 || first > float64(len(data)) {
+		return nil
+	}
+	header := &lexer{data: data[:int(first)]}
+	for i := 0; i < int(n); i++ {
+		num, ok1 := header.object().(float64)
+		offset, ok2 := header.object().(float64)
+		if !ok1 || !ok2 || offset < 0 {
+			return header.err
+		}
+		if _, exists := f.objects[int(num)]; exists {
+			continue
+		}
+		at := int(first) + int(offset)
+		if offset >= float64(len(data)) || at >= len(data) {
+			continue
+		}
+		l := &lexer{data: data, pos: at}
+		f.objects[int(num)] = l.object()
+		if l.err != nil {
+			return l.err
+		}
+	}
+	return nil
+}
+
+// resolve follows references
+func (f *file) resolve(obj interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		r, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj = f.objects[r.num]
+	}
+	return nil
+}
+
+// dictOf returns the dictionary of obj, or of the stream obj is
+func (f *file) dictOf(obj interface{}) dict {
+	switch v := f.resolve(obj).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.dict
+	}
+	return nil
+}
+
+// page is a page dictionary with the resources it inherits
+type page struct {
+	dict      dict
+	resources dict
+}
+
+// pages returns the pages in document order, walking the page tree from the
+// catalog. Files whose tree cannot be found yield their page objects in
+// object number order.
+func (f *file) pages() []page {
+	var root dict
+	for i := len(f.trailers) - 1; i >= 0 && root == nil; i-- {
+		root = f.dictOf(f.trailers[i]["Root"])
+	}
+	if root == nil {
+		for _, obj := range f.objects {
+			if d := f.dictOf(obj); d != nil && d["Type"] == name("Catalog") {
+				root = d
+				break
+			}
+		}
+	}
+
+	var result []page
+	if root != nil {
+		visited := make(map[int]bool)
+		var walk func(node interface{}, resources dict, depth int)
+		walk = func(node interface{}, resources dict, depth int) {
+			if r, ok := node.(ref); ok {
+				if visited[r.num] {
+					return
+				}
+				visited[r.num] = true
+			}
+			d := f.dictOf(node)
+			if d == nil || depth > maxPageTreeDepth {
+				return
+			}
+			if res := f.dictOf(d["Resources"]); res != nil {
+				resources = res
+			}
+			kids, isNode := f.resolve(d["Kids"]).(array)
+			if !isNode || d["Type"] == name("Page") {
+				result = append(result, page{dict: d, resources: resources})
+				return
+			}
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+		}
+		walk(root["Pages"], nil, 0)
+	}
+	if len(result) > 0 {
+		return result
+	}
+
+	var nums []int
+	for num, obj := range f.objects {
+		if d := f.dictOf(obj); d != nil && d["Type"] == name("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		d := f.dictOf(f.objects[num])
+		result = append(result, page{dict: d, resources: f.dictOf(d["Resources"])})
+	}
+	return result
+}
+
+// contents returns the decoded content streams of a page or form
+func (f *file) contents(d dict) []byte {
+	var parts []interface{}
+	switch v := f.resolve(d["Contents"]).(type) {
+	case array:
+		parts = v
+	case nil:
+	default:
+		parts = []interface{}{v}
+	}
+	var b bytes.Buffer
+	for _, part := range parts {
+		if s, ok := f.resolve(part).(*stream); ok {
+			if data, err := decode(s); err == nil {
+				b.Write(data)
+				b.WriteByte('\n')
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// title returns the title in the document information dictionary
+func (f *file) title() string {
+	for i := len(f.trailers) - 1; i >= 0; i-- {
+		if info := f.dictOf(f.trailers[i]["Info"]); info != nil {
+			if s, ok := f.resolve(info["Title"]).([]byte); ok {
+				return strings.TrimSpace(textString(s))
+			}
+		}
+	}
+	return ""
+}
+
+// textString decodes a string outside content streams: UTF-16 with a byte
+// order mark, otherwise PDFDocEncoding (close to Latin-1)
+func textString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		return utf16BE(s[2:])
+	}
+	runes := make([]rune, len(s))
+	for i, c := range s {
+		runes[i] = charmap.ISO8859_1.DecodeByte(c)
+	}
+	return string(runes)
+}
+
+func utf16BE(s []byte) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decode applies the filters of a stream
+func decode(s *stream) ([]byte, error) {
+	var filters []interface{}
+	switch v := s.dict["Filter"].(type) {
+	case name:
+		filters = []interface{}{v}
+	case array:
+		filters = v
+	}
+
+	data := s.data
+	for _, filter := range filters {
+		switch filter {
+		case name("FlateDecode"), name("Fl"):
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			out, err := io.ReadAll(io.LimitReader(r, maxDecodedSize+1))
+			if len(out) > maxDecodedSize {
+				return nil, ErrTooLarge
+			}
+			// Truncated streams still give the text before the damage
+			if err != nil && len(out) == 0 {
+				return nil, err
+			}
+			data = out
+		case name("ASCIIHexDecode"), name("AHx"):
+			l := &lexer{data: append(append([]byte(nil), data...), '>')}
+			data = l.hexString()
+		case name("ASCII85Decode"), name("A85"):
+			trimmed := bytes.TrimSuffix(bytes.TrimSpace(data), []byte("~>"))
+			out := make([]byte, 4*len(trimmed)+4) // "z" stands for four bytes
+			n, _, err := ascii85.Decode(out, trimmed, true)
+			if err != nil {
+				return nil, err
+			}
+			data = out[:n]
+		default:
+			return nil, errors.New("unsupported filter")
+		}
+	}
+	return data, nil
+}
+
+func atoi(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,55 @@
+package pdftext
+
+import (
+	"strconv"
+	"strings"
+)
+
+// glyphNames maps the glyph names found in /Differences arrays to characters:
+// punctuation, digits and the accented letters of Turkish. Single letters and
+// uniXXXX names are handled by glyphRune.
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$', "percent": '%',
+	"ampersand": '&', "quotesingle": '\'', "quoteright": '’', "quoteleft": '‘', "parenleft": '(',
+	"parenright": ')', "asterisk": '*', "plus": '+', "comma": ',', "hyphen": '-', "period": '.',
+	"slash": '/', "colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\', "bracketright": ']',
+	"underscore": '_', "braceleft": '{', "bar": '|', "braceright": '}', "section": '§',
+	"paragraph": '¶', "bullet": '•', "endash": '–', "emdash": '—', "quotedblleft": '“',
+	"quotedblright": '”', "quotedblbase": '„', "guillemotleft": '«', "guillemotright": '»',
+	"ellipsis": '…', "degree": '°', "copyright": '©', "registered": '®', "periodcentered": '·',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4',
+	"five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"Gbreve": 'Ğ', "gbreve": 'ğ', "Idotaccent": 'İ', "Idot": 'İ', "dotlessi": 'ı',
+	"Scedilla": 'Ş', "scedilla": 'ş', "Ccedilla": 'Ç', "ccedilla": 'ç',
+	"Odieresis": 'Ö', "odieresis": 'ö', "Udieresis": 'Ü', "udieresis": 'ü',
+	"Acircumflex": 'Â', "acircumflex": 'â', "Icircumflex": 'Î', "icircumflex": 'î',
+	"Ucircumflex": 'Û', "ucircumflex": 'û', "fi": 'ﬁ', "fl": 'ﬂ',
+}
+
+// glyphRune returns the character of a glyph name
+func glyphRune(glyph string) (rune, bool) {
+	// Suffixes such as ".sc" or ".alt" name variants of the same character
+	if i := strings.IndexByte(glyph, '.'); i > 0 {
+		glyph = glyph[:i]
+	}
+	if r, ok := glyphNames[glyph]; ok {
+		return r, true
+	}
+	if len(glyph) == 1 && (glyph[0] >= 'a' && glyph[0] <= 'z' || glyph[0] >= 'A' && glyph[0] <= 'Z') {
+		return rune(glyph[0]), true
+	}
+	for _, prefix := range []string{"uni", "u"} {
+		hex := strings.TrimPrefix(glyph, prefix)
+		if hex == glyph || len(hex) < 4 || len(hex) > 6 {
+			continue
+		}
+		if v, err := strconv.ParseUint(hex[:4], 16, 32); err == nil && prefix == "uni" {
+			return rune(v), true
+		}
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil && prefix == "u" {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
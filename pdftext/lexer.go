@@ -0,0 +1,297 @@
+package pdftext
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// PDF objects are represented as nil, bool, float64, name, []byte (strings),
+// array, dict, ref, keyword and *stream
+type (
+	name    string
+	keyword string
+	array   []interface{}
+	dict    map[name]interface{}
+	ref     struct{ num, gen int }
+)
+
+// stream is a dictionary followed by encoded data
+type stream struct {
+	dict dict
+	data []byte
+}
+
+// maxNesting bounds how deeply arrays and dictionaries may nest, so that
+// hostile files cannot exhaust the stack
+const maxNesting = 256
+
+// lexer reads PDF objects from a byte slice
+type lexer struct {
+	data  []byte
+	pos   int
+	depth int   // arrays and dictionaries open
+	err   error // set when the lexer gave up on the data
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return c == '(' || c == ')' || c == '<' || c == '>' || c == '[' || c == ']' || c == '{' || c == '}' || c == '/' || c == '%'
+}
+
+// skipSpace skips white space and comments
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isSpace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// enter opens an array or dictionary. Past maxNesting it records ErrTooDeep
+// and skips the rest of the data, so every caller loop ends.
+func (l *lexer) enter() bool {
+	if l.depth >= maxNesting {
+		l.err = ErrTooDeep
+		l.pos = len(l.data)
+		return false
+	}
+	l.depth++
+	return true
+}
+
+// eof reports whether only white space is left
+func (l *lexer) eof() bool {
+	l.skipSpace()
+	return l.pos >= len(l.data)
+}
+
+// regular reads a run of regular characters: a number or a keyword
+func (l *lexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// object reads the next object. Closing delimiters and unknown words are
+// returned as keywords, so content stream operators come back as keywords.
+func (l *lexer) object() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+	switch c := l.data[l.pos]; c {
+	case '/':
+		l.pos++
+		return l.name()
+	case '(':
+		l.pos++
+		return l.literalString()
+	case '[':
+		l.pos++
+		if !l.enter() {
+			return nil
+		}
+		defer func() { l.depth-- }()
+		var items array
+		for !l.eof() {
+			if l.data[l.pos] == ']' {
+				l.pos++
+				break
+			}
+			items = append(items, l.object())
+		}
+		return items
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			if !l.enter() {
+				return nil
+			}
+			defer func() { l.depth-- }()
+			return l.dict()
+		}
+		l.pos++
+		return l.hexString()
+	case '>', ']', ')', '{', '}':
+		l.pos++
+		if c == '>' && l.pos < len(l.data) && l.data[l.pos] == '>' {
+			l.pos++
+			return keyword(">>")
+		}
+		return keyword(string(c))
+	}
+
+	word := l.regular()
+	if word == "" {
+		l.pos++
+		return keyword("")
+	}
+	switch word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	n, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return keyword(word)
+	}
+	// An integer followed by another and R is a reference
+	if n == float64(int(n)) && n >= 0 {
+		save := l.pos
+		l.skipSpace()
+		gen := l.regular()
+		l.skipSpace()
+		if g, err := strconv.Atoi(gen); err == nil && l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+			(l.pos+1 == len(l.data) || isSpace(l.data[l.pos+1]) || isDelimiter(l.data[l.pos+1])) {
+			l.pos++
+			return ref{num: int(n), gen: g}
+		}
+		l.pos = save
+	}
+	return n
+}
+
+func (l *lexer) dict() dict {
+	d := make(dict)
+	for !l.eof() {
+		key := l.object()
+		if key == keyword(">>") {
+			break
+		}
+		k, ok := key.(name)
+		if !ok {
+			continue
+		}
+		value := l.object()
+		if value == keyword(">>") {
+			break
+		}
+		d[k] = value
+	}
+	return d
+}
+
+func (l *lexer) name() name {
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	raw := l.data[start:l.pos]
+	if bytes.IndexByte(raw, '#') < 0 {
+		return name(raw)
+	}
+	var b []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if v, err := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, raw[i])
+	}
+	return name(b)
+}
+
+func (l *lexer) literalString() []byte {
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return b
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// A backslash at the end of a line continues the string
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					v := int(c - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (l *lexer) hexString() []byte {
+	var b []byte
+	var digit int
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		var v int
+		switch {
+		case c == '>':
+			if half {
+				b = append(b, byte(digit<<4))
+			}
+			return b
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c >= 'a' && c <= 'f':
+			v = int(c-'a') + 10
+		case c >= 'A' && c <= 'F':
+			v = int(c-'A') + 10
+		default:
+			continue
+		}
+		if half {
+			b = append(b, byte(digit<<4|v))
+		} else {
+			digit = v
+		}
+		half = !half
+	}
+	return b
+}
@@ -0,0 +1,221 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// build writes a PDF file of the given objects, numbered from 1, followed by
+// trailer
+func build(trailer string, objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	fmt.Fprintf(&b, "trailer\n%s\n%%%%EOF\n", trailer)
+	return b.Bytes()
+}
+
+// streamObject writes a stream with the given dictionary entries
+func streamObject(entries string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", entries, len(data), data)
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// bomb is a small zlib stream decoding to more than maxDecodedSize zeros
+func bomb() []byte {
+	var b bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&b, zlib.BestSpeed)
+	chunk := make([]byte, 1<<20)
+	for n := 0; n <= maxDecodedSize; n += len(chunk) {
+		w.Write(chunk)
+	}
+	w.Close()
+	return b.Bytes()
+}
+
+const (
+	catalog    = "<< /Type /Catalog /Pages 2 0 R >>"
+	pageTree   = "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"
+	pageObj    = "<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>"
+	helv       = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+	contentOps = "BT /F1 12 Tf 72 700 Td (Madde 1) Tj 0 -14 Td (Kanun) Tj ET"
+	root       = "<< /Root 1 0 R >>"
+)
+
+// simple is a one page file showing contentOps, followed by extra objects
+func simple(trailer string, extra ...string) []byte {
+	objects := append([]string{catalog, pageTree, pageObj, streamObject("", []byte(contentOps)), helv}, extra...)
+	return build(trailer, objects...)
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		text  string
+		title string
+	}{
+		{"plain", simple(root), "Madde 1\nKanun", ""},
+		{
+			name: "compressed content",
+			data: build(root, catalog, pageTree, pageObj,
+				streamObject("/Filter /FlateDecode", deflate([]byte(contentOps))), helv),
+			text: "Madde 1\nKanun",
+		},
+		{
+			name:  "UTF-16 title",
+			data:  simple("<< /Root 1 0 R /Info 6 0 R >>", "<< /Title <FEFF0130015F0020004B0061006E0075006E0075> >>"),
+			text:  "Madde 1\nKanun",
+			title: "İş Kanunu",
+		},
+		{
+			name: "objects in an object stream",
+			data: build(root, catalog, "<< /Type /Pages /Kids [6 0 R] /Count 1 >>",
+				streamObject("/Type /ObjStm /N 1 /First 4", []byte("6 0 "+pageObj)),
+				streamObject("", []byte(contentOps)), helv),
+			text: "Madde 1\nKanun",
+		},
+		{
+			name: "no page tree",
+			data: build("<< >>", pageObj, pageTree, pageObj, streamObject("", []byte(contentOps)), helv),
+			text: "Madde 1\nKanun\n\nMadde 1\nKanun",
+		},
+		// Damaged object streams are skipped
+		{"negative First", simple(root, streamObject("/Type /ObjStm /N 1 /First -4", []byte("9 0 (x)"))), "Madde 1\nKanun", ""},
+		{"negative offset", simple(root, streamObject("/Type /ObjStm /N 1 /First 5", []byte("9 -3 (x)"))), "Madde 1\nKanun", ""},
+		{"First beyond the data", simple(root, streamObject("/Type /ObjStm /N 1 /First 99", []byte("9 0 (x)"))), "Madde 1\nKanun", ""},
+		{"offset beyond the data", simple(root, streamObject("/Type /ObjStm /N 1 /First 4", []byte("9 99 (x)"))), "Madde 1\nKanun", ""},
+		{"huge N", simple(root, streamObject("/Type /ObjStm /N 1e12 /First 4", []byte("9 0 (x)"))), "Madde 1\nKanun", ""},
+		{"corrupt stream", simple(root, streamObject("/Type /ObjStm /N 1 /First 4 /Filter /FlateDecode", []byte("not zlib"))), "Madde 1\nKanun", ""},
+		{"wrong Length", bytes.Replace(simple(root), []byte(fmt.Sprintf("/Length %d", len(contentOps))), []byte("/Length 9999"), 1), "Madde 1\nKanun", ""},
+		{"reference loop", simple(root, "7 0 R", "6 0 R"), "Madde 1\nKanun", ""},
+	}
+	for _, tt := range tests {
+		doc, err := Extract(tt.data)
+		if err != nil {
+			t.Errorf("%s: Extract failed: %v", tt.name, err)
+			continue
+		}
+		if doc.Text != tt.text || doc.Title != tt.title {
+			t.Errorf("%s: Extract = %q titled %q, want %q titled %q", tt.name, doc.Text, doc.Title, tt.text, tt.title)
+		}
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrNotPDF},
+		{"not a PDF", []byte("<html><body>%PDF-1.4</body></html>"), ErrNotPDF},
+		{"header only", []byte("%PDF-1.7\n"), ErrNoPages},
+		{"empty page tree", build(root, catalog, "<< /Type /Pages /Kids [] /Count 0 >>"), ErrNoPages},
+		{"encrypted", simple("<< /Root 1 0 R /Encrypt << /Filter /Standard >> >>"), ErrEncrypted},
+		{"nested arrays", []byte("%PDF-1.4\n1 0 obj\n" + strings.Repeat("[", 100000)), ErrTooDeep},
+		{"nested dictionaries", []byte("%PDF-1.4\n1 0 obj\n" + strings.Repeat("<< /A ", 100000)), ErrTooDeep},
+		{"nested trailer", []byte("%PDF-1.4\ntrailer\n" + strings.Repeat("<< /A ", 100000)), ErrTooDeep},
+		{
+			name: "nested in an object stream",
+			data: simple(root, streamObject("/Type /ObjStm /N 1 /First 4", []byte("9 0 "+strings.Repeat("[", 100000)))),
+			want: ErrTooDeep,
+		},
+		{
+			name: "decompression bomb",
+			data: simple(root, streamObject("/Type /ObjStm /N 1 /First 4 /Filter /FlateDecode", bomb())),
+			want: ErrTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		if _, err := Extract(tt.data); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Extract error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestExtractTruncated(t *testing.T) {
+	data := build(root, catalog, pageTree, pageObj,
+		streamObject("/Filter /FlateDecode", deflate([]byte(contentOps))), helv)
+	for n := 0; n < len(data); n++ {
+		// Any result will do as long as the damage does not panic
+		Extract(data[:n])
+	}
+}
+
+// onePage is a one page file showing ops with the font object fontObj as /F1,
+// followed by extra objects numbered from 6
+func onePage(ops, fontObj string, extra ...string) []byte {
+	objects := append([]string{catalog, pageTree, pageObj, streamObject("", []byte(ops)), fontObj}, extra...)
+	return build(root, objects...)
+}
+
+func TestExtractTurkishText(t *testing.T) {
+	toUnicode := streamObject("", []byte(
+		"/CIDInit /ProcSet findresource begin\nbegincmap\n"+
+			"1 begincodespacerange <0000> <FFFF> endcodespacerange\n"+
+			"2 beginbfchar <0001> <0130> <0002> <015F> endbfchar\n"+
+			"1 beginbfrange <0010> <0012> <0061> endbfrange\n"+
+			"1 beginbfrange <0020> <0021> [<011F> <0131>] endbfrange\n"+
+			"endcmap end"))
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			// Simple fonts lack ş, ğ and ı; they are mapped in by glyph name
+			name: "Differences",
+			data: onePage("BT /F1 12 Tf (\x80\x81\x82 \x83) Tj ET",
+				"<< /Type /Font /Subtype /Type1 /Encoding << /BaseEncoding /WinAnsiEncoding /Differences [128 /scedilla /gbreve /dotlessi /Idotaccent] >> >>"),
+			want: "şğı İ",
+		},
+		{
+			name: "Windows-1252 base encoding",
+			data: onePage("BT /F1 12 Tf (\xc7\xd6\xdc \xe7\xf6\xfc) Tj ET", helv),
+			want: "ÇÖÜ çöü",
+		},
+		{
+			// Two-byte codes of a composite font through ToUnicode bfchar and
+			// both bfrange forms
+			name: "ToUnicode",
+			data: onePage("BT /F1 12 Tf <0001000200100011001200200021> Tj ET",
+				"<< /Type /Font /Subtype /Type0 /ToUnicode 6 0 R >>", toUnicode),
+			want: "İşabcğı",
+		},
+		{
+			// Large negative TJ adjustments are word gaps, small ones kerning
+			name: "TJ spacing",
+			data: onePage("BT /F1 12 Tf [(Ka) -30 (nun) -400 (metni)] TJ ET", helv),
+			want: "Kanun metni",
+		},
+		{
+			name: "lines and words",
+			data: onePage("BT /F1 12 Tf 72 700 Td (Birinci) Tj 60 0 Td (satir) Tj 0 -14 Td (Ikinci) Tj T* (son) Tj ET", helv),
+			want: "Birinci satir\nIkinci\nson",
+		},
+	}
+	for _, tt := range tests {
+		doc, err := Extract(tt.data)
+		if err != nil {
+			t.Errorf("%s: Extract failed: %v", tt.name, err)
+			continue
+		}
+		if doc.Text != tt.want {
+			t.Errorf("%s: Extract = %q, want %q", tt.name, doc.Text, tt.want)
+		}
+	}
+}
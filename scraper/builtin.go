@@ -0,0 +1,151 @@
+package scraper
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+
+	"legal-documents-api/models"
+)
+
+func init() {
+	yargitay := &linkScraper{
+		passes: []linkPass{
+			// Yargıtay item links and keyword-based links
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*(?:/item/\d+/[^"']*|(?:duyuru|haber|news|announcement)[^"']*))["'][^>]*>([\s\S]*?)</a>`), minTitle: 10},
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/item/\d+/[^"']*)["'][^>]*>([\s\S]*?)</a>`), minTitle: 10, skipNav: true},
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*)["'][^>]*>([\s\S]{15,}?)</a>`), minTitle: 15, skipNav: true},
+		},
+		dateWindow: 500,
+		date:       findDate,
+	}
+	Register("yargitay", yargitay, "yargitay.gov.tr")
+	Register(Generic, yargitay)
+
+	Register("sgk", &linkScraper{
+		passes: []linkPass{
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/Duyuru/Detay/[^"']*)["'][^>]*>([\s\S]*?)</a>`), minTitle: 10},
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*(?:duyuru|Duyuru)[^"']*)["'][^>]*>([\s\S]*?)</a>`), minTitle: 15, skipNav: true},
+		},
+		// SGK writes dates with month names: "23 Eylül 2025 Salı"
+		dateWindow: 1000,
+		date:       findMonthDate,
+	}, "sgk.gov.tr")
+
+	Register("iskur", &linkScraper{
+		passes: []linkPass{
+			// İşkur links carry the title in their title attribute
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/duyurular/[^"']+)["'][^>]*title=["']([^"']+)["']`), minTitle: 10},
+			{pattern: regexp.MustCompile(`(?is)<a[^>]+href=["']([^"']*/duyurular/[^"']*)["'][^>]*>([\s\S]*?)</a>`), minTitle: 15, skipNav: true},
+		},
+		// İşkur abbreviates month names: "11 Ağu 2025"
+		dateWindow: 1500,
+		date:       findMonthDate,
+	}, "iskur.gov.tr")
+}
+
+// linkScraper reads announcements from the links of a page that match a
+// series of patterns; later passes only run while fewer than DefaultLimit
+// announcements were found
+type linkScraper struct {
+	passes     []linkPass
+	dateWindow int                 // bytes searched around a title for its date
+	date       func(string) string // finds a date in text, empty when none
+}
+
+// linkPass is a pattern capturing the href and the title of a link
+type linkPass struct {
+	pattern  *regexp.Regexp
+	minTitle int  // shorter titles are skipped
+	skipNav  bool // skip titles that look like navigation
+}
+
+// Scrape implements Scraper
+func (s *linkScraper) Scrape(ctx context.Context, pageURL string) ([]models.DuyuruItem, error) {
+	page, err := Fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.extract(page, pageURL), nil
+}
+
+func (s *linkScraper) extract(page, pageURL string) []models.DuyuruItem {
+	var duyurular []models.DuyuruItem
+	seen := make(map[string]bool)
+	for i, pass := range s.passes {
+		if i > 0 && len(duyurular) >= DefaultLimit {
+			break
+		}
+		for _, match := range pass.pattern.FindAllStringSubmatchIndex(page, -1) {
+			if i > 0 && len(duyurular) >= DefaultLimit {
+				break
+			}
+			href := strings.TrimSpace(page[match[2]:match[3]])
+			title := cleanHTML(page[match[4]:match[5]])
+			link := absoluteURL(href, pageURL)
+			if href == "" || len(title) <= pass.minTitle || seen[link] || (pass.skipNav && isNavigation(title)) {
+				continue
+			}
+			seen[link] = true
+			duyurular = append(duyurular, models.DuyuruItem{
+				Baslik: title,
+				Link:   link,
+				Tarih:  s.dateNear(page, match[0], match[1]),
+			})
+		}
+	}
+	if len(duyurular) > DefaultLimit {
+		duyurular = duyurular[:DefaultLimit]
+	}
+	return duyurular
+}
+
+// dateNear finds the date written around the link at page[start:end], or
+// today's date
+func (s *linkScraper) dateNear(page string, start, end int) string {
+	start -= s.dateWindow
+	if start < 0 {
+		start = 0
+	}
+	end += s.dateWindow
+	if end > len(page) {
+		end = len(page)
+	}
+	if date := s.date(page[start:end]); date != "" {
+		return date
+	}
+	return today()
+}
+
+var (
+	tagPattern   = regexp.MustCompile(`<[^>]*>`)
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// cleanHTML removes tags and entities from a fragment of HTML
+func cleanHTML(text string) string {
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = spacePattern.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// navKeywords appear in menu links rather than announcements
+var navKeywords = []string{
+	"ana sayfa", "anasayfa", "home", "menü", "menu",
+	"hakkımızda", "iletişim", "contact", "about",
+	"giriş", "login", "kayıt", "register", "çıkış", "logout",
+	"ara", "search", "site haritası", "sitemap",
+}
+
+// isNavigation reports whether a link text looks like a navigation item
+func isNavigation(text string) bool {
+	lower := strings.ToLower(text)
+	for _, keyword := range navKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return len(strings.TrimSpace(text)) < 15
+}
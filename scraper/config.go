@@ -0,0 +1,196 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"legal-documents-api/models"
+)
+
+// ConfigScraper reads announcements with the selectors of a ScraperConfig
+type ConfigScraper struct {
+	config models.ScraperConfig
+	item   *Selector
+	title  field
+	link   field
+	date   field
+}
+
+// field is where a value is read from an item: the text or an attribute of
+// the element matching sel, or of the item itself when sel is nil
+type field struct {
+	sel  *Selector
+	attr string
+}
+
+// NewConfigScraper compiles the selectors of a config. The item selector is
+// required; the others are relative to each item.
+func NewConfigScraper(config models.ScraperConfig) (*ConfigScraper, error) {
+	if strings.TrimSpace(config.ItemSelector) == "" {
+		return nil, fmt.Errorf("item_selector is required")
+	}
+	if config.Limit < 0 {
+		return nil, fmt.Errorf("limit cannot be negative")
+	}
+	item, err := Compile(config.ItemSelector)
+	if err != nil {
+		return nil, fmt.Errorf("item_selector: %w", err)
+	}
+	s := &ConfigScraper{config: config, item: item}
+	for _, f := range []struct {
+		name string
+		spec string
+		dst  *field
+	}{
+		{"title_selector", config.TitleSelector, &s.title},
+		{"link_selector", config.LinkSelector, &s.link},
+		{"date_selector", config.DateSelector, &s.date},
+	} {
+		parsed, err := parseField(f.spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.dst = parsed
+	}
+	if config.LinkSelector == "" {
+		s.link = field{sel: linkSelector, attr: "href"}
+	}
+	if config.DateFormat != "" && !validLayout(config.DateFormat) {
+		return nil, fmt.Errorf("date_format %q does not hold a day, month and year (use a Go layout such as 02.01.2006 or 2 Ocak 2006)", config.DateFormat)
+	}
+	return s, nil
+}
+
+// linkSelector finds the first link of an item without a link selector
+var linkSelector, _ = Compile("a[href]")
+
+// parseField reads "selector", "selector@attr" or "@attr"
+func parseField(spec string) (field, error) {
+	spec = strings.TrimSpace(spec)
+	var f field
+	if i := strings.LastIndexByte(spec, '@'); i >= 0 && !strings.ContainsAny(spec[i:], "]\"'") {
+		f.attr = strings.ToLower(strings.TrimSpace(spec[i+1:]))
+		if f.attr == "" {
+			return f, fmt.Errorf("missing attribute after @")
+		}
+		spec = strings.TrimSpace(spec[:i])
+	}
+	if spec != "" {
+		sel, err := Compile(spec)
+		if err != nil {
+			return f, err
+		}
+		f.sel = sel
+	}
+	return f, nil
+}
+
+// Scrape implements Scraper
+func (s *ConfigScraper) Scrape(ctx context.Context, pageURL string) ([]models.DuyuruItem, error) {
+	page, err := Fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse page: %v", err)
+	}
+	return s.Extract(doc, pageURL), nil
+}
+
+// Extract reads the announcements of a parsed page. Items without a title
+// are skipped; items without a link point to the page.
+func (s *ConfigScraper) Extract(doc *html.Node, pageURL string) []models.DuyuruItem {
+	limit := s.config.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	var duyurular []models.DuyuruItem
+	seen := make(map[string]bool)
+	for _, item := range s.item.All(doc) {
+		if len(duyurular) >= limit {
+			break
+		}
+		title, _ := s.title.value(item)
+		if title == "" {
+			continue
+		}
+		link := pageURL
+		if href, ok := s.link.value(item); ok && href != "" {
+			link = absoluteURL(href, pageURL)
+		}
+		if seen[link+"\x00"+title] {
+			continue
+		}
+		seen[link+"\x00"+title] = true
+		duyurular = append(duyurular, models.DuyuruItem{
+			Baslik: title,
+			Link:   link,
+			Tarih:  s.dateOf(item),
+		})
+	}
+	return duyurular
+}
+
+// dateOf reads the date of an item in the configured format, looking for any
+// familiar date in its text when there is no format or the text does not
+// fit it, and falls back to today's date
+func (s *ConfigScraper) dateOf(item *html.Node) string {
+	text, ok := s.date.value(item)
+	if !ok {
+		return today()
+	}
+	if s.config.DateFormat != "" {
+		if date, ok := parseDate(text, s.config.DateFormat); ok {
+			return date
+		}
+	}
+	if date := findMonthDate(text); date != "" {
+		return date
+	}
+	return today()
+}
+
+// value reads a field of an item, reporting false when its element or
+// attribute is missing
+func (f field) value(item *html.Node) (string, bool) {
+	n := item
+	if f.sel != nil {
+		// The item itself counts, so "a[href]" finds the link of an item
+		// that is a link
+		if !f.sel.Match(item) {
+			n = f.sel.First(item)
+		}
+		if n == nil {
+			return "", false
+		}
+	}
+	if f.attr != "" {
+		value, ok := lookupAttr(n, f.attr)
+		return strings.Join(strings.Fields(value), " "), ok
+	}
+	return text(n), true
+}
+
+// text returns the text of a node with whitespace collapsed
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style"):
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"legal-documents-api/models"
+)
+
+const announcementPage = `<html><body>
+<div class="duyurular">
+  <div class="duyuru">
+    <h3><a href="/duyuru/1" title="Birinci duyuru">Birinci</a></h3>
+    <span class="tarih" data-date="2025-09-23">23 Eylül 2025</span>
+  </div>
+  <div class="duyuru">
+    <h3><a href="https://example.gov.tr/duyuru/2">İkinci   duyuru</a></h3>
+    <span class="tarih">Yayın: 11.08.2025</span>
+  </div>
+  <div class="duyuru"><h3></h3></div>
+  <div class="duyuru">
+    <h3><a href="/duyuru/1">Birinci</a></h3>
+  </div>
+</div>
+</body></html>`
+
+func TestNewConfigScraperErrors(t *testing.T) {
+	tests := []struct {
+		config  models.ScraperConfig
+		message string
+	}{
+		{models.ScraperConfig{}, "item_selector is required"},
+		{models.ScraperConfig{ItemSelector: "li", Limit: -1}, "limit cannot be negative"},
+		{models.ScraperConfig{ItemSelector: "li:hover"}, "item_selector: "},
+		{models.ScraperConfig{ItemSelector: "li", TitleSelector: "h3 +"}, "title_selector: "},
+		{models.ScraperConfig{ItemSelector: "li", LinkSelector: "a@"}, "link_selector: "},
+		{models.ScraperConfig{ItemSelector: "li", DateSelector: "span[", DateFormat: "02.01.2006"}, "date_selector: "},
+		{models.ScraperConfig{ItemSelector: "li", DateFormat: "Ocak 2006"}, "date_format"},
+	}
+	for _, tt := range tests {
+		_, err := NewConfigScraper(tt.config)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("NewConfigScraper(%+v) error = %v, want %q", tt.config, err, tt.message)
+		}
+	}
+}
+
+func TestConfigScraperExtract(t *testing.T) {
+	doc := parsePage(t, announcementPage)
+	pageURL := "https://example.gov.tr/duyurular"
+	tests := []struct {
+		name   string
+		config models.ScraperConfig
+		want   []models.DuyuruItem
+	}{
+		{
+			name: "text and default link",
+			config: models.ScraperConfig{
+				ItemSelector:  ".duyuru",
+				TitleSelector: "h3",
+				DateSelector:  ".tarih",
+				DateFormat:    "2 Ocak 2006",
+			},
+			want: []models.DuyuruItem{
+				{Baslik: "Birinci", Link: "https://example.gov.tr/duyuru/1", Tarih: "23.09.2025"},
+				{Baslik: "İkinci duyuru", Link: "https://example.gov.tr/duyuru/2", Tarih: "11.08.2025"},
+			},
+		},
+		{
+			name: "attributes",
+			config: models.ScraperConfig{
+				ItemSelector:  ".duyuru",
+				TitleSelector: "a@title",
+				LinkSelector:  "h3 > a@href",
+				DateSelector:  "[data-date]@data-date",
+				DateFormat:    "2006-01-02",
+				Limit:         1,
+			},
+			want: []models.DuyuruItem{
+				{Baslik: "Birinci duyuru", Link: "https://example.gov.tr/duyuru/1", Tarih: "23.09.2025"},
+			},
+		},
+		{
+			name:   "items that are links",
+			config: models.ScraperConfig{ItemSelector: "h3 > a", DateSelector: "span"},
+			want: []models.DuyuruItem{
+				{Baslik: "Birinci", Link: "https://example.gov.tr/duyuru/1", Tarih: today()},
+				{Baslik: "İkinci duyuru", Link: "https://example.gov.tr/duyuru/2", Tarih: today()},
+			},
+		},
+	}
+	for _, tt := range tests {
+		s, err := NewConfigScraper(tt.config)
+		if err != nil {
+			t.Errorf("%s: NewConfigScraper failed: %v", tt.name, err)
+			continue
+		}
+		got := s.Extract(doc, pageURL)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Extract = %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: item %d = %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
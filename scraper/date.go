@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"legal-documents-api/utils"
+)
+
+// dateLayout is how announcement dates are returned
+const dateLayout = "02.01.2006"
+
+func today() string {
+	return time.Now().Format(dateLayout)
+}
+
+// numericDatePatterns are the numeric dates of Turkish pages, most specific first
+var numericDatePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{1,2}[./]\d{1,2}[./]\d{4}`), // 01.01.2024 or 01/01/2024
+	regexp.MustCompile(`\d{1,2}[./]\d{1,2}[./]\d{2}`), // 01.01.24
+	regexp.MustCompile(`\d{4}[.-]\d{1,2}[.-]\d{1,2}`), // 2024-01-01
+}
+
+// findDate returns the first numeric date in text as written, or ""
+func findDate(text string) string {
+	for _, pattern := range numericDatePatterns {
+		if match := pattern.FindString(text); match != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+// findMonthDate finds a date written with a Turkish month name or its
+// abbreviation, like "23 Eylül 2025" or "11 Ağu 2025", and returns it as
+// dd.mm.yyyy, falling back to numeric dates
+func findMonthDate(text string) string {
+	if t, ok := utils.FindMonthDate(text); ok {
+		return t.Format(dateLayout)
+	}
+	return findDate(text)
+}
+
+// parseDate reads a date written in layout, a Go time layout in which
+// Turkish month names may stand for the English ones, and returns it as
+// dd.mm.yyyy
+func parseDate(value, layout string) (string, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	t, err := time.Parse(utils.EnglishMonths(layout), utils.EnglishMonths(value))
+	if err != nil {
+		return "", false
+	}
+	return t.Format(dateLayout), true
+}
+
+// validLayout reports whether a date layout keeps the day, month and year of
+// the dates written with it
+func validLayout(layout string) bool {
+	layout = utils.EnglishMonths(layout)
+	want := time.Date(2025, time.September, 23, 0, 0, 0, 0, time.UTC)
+	got, err := time.Parse(layout, want.Format(layout))
+	return err == nil && got.Equal(want)
+}
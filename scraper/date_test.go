@@ -0,0 +1,89 @@
+package scraper
+
+import "testing"
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value, layout string
+		want          string // "" when the value does not fit the layout
+	}{
+		{"23 Eylül 2025", "2 Ocak 2006", "23.09.2025"},
+		{"  23   Eylül\n2025 ", "2 Ocak 2006", "23.09.2025"},
+		{"1 Şubat 2024", "2 Ocak 2006", "01.02.2024"},
+		{"5 Mayıs 2024", "2 Ocak 2006", "05.05.2024"},
+		{"12 Mart 2024", "2 Ocak 2006", "12.03.2024"},
+		{"30 Ağustos 2024", "2 Ocak 2006", "30.08.2024"},
+		{"5 May 2024", "2 Oca 2006", "05.05.2024"},
+		{"12 Mar 2024", "2 Oca 2006", "12.03.2024"},
+		{"30 Ağu 2024", "2 Oca 2006", "30.08.2024"},
+		{"12 Mart 2024", "2 Oca 2006", ""}, // a full name where an abbreviation is expected
+		{"12 Mar 2024", "2 Ocak 2006", ""},
+		{"02.01.2006", "02.01.2006", "02.01.2006"},
+		{"23.09.2025", "02.01.2006", "23.09.2025"},
+		{"2025-09-23", "2006-01-02", "23.09.2025"},
+		{"23/09/25", "02/01/06", "23.09.2025"},
+		{"3.9.2025", "2.1.2006", "03.09.2025"},
+		{"31.02.2025", "02.01.2006", ""},
+		{"23 Eylül", "2 Ocak 2006", ""},
+		{"", "02.01.2006", ""},
+	}
+	for _, tt := range tests {
+		got, ok := parseDate(tt.value, tt.layout)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("parseDate(%q, %q) = %q, %v, want %q", tt.value, tt.layout, got, ok, tt.want)
+		}
+	}
+}
+
+func TestValidLayout(t *testing.T) {
+	tests := []struct {
+		layout string
+		valid  bool
+	}{
+		{"02.01.2006", true},
+		{"2.1.2006", true},
+		{"2006-01-02", true},
+		{"02/01/06", true},
+		{"2 Ocak 2006", true},
+		{"2 Oca 2006", true},
+		{"2 January 2006", true},
+		{"Yayın: 02.01.2006", true},
+		{"", false},
+		{"02.01", false},      // no year
+		{"01.2006", false},    // no day
+		{"Ocak 2006", false},  // no day
+		{"02.2006", false},    // no month
+		{"02.02.2006", false}, // the day twice, no month
+		{"2006", false},
+		{"15:04", false},
+		{"dd.mm.yyyy", false},
+	}
+	for _, tt := range tests {
+		if got := validLayout(tt.layout); got != tt.valid {
+			t.Errorf("validLayout(%q) = %v, want %v", tt.layout, got, tt.valid)
+		}
+	}
+}
+
+func TestFindMonthDate(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Yayın tarihi: 23 Eylül 2025, Pazartesi", "23.09.2025"},
+		{"1 Mayıs 2024", "01.05.2024"},
+		{"11 Ağu 2025", "11.08.2025"},
+		{"3 Mar 2024 ve 4 Mart 2024", "03.03.2024"},
+		{"Sayı: 12 adet 2024 yılı, 4 Mart 2024", "04.03.2024"}, // words that are not months are skipped
+		{"31 Şubat 2025, 01.03.2025", "01.03.2025"},            // no such day
+		{"Tarih: 01/02/2024", "01/02/2024"},                    // numeric dates as written
+		{"2024-02-01 tarihli", "2024-02-01"},
+		{"23 Eylul 2025", "23.09.2025"}, // written without Turkish letters
+		{"23 Eylem 2025", ""},           // not a month, no numeric date
+		{"tarih yok", ""},
+	}
+	for _, tt := range tests {
+		if got := findMonthDate(tt.text); got != tt.want {
+			t.Errorf("findMonthDate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
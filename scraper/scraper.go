@@ -0,0 +1,172 @@
+// Package scraper reads the announcements of institutions from their web
+// pages. Scrapers are registered by name and host; an institution whose
+// kurum_duyuru record carries selectors is read by a ConfigScraper built from
+// them, so new sites need no code.
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html/charset"
+
+	"legal-documents-api/models"
+)
+
+const (
+	// DefaultLimit is the number of announcements kept per page
+	DefaultLimit = 5
+	// maxPageSize bounds the size of a page read
+	maxPageSize = 8 << 20
+	// Generic is the name of the scraper used for hosts without their own
+	Generic = "generic"
+)
+
+// Scraper reads the announcements listed on a page
+type Scraper interface {
+	Scrape(ctx context.Context, pageURL string) ([]models.DuyuruItem, error)
+}
+
+// ErrUnknownScraper is returned for a scraper name nothing is registered under
+var ErrUnknownScraper = errors.New("unknown scraper")
+
+type registration struct {
+	scraper Scraper
+	hosts   []string
+}
+
+var (
+	registry = make(map[string]registration)
+	mu       sync.RWMutex
+)
+
+// Register adds a scraper under a name. It is used for pages on the given
+// hosts and their subdomains unless a page names another scraper.
+func Register(name string, s Scraper, hosts ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = registration{scraper: s, hosts: hosts}
+}
+
+// Lookup returns the scraper registered under name
+func Lookup(name string) (Scraper, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	reg, ok := registry[name]
+	return reg.scraper, ok
+}
+
+// Registered returns the registered scraper names with their hosts
+func Registered() map[string][]string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make(map[string][]string, len(registry))
+	for name, reg := range registry {
+		names[name] = append([]string{}, reg.hosts...)
+	}
+	return names
+}
+
+// For returns the scraper of an announcement page: one built from its
+// selectors, the one it names, the one registered for its host, or the
+// generic scraper
+func For(page models.KurumDuyuru) (Scraper, error) {
+	if cfg := page.Scraper; cfg != nil {
+		if cfg.ItemSelector != "" {
+			return NewConfigScraper(*cfg)
+		}
+		if cfg.Name != "" {
+			s, ok := Lookup(cfg.Name)
+			if !ok {
+				return nil, fmt.Errorf("%w %q", ErrUnknownScraper, cfg.Name)
+			}
+			return s, nil
+		}
+	}
+	if s, ok := forHost(page.DuyuruLinki); ok {
+		return s, nil
+	}
+	if s, ok := Lookup(Generic); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w for %s", ErrUnknownScraper, page.DuyuruLinki)
+}
+
+// forHost finds the scraper registered for the host of pageURL, preferring
+// the most specific host
+func forHost(pageURL string) (Scraper, bool) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var best Scraper
+	bestLen := 0
+	for _, name := range names {
+		for _, h := range registry[name].hosts {
+			if (host == h || strings.HasSuffix(host, "."+h)) && len(h) > bestLen {
+				best, bestLen = registry[name].scraper, len(h)
+			}
+		}
+	}
+	return best, best != nil
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// Fetch downloads a page and returns it as UTF-8 text
+func Fetch(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("HTTP request failed: %v", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, maxPageSize)
+	reader, err := charset.NewReader(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		reader = body // Fallback to original body if charset detection fails
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read response: %v", err)
+	}
+	return string(data), nil
+}
+
+// absoluteURL resolves a link found on a page against the page address
+func absoluteURL(href, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
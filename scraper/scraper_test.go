@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"legal-documents-api/models"
+)
+
+// stubScraper is a registered scraper told apart by its name
+type stubScraper struct{ name string }
+
+func (s *stubScraper) Scrape(ctx context.Context, pageURL string) ([]models.DuyuruItem, error) {
+	return nil, nil
+}
+
+func TestFor(t *testing.T) {
+	Register("test-bakanlik", &stubScraper{"test-bakanlik"}, "bakanlik.test")
+	Register("test-il", &stubScraper{"test-il"}, "il.bakanlik.test")
+	defer func() {
+		mu.Lock()
+		delete(registry, "test-bakanlik")
+		delete(registry, "test-il")
+		mu.Unlock()
+	}()
+
+	tests := []struct {
+		name string
+		page models.KurumDuyuru
+		want string // registered name, "config" or "" for an error
+	}{
+		{"host", models.KurumDuyuru{DuyuruLinki: "https://bakanlik.test/duyurular"}, "test-bakanlik"},
+		{"subdomain", models.KurumDuyuru{DuyuruLinki: "https://www.bakanlik.test/duyurular"}, "test-bakanlik"},
+		// The most specific registered host wins
+		{"more specific host", models.KurumDuyuru{DuyuruLinki: "https://ankara.il.bakanlik.test/"}, "test-il"},
+		{"host casing", models.KurumDuyuru{DuyuruLinki: "https://BAKANLIK.TEST/"}, "test-bakanlik"},
+		// A host that merely ends with a registered one is someone else's
+		{"lookalike host", models.KurumDuyuru{DuyuruLinki: "https://kotubakanlik.test/"}, Generic},
+		{"named", models.KurumDuyuru{DuyuruLinki: "https://bakanlik.test/", Scraper: &models.ScraperConfig{Name: "test-il"}}, "test-il"},
+		{"selectors", models.KurumDuyuru{DuyuruLinki: "https://bakanlik.test/", Scraper: &models.ScraperConfig{Name: "test-il", ItemSelector: "li"}}, "config"},
+		{"unknown name", models.KurumDuyuru{Scraper: &models.ScraperConfig{Name: "yok"}}, ""},
+	}
+	for _, tt := range tests {
+		s, err := For(tt.page)
+		got := ""
+		switch v := s.(type) {
+		case *stubScraper:
+			got = v.name
+		case *ConfigScraper:
+			got = "config"
+		case *linkScraper:
+			if generic, _ := Lookup(Generic); v == generic {
+				got = Generic
+			}
+		}
+		if tt.want == "" {
+			if !errors.Is(err, ErrUnknownScraper) {
+				t.Errorf("%s: For error = %v, want ErrUnknownScraper", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: For = %q (%v), want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestBuiltinScrapers(t *testing.T) {
+	// The title is written with an entity, so the date is found by the
+	// position of the link rather than by its text
+	const sgkPage = `<ul>
+<li><a href="/Anasayfa">Ana Sayfa</a></li>
+<li><span>23 Eylül 2025 Salı</span><a href="/Duyuru/Detay/Emeklilik">Emeklilik başvurularında yeni d&#246;nem</a></li>
+<li><a href="https://www.sgk.gov.tr/Duyuru/Detay/Emeklilik">Emeklilik başvurularında yeni dönem</a></li>
+</ul>`
+	s, _ := Lookup("sgk")
+	items := s.(*linkScraper).extract(sgkPage, "https://www.sgk.gov.tr/Duyurular")
+	want := models.DuyuruItem{Baslik: "Emeklilik başvurularında yeni dönem", Link: "https://www.sgk.gov.tr/Duyuru/Detay/Emeklilik", Tarih: "23.09.2025"}
+	if len(items) != 1 || items[0] != want {
+		t.Errorf("sgk extract = %+v, want only %+v", items, want)
+	}
+
+	// Pages listing many announcements keep the first DefaultLimit
+	var page strings.Builder
+	for i := 0; i < 8; i++ {
+		page.WriteString(`<a href="/item/` + string(rune('1'+i)) + `/duyuru">Yargıtay Büyük Genel Kurulu duyurusu ` + string(rune('1'+i)) + `</a> 01.02.2025`)
+	}
+	generic, _ := Lookup(Generic)
+	if items := generic.(*linkScraper).extract(page.String(), "https://www.yargitay.gov.tr/"); len(items) != DefaultLimit || items[0].Tarih != "01.02.2025" {
+		t.Errorf("generic extract = %+v, want %d items dated 01.02.2025", items, DefaultLimit)
+	}
+}
@@ -0,0 +1,364 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector is a compiled CSS selector. The supported subset covers what
+// announcement lists need: type, #id, .class and [attr], [attr=v], [attr~=v],
+// [attr*=v], [attr^=v], [attr$=v] conditions, the universal selector, the
+// descendant and child combinators and comma separated alternatives.
+type Selector struct {
+	source       string
+	alternatives [][]step
+}
+
+// step is one compound selector of a complex selector with the combinator
+// linking it to the previous step: ' ' for a descendant, '>' for a child
+type step struct {
+	combinator byte
+	tag        string // empty for any element
+	id         string
+	classes    []string
+	attrs      []attrCondition
+}
+
+type attrCondition struct {
+	name  string
+	op    string // "" for presence
+	value string
+}
+
+// Compile parses a CSS selector
+func Compile(source string) (*Selector, error) {
+	sel := &Selector{source: source}
+	for _, part := range splitTopLevel(source, ',') {
+		steps, err := parseComplex(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", source, err)
+		}
+		sel.alternatives = append(sel.alternatives, steps)
+	}
+	if len(sel.alternatives) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return sel, nil
+}
+
+// String returns the source of the selector
+func (s *Selector) String() string {
+	return s.source
+}
+
+// Match reports whether an element matches the selector
+func (s *Selector) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	for _, steps := range s.alternatives {
+		if matchSteps(steps, len(steps)-1, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns the descendants of root matching the selector in document order
+func (s *Selector) All(root *html.Node) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if s.Match(c) {
+				found = append(found, c)
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return found
+}
+
+// First returns the first descendant of root matching the selector, or nil
+func (s *Selector) First(root *html.Node) *html.Node {
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if s.Match(c) {
+			return c
+		}
+		if found := s.First(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func matchSteps(steps []step, i int, n *html.Node) bool {
+	if !steps[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch steps[i].combinator {
+	case '>':
+		p := n.Parent
+		return p != nil && p.Type == html.ElementNode && matchSteps(steps, i-1, p)
+	default:
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.Type == html.ElementNode && matchSteps(steps, i-1, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (st step) match(n *html.Node) bool {
+	if st.tag != "" && st.tag != n.Data {
+		return false
+	}
+	if st.id != "" && attr(n, "id") != st.id {
+		return false
+	}
+	if len(st.classes) > 0 {
+		classes := strings.Fields(attr(n, "class"))
+		for _, want := range st.classes {
+			if !containsString(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, cond := range st.attrs {
+		value, ok := lookupAttr(n, cond.name)
+		if !ok {
+			return false
+		}
+		switch cond.op {
+		case "=":
+			ok = value == cond.value
+		case "~=":
+			ok = containsString(strings.Fields(value), cond.value)
+		case "*=":
+			ok = cond.value != "" && strings.Contains(value, cond.value)
+		case "^=":
+			ok = cond.value != "" && strings.HasPrefix(value, cond.value)
+		case "$=":
+			ok = cond.value != "" && strings.HasSuffix(value, cond.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseComplex parses compound selectors joined by combinators
+func parseComplex(s string) ([]step, error) {
+	var steps []step
+	p := &parser{s: strings.TrimSpace(s)}
+	if p.s == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+	combinator := byte(0)
+	for {
+		st, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		st.combinator = combinator
+		steps = append(steps, st)
+
+		spaced := p.skipSpace()
+		if p.done() {
+			return steps, nil
+		}
+		switch p.peek() {
+		case '>':
+			p.pos++
+			p.skipSpace()
+			combinator = '>'
+		case '+', '~':
+			return nil, fmt.Errorf("sibling combinators are not supported")
+		default:
+			if !spaced {
+				return nil, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+			}
+			combinator = ' '
+		}
+	}
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.s) }
+func (p *parser) peek() byte { return p.s[p.pos] }
+
+func (p *parser) skipSpace() bool {
+	start := p.pos
+	for !p.done() && isSpace(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// compound parses a tag or * followed by any #id, .class and [attr] parts
+func (p *parser) compound() (step, error) {
+	var st step
+	start := p.pos
+	if !p.done() && p.peek() == '*' {
+		p.pos++
+	} else if !p.done() && isNameByte(p.peek()) {
+		st.tag = strings.ToLower(p.name())
+	}
+	for !p.done() {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			if st.id = p.name(); st.id == "" {
+				return st, fmt.Errorf("missing id at %d", p.pos)
+			}
+		case '.':
+			p.pos++
+			class := p.name()
+			if class == "" {
+				return st, fmt.Errorf("missing class at %d", p.pos)
+			}
+			st.classes = append(st.classes, class)
+		case '[':
+			cond, err := p.attribute()
+			if err != nil {
+				return st, err
+			}
+			st.attrs = append(st.attrs, cond)
+		case ':':
+			return st, fmt.Errorf("pseudo-classes are not supported")
+		default:
+			if p.pos == start {
+				return st, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+			}
+			return st, nil
+		}
+	}
+	if p.pos == start {
+		return st, fmt.Errorf("selector ends with a combinator")
+	}
+	return st, nil
+}
+
+// attribute parses [name], [name=value] and the other operators
+func (p *parser) attribute() (attrCondition, error) {
+	var cond attrCondition
+	p.pos++ // [
+	p.skipSpace()
+	cond.name = strings.ToLower(p.name())
+	if cond.name == "" {
+		return cond, fmt.Errorf("missing attribute name at %d", p.pos)
+	}
+	p.skipSpace()
+	if p.done() {
+		return cond, fmt.Errorf("unterminated attribute selector")
+	}
+	if p.peek() != ']' {
+		for _, op := range []string{"=", "~=", "*=", "^=", "$="} {
+			if strings.HasPrefix(p.s[p.pos:], op) {
+				cond.op = op
+				p.pos += len(op)
+				break
+			}
+		}
+		if cond.op == "" {
+			return cond, fmt.Errorf("unsupported attribute operator at %d", p.pos)
+		}
+		p.skipSpace()
+		if p.done() {
+			return cond, fmt.Errorf("unterminated attribute selector")
+		}
+		if quote := p.peek(); quote == '"' || quote == '\'' {
+			end := strings.IndexByte(p.s[p.pos+1:], quote)
+			if end < 0 {
+				return cond, fmt.Errorf("unterminated string at %d", p.pos)
+			}
+			cond.value = p.s[p.pos+1 : p.pos+1+end]
+			p.pos += end + 2
+		} else {
+			cond.value = p.name()
+		}
+		p.skipSpace()
+	}
+	if p.done() || p.peek() != ']' {
+		return cond, fmt.Errorf("unterminated attribute selector")
+	}
+	p.pos++
+	return cond, nil
+}
+
+func (p *parser) name() string {
+	start := p.pos
+	for !p.done() && isNameByte(p.peek()) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// splitTopLevel splits s at sep outside brackets and quotes
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, quote, start := 0, byte(0), 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func attr(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const selectorPage = `<html><body>
+<div id="list" class="news list">
+  <ul id="ul">
+    <li id="li1" class="item new"><a id="a1" href="/duyuru/1.pdf" title="Duyuru bir">Bir</a></li>
+    <li id="li2" class="item"><span id="s2"><a id="a2" href="https://example.gov.tr/duyuru/2" data-tip="önemli duyuru">İki</a></span></li>
+  </ul>
+</div>
+<p id="p1"><a id="a3" name="x">Üç</a></p>
+</body></html>`
+
+func parsePage(t *testing.T, page string) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("parse page: %v", err)
+	}
+	return doc
+}
+
+// ids lists the id attributes of nodes
+func ids(nodes []*html.Node) string {
+	list := make([]string, len(nodes))
+	for i, n := range nodes {
+		list[i] = attr(n, "id")
+	}
+	return strings.Join(list, " ")
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		selector, message string
+	}{
+		{"", "empty selector"},
+		{"  ", "empty selector"},
+		{"li, ", "empty selector"},
+		{"a:hover", "pseudo-classes are not supported"},
+		{"li:first-child a", "pseudo-classes are not supported"},
+		{"h1 + p", "sibling combinators are not supported"},
+		{"h1 ~ p", "sibling combinators are not supported"},
+		{"a[href", "unterminated attribute selector"},
+		{"a[href=", "unterminated attribute selector"},
+		{"a[href=x", "unterminated attribute selector"},
+		{`a[title="x]`, "unterminated string"},
+		{"a[]", "missing attribute name"},
+		{"a[lang|=tr]", "unsupported attribute operator"},
+		{"ul >", "selector ends with a combinator"},
+		{"ul > ", "selector ends with a combinator"},
+		{"ul > > li", `unexpected '>'`},
+		{"li#", "missing id"},
+		{"li.", "missing class"},
+		{"li..item", "missing class"},
+		{"li!", `unexpected '!'`},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.selector)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Compile(%q) error = %v, want %q", tt.selector, err, tt.message)
+		}
+	}
+}
+
+func TestSelectorAll(t *testing.T) {
+	doc := parsePage(t, selectorPage)
+	tests := []struct {
+		selector, want string
+	}{
+		{"li", "li1 li2"},
+		{"LI", "li1 li2"},
+		{"#ul", "ul"},
+		{".news", "list"},
+		{"li.item", "li1 li2"},
+		{"li.item.new", "li1"},
+		{"div.news.list#list", "list"},
+		{"ul > *", "li1 li2"},
+		{"p, ul", "ul p1"}, // document order, not selector order
+
+		// Attribute operators
+		{"a[href]", "a1 a2"},
+		{"[title]", "a1"},
+		{"a[name=x]", "a3"},
+		{"a[NAME='x']", "a3"},
+		{`a[title="Duyuru bir"]`, "a1"},
+		{`a[title="Duyuru"]`, ""},
+		{"a[data-tip~=önemli]", "a2"},
+		{"a[data-tip~=önem]", ""},
+		{"a[href*=duyuru]", "a1 a2"},
+		{"a[href*='']", ""},
+		{"a[href^=https]", "a2"},
+		{`a[href^=""]`, ""},
+		{`a[href$=".pdf"]`, "a1"},
+		{"a[ href $= '.pdf' ]", "a1"},
+		{"a[title][href]", "a1"},
+
+		// Combinators
+		{"li a", "a1 a2"},
+		{"li > a", "a1"},
+		{"li > span > a", "a2"},
+		{"div li > span a", "a2"},
+		{"div > li", ""},
+		{"#list a", "a1 a2"},
+		{"ul>li>a", "a1"},
+	}
+	for _, tt := range tests {
+		sel, err := Compile(tt.selector)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", tt.selector, err)
+			continue
+		}
+		if got := ids(sel.All(doc)); got != tt.want {
+			t.Errorf("%q matches %q, want %q", tt.selector, got, tt.want)
+		}
+		got := ""
+		if first := sel.First(doc); first != nil {
+			got = attr(first, "id")
+		}
+		if want := strings.SplitN(tt.want, " ", 2)[0]; got != want {
+			t.Errorf("%q first matches %q, want %q", tt.selector, got, want)
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		spec     string
+		selector string // "" for the item itself
+		attr     string
+		message  string // expected error
+	}{
+		{spec: ""},
+		{spec: "a", selector: "a"},
+		{spec: "a@href", selector: "a", attr: "href"},
+		{spec: " a @ HREF ", selector: "a", attr: "href"},
+		{spec: "@href", attr: "href"},
+		{spec: "time@datetime", selector: "time", attr: "datetime"},
+		// An @ inside a quoted value belongs to the selector
+		{spec: `a[title="x@y"]`, selector: `a[title="x@y"]`},
+		{spec: `a[href^='mailto:info@']`, selector: `a[href^='mailto:info@']`},
+		{spec: `a[title="x@y"]@href`, selector: `a[title="x@y"]`, attr: "href"},
+		{spec: `a[data-mail='a@b'] span`, selector: `a[data-mail='a@b'] span`},
+		{spec: "a@", message: "missing attribute after @"},
+		{spec: "a:hover@href", message: "pseudo-classes are not supported"},
+		{spec: `a[title="x@y]`, message: "unterminated string"},
+	}
+	for _, tt := range tests {
+		f, err := parseField(tt.spec)
+		if tt.message != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("parseField(%q) error = %v, want %q", tt.spec, err, tt.message)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseField(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		selector := ""
+		if f.sel != nil {
+			selector = f.sel.String()
+		}
+		if selector != tt.selector || f.attr != tt.attr {
+			t.Errorf("parseField(%q) = %q@%q, want %q@%q", tt.spec, selector, f.attr, tt.selector, tt.attr)
+		}
+	}
+}
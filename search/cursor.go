@@ -0,0 +1,73 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// ErrInvalidCursor is returned when a pagination token cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a sorted result list. Passing it back returns
+// the hits ranked strictly after it, so pages stay stable even when earlier
+// pages are not requested (search_after style pagination).
+type Cursor struct {
+	Score float64   `json:"s"`
+	Key   []byte    `json:"k,omitempty"` // sort key under date and title orders
+	ID    string    `json:"id"`
+	Sort  SortOrder `json:"o,omitempty"` // order the cursor was issued for; empty means relevance
+}
+
+// CursorFor returns the cursor pointing at hit within results sorted by order
+func CursorFor(hit Hit, order SortOrder) Cursor {
+	c := Cursor{Score: hit.Score, Key: hit.sortKey, ID: hit.Document.ID()}
+	if order != SortRelevance {
+		c.Sort = order
+	}
+	return c
+}
+
+// Encode returns the opaque token sent to clients
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Matches reports whether the cursor was issued for results sorted by order
+func (c Cursor) Matches(order SortOrder) bool {
+	if c.Sort == "" {
+		return order == "" || order == SortRelevance
+	}
+	return c.Sort == order
+}
+
+// After returns the position of the first hit ranked after the cursor. Scores
+// shift as documents are added and removed, so while the cursor's document
+// still matches, the page continues right after it; otherwise the cursor's
+// rank key places it.
+func (r *Results) After(c Cursor) int {
+	for i, hit := range r.Hits {
+		if hit.Document.ID() == c.ID {
+			return i + 1
+		}
+	}
+	at := rankKey{score: c.Score, key: c.Key, id: c.ID}
+	return sort.Search(len(r.Hits), func(i int) bool {
+		return r.Sort.before(at, r.Hits[i].rankKey())
+	})
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// page returns the numbers of up to limit hits after cursor, and the cursor
+// of the last of them
+func page(t *testing.T, idx *Index, q Query, after *Cursor, limit int) ([]int, Cursor) {
+	t.Helper()
+	results, err := idx.Search(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Search(%q) failed: %v", q.Text, err)
+	}
+	start := 0
+	if after != nil {
+		start = results.After(*after)
+	}
+	end := start + limit
+	if end > len(results.Hits) {
+		end = len(results.Hits)
+	}
+	var ids []int
+	for _, hit := range results.Hits[start:end] {
+		ids = append(ids, testNumber(hit.Document))
+	}
+	var next Cursor
+	if end > start {
+		next = CursorFor(results.Hits[end-1], results.Sort)
+	}
+	return ids, next
+}
+
+func TestCursorPagesThroughAllHits(t *testing.T) {
+	idx := NewIndex()
+	for n := 1; n <= 7; n++ {
+		// Fewer repetitions rank lower, so scores differ from page to page
+		idx.Index(testDocument(n, "Rapor", strings.Repeat("vergi ", 8-n)+strings.Repeat("ek ", n)))
+	}
+	q := Query{Text: "vergi"}
+
+	var seen []int
+	var cursor *Cursor
+	for i := 0; i < 4; i++ {
+		ids, next := page(t, idx, q, cursor, 3)
+		if len(ids) == 0 {
+			break
+		}
+		seen = append(seen, ids...)
+		decoded, err := DecodeCursor(next.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor(Encode()) failed: %v", err)
+		}
+		cursor = &decoded
+	}
+	if want := []int{1, 2, 3, 4, 5, 6, 7}; !equalInts(seen, want) {
+		t.Errorf("pages = %v, want %v", seen, want)
+	}
+}
+
+func TestCursorStableAcrossInsertsAndDeletes(t *testing.T) {
+	idx := NewIndex()
+	for n := 1; n <= 10; n++ {
+		idx.Index(testDocument(n, fmt.Sprintf("Rapor %d", n), "vergi"))
+	}
+	q := Query{Text: "vergi"}
+
+	first, cursor := page(t, idx, q, nil, 3)
+	if want := []int{1, 2, 3}; !equalInts(first, want) {
+		t.Fatalf("first page = %v, want %v", first, want)
+	}
+
+	// A new document changes every idf and so every score, and removing a
+	// third of the documents compacts the index and renumbers them
+	idx.Index(testDocument(11, "Rapor 11", "vergi"))
+	idx.Remove(testDocument(2, "", "").ID(), testDocument(5, "", "").ID(), testDocument(6, "", "").ID())
+	if idx.deleted != 0 {
+		t.Fatalf("index not compacted: %d tombstones", idx.deleted)
+	}
+
+	second, cursor := page(t, idx, q, &cursor, 3)
+	if want := []int{4, 7, 8}; !equalInts(second, want) {
+		t.Errorf("second page = %v, want %v", second, want)
+	}
+	third, _ := page(t, idx, q, &cursor, 3)
+	if want := []int{9, 10, 11}; !equalInts(third, want) {
+		t.Errorf("third page = %v, want %v", third, want)
+	}
+}
+
+func TestCursorAfterItsDocumentIsDeleted(t *testing.T) {
+	idx := NewIndex()
+	titles := []string{"Ceza", "Çevre", "Dernek", "Gümrük", "Harç", "İmar"}
+	for i, title := range titles {
+		idx.Index(testDocument(i+1, title+" yönetmeliği", ""))
+	}
+	q := Query{Text: "yönetmeliği", Sort: SortTitle}
+
+	first, cursor := page(t, idx, q, nil, 2)
+	if want := []int{1, 2}; !equalInts(first, want) {
+		t.Fatalf("first page = %v, want %v", first, want)
+	}
+	// The cursor's own document is gone; its title key still places the page
+	idx.Remove(testDocument(2, "", "").ID())
+	second, _ := page(t, idx, q, &cursor, 2)
+	if want := []int{3, 4}; !equalInts(second, want) {
+		t.Errorf("second page = %v, want %v", second, want)
+	}
+}
+
+func TestDecodeCursorRejectsMalformedTokens(t *testing.T) {
+	valid := Cursor{Score: 1.5, ID: "0123456789abcdef01234567", Sort: SortDateDesc}.Encode()
+	for _, token := range []string{
+		"not base64!",
+		"e30",                // {}: no document id
+		"bm90IGpzb24",        // "not json"
+		valid[:len(valid)-4], // truncated
+	} {
+		if _, err := DecodeCursor(token); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+
+	c, err := DecodeCursor(valid)
+	if err != nil {
+		t.Fatalf("DecodeCursor(valid) failed: %v", err)
+	}
+	if !c.Matches(SortDateDesc) || c.Matches(SortRelevance) {
+		t.Errorf("cursor issued for date_desc matches the wrong orders")
+	}
+	if relevance := (Cursor{ID: "x"}); !relevance.Matches(SortRelevance) || !relevance.Matches("") {
+		t.Errorf("relevance cursor does not match the default order")
+	}
+}
@@ -0,0 +1,189 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"legal-documents-api/models"
+)
+
+// Field identifies a searchable part of a document
+type Field int
+
+const (
+	FieldTitle Field = iota
+	FieldInstitution
+	FieldTags
+	FieldKeywords
+	FieldDescription
+	FieldContent
+	numFields
+)
+
+// fieldNames are the public names of each field, also used as match types
+var fieldNames = [numFields]string{
+	FieldTitle:       "title",
+	FieldInstitution: "institution",
+	FieldTags:        "tags",
+	FieldKeywords:    "keywords",
+	FieldDescription: "description",
+	FieldContent:     "content",
+}
+
+// fieldBoosts weight BM25 scores per field; titles matter most, body text least
+var fieldBoosts = [numFields]float64{
+	FieldTitle:       3.0,
+	FieldInstitution: 2.0,
+	FieldTags:        1.5,
+	FieldKeywords:    1.2,
+	FieldDescription: 1.0,
+	FieldContent:     1.0,
+}
+
+// String returns the public name of the field
+func (f Field) String() string {
+	if f < 0 || f >= numFields {
+		return "unknown"
+	}
+	return fieldNames[f]
+}
+
+// Document is a single searchable document: its metadata plus the text of
+// every indexed field. Content is only used while indexing and is not retained.
+type Document struct {
+	Metadata models.DocumentMetadata
+	KurumAdi string
+	Content  string
+}
+
+// ID returns the hex metadata id used as the document key
+func (d Document) ID() string {
+	return d.Metadata.ID.Hex()
+}
+
+// fieldText returns the raw text of a field
+func (d Document) fieldText(f Field) string {
+	switch f {
+	case FieldTitle:
+		return d.Metadata.PdfAdi
+	case FieldInstitution:
+		return d.KurumAdi
+	case FieldTags:
+		return d.Metadata.Etiketler
+	case FieldKeywords:
+		return d.Metadata.AnahtarKelimeler
+	case FieldDescription:
+		return d.Metadata.Aciklama
+	case FieldContent:
+		return d.Content
+	}
+	return ""
+}
+
+// Query describes a full-text search request
+type Query struct {
+	Text    string  // raw query, parsed with ParseQuery when Node is nil
+	Node    Node    // pre-parsed query
+	Filters Filters // optional structured filters
+	Facets  []Facet // facets to count over the full matching set
+	Fuzzy   bool    // match words missing from the index to close spellings
+
+	DateField DateField // date bounded by From/To and used by date sorts (default DatePublished)
+	From      time.Time // inclusive day bounds; zero means unbounded
+	To        time.Time
+	Sort      SortOrder // default SortRelevance
+}
+
+// Hit is a single matching document
+type Hit struct {
+	Document      *Document
+	Score         float64
+	MatchedFields []Field // in field priority order
+	MatchCount    int     // total term occurrences across all fields
+	sortKey       []byte  // date or title key under non-relevance sorts
+}
+
+func (h Hit) rankKey() rankKey {
+	return rankKey{score: h.Score, key: h.sortKey, id: h.Document.ID()}
+}
+
+// MatchedIn reports whether the query matched in field f
+func (h Hit) MatchedIn(f Field) bool {
+	for _, matched := range h.MatchedFields {
+		if matched == f {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchType summarises where the query matched, e.g. "title+content"
+func (h Hit) MatchType() string {
+	var primary string
+	content := false
+	for _, f := range h.MatchedFields {
+		if f == FieldContent {
+			content = true
+			continue
+		}
+		if primary == "" {
+			primary = f.String()
+		}
+	}
+	switch {
+	case primary == "":
+		return FieldContent.String()
+	case content:
+		return primary + "+content"
+	default:
+		return primary
+	}
+}
+
+// Results is the ranked outcome of a search
+type Results struct {
+	Hits     []Hit
+	Total    int
+	MaxScore float64
+	Facets   map[Facet][]FacetBucket // only the facets requested in the query
+	Fuzzy    bool                    // some words only matched through spelling corrections
+	Sort     SortOrder               // order of Hits
+}
+
+// SuggestQuery describes an autocomplete request
+type SuggestQuery struct {
+	Text    string
+	KurumID string
+	Limit   int
+}
+
+// Suggestion is a single autocomplete candidate
+type Suggestion struct {
+	Text  string
+	Count int   // number of documents containing the suggestion
+	Field Field // highest priority field the suggestion was found in
+	Fuzzy bool  // completes a corrected spelling of what was typed
+}
+
+// SearchEngine is implemented by every full-text search backend
+type SearchEngine interface {
+	// Search returns all documents matching the query, best match first
+	Search(ctx context.Context, q Query) (*Results, error)
+	// Suggest returns completions for a partially typed query
+	Suggest(ctx context.Context, q SuggestQuery) ([]Suggestion, error)
+	// SuggestionCount returns the document count of the completion equal to
+	// text, optionally of one institution; 0 when there is no such completion
+	SuggestionCount(text, kurumID string) int
+	// DidYouMean returns the query with misspelled words corrected, if any
+	DidYouMean(ctx context.Context, text string) (string, bool)
+	// Related returns the documents most similar to source, best first
+	Related(ctx context.Context, source Document, limit int) ([]Hit, error)
+	// Document returns the indexed document with the given metadata id
+	Document(id string) (*Document, bool)
+	// Index adds or replaces documents
+	Index(docs ...Document)
+	// Remove deletes documents by metadata id
+	Remove(ids ...string)
+	// Ready reports whether the engine has finished its initial build
+	Ready() bool
+}
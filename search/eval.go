@@ -0,0 +1,279 @@
+package search
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// accumulator collects the score of one document while a query is evaluated
+type accumulator struct {
+	score  float64
+	fields uint32 // bit per matched field
+	count  int    // matched term occurrences
+}
+
+// matchSet maps internal document ids to their accumulated scores
+type matchSet map[uint32]*accumulator
+
+func (m matchSet) add(doc uint32, f Field, score float64, count int) {
+	a := m[doc]
+	if a == nil {
+		a = &accumulator{}
+		m[doc] = a
+	}
+	a.score += score
+	a.fields |= 1 << uint(f)
+	a.count += count
+}
+
+// evalState carries per-query options and observations through evaluation
+type evalState struct {
+	fuzzy     bool // expand words missing from the index to close spellings
+	fuzzyUsed bool // set when some word was matched through a correction
+}
+
+// queryFields returns the fields a node scoped to f searches
+func queryFields(f Field) []Field {
+	if f != AnyField {
+		return []Field{f}
+	}
+	fields := make([]Field, numFields)
+	for i := range fields {
+		fields[i] = Field(i)
+	}
+	return fields
+}
+
+// evalLocked evaluates a parsed query against the index
+func (idx *Index) evalLocked(node Node, st *evalState) matchSet {
+	switch n := node.(type) {
+	case *TermNode:
+		return idx.evalTermLocked(n, st)
+	case *PhraseNode:
+		return idx.evalPhraseLocked(n)
+	case *AndNode:
+		return idx.evalAndLocked(n, st)
+	case *OrNode:
+		result := make(matchSet)
+		for _, child := range n.Children {
+			for doc, a := range idx.evalLocked(child, st) {
+				merge(result, doc, a)
+			}
+		}
+		return result
+	case *NotNode:
+		// A bare NOT never matches on its own; AndNode handles exclusion
+		return make(matchSet)
+	}
+	return make(matchSet)
+}
+
+func merge(m matchSet, doc uint32, a *accumulator) {
+	existing := m[doc]
+	if existing == nil {
+		copied := *a
+		m[doc] = &copied
+		return
+	}
+	existing.score += a.score
+	existing.fields |= a.fields
+	existing.count += a.count
+}
+
+func (idx *Index) evalTermLocked(n *TermNode, st *evalState) matchSet {
+	var matches []termMatch
+	if n.Prefix {
+		matches = idx.prefixTermsLocked(n.Term, 1)
+	} else {
+		matches = idx.expandLocked(n.Term)
+		if len(matches) == 0 && st.fuzzy {
+			matches = idx.fuzzyExpandLocked(n.Term)
+			st.fuzzyUsed = st.fuzzyUsed || len(matches) > 0
+		}
+	}
+
+	result := make(matchSet)
+	for _, match := range matches {
+		entry := idx.terms[match.term]
+		for _, f := range queryFields(n.Field) {
+			postings := entry.postings[f]
+			if len(postings) == 0 {
+				continue
+			}
+			idf := idx.idfLocked(len(postings))
+			avgLen := idx.avgLenLocked(f)
+			for _, p := range postings {
+				doc := idx.docs[p.doc]
+				if doc == nil {
+					continue
+				}
+				score := match.weight * fieldBoosts[f] * bm25(p.freq, doc.lengths[f], avgLen, idf)
+				result.add(p.doc, f, score, int(p.freq))
+			}
+		}
+	}
+	return result
+}
+
+// prefixTermsLocked returns every term starting with prefix, most frequent
+// first, capped at maxPrefixExpansions
+func (idx *Index) prefixTermsLocked(prefix string, weight float64) []termMatch {
+	var terms []string
+	for i := sort.SearchStrings(idx.lexicon, prefix); i < len(idx.lexicon); i++ {
+		term := idx.lexicon[i]
+		if !strings.HasPrefix(term, prefix) {
+			break
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) > maxPrefixExpansions {
+		sort.SliceStable(terms, func(i, j int) bool {
+			return idx.docFreqLocked(terms[i]) > idx.docFreqLocked(terms[j])
+		})
+		terms = terms[:maxPrefixExpansions]
+	}
+	matches := make([]termMatch, len(terms))
+	for i, term := range terms {
+		matches[i] = termMatch{term: term, weight: weight}
+	}
+	return matches
+}
+
+// evalPhraseLocked finds documents where the phrase terms occur consecutively
+// within a single field. The phrase frequency is scored like a term whose idf
+// is the sum of its words' idfs.
+func (idx *Index) evalPhraseLocked(n *PhraseNode) matchSet {
+	result := make(matchSet)
+	entries := make([]*termEntry, len(n.Terms))
+	for i, term := range n.Terms {
+		entries[i] = idx.terms[term]
+		if entries[i] == nil {
+			return result
+		}
+	}
+
+	for _, f := range queryFields(n.Field) {
+		lists := make([]map[uint32]posting, len(entries))
+		idf := 0.0
+		empty := false
+		for i, entry := range entries {
+			postings := entry.postings[f]
+			if len(postings) == 0 {
+				empty = true
+				break
+			}
+			idf += idx.idfLocked(len(postings))
+			lists[i] = make(map[uint32]posting, len(postings))
+			for _, p := range postings {
+				lists[i][p.doc] = p
+			}
+		}
+		if empty {
+			continue
+		}
+
+		avgLen := idx.avgLenLocked(f)
+		for _, first := range entries[0].postings[f] {
+			doc := idx.docs[first.doc]
+			if doc == nil {
+				continue
+			}
+			// Each later word must occur at start+i
+			candidates := make(map[uint32]bool)
+			for _, pos := range decodePositions(first.positions) {
+				candidates[pos] = true
+			}
+			for i := 1; i < len(entries) && len(candidates) > 0; i++ {
+				p, ok := lists[i][first.doc]
+				if !ok {
+					candidates = nil
+					break
+				}
+				present := make(map[uint32]bool)
+				for _, pos := range decodePositions(p.positions) {
+					present[pos] = true
+				}
+				for start := range candidates {
+					if !present[start+uint32(i)] {
+						delete(candidates, start)
+					}
+				}
+			}
+			if len(candidates) == 0 {
+				continue
+			}
+			freq := uint32(len(candidates))
+			score := fieldBoosts[f] * bm25(freq, doc.lengths[f], avgLen, idf)
+			result.add(first.doc, f, score, int(freq))
+		}
+	}
+	return result
+}
+
+// evalAndLocked intersects the positive children and removes documents that
+// match any negated child. Exclusions never match fuzzily.
+func (idx *Index) evalAndLocked(n *AndNode, st *evalState) matchSet {
+	var positives []matchSet
+	var negatives []matchSet
+	for _, child := range n.Children {
+		if not, ok := child.(*NotNode); ok {
+			negatives = append(negatives, idx.evalLocked(not.Child, &evalState{}))
+			continue
+		}
+		positives = append(positives, idx.evalLocked(child, st))
+	}
+	if len(positives) == 0 {
+		return make(matchSet)
+	}
+
+	// Start from the smallest set to keep the intersection cheap
+	sort.Slice(positives, func(i, j int) bool { return len(positives[i]) < len(positives[j]) })
+	result := make(matchSet)
+	for doc, a := range positives[0] {
+		merge(result, doc, a)
+	}
+	for _, set := range positives[1:] {
+		for doc := range result {
+			a, ok := set[doc]
+			if !ok {
+				delete(result, doc)
+				continue
+			}
+			merge(result, doc, a)
+		}
+	}
+	for _, set := range negatives {
+		for doc := range set {
+			delete(result, doc)
+		}
+	}
+	return result
+}
+
+func encodePositions(positions []uint32) []byte {
+	buf := make([]byte, 0, len(positions)*2)
+	var tmp [binary.MaxVarintLen32]byte
+	prev := uint32(0)
+	for _, pos := range positions {
+		n := binary.PutUvarint(tmp[:], uint64(pos-prev))
+		buf = append(buf, tmp[:n]...)
+		prev = pos
+	}
+	return buf
+}
+
+func decodePositions(buf []byte) []uint32 {
+	var positions []uint32
+	prev := uint32(0)
+	for len(buf) > 0 {
+		delta, n := binary.Uvarint(buf)
+		if n <= 0 {
+			break
+		}
+		prev += uint32(delta)
+		positions = append(positions, prev)
+		buf = buf[n:]
+	}
+	return positions
+}
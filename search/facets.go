@@ -0,0 +1,142 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"legal-documents-api/models"
+)
+
+// Facet names a document attribute results can be filtered and counted by
+type Facet string
+
+const (
+	FacetKurum       Facet = "kurum_id"
+	FacetBelgeTuru   Facet = "belge_turu"
+	FacetBelgeDurumu Facet = "belge_durumu"
+	FacetYil         Facet = "yil" // publication year from belge_yayin_tarihi
+)
+
+// AllFacets lists every supported facet in display order
+var AllFacets = []Facet{FacetKurum, FacetBelgeTuru, FacetBelgeDurumu, FacetYil}
+
+// Filters restricts results to documents whose facet value is one of the
+// listed values. Values within a facet are ORed, facets are ANDed.
+type Filters map[Facet][]string
+
+// ParseFacet returns the facet with the given name
+func ParseFacet(name string) (Facet, bool) {
+	for _, facet := range AllFacets {
+		if string(facet) == name {
+			return facet, true
+		}
+	}
+	return "", false
+}
+
+// FiltersFromMap converts filters keyed by facet name, as stored with saved
+// searches, rejecting unknown facets
+func FiltersFromMap(m map[string][]string) (Filters, error) {
+	filters := make(Filters, len(m))
+	for name, values := range m {
+		facet, ok := ParseFacet(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", name)
+		}
+		if len(values) > 0 {
+			filters[facet] = values
+		}
+	}
+	return filters, nil
+}
+
+// FacetBucket is the number of matching documents sharing one facet value
+type FacetBucket struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+var yearPattern = regexp.MustCompile(`\b(1[89]\d{2}|20\d{2})\b`)
+
+// publicationYear returns the four digit publication year of a document
+func publicationYear(metadata *models.DocumentMetadata) string {
+	if metadata.BelgeYayinDate != nil {
+		return strconv.Itoa(metadata.BelgeYayinDate.Year())
+	}
+	return yearPattern.FindString(metadata.BelgeYayinTarihi)
+}
+
+// facetValue returns the value of facet for a document
+func facetValue(doc *Document, facet Facet) string {
+	switch facet {
+	case FacetKurum:
+		return doc.Metadata.KurumID
+	case FacetBelgeTuru:
+		return doc.Metadata.BelgeTuru
+	case FacetBelgeDurumu:
+		return doc.Metadata.BelgeDurumu
+	case FacetYil:
+		return publicationYear(&doc.Metadata)
+	}
+	return ""
+}
+
+// Matches reports whether a document satisfies every filter
+func (f Filters) Matches(doc *Document) bool {
+	return f.passes(doc, "")
+}
+
+// passes reports whether a document satisfies every filter except skip
+func (f Filters) passes(doc *Document, skip Facet) bool {
+	for facet, values := range f {
+		if facet == skip || len(values) == 0 {
+			continue
+		}
+		value := facetValue(doc, facet)
+		matched := false
+		for _, v := range values {
+			if v == value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// countFacets builds buckets for the requested facets. Each facet is counted
+// over documents passing every other filter, so a selected value does not hide
+// its alternatives from the sidebar.
+func countFacets(docs []*Document, filters Filters, facets []Facet) map[Facet][]FacetBucket {
+	result := make(map[Facet][]FacetBucket, len(facets))
+	for _, facet := range facets {
+		counts := make(map[string]int)
+		for _, doc := range docs {
+			if !filters.passes(doc, facet) {
+				continue
+			}
+			if value := facetValue(doc, facet); value != "" {
+				counts[value]++
+			}
+		}
+
+		buckets := make([]FacetBucket, 0, len(counts))
+		for value, count := range counts {
+			buckets = append(buckets, FacetBucket{Value: value, Count: count})
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].Count != buckets[j].Count {
+				return buckets[i].Count > buckets[j].Count
+			}
+			return buckets[i].Value < buckets[j].Value
+		})
+		result[facet] = buckets
+	}
+	return result
+}
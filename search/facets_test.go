@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func facetDocument(n int, kurumID, turu, yayin string) Document {
+	doc := testDocument(n, "Yönetmelik", "kira")
+	doc.Metadata.KurumID = kurumID
+	doc.Metadata.BelgeTuru = turu
+	doc.Metadata.BelgeYayinTarihi = yayin
+	return doc
+}
+
+func TestFacetCountsUnderFilters(t *testing.T) {
+	idx := newTestIndex(
+		facetDocument(1, "sgk", "Yönetmelik", "01.02.2021"),
+		facetDocument(2, "sgk", "Genelge", "2022-05-10"),
+		facetDocument(3, "sgk", "Genelge", "Resmi Gazete 2022"),
+		facetDocument(4, "gib", "Genelge", "2021-07-01"),
+		facetDocument(5, "gib", "Tebliğ", ""),
+	)
+	results, err := idx.Search(context.Background(), Query{
+		Text:    "kira",
+		Filters: Filters{FacetKurum: {"sgk"}, FacetBelgeTuru: {"Genelge"}},
+		Facets:  []Facet{FacetKurum, FacetBelgeTuru, FacetYil},
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if results.Total != 2 {
+		t.Errorf("Total = %d, want 2", results.Total)
+	}
+
+	tests := []struct {
+		facet Facet
+		want  []FacetBucket
+	}{
+		// Each facet is counted ignoring its own filter, so the other
+		// institutions and types stay selectable
+		{FacetKurum, []FacetBucket{{Value: "sgk", Count: 2}, {Value: "gib", Count: 1}}},
+		{FacetBelgeTuru, []FacetBucket{{Value: "Genelge", Count: 2}, {Value: "Yönetmelik", Count: 1}}},
+		// Years come from any date spelling; both filters apply
+		{FacetYil, []FacetBucket{{Value: "2022", Count: 2}}},
+	}
+	for _, tt := range tests {
+		got := results.Facets[tt.facet]
+		if !equalBuckets(got, tt.want) {
+			t.Errorf("facet %s = %v, want %v", tt.facet, got, tt.want)
+		}
+	}
+}
+
+func TestFacetCountsOrderAndTypedYear(t *testing.T) {
+	published := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	typed := facetDocument(4, "gib", "Genelge", "bilinmiyor")
+	typed.Metadata.BelgeYayinDate = &published
+	idx := newTestIndex(
+		facetDocument(1, "sgk", "Genelge", ""),
+		facetDocument(2, "meb", "Genelge", ""),
+		facetDocument(3, "gib", "Genelge", ""),
+		typed,
+	)
+	results, err := idx.Search(context.Background(), Query{Text: "kira", Facets: []Facet{FacetKurum, FacetYil}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// Largest count first, ties by value
+	want := []FacetBucket{{Value: "gib", Count: 2}, {Value: "meb", Count: 1}, {Value: "sgk", Count: 1}}
+	if got := results.Facets[FacetKurum]; !equalBuckets(got, want) {
+		t.Errorf("kurum_id buckets = %v, want %v", got, want)
+	}
+	if got, want := results.Facets[FacetYil], []FacetBucket{{Value: "2019", Count: 1}}; !equalBuckets(got, want) {
+		t.Errorf("yil buckets = %v, want %v", got, want)
+	}
+	if _, requested := results.Facets[FacetBelgeTuru]; requested {
+		t.Errorf("unrequested facet belge_turu was counted")
+	}
+}
+
+func TestFiltersFromMap(t *testing.T) {
+	filters, err := FiltersFromMap(map[string][]string{"kurum_id": {"sgk"}, "yil": nil})
+	if err != nil {
+		t.Fatalf("FiltersFromMap failed: %v", err)
+	}
+	if len(filters) != 1 || len(filters[FacetKurum]) != 1 {
+		t.Errorf("FiltersFromMap = %v, want only kurum_id", filters)
+	}
+	if _, err := FiltersFromMap(map[string][]string{"status": {"aktif"}}); err == nil {
+		t.Errorf("FiltersFromMap accepted an unknown facet")
+	}
+}
+
+func equalBuckets(a, b []FacetBucket) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Value != b[i].Value || a[i].Count != b[i].Count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"legal-documents-api/textnorm"
+)
+
+const (
+	// fuzzyWeight scales the score of a one-edit correction; two edits score half
+	fuzzyWeight = 0.5
+	// maxFuzzyExpansions caps how many index terms a misspelled token expands to
+	maxFuzzyExpansions = 16
+	// rareTermFrequency is the document frequency below which DidYouMean looks
+	// for a much more common spelling of a word that does exist
+	rareTermFrequency = 3
+)
+
+// Edit distance is measured between folded terms, so the Turkish letters
+// ç ğ ı ö ş ü already equal their ASCII counterparts: "teblig", "tebliğ" and
+// "TEBLİĞ" are the same term and only genuine typos cost an edit.
+
+// maxEdits returns how many edits a token of n runes tolerates
+func maxEdits(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and b
+// (insertions, deletions, substitutions and adjacent transpositions), or
+// max+1 once it is certain to exceed max
+func editDistance(a, b []rune, max int) int {
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return max + 1
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := prev[j-1] + cost
+			if v := prev[j] + 1; v < d {
+				d = v
+			}
+			if v := curr[j-1] + 1; v < d {
+				d = v
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if v := prev2[j-2] + 1; v < d {
+					d = v
+				}
+			}
+			curr[j] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+// fuzzyMatch is an index term within edit distance of a query token
+type fuzzyMatch struct {
+	term     string
+	distance int
+	docFreq  int
+}
+
+// fuzzyTermsLocked returns the index terms within the allowed edit distance of
+// token, closest and most frequent first. The first letter must match, which
+// keeps the lexicon scan to a single sorted range; typos there are rare.
+// When prefix is set, terms are compared by their leading runes only, for
+// completing a partially typed word.
+func (idx *Index) fuzzyTermsLocked(token string, prefix bool) []fuzzyMatch {
+	query := []rune(token)
+	max := maxEdits(len(query))
+	if max == 0 {
+		return nil
+	}
+
+	first, size := utf8.DecodeRuneInString(token)
+	from := sort.SearchStrings(idx.lexicon, token[:size])
+	var matches []fuzzyMatch
+	for i := from; i < len(idx.lexicon); i++ {
+		term := idx.lexicon[i]
+		if r, _ := utf8.DecodeRuneInString(term); r != first {
+			break
+		}
+		if term == token {
+			continue
+		}
+		candidate := []rune(term)
+		if prefix && len(candidate) > len(query) {
+			candidate = candidate[:len(query)]
+		}
+		if d := editDistance(query, candidate, max); d <= max {
+			matches = append(matches, fuzzyMatch{term: term, distance: d, docFreq: idx.docFreqLocked(term)})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		if matches[i].docFreq != matches[j].docFreq {
+			return matches[i].docFreq > matches[j].docFreq
+		}
+		return matches[i].term < matches[j].term
+	})
+	if len(matches) > maxFuzzyExpansions {
+		matches = matches[:maxFuzzyExpansions]
+	}
+	return matches
+}
+
+// fuzzyExpandLocked expands a token that is not in the index to its likely
+// corrections, each weighted down by its distance
+func (idx *Index) fuzzyExpandLocked(token string) []termMatch {
+	var matches []termMatch
+	for _, m := range idx.fuzzyTermsLocked(token, false) {
+		matches = append(matches, termMatch{term: m.term, weight: fuzzyWeight / float64(m.distance)})
+	}
+	return matches
+}
+
+// correctionLocked returns a better spelling for token: the closest, most
+// frequent term when token is unknown, or a one-edit variant that is far more
+// common when token is rare
+func (idx *Index) correctionLocked(token string) (string, bool) {
+	df := idx.docFreqLocked(token)
+	if df >= rareTermFrequency {
+		return "", false
+	}
+	if df == 0 && utf8.RuneCountInString(token) >= prefixMinLength && len(idx.prefixTermsLocked(token, 1)) > 0 {
+		return "", false // a partially typed word, not a typo
+	}
+	for _, m := range idx.fuzzyTermsLocked(token, false) {
+		if df == 0 || (m.distance == 1 && m.docFreq >= 10*df) {
+			return m.term, true
+		}
+	}
+	return "", false
+}
+
+// DidYouMean implements SearchEngine. Words of the query that are unknown or
+// rare are replaced by their most likely correction; operators, field names
+// and punctuation are kept as typed.
+func (idx *Index) DidYouMean(ctx context.Context, text string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	source := textnorm.NewText(text)
+	var b strings.Builder
+	last := 0
+	changed := false
+	for _, word := range source.Words() {
+		if ctx.Err() != nil {
+			return "", false
+		}
+		raw := text[word.Start:word.End]
+		if raw == "AND" || raw == "OR" || raw == "NOT" || strings.HasPrefix(text[word.End:], ":") {
+			continue
+		}
+		correction, ok := idx.correctionLocked(word.Term)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:word.Start])
+		b.WriteString(matchCase(raw, idx.displayLocked(correction)))
+		last = word.End
+		changed = true
+	}
+	if !changed {
+		return "", false
+	}
+	b.WriteString(text[last:])
+	return b.String(), true
+}
+
+// matchCase capitalises the correction when the typed word was capitalised
+func matchCase(typed, correction string) string {
+	r, _ := utf8.DecodeRuneInString(typed)
+	if !unicode.IsUpper(r) {
+		return correction
+	}
+	c, size := utf8.DecodeRuneInString(correction)
+	return string(unicode.TurkishCase.ToUpper(c)) + correction[size:]
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"kanun", "kanun", 2, 0},
+		{"kanun", "kaunn", 2, 1}, // adjacent transposition
+		{"kanun", "kanu", 2, 1},
+		{"kanun", "kanunu", 2, 1},
+		{"kanun", "kalun", 2, 1},
+		{"yonetmelik", "yontemelik", 2, 1},
+		{"yonetmelik", "yonetmlik", 2, 1},
+		{"yonetmelik", "yntemelik", 2, 2},
+		// Past max the distance is only known to exceed it
+		{"kanun", "karar", 1, 2},
+		{"kanun", "kanunlarin", 2, 3},
+		{"", "abc", 3, 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b), tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestMaxEdits(t *testing.T) {
+	for n, want := range map[int]int{0: 0, 3: 0, 4: 1, 6: 1, 7: 2, 20: 2} {
+		if got := maxEdits(n); got != want {
+			t.Errorf("maxEdits(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestFuzzyTerms(t *testing.T) {
+	var docs []Document
+	for i := 1; i <= 12; i++ {
+		docs = append(docs, testDocument(i, "Tebliğ", "yönetmelik kanun"))
+	}
+	docs = append(docs,
+		testDocument(13, "Tebliğler", "yönetmelikler kanunu"),
+		testDocument(14, "Vergi", "sgk sgb"),
+	)
+	idx := newTestIndex(docs...)
+
+	terms := func(token string, prefix bool) string {
+		idx.mu.RLock()
+		defer idx.mu.RUnlock()
+		var got []string
+		for _, m := range idx.fuzzyTermsLocked(token, prefix) {
+			got = append(got, fmt.Sprintf("%s/%d", m.term, m.distance))
+		}
+		return fmt.Sprint(got)
+	}
+	tests := []struct {
+		token  string
+		prefix bool
+		want   string
+	}{
+		{"kanin", false, "[kanun/1]"},
+		{"yonetmleik", false, "[yonetmelik/1]"},
+		{"teblgi", false, "[teblig/1]"},
+		// Three-rune words are never fuzzed
+		{"sgc", false, "[]"},
+		// The first letter must match
+		{"ganun", false, "[]"},
+		// Prefixes compare the leading runes of longer terms
+		{"yonetm", true, "[yonetmelik/0 yonetmelikler/0]"},
+		{"yontem", true, "[yonetmelik/1 yonetmelikler/1]"},
+	}
+	for _, tt := range tests {
+		if got := terms(tt.token, tt.prefix); got != tt.want {
+			t.Errorf("fuzzyTermsLocked(%q, %v) = %s, want %s", tt.token, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	var docs []Document
+	for i := 1; i <= 12; i++ {
+		docs = append(docs, testDocument(i, "İşçi Sağlığı Tebliği", "ılık su işyeri yönetmeliği"))
+	}
+	// A rare misspelling found in one document
+	docs = append(docs, testDocument(13, "Yönetmeligi", "teblii"))
+	idx := newTestIndex(docs...)
+
+	tests := []struct {
+		text, want string
+	}{
+		{"işçi sağlıgı", ""}, // already matches: folded letters are no typo
+		{"İşci", ""},
+		{"Tebliği", ""},
+		{"tebliği isçi", ""},
+		{"tebiği", "tebliği"},
+		{"Tebiği", "Tebliği"},
+		{"İsyeri", ""},
+		{"İşyri", "İşyeri"},
+		{"Ilik", ""},
+		{"Ilık sü", ""},
+		{"Iyık su", "Ilık su"},
+		// A rare spelling gives way to one far more common
+		{"yönetmeligi teblii", "yönetmeligi tebliği"},
+		// Operators and field names are kept
+		{"baslik:tebiği AND işyri", "baslik:tebliği AND işyeri"},
+		{"xyz", ""},
+	}
+	for _, tt := range tests {
+		got, ok := idx.DidYouMean(context.Background(), tt.text)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("DidYouMean(%q) = %q, %v, want %q", tt.text, got, ok, tt.want)
+		}
+	}
+}
+
+func TestMatchCase(t *testing.T) {
+	tests := []struct {
+		typed, correction, want string
+	}{
+		{"istanbul", "istanbul", "istanbul"},
+		{"İstanbul", "istanbul", "İstanbul"},
+		{"Isparta", "ılık", "Ilık"},
+		{"Ilık", "ılık", "Ilık"},
+		{"ılık", "ılık", "ılık"},
+		{"İSTANBUL", "işçi", "İşçi"},
+		{"Çanta", "çalışma", "Çalışma"},
+		{"5510", "kanun", "kanun"},
+	}
+	for _, tt := range tests {
+		if got := matchCase(tt.typed, tt.correction); got != tt.want {
+			t.Errorf("matchCase(%q, %q) = %q, want %q", tt.typed, tt.correction, got, tt.want)
+		}
+	}
+}
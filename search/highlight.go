@@ -0,0 +1,250 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"legal-documents-api/textnorm"
+)
+
+// Span is a [Start, End) range of runes within a fragment's text
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Fragment is a window of a field's text around one or more query matches
+type Fragment struct {
+	Text    string  `json:"text"`
+	Matches []Span  `json:"matches,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// Marked returns the fragment text, HTML escaped, with every match wrapped in
+// preTag and postTag. The tags are written as given, so callers must build
+// them rather than pass user input through
+func (f Fragment) Marked(preTag, postTag string) string {
+	runes := []rune(f.Text)
+	var b strings.Builder
+	last := 0
+	for _, m := range f.Matches {
+		b.WriteString(html.EscapeString(string(runes[last:m.Start])))
+		b.WriteString(preTag)
+		b.WriteString(html.EscapeString(string(runes[m.Start:m.End])))
+		b.WriteString(postTag)
+		last = m.End
+	}
+	b.WriteString(html.EscapeString(string(runes[last:])))
+	return b.String()
+}
+
+// highlightTerm is a single positive query word or phrase
+type highlightTerm struct {
+	field  Field
+	words  []string
+	prefix bool   // last word matches as a prefix
+	fuzzy  []rune // set for single words that also match close spellings
+}
+
+// Highlighter locates the positive parts of a query in document text
+type Highlighter struct {
+	terms []highlightTerm
+}
+
+// NewHighlighter prepares a highlighter for a parsed query. Negated parts are
+// ignored; single words of prefixMinLength runes or more also match as
+// prefixes, mirroring how the index expands them. With fuzzy set, words also
+// match their misspellings the way a fuzzy search does.
+func NewHighlighter(node Node, fuzzy bool) *Highlighter {
+	h := &Highlighter{}
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *TermNode:
+			term := highlightTerm{field: n.Field, words: []string{n.Term}}
+			term.prefix = n.Prefix || utf8.RuneCountInString(n.Term) >= prefixMinLength
+			if fuzzy && !n.Prefix {
+				term.fuzzy = []rune(n.Term)
+			}
+			h.terms = append(h.terms, term)
+		case *PhraseNode:
+			h.terms = append(h.terms, highlightTerm{field: n.Field, words: n.Terms})
+		case *AndNode:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		case *OrNode:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return h
+}
+
+// textMatch is a match in source text, covering words[first:last+1]
+type textMatch struct {
+	first, last int
+	term        int
+}
+
+// Fragments returns up to max fragments of roughly size runes from text,
+// best first. Fragments covering more distinct query terms rank higher, so a
+// window containing every word of a multi-word query beats one that repeats a
+// single word.
+func (h *Highlighter) Fragments(field Field, text string, max, size int) []Fragment {
+	if max <= 0 || text == "" {
+		return nil
+	}
+	words := textnorm.NewText(text).Words()
+	matches := h.locate(field, words)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// Candidate windows start at each match and take in the following matches
+	// that still fit
+	type window struct {
+		from, to int // indexes into matches, inclusive
+		score    float64
+	}
+	var windows []window
+	for i := range matches {
+		j := i
+		for j+1 < len(matches) && runeLen(text, words[matches[i].first].Start, words[matches[j+1].last].End) <= size {
+			j++
+		}
+		distinct := make(map[int]bool)
+		for k := i; k <= j; k++ {
+			distinct[matches[k].term] = true
+		}
+		windows = append(windows, window{from: i, to: j, score: float64(len(distinct))*2 + float64(j-i+1)})
+	}
+	sort.SliceStable(windows, func(i, j int) bool { return windows[i].score > windows[j].score })
+
+	var fragments []Fragment
+	var taken [][2]int // word ranges already used
+	for _, win := range windows {
+		if len(fragments) == max {
+			break
+		}
+		first, last := matches[win.from].first, matches[win.to].last
+		first, last = growWindow(text, words, first, last, size)
+
+		overlaps := false
+		for _, r := range taken {
+			if first <= r[1] && last >= r[0] {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		taken = append(taken, [2]int{first, last})
+		fragments = append(fragments, buildFragment(text, words, matches, first, last, win.score))
+	}
+	return fragments
+}
+
+// locate finds every occurrence of the query terms among words
+func (h *Highlighter) locate(field Field, words []textnorm.Word) []textMatch {
+	var matches []textMatch
+	for i := 0; i < len(words); i++ {
+		for t, term := range h.terms {
+			if term.field != AnyField && term.field != field {
+				continue
+			}
+			if i+len(term.words) > len(words) {
+				continue
+			}
+			matched := true
+			for k, w := range term.words {
+				got := words[i+k].Term
+				if got == w {
+					continue
+				}
+				if k == len(term.words)-1 && term.prefix && strings.HasPrefix(got, w) {
+					continue
+				}
+				if term.fuzzy != nil && fuzzyEqual(term.fuzzy, got) {
+					continue
+				}
+				matched = false
+				break
+			}
+			if matched {
+				matches = append(matches, textMatch{first: i, last: i + len(term.words) - 1, term: t})
+				i += len(term.words) - 1
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// growWindow widens words[first:last+1] with surrounding context, alternating
+// sides, while the window stays within size runes
+func growWindow(text string, words []textnorm.Word, first, last, size int) (int, int) {
+	for {
+		grown := false
+		if first > 0 && runeLen(text, words[first-1].Start, words[last].End) <= size {
+			first--
+			grown = true
+		}
+		if last+1 < len(words) && runeLen(text, words[first].Start, words[last+1].End) <= size {
+			last++
+			grown = true
+		}
+		if !grown {
+			return first, last
+		}
+	}
+}
+
+// buildFragment cuts words[first:last+1] out of text, adding ellipses where the
+// fragment does not reach the ends of the text
+func buildFragment(text string, words []textnorm.Word, matches []textMatch, first, last int, score float64) Fragment {
+	start, end := words[first].Start, words[last].End
+	var b strings.Builder
+	offset := 0
+	if first > 0 {
+		b.WriteString("...")
+		offset = 3
+	}
+	b.WriteString(text[start:end])
+	if last < len(words)-1 {
+		b.WriteString("...")
+	}
+
+	fragment := Fragment{Text: b.String(), Score: score}
+	for _, m := range matches {
+		if m.first < first || m.last > last {
+			continue
+		}
+		from := offset + runeLen(text, start, words[m.first].Start)
+		to := offset + runeLen(text, start, words[m.last].End)
+		fragment.Matches = append(fragment.Matches, Span{Start: from, End: to})
+	}
+	return fragment
+}
+
+// fuzzyEqual reports whether word is within the edit distance a fuzzy search
+// tolerates for term, sharing its first letter
+func fuzzyEqual(term []rune, word string) bool {
+	max := maxEdits(len(term))
+	if max == 0 {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(word); r != term[0] {
+		return false
+	}
+	return editDistance(term, []rune(word), max) <= max
+}
+
+func runeLen(text string, start, end int) int {
+	return utf8.RuneCountInString(text[start:end])
+}
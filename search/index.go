@@ -0,0 +1,449 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
+
+	"legal-documents-api/textnorm"
+	"legal-documents-api/utils"
+)
+
+// BM25 tuning parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+const (
+	// prefixMinLength is the shortest query token expanded to longer index terms,
+	// so "sözleşme" also matches "sözleşmesi" and "sözleşmeler"
+	prefixMinLength = 4
+	// prefixWeight scales the score of a prefix expansion relative to an exact match
+	prefixWeight = 0.7
+	// maxPrefixExpansions caps how many index terms a single token expands to
+	maxPrefixExpansions = 64
+)
+
+type posting struct {
+	doc       uint32
+	freq      uint32
+	positions []byte // varint-encoded deltas of word positions, used for phrase queries
+}
+
+type termEntry struct {
+	postings [numFields][]posting
+	display  string // Turkish-lowercased surface form, used for suggestions and corrections
+}
+
+type indexedDoc struct {
+	doc       Document
+	lengths   [numFields]uint32
+	published []byte // day keys of the parsed dates, nil when unparsable
+	uploaded  []byte
+	titleKey  []byte // collation key of the title
+
+	contentHash string // utils.ContentHash of the indexed content
+}
+
+// Index is an in-memory inverted index with BM25 ranking. It is safe for
+// concurrent use; writers take an exclusive lock.
+type Index struct {
+	mu       sync.RWMutex
+	terms    map[string]*termEntry
+	lexicon  []string // sorted terms for prefix lookups
+	added    []string // terms not merged into the lexicon yet
+	docs     []*indexedDoc
+	byID     map[string]uint32
+	fieldLen [numFields]uint64
+	live     int
+	deleted  int
+	ready    bool
+	collator *collate.Collator // builds title sort keys; guarded by mu
+
+	// generation counts document changes; the suggestion dictionary is
+	// rebuilt by RefreshSuggestions when it was built from an older one
+	generation        uint64
+	suggest           *suggesters
+	suggestGeneration uint64
+}
+
+// NewIndex returns an empty index
+func NewIndex() *Index {
+	return &Index{
+		terms:    make(map[string]*termEntry),
+		byID:     make(map[string]uint32),
+		collator: newTitleCollator(),
+	}
+}
+
+// Ready reports whether the initial build has completed
+func (idx *Index) Ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ready
+}
+
+// MarkReady flags the initial build as complete
+func (idx *Index) MarkReady() {
+	idx.mu.Lock()
+	idx.ready = true
+	idx.mu.Unlock()
+}
+
+// Len returns the number of live documents
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.live
+}
+
+// Document returns the indexed document with the given metadata id
+func (idx *Index) Document(id string) (*Document, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	internal, ok := idx.byID[id]
+	if !ok {
+		return nil, false
+	}
+	doc := idx.docs[internal].doc
+	return &doc, true
+}
+
+// contentHash returns the hash of the content a document was indexed with
+func (idx *Index) contentHash(id string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	internal, ok := idx.byID[id]
+	if !ok {
+		return "", false
+	}
+	return idx.docs[internal].contentHash, true
+}
+
+// IDs returns the metadata ids of all live documents
+func (idx *Index) IDs() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	ids := make([]string, 0, len(idx.byID))
+	for id := range idx.byID {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Index adds or replaces documents
+func (idx *Index) Index(docs ...Document) {
+	if len(docs) == 0 {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, doc := range docs {
+		idx.removeLocked(doc.ID())
+		idx.addLocked(doc)
+	}
+	idx.maintainLocked()
+}
+
+// Remove deletes documents by metadata id
+func (idx *Index) Remove(ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, id := range ids {
+		idx.removeLocked(id)
+	}
+	idx.maintainLocked()
+}
+
+func (idx *Index) addLocked(doc Document) {
+	internal := uint32(len(idx.docs))
+	entry := &indexedDoc{}
+
+	for f := Field(0); f < numFields; f++ {
+		text := textnorm.NewText(doc.fieldText(f))
+		words := text.Words()
+		entry.lengths[f] = uint32(len(words))
+		idx.fieldLen[f] += uint64(len(words))
+
+		positions := make(map[string][]uint32, len(words))
+		for pos, word := range words {
+			positions[word.Term] = append(positions[word.Term], uint32(pos))
+		}
+		for _, word := range words {
+			termPositions, pending := positions[word.Term]
+			if !pending {
+				continue
+			}
+			delete(positions, word.Term)
+
+			term := idx.terms[word.Term]
+			if term == nil {
+				term = &termEntry{}
+				idx.terms[word.Term] = term
+				idx.added = append(idx.added, word.Term)
+			}
+			term.postings[f] = append(term.postings[f], posting{
+				doc:       internal,
+				freq:      uint32(len(termPositions)),
+				positions: encodePositions(termPositions),
+			})
+			if term.display == "" {
+				term.display = textnorm.ToLower(text.Source[word.Start:word.End])
+			}
+		}
+	}
+
+	// Sort and range keys, from the typed dates once they are backfilled
+	entry.published = documentDateKey(doc.Metadata.BelgeYayinDate, doc.Metadata.BelgeYayinTarihi)
+	entry.uploaded = documentDateKey(doc.Metadata.YuklemeDate, doc.Metadata.YuklemeTarihi)
+	if key := idx.collator.KeyFromString(&collate.Buffer{}, doc.Metadata.PdfAdi); len(key) > 0 {
+		entry.titleKey = append([]byte(nil), key...)
+	}
+
+	// The body is only needed to build postings; its hash notices edits
+	entry.contentHash = utils.ContentHash(doc.Content)
+	doc.Content = ""
+	entry.doc = doc
+
+	idx.docs = append(idx.docs, entry)
+	idx.byID[doc.ID()] = internal
+	idx.live++
+}
+
+func (idx *Index) removeLocked(id string) {
+	internal, ok := idx.byID[id]
+	if !ok {
+		return
+	}
+	entry := idx.docs[internal]
+	for f := Field(0); f < numFields; f++ {
+		idx.fieldLen[f] -= uint64(entry.lengths[f])
+	}
+	idx.docs[internal] = nil
+	delete(idx.byID, id)
+	idx.live--
+	idx.deleted++
+}
+
+// maintainLocked compacts tombstoned postings once they make up a quarter of
+// the index and merges the terms added since the last call into the sorted
+// lexicon. A write touching a few documents sorts only their new terms.
+func (idx *Index) maintainLocked() {
+	idx.generation++
+	if idx.deleted > 0 && idx.deleted*4 >= idx.live {
+		idx.compactLocked()
+	}
+	if len(idx.added) == 0 {
+		return
+	}
+	added := idx.added[:0]
+	for _, term := range idx.added {
+		// Terms emptied by the compaction above are gone again
+		if _, ok := idx.terms[term]; ok {
+			added = append(added, term)
+		}
+	}
+	sort.Strings(added)
+	idx.lexicon = mergeSorted(idx.lexicon, added)
+	idx.added = idx.added[:0]
+}
+
+// mergeSorted merges the sorted terms b into the sorted terms a in place,
+// working from the back so that each term of a moves at most once
+func mergeSorted(a, b []string) []string {
+	n := len(a)
+	for range b {
+		a = append(a, "")
+	}
+	i, j := n-1, len(b)-1
+	for k := len(a) - 1; j >= 0; k-- {
+		if i >= 0 && a[i] > b[j] {
+			a[k] = a[i]
+			i--
+		} else {
+			a[k] = b[j]
+			j--
+		}
+	}
+	return a
+}
+
+func (idx *Index) compactLocked() {
+	remap := make([]int64, len(idx.docs))
+	docs := make([]*indexedDoc, 0, idx.live)
+	for i, entry := range idx.docs {
+		if entry == nil {
+			remap[i] = -1
+			continue
+		}
+		remap[i] = int64(len(docs))
+		idx.byID[entry.doc.ID()] = uint32(len(docs))
+		docs = append(docs, entry)
+	}
+
+	for key, term := range idx.terms {
+		empty := true
+		for f := Field(0); f < numFields; f++ {
+			kept := term.postings[f][:0]
+			for _, p := range term.postings[f] {
+				if remap[p.doc] >= 0 {
+					p.doc = uint32(remap[p.doc])
+					kept = append(kept, p)
+				}
+			}
+			term.postings[f] = kept
+			if len(kept) > 0 {
+				empty = false
+			}
+		}
+		if empty {
+			delete(idx.terms, key)
+		}
+	}
+
+	// Drop the emptied terms from the lexicon, keeping its order
+	lexicon := idx.lexicon[:0]
+	for _, term := range idx.lexicon {
+		if _, ok := idx.terms[term]; ok {
+			lexicon = append(lexicon, term)
+		}
+	}
+	idx.lexicon = lexicon
+	idx.docs = docs
+	idx.deleted = 0
+}
+
+// idfLocked is the BM25 inverse document frequency for a posting list
+func (idx *Index) idfLocked(df int) float64 {
+	n := float64(idx.live)
+	d := float64(df)
+	return math.Log(1 + (n-d+0.5)/(d+0.5))
+}
+
+func (idx *Index) avgLenLocked(f Field) float64 {
+	if idx.live == 0 {
+		return 1
+	}
+	avg := float64(idx.fieldLen[f]) / float64(idx.live)
+	if avg <= 0 {
+		return 1
+	}
+	return avg
+}
+
+func bm25(freq, length uint32, avgLen, idf float64) float64 {
+	tf := float64(freq)
+	norm := bm25K1 * (1 - bm25B + bm25B*float64(length)/avgLen)
+	return idf * tf * (bm25K1 + 1) / (tf + norm)
+}
+
+type termMatch struct {
+	term   string
+	weight float64
+}
+
+// expandLocked resolves a query token to index terms: the exact term plus, for
+// long enough tokens, the most common terms it is a prefix of
+func (idx *Index) expandLocked(token string) []termMatch {
+	var matches []termMatch
+	if _, ok := idx.terms[token]; ok {
+		matches = append(matches, termMatch{term: token, weight: 1})
+	}
+	if utf8.RuneCountInString(token) < prefixMinLength {
+		return matches
+	}
+
+	for _, expansion := range idx.prefixTermsLocked(token, prefixWeight) {
+		if expansion.term != token {
+			matches = append(matches, expansion)
+		}
+	}
+	return matches
+}
+
+func (idx *Index) docFreqLocked(term string) int {
+	entry := idx.terms[term]
+	if entry == nil {
+		return 0
+	}
+	df := 0
+	for f := Field(0); f < numFields; f++ {
+		df += len(entry.postings[f])
+	}
+	return df
+}
+
+// Search implements SearchEngine. The query text is parsed with ParseQuery
+// unless a parsed Node is supplied; documents are ranked by the sum of
+// field-boosted BM25 scores of the parts they match.
+func (idx *Index) Search(ctx context.Context, q Query) (*Results, error) {
+	node := q.Node
+	if node == nil {
+		parsed, err := ParseQuery(q.Text)
+		if err != nil {
+			return nil, err
+		}
+		node = parsed
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	st := &evalState{fuzzy: q.Fuzzy}
+	matches := idx.evalLocked(node, st)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := &Results{Fuzzy: st.fuzzyUsed, Sort: q.Sort}
+	var matched []*Document
+	for internal, a := range matches {
+		entry := idx.docs[internal]
+		if entry == nil || !q.inDateRange(entry) {
+			continue
+		}
+		if len(q.Facets) > 0 {
+			matched = append(matched, &entry.doc)
+		}
+		if !q.Filters.passes(&entry.doc, "") {
+			continue
+		}
+		doc := entry.doc
+		hit := Hit{Document: &doc, Score: a.score, MatchCount: a.count, sortKey: q.sortKey(entry)}
+		for f := Field(0); f < numFields; f++ {
+			if a.fields&(1<<uint(f)) != 0 {
+				hit.MatchedFields = append(hit.MatchedFields, f)
+			}
+		}
+		results.Hits = append(results.Hits, hit)
+		if a.score > results.MaxScore {
+			results.MaxScore = a.score
+		}
+	}
+
+	sort.Slice(results.Hits, func(i, j int) bool {
+		return q.Sort.before(results.Hits[i].rankKey(), results.Hits[j].rankKey())
+	})
+	results.Total = len(results.Hits)
+	if len(q.Facets) > 0 {
+		results.Facets = countFacets(matched, q.Filters, q.Facets)
+	}
+	return results, nil
+}
+
+func (idx *Index) displayLocked(term string) string {
+	if entry := idx.terms[term]; entry != nil && entry.display != "" {
+		return entry.display
+	}
+	return term
+}
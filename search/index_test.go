@@ -0,0 +1,196 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"legal-documents-api/models"
+)
+
+// testDocument builds a document whose metadata id encodes n
+func testDocument(n int, title, content string) Document {
+	id, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%024x", n))
+	return Document{
+		Metadata: models.DocumentMetadata{ID: id, PdfAdi: title},
+		Content:  content,
+	}
+}
+
+// testNumber recovers n from the id of a document built by testDocument
+func testNumber(doc *Document) int {
+	var n int
+	fmt.Sscanf(doc.ID(), "%x", &n)
+	return n
+}
+
+func newTestIndex(docs ...Document) *Index {
+	idx := NewIndex()
+	idx.Index(docs...)
+	idx.MarkReady()
+	return idx
+}
+
+// searchIDs returns the numbers of the documents a query matches, sorted
+func searchIDs(t *testing.T, idx *Index, q Query) []int {
+	t.Helper()
+	results, err := idx.Search(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Search(%q) failed: %v", q.Text, err)
+	}
+	var ids []int
+	for _, hit := range results.Hits {
+		ids = append(ids, testNumber(hit.Document))
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rankedIDs returns the numbers of the documents a query matches, best first
+func rankedIDs(t *testing.T, idx *Index, q Query) []int {
+	t.Helper()
+	results, err := idx.Search(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Search(%q) failed: %v", q.Text, err)
+	}
+	ids := make([]int, len(results.Hits))
+	for i, hit := range results.Hits {
+		ids[i] = testNumber(hit.Document)
+	}
+	return ids
+}
+
+func TestSearchRanksByFieldBoost(t *testing.T) {
+	// Every field of every document is two words long and "kira" occurs once
+	// per document, in a different field each, so only the field boosts
+	// separate the scores
+	in := func(n int, field Field) Document {
+		doc := testDocument(n, "genel hükümler", "genel hükümler")
+		doc.KurumAdi = "diğer kurum"
+		doc.Metadata.Etiketler = "diğer etiket"
+		doc.Metadata.AnahtarKelimeler = "diğer kelime"
+		doc.Metadata.Aciklama = "diğer açıklama"
+		switch field {
+		case FieldTitle:
+			doc.Metadata.PdfAdi = "kira hükümleri"
+		case FieldInstitution:
+			doc.KurumAdi = "kira kurumu"
+		case FieldTags:
+			doc.Metadata.Etiketler = "kira etiketi"
+		case FieldKeywords:
+			doc.Metadata.AnahtarKelimeler = "kira kelimesi"
+		case FieldDescription:
+			doc.Metadata.Aciklama = "kira açıklaması"
+		case FieldContent:
+			doc.Content = "kira bedeli"
+		}
+		return doc
+	}
+	idx := newTestIndex(
+		in(1, FieldContent),
+		in(2, FieldDescription),
+		in(3, FieldKeywords),
+		in(4, FieldTags),
+		in(5, FieldInstitution),
+		in(6, FieldTitle),
+	)
+
+	// title 3 > institution 2 > tags 1.5 > keywords 1.2 > description 1 =
+	// content 1, the tie broken by id
+	want := []int{6, 5, 4, 3, 1, 2}
+	if got := rankedIDs(t, idx, Query{Text: "kira"}); !equalInts(got, want) {
+		t.Errorf("Search(kira) = %v, want %v", got, want)
+	}
+	results, _ := idx.Search(context.Background(), Query{Text: "kira"})
+	if title, content := results.Hits[0].Score, results.Hits[len(results.Hits)-1].Score; math.Abs(title-3*content) > 1e-9 {
+		t.Errorf("title score %v is not 3 times the content score %v", title, content)
+	}
+	if got := results.Hits[0].MatchType(); got != "title" {
+		t.Errorf("MatchType() = %q, want title", got)
+	}
+}
+
+func TestSearchRanksByTermFrequencyAndLength(t *testing.T) {
+	idx := newTestIndex(
+		testDocument(1, "Rapor", "vergi usul kanunu ile ilgili uzun bir açıklama metni burada yer alır"),
+		testDocument(2, "Rapor", "vergi vergi vergi"),
+		testDocument(3, "Rapor", "vergi usul"),
+		testDocument(4, "Rapor", "harç"),
+	)
+	want := []int{2, 3, 1}
+	if got := rankedIDs(t, idx, Query{Text: "vergi"}); !equalInts(got, want) {
+		t.Errorf("Search(vergi) = %v, want %v", got, want)
+	}
+}
+
+func TestIndexReplaceAndRemove(t *testing.T) {
+	idx := newTestIndex(
+		testDocument(1, "Kira yönetmeliği", ""),
+		testDocument(2, "Tapu yönetmeliği", ""),
+	)
+	idx.Index(testDocument(1, "Harç tarifesi", ""))
+	if got := searchIDs(t, idx, Query{Text: "kira"}); len(got) != 0 {
+		t.Errorf("replaced document still matches its old title: %v", got)
+	}
+	if got := searchIDs(t, idx, Query{Text: "harç"}); !equalInts(got, []int{1}) {
+		t.Errorf("Search(harç) = %v, want [1]", got)
+	}
+
+	idx.Remove(testDocument(2, "", "").ID())
+	if idx.Len() != 1 {
+		t.Errorf("Len() = %d after a removal, want 1", idx.Len())
+	}
+	if got := searchIDs(t, idx, Query{Text: "yönetmeliği"}); len(got) != 0 {
+		t.Errorf("removed document still matches: %v", got)
+	}
+}
+
+func TestIndexCompactionKeepsPostings(t *testing.T) {
+	idx := NewIndex()
+	for n := 1; n <= 8; n++ {
+		idx.Index(testDocument(n, fmt.Sprintf("Yönetmelik %d", n), "ortak metin"))
+	}
+	// Removing a quarter of the documents compacts the index and renumbers
+	// the internal ids of those after them
+	idx.Remove(testDocument(1, "", "").ID(), testDocument(2, "", "").ID())
+	if idx.deleted != 0 {
+		t.Fatalf("index not compacted: %d tombstones", idx.deleted)
+	}
+	if got, want := searchIDs(t, idx, Query{Text: "ortak"}), []int{3, 4, 5, 6, 7, 8}; !equalInts(got, want) {
+		t.Errorf("Search(ortak) after compaction = %v, want %v", got, want)
+	}
+	if got := searchIDs(t, idx, Query{Text: `"yönetmelik 7"`}); !equalInts(got, []int{7}) {
+		t.Errorf("phrase search after compaction = %v, want [7]", got)
+	}
+	if doc, ok := idx.Document(testDocument(8, "", "").ID()); !ok || doc.Metadata.PdfAdi != "Yönetmelik 8" {
+		t.Errorf("Document(8) after compaction = %v, %v", doc, ok)
+	}
+}
+
+func TestIndexPrefixLookupAfterIncrementalAdds(t *testing.T) {
+	idx := newTestIndex(testDocument(1, "Sözleşme", ""))
+	idx.Index(testDocument(2, "Sözleşmeler", ""))
+	idx.Index(testDocument(3, "Sözleşmesi", ""))
+	if got := searchIDs(t, idx, Query{Text: "sözleşme"}); !equalInts(got, []int{1, 2, 3}) {
+		t.Errorf("Search(sözleşme) = %v, want [1 2 3]", got)
+	}
+	if got := searchIDs(t, idx, Query{Text: "sözleşmel*"}); !equalInts(got, []int{2}) {
+		t.Errorf("Search(sözleşmel*) = %v, want [2]", got)
+	}
+}
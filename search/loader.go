@@ -0,0 +1,232 @@
+package search
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+// contentBatchSize is how many documents' content is fetched per query while indexing
+const contentBatchSize = 200
+
+// LoadFromMongo builds the index from every active document and marks it ready
+func LoadFromMongo(ctx context.Context, client *mongo.Client, idx *Index) error {
+	start := time.Now()
+	if err := Refresh(ctx, client, idx); err != nil {
+		return err
+	}
+	idx.MarkReady()
+	log.Printf("Search index built with %d documents in %s", idx.Len(), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Refresh brings the index in line with MongoDB: new or modified active
+// documents are (re)indexed and documents that are gone or no longer active
+// are removed. Documents with unchanged metadata are compared by the
+// content_hash stored with their content, so only edited content is read.
+// Content stored without a hash is read and hashed once.
+func Refresh(ctx context.Context, client *mongo.Client, idx *Index) error {
+	return refresh(ctx, client, idx, false)
+}
+
+// Rebuild indexes every active document again with its current content. The
+// index keeps serving the previous entries meanwhile.
+func Rebuild(ctx context.Context, client *mongo.Client, idx *Index) error {
+	return refresh(ctx, client, idx, true)
+}
+
+func refresh(ctx context.Context, client *mongo.Client, idx *Index, all bool) error {
+	collection := config.GetMetadataCollection(client)
+
+	cursor, err := collection.Find(ctx, bson.M{"status": editorial.ListedFilter()})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	active := make(map[string]bool)
+	var changed, unchanged []Document
+	for cursor.Next(ctx) {
+		var metadata models.DocumentMetadata
+		if err := cursor.Decode(&metadata); err != nil {
+			continue
+		}
+		doc := Document{
+			Metadata: metadata,
+			KurumAdi: utils.GetKurumAdiByID(metadata.KurumID),
+		}
+		active[doc.ID()] = true
+
+		if existing, ok := idx.Document(doc.ID()); !all && ok && reflect.DeepEqual(existing.Metadata, doc.Metadata) && existing.KurumAdi == doc.KurumAdi {
+			unchanged = append(unchanged, doc)
+			continue
+		}
+		changed = append(changed, doc)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, id := range idx.IDs() {
+		if !active[id] {
+			removed = append(removed, id)
+		}
+	}
+	idx.Remove(removed...)
+
+	for start := 0; start < len(changed); start += contentBatchSize {
+		batch := changed[start:batchEnd(start, len(changed))]
+		if err := attachContent(ctx, client, batch); err != nil {
+			return err
+		}
+		idx.Index(batch...)
+	}
+
+	edited := 0
+	for start := 0; start < len(unchanged); start += contentBatchSize {
+		stale, err := staleContent(ctx, client, idx, unchanged[start:batchEnd(start, len(unchanged))])
+		if err != nil {
+			return err
+		}
+		idx.Index(stale...)
+		edited += len(stale)
+	}
+	idx.RefreshSuggestions()
+
+	if len(changed) > 0 || edited > 0 || len(removed) > 0 {
+		log.Printf("Search index refreshed: %d indexed, %d with edited content, %d removed", len(changed), edited, len(removed))
+	}
+	return nil
+}
+
+func batchEnd(start, n int) int {
+	if end := start + contentBatchSize; end < n {
+		return end
+	}
+	return n
+}
+
+// staleContent returns the documents of docs, with their content attached,
+// whose stored content hash differs from the indexed one. Content without a
+// stored hash is loaded and compared, and its hash stored so the next
+// refresh does not read it again.
+func staleContent(ctx context.Context, client *mongo.Client, idx *Index, docs []Document) ([]Document, error) {
+	ids := make([]primitive.ObjectID, len(docs))
+	byID := make(map[primitive.ObjectID]Document, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.Metadata.ID
+		byID[doc.Metadata.ID] = doc
+	}
+
+	collection := config.GetContentCollection(client)
+	findOptions := options.Find().SetProjection(bson.M{"metadata_id": 1, "content_hash": 1})
+	cursor, err := collection.Find(ctx, bson.M{"metadata_id": bson.M{"$in": ids}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []models.DocumentContent
+	if err := cursor.All(ctx, &hashes); err != nil {
+		return nil, err
+	}
+
+	var edited, unhashed []Document
+	for _, content := range hashes {
+		doc, ok := byID[content.MetadataID]
+		if !ok {
+			continue
+		}
+		indexed, _ := idx.contentHash(doc.ID())
+		switch {
+		case content.ContentHash == "":
+			unhashed = append(unhashed, doc)
+		case content.ContentHash != indexed:
+			edited = append(edited, doc)
+		}
+	}
+	if err := attachContent(ctx, client, edited); err != nil {
+		return nil, err
+	}
+	if err := attachContent(ctx, client, unhashed); err != nil {
+		return nil, err
+	}
+
+	var updates []mongo.WriteModel
+	for _, doc := range unhashed {
+		hash := utils.ContentHash(doc.Content)
+		updates = append(updates, mongo.NewUpdateOneModel().
+			// Not if the content changed since it was read
+			SetFilter(bson.M{"metadata_id": doc.Metadata.ID, "icerik": doc.Content, "content_hash": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{"content_hash": hash}}))
+		if indexed, _ := idx.contentHash(doc.ID()); hash != indexed {
+			edited = append(edited, doc)
+		}
+	}
+	if len(updates) > 0 {
+		// A failure only means the content is read again next time
+		if _, err := collection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false)); err != nil {
+			log.Printf("Warning: Failed to store content hashes: %v", err)
+		}
+	}
+	return edited, nil
+}
+
+// attachContent loads the icerik of each document in docs
+func attachContent(ctx context.Context, client *mongo.Client, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, len(docs))
+	positions := make(map[primitive.ObjectID]int, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.Metadata.ID
+		positions[doc.Metadata.ID] = i
+	}
+
+	findOptions := options.Find().SetProjection(bson.M{"metadata_id": 1, "icerik": 1})
+	cursor, err := config.GetContentCollection(client).Find(ctx, bson.M{"metadata_id": bson.M{"$in": ids}}, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var content models.DocumentContent
+		if err := cursor.Decode(&content); err != nil {
+			continue
+		}
+		if i, ok := positions[content.MetadataID]; ok {
+			docs[i].Content = content.Icerik
+		}
+	}
+	return cursor.Err()
+}
+
+// StartRefresher periodically refreshes the index in the background
+func StartRefresher(client *mongo.Client, idx *Index, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := Refresh(ctx, client, idx); err != nil {
+				log.Printf("Warning: Search index refresh failed: %v", err)
+			} else if !idx.Ready() {
+				// The initial build failed earlier; a full refresh makes it usable
+				idx.MarkReady()
+			}
+			cancel()
+		}
+	}()
+}
@@ -0,0 +1,534 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"legal-documents-api/textnorm"
+)
+
+// AnyField scopes a query node to every field
+const AnyField Field = -1
+
+// fieldAliases maps the field prefixes accepted in queries (baslik:, etiket:, ...)
+var fieldAliases = map[string]Field{
+	"baslik":    FieldTitle,
+	"title":     FieldTitle,
+	"kurum":     FieldInstitution,
+	"etiket":    FieldTags,
+	"etiketler": FieldTags,
+	"anahtar":   FieldKeywords,
+	"aciklama":  FieldDescription,
+	"icerik":    FieldContent,
+}
+
+// minPrefixLength is the shortest stem allowed before a trailing wildcard
+const minPrefixLength = 2
+
+// Node is an element of a parsed query
+type Node interface {
+	String() string
+}
+
+// TermNode matches a single word, optionally as a prefix ("yönet*")
+type TermNode struct {
+	Field  Field
+	Term   string // normalized
+	Prefix bool
+}
+
+// PhraseNode matches consecutive words ("iş sağlığı")
+type PhraseNode struct {
+	Field Field
+	Terms []string // normalized
+}
+
+// AndNode matches documents matching every child
+type AndNode struct {
+	Children []Node
+}
+
+// OrNode matches documents matching any child
+type OrNode struct {
+	Children []Node
+}
+
+// NotNode excludes documents matching its child; only valid inside an AndNode
+type NotNode struct {
+	Child Node
+}
+
+func fieldPrefix(f Field) string {
+	if f == AnyField {
+		return ""
+	}
+	return f.String() + ":"
+}
+
+func (n *TermNode) String() string {
+	if n.Prefix {
+		return fieldPrefix(n.Field) + n.Term + "*"
+	}
+	return fieldPrefix(n.Field) + n.Term
+}
+
+func (n *PhraseNode) String() string {
+	return fieldPrefix(n.Field) + `"` + strings.Join(n.Terms, " ") + `"`
+}
+
+func (n *AndNode) String() string {
+	parts := make([]string, len(n.Children))
+	for i, child := range n.Children {
+		parts[i] = child.String()
+	}
+	return "(" + strings.Join(parts, " AND ") + ")"
+}
+
+func (n *OrNode) String() string {
+	parts := make([]string, len(n.Children))
+	for i, child := range n.Children {
+		parts[i] = child.String()
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func (n *NotNode) String() string {
+	return "NOT " + n.Child.String()
+}
+
+// SyntaxError describes an invalid query
+type SyntaxError struct {
+	Pos     int // rune offset in the query
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokMinus
+	tokEOF
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // raw word or phrase contents
+	field string // field prefix, lowercased and folded
+	pos   int
+}
+
+// lex splits a query into tokens. Words may carry a field prefix (kurum:sgk)
+// and phrases are delimited by double quotes. A colon after anything but a
+// field name is part of the word, as in "No: 2023/15" or a pasted URL.
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, pos: i})
+			i++
+		case r == '-' && (i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '('):
+			tokens = append(tokens, token{kind: tokMinus, pos: i})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &SyntaxError{Pos: i, Message: "unterminated phrase, missing closing quote"}
+			}
+			tokens = append(tokens, token{kind: tokPhrase, text: string(runes[i+1 : end]), pos: i})
+			i = end + 1
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				if runes[i] == ':' {
+					break
+				}
+				i++
+			}
+			word := string(runes[start:i])
+			field := textnorm.Fold(word)
+			if _, known := fieldAliases[field]; i < len(runes) && runes[i] == ':' && !known {
+				for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+					i++
+				}
+				word = string(runes[start:i])
+			}
+
+			// field:value
+			if i < len(runes) && runes[i] == ':' {
+				i++
+				if i < len(runes) && runes[i] == '"' {
+					end := i + 1
+					for end < len(runes) && runes[end] != '"' {
+						end++
+					}
+					if end == len(runes) {
+						return nil, &SyntaxError{Pos: i, Message: "unterminated phrase, missing closing quote"}
+					}
+					tokens = append(tokens, token{kind: tokPhrase, text: string(runes[i+1 : end]), field: field, pos: start})
+					i = end + 1
+					continue
+				}
+				valueStart := i
+				for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+					i++
+				}
+				if valueStart == i {
+					return nil, &SyntaxError{Pos: start, Message: fmt.Sprintf("missing value after %q", word+":")}
+				}
+				tokens = append(tokens, token{kind: tokWord, text: string(runes[valueStart:i]), field: field, pos: start})
+				continue
+			}
+
+			switch word {
+			case "AND", "&&":
+				tokens = append(tokens, token{kind: tokAnd, pos: start})
+			case "OR", "||":
+				tokens = append(tokens, token{kind: tokOr, pos: start})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokNot, pos: start})
+			default:
+				tokens = append(tokens, token{kind: tokWord, text: word, pos: start})
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// ParseQuery parses the search syntax:
+//
+//	"exact phrase"   quoted phrase
+//	a AND b, a b     both terms (AND is implicit)
+//	a OR b           either term
+//	NOT a, -a        exclude documents matching a
+//	baslik:x         scope to a field (baslik, etiket, kurum, anahtar, aciklama, icerik)
+//	yönet*           prefix wildcard
+//	( ... )          grouping
+//
+// Words are normalized with textnorm, so operators are the only case-sensitive
+// part of the syntax.
+func ParseQuery(input string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &SyntaxError{Pos: 0, Message: "empty query"}
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokRParen {
+			return nil, &SyntaxError{Pos: t.pos, Message: "unbalanced closing parenthesis"}
+		}
+		return nil, &SyntaxError{Pos: t.pos, Message: "unexpected input"}
+	}
+	if node == nil {
+		return nil, &SyntaxError{Pos: 0, Message: "query contains no searchable words"}
+	}
+	if !hasPositive(node) {
+		return nil, &SyntaxError{Pos: 0, Message: "query must contain at least one term that is not excluded"}
+	}
+	return node, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []Node{first}
+	for p.peek().kind == tokOr {
+		op := p.next()
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if child == nil || first == nil {
+			return nil, &SyntaxError{Pos: op.pos, Message: "OR needs a search term on both sides"}
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	for _, child := range children {
+		if !hasPositive(child) {
+			return nil, &SyntaxError{Pos: p.peek().pos, Message: "NOT cannot be an operand of OR"}
+		}
+	}
+	return &OrNode{Children: children}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var children []Node
+	for {
+		t := p.peek()
+		switch t.kind {
+		case tokEOF, tokRParen, tokOr:
+			return combineAnd(children), nil
+		case tokAnd:
+			p.next()
+			if len(children) == 0 {
+				return nil, &SyntaxError{Pos: t.pos, Message: "AND needs a search term on its left"}
+			}
+			if k := p.peek().kind; k == tokEOF || k == tokRParen || k == tokOr || k == tokAnd {
+				return nil, &SyntaxError{Pos: t.pos, Message: "AND needs a search term on its right"}
+			}
+			continue
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			children = append(children, child)
+		}
+	}
+}
+
+// combineAnd joins the children of an implicit or explicit AND. A nested
+// group of only exclusions, as in "(-a -b) c", matches nothing by itself, so
+// its exclusions are hoisted into this AND where they filter the other terms.
+func combineAnd(children []Node) Node {
+	if len(children) > 1 {
+		var flat []Node
+		for _, child := range children {
+			if and, ok := child.(*AndNode); ok && !hasPositive(and) {
+				flat = append(flat, and.Children...)
+				continue
+			}
+			flat = append(flat, child)
+		}
+		children = flat
+	}
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+	return &AndNode{Children: children}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.peek()
+	if t.kind == tokNot || t.kind == tokMinus {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, &SyntaxError{Pos: t.pos, Message: "nothing to exclude after NOT"}
+		}
+		if inner, ok := child.(*NotNode); ok {
+			return inner.Child, nil // double negation
+		}
+		if !hasPositive(child) {
+			return nil, &SyntaxError{Pos: t.pos, Message: "cannot exclude a group of only excluded terms"}
+		}
+		return &NotNode{Child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &SyntaxError{Pos: t.pos, Message: "unbalanced opening parenthesis"}
+		}
+		if node == nil {
+			return nil, &SyntaxError{Pos: t.pos, Message: "empty parentheses"}
+		}
+		return node, nil
+	case tokWord, tokPhrase:
+		field := resolveField(t)
+		if t.kind == tokPhrase {
+			terms := textnorm.Tokens(t.text)
+			if len(terms) == 0 {
+				return nil, &SyntaxError{Pos: t.pos, Message: "empty phrase"}
+			}
+			return phraseOrTerm(field, terms), nil
+		}
+		return wordNode(field, t)
+	case tokRParen:
+		return nil, &SyntaxError{Pos: t.pos, Message: "unbalanced closing parenthesis"}
+	case tokEOF:
+		return nil, &SyntaxError{Pos: t.pos, Message: "unexpected end of query"}
+	}
+	return nil, &SyntaxError{Pos: t.pos, Message: "unexpected operator"}
+}
+
+// resolveField returns the field a token is scoped to. lex only sets known
+// field prefixes.
+func resolveField(t token) Field {
+	if t.field == "" {
+		return AnyField
+	}
+	return fieldAliases[t.field]
+}
+
+// wordNode turns a bare word into a term, prefix or (when normalization splits
+// it, e.g. "5510-sayılı") a phrase node
+func wordNode(field Field, t token) (Node, error) {
+	text := t.text
+	prefix := strings.HasSuffix(text, "*")
+	if prefix {
+		text = strings.TrimRight(text, "*")
+	}
+	if strings.Contains(text, "*") {
+		return nil, &SyntaxError{Pos: t.pos, Message: "wildcards are only supported at the end of a word"}
+	}
+
+	terms := textnorm.Tokens(text)
+	if len(terms) == 0 {
+		if prefix {
+			return nil, &SyntaxError{Pos: t.pos, Message: "wildcard needs a word before it"}
+		}
+		return nil, nil // punctuation only, ignore
+	}
+	if !prefix {
+		return phraseOrTerm(field, terms), nil
+	}
+
+	last := terms[len(terms)-1]
+	if utf8.RuneCountInString(last) < minPrefixLength {
+		return nil, &SyntaxError{Pos: t.pos, Message: fmt.Sprintf("wildcard prefix must be at least %d characters", minPrefixLength)}
+	}
+	if len(terms) == 1 {
+		return &TermNode{Field: field, Term: last, Prefix: true}, nil
+	}
+	return &AndNode{Children: []Node{
+		phraseOrTerm(field, terms[:len(terms)-1]),
+		&TermNode{Field: field, Term: last, Prefix: true},
+	}}, nil
+}
+
+func phraseOrTerm(field Field, terms []string) Node {
+	if len(terms) == 1 {
+		return &TermNode{Field: field, Term: terms[0]}
+	}
+	return &PhraseNode{Field: field, Terms: terms}
+}
+
+// hasPositive reports whether a node can match documents on its own
+func hasPositive(node Node) bool {
+	switch n := node.(type) {
+	case *NotNode:
+		return false
+	case *AndNode:
+		for _, child := range n.Children {
+			if hasPositive(child) {
+				return true
+			}
+		}
+		return false
+	case *OrNode:
+		for _, child := range n.Children {
+			if !hasPositive(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// PositiveTerms returns the words and phrases a document must (or may) contain
+// to match, ignoring excluded parts. Used for previews and highlighting.
+func PositiveTerms(node Node) []string {
+	var terms []string
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *TermNode:
+			terms = append(terms, n.Term)
+		case *PhraseNode:
+			terms = append(terms, strings.Join(n.Terms, " "))
+		case *AndNode:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		case *OrNode:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return terms
+}
+
+// AnyOf relaxes a query so that documents matching only some of its words
+// still match: the top-level AND becomes an OR and exclusions are dropped.
+// Natural-language questions rarely match every word of a document.
+func AnyOf(node Node) Node {
+	and, ok := node.(*AndNode)
+	if !ok {
+		return node
+	}
+	var children []Node
+	for _, child := range and.Children {
+		if hasPositive(child) {
+			children = append(children, child)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return node
+	case 1:
+		return children[0]
+	}
+	return &OrNode{Children: children}
+}
@@ -0,0 +1,165 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"iş kanunu", "(is AND kanunu)"},
+		{`"iş kanunu"`, `"is kanunu"`},
+		{"sgk OR bağkur", "(sgk OR bagkur)"},
+		{"kanun -yönetmelik", "(kanun AND NOT yonetmelik)"},
+		{"kanun NOT yönetmelik", "(kanun AND NOT yonetmelik)"},
+		{"(a OR b) c", "((a OR b) AND c)"},
+		{"yönet*", "yonet*"},
+		{"baslik:yönetmelik", "title:yonetmelik"},
+		{"Başlık:yönetmelik", "title:yonetmelik"},
+		{`icerik:"iş kanunu"`, `content:"is kanunu"`},
+		{"5510-sayılı", `"5510 sayili"`},
+
+		// A colon after anything but a field name is part of the word
+		{"Karar No: 2023/15", `(karar AND no AND "2023 15")`},
+		{"https://www.resmigazete.gov.tr/x.pdf", `"https www resmigazete gov tr x pdf"`},
+		{"kurum:sgk ek:3", `(institution:sgk AND "ek 3")`},
+		{"saat 10:30", `(saat AND "10 30")`},
+	}
+	for _, tt := range tests {
+		node, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", tt.query, err)
+			continue
+		}
+		if got := node.String(); got != tt.want {
+			t.Errorf("ParseQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		pos     int
+		message string
+	}{
+		{"", 0, "empty query"},
+		{"   ", 0, "empty query"},
+		{`"iş kanunu`, 0, "unterminated phrase"},
+		{`baslik:"iş`, 7, "unterminated phrase"},
+		{"baslik: kanun", 0, `missing value after "baslik:"`},
+		{"(kanun", 0, "unbalanced opening parenthesis"},
+		{"kanun)", 5, "unbalanced closing parenthesis"},
+		{"()", 0, "empty parentheses"},
+		{"kanun OR", 6, "OR needs a search term on both sides"},
+		{"-kanun", 0, "at least one term that is not excluded"},
+		{"ka*nun", 0, "wildcards are only supported at the end"},
+		{"y*", 0, "wildcard prefix must be at least"},
+		{"!!!", 0, "no searchable words"},
+		{`""`, 0, "empty phrase"},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ParseQuery(%q) error = %v, want a syntax error", tt.query, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Message, tt.message) {
+			t.Errorf("ParseQuery(%q) error = %v, want %q at position %d", tt.query, err, tt.message, tt.pos)
+		}
+	}
+}
+
+func TestParseQueryHoistsNegatedGroups(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"(-a -b) c", "(NOT a AND NOT b AND c)"},
+		{"c AND (-x -y)", "(c AND NOT x AND NOT y)"},
+		{"c (d (-x -y))", "(c AND (d AND NOT x AND NOT y))"},
+		{"(a -b) c", "((a AND NOT b) AND c)"},
+	}
+	for _, tt := range tests {
+		node, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", tt.query, err)
+			continue
+		}
+		if got := node.String(); got != tt.want {
+			t.Errorf("ParseQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	// A group of only exclusions cannot stand on its own anywhere else
+	for _, query := range []string{"(-a -b)", "c OR (-x -y)", "c -(-x -y)"} {
+		var syntaxErr *SyntaxError
+		if _, err := ParseQuery(query); !errors.As(err, &syntaxErr) {
+			t.Errorf("ParseQuery(%q) error = %v, want a syntax error", query, err)
+		}
+	}
+}
+
+func TestSearchNegatedGroups(t *testing.T) {
+	idx := newTestIndex(
+		testDocument(1, "Kira yönetmeliği", "kira artışı tapu"),
+		testDocument(2, "Kira kanunu", "kira artışı"),
+		testDocument(3, "Tapu yönetmeliği", "tapu sicili"),
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"(-tapu -sicil) kira", []int{2}},
+		{"kira AND (-tapu -sicil)", []int{2}},
+		{"yönetmeliği (-kanun -sicil)", []int{1}},
+	}
+	for _, tt := range tests {
+		if got := searchIDs(t, idx, Query{Text: tt.query}); !equalInts(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQuerySyntax(t *testing.T) {
+	tapu := testDocument(3, "Tapu yönetmeliği", "tapu sicili ve kira")
+	tapu.Metadata.Etiketler = "gayrimenkul"
+	tapu.KurumAdi = "Tapu ve Kadastro Genel Müdürlüğü"
+	idx := newTestIndex(
+		testDocument(1, "Kira yönetmeliği", "kira sözleşmesi ve iş sözleşmesi"),
+		testDocument(2, "İş Kanunu", "iş sağlığı ve güvenliği kanunu"),
+		tapu,
+		testDocument(4, "Sağlık kanunu", "güvenliği sağlığı iş"),
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"kira", []int{1, 3}},
+		{"kira -tapu", []int{1}},
+		{"kira NOT baslik:tapu", []int{1}},
+		{"kira OR kanunu", []int{1, 2, 3, 4}},
+		{"(kira OR kanun) yönetmeliği", []int{1, 3}},
+		// Phrases need the words in order and next to each other
+		{`"iş sağlığı"`, []int{2}},
+		{`"sağlığı iş"`, []int{4}},
+		{`"iş" "sağlığı"`, []int{2, 4}},
+		// Field scopes
+		{"baslik:kira", []int{1}},
+		{"icerik:kanunu", []int{2}},
+		{"etiket:gayrimenkul", []int{3}},
+		{"kurum:kadastro", []int{3}},
+		{"baslik:sağlığı", nil},
+		// Explicit prefixes match shorter stems than automatic expansion
+		{"sözleş*", []int{1}},
+		{"yön*", []int{1, 3}},
+	}
+	for _, tt := range tests {
+		if got := searchIDs(t, idx, Query{Text: tt.query}); !equalInts(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"legal-documents-api/textnorm"
+)
+
+const (
+	// maxRelatedTerms is how many of the source's most distinctive words are
+	// compared, as in Lucene's MoreLikeThis
+	maxRelatedTerms = 30
+	// minRelatedTermLength skips short words that carry little meaning
+	minRelatedTermLength = 3
+	// maxRelatedDocFreq drops words found in more than this share of documents
+	maxRelatedDocFreq = 0.5
+
+	// Weights of the similarity signals. Content similarity is scaled to [0, 1]
+	// first, so the metadata signals decide between comparable bodies.
+	relatedContentWeight = 1.0
+	relatedTagWeight     = 0.3 // per shared tag
+	relatedKeywordWeight = 0.2 // per shared keyword
+	relatedMaxShared     = 3   // shared tags/keywords counted at most
+	relatedKurumWeight   = 0.15
+	relatedTypeWeight    = 0.15
+)
+
+// Related returns the documents most similar to source, best first. Source
+// does not have to be indexed; a document with the same id is never returned.
+// Similarity combines the distinctive words of the content and title, shared
+// tags and keywords, and the same institution and document type.
+func (idx *Index) Related(ctx context.Context, source Document, limit int) ([]Hit, error) {
+	tags := listSet(source.Metadata.Etiketler)
+	keywords := listSet(source.Metadata.AnahtarKelimeler)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	candidates := make(matchSet)
+	for _, f := range []Field{FieldContent, FieldTitle} {
+		for _, qt := range idx.distinctiveTermsLocked(source.fieldText(f), f) {
+			postings := idx.terms[qt.term].postings[f]
+			avgLen := idx.avgLenLocked(f)
+			for _, p := range postings {
+				doc := idx.docs[p.doc]
+				if doc == nil {
+					continue
+				}
+				candidates.add(p.doc, f, qt.weight*fieldBoosts[f]*bm25(p.freq, doc.lengths[f], avgLen, 1), 1)
+			}
+		}
+	}
+	// Documents sharing a tag or keyword are candidates even without similar text
+	for _, set := range []struct {
+		field Field
+		items map[string]bool
+	}{{FieldTags, tags}, {FieldKeywords, keywords}} {
+		for item := range set.items {
+			for _, term := range strings.Fields(item) {
+				entry := idx.terms[term]
+				if entry == nil {
+					continue
+				}
+				for _, p := range entry.postings[set.field] {
+					candidates.add(p.doc, set.field, 0, 0)
+				}
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// The source itself, when indexed, is always the best match
+	if internal, ok := idx.byID[source.ID()]; ok {
+		delete(candidates, internal)
+	}
+	maxContent := 0.0
+	for _, a := range candidates {
+		maxContent = math.Max(maxContent, a.score)
+	}
+
+	var hits []Hit
+	for internal, a := range candidates {
+		entry := idx.docs[internal]
+		if entry == nil {
+			continue
+		}
+		meta := &entry.doc.Metadata
+
+		score := 0.0
+		if maxContent > 0 {
+			score = relatedContentWeight * a.score / maxContent
+		}
+		score += relatedTagWeight * float64(sharedCount(tags, meta.Etiketler))
+		score += relatedKeywordWeight * float64(sharedCount(keywords, meta.AnahtarKelimeler))
+		if score == 0 {
+			continue // only shared a word of some tag
+		}
+		if meta.KurumID != "" && meta.KurumID == source.Metadata.KurumID {
+			score += relatedKurumWeight
+		}
+		if meta.BelgeTuru != "" && textnorm.Normalize(meta.BelgeTuru) == textnorm.Normalize(source.Metadata.BelgeTuru) {
+			score += relatedTypeWeight
+		}
+
+		doc := entry.doc
+		hit := Hit{Document: &doc, Score: score, MatchCount: a.count}
+		for f := Field(0); f < numFields; f++ {
+			if a.fields&(1<<uint(f)) != 0 {
+				hit.MatchedFields = append(hit.MatchedFields, f)
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return SortRelevance.before(hits[i].rankKey(), hits[j].rankKey())
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// distinctiveTermsLocked returns the indexed words of text with the highest
+// tf-idf in field f, weighted by their share of the selection
+func (idx *Index) distinctiveTermsLocked(text string, f Field) []termMatch {
+	freqs := make(map[string]int)
+	for _, token := range textnorm.Tokens(text) {
+		if utf8.RuneCountInString(token) >= minRelatedTermLength {
+			freqs[token]++
+		}
+	}
+
+	var terms []termMatch
+	for term, freq := range freqs {
+		entry := idx.terms[term]
+		if entry == nil {
+			continue
+		}
+		df := len(entry.postings[f])
+		if df == 0 || float64(df) > maxRelatedDocFreq*float64(idx.live) {
+			continue
+		}
+		terms = append(terms, termMatch{term: term, weight: float64(freq) * idx.idfLocked(df)})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].weight != terms[j].weight {
+			return terms[i].weight > terms[j].weight
+		}
+		return terms[i].term < terms[j].term
+	})
+	if len(terms) > maxRelatedTerms {
+		terms = terms[:maxRelatedTerms]
+	}
+
+	total := 0.0
+	for _, t := range terms {
+		total += t.weight
+	}
+	for i := range terms {
+		terms[i].weight /= total
+	}
+	return terms
+}
+
+// listSet splits a comma or semicolon separated list such as etiketler into
+// its normalized items
+func listSet(list string) map[string]bool {
+	items := make(map[string]bool)
+	for _, item := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ';' }) {
+		if normalized := textnorm.Normalize(item); normalized != "" {
+			items[normalized] = true
+		}
+	}
+	return items
+}
+
+// sharedCount returns how many items of list are in set, capped at relatedMaxShared
+func sharedCount(set map[string]bool, list string) int {
+	if len(set) == 0 {
+		return 0
+	}
+	shared := 0
+	for item := range listSet(list) {
+		if set[item] {
+			shared++
+		}
+	}
+	if shared > relatedMaxShared {
+		shared = relatedMaxShared
+	}
+	return shared
+}
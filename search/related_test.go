@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func relatedDocument(n int, title, content, tags, kurumID, turu string) Document {
+	doc := testDocument(n, title, content)
+	doc.Metadata.Etiketler = tags
+	doc.Metadata.KurumID = kurumID
+	doc.Metadata.BelgeTuru = turu
+	return doc
+}
+
+func TestRelated(t *testing.T) {
+	const lease = "konut kira sözleşmesi artış oranı kiracı tahliye"
+	source := relatedDocument(1, "Konut kira artışı", lease, "kira, konut", "k1", "Genelge")
+	idx := newTestIndex(
+		source,
+		relatedDocument(2, "Kira sözleşmesi", lease, "", "k2", "Yönetmelik"),
+		// Same text, but also the institution and document type of the source
+		relatedDocument(3, "Kira sözleşmesi", lease, "", "k1", "GENELGE"),
+		// Nothing but a shared tag
+		relatedDocument(4, "Vergi", "gelir vergisi beyanname", "Kira", "k1", "Tebliğ"),
+		// Shares a word of a tag, not the tag itself
+		relatedDocument(5, "Emlak", "emlak vergisi ödeme", "konut finansmanı", "k1", "Genelge"),
+		relatedDocument(6, "Trafik", "trafik cezası ödeme", "", "k1", "Genelge"),
+		relatedDocument(7, "Sağlık", "sağlık raporu", "", "k2", "Genelge"),
+		relatedDocument(8, "Gümrük", "gümrük beyanı", "", "k2", "Genelge"),
+	)
+
+	related := func(source Document, limit int) ([]int, []Hit) {
+		t.Helper()
+		hits, err := idx.Related(context.Background(), source, limit)
+		if err != nil {
+			t.Fatalf("Related failed: %v", err)
+		}
+		ids := make([]int, len(hits))
+		for i, hit := range hits {
+			ids[i] = testNumber(hit.Document)
+		}
+		return ids, hits
+	}
+
+	ids, hits := related(source, 10)
+	if want := []int{3, 2, 4}; !equalInts(ids, want) {
+		t.Fatalf("Related = %v, want %v", ids, want)
+	}
+	if diff := hits[0].Score - hits[1].Score; math.Abs(diff-relatedKurumWeight-relatedTypeWeight) > 1e-9 {
+		t.Errorf("institution and type add %v, want %v", diff, relatedKurumWeight+relatedTypeWeight)
+	}
+	if want := relatedTagWeight + relatedKurumWeight; math.Abs(hits[2].Score-want) > 1e-9 {
+		t.Errorf("tag-only score = %v, want %v", hits[2].Score, want)
+	}
+
+	if ids, _ := related(source, 2); !equalInts(ids, []int{3, 2}) {
+		t.Errorf("Related with limit 2 = %v, want [3 2]", ids)
+	}
+
+	// A source outside the index finds the indexed copy of itself first
+	copied := source
+	copied.Metadata.ID = testDocument(9, "", "").Metadata.ID
+	if ids, _ := related(copied, 10); !equalInts(ids, []int{1, 3, 2, 4}) {
+		t.Errorf("Related for an unindexed source = %v, want [1 3 2 4]", ids)
+	}
+}
+
+func TestListSet(t *testing.T) {
+	set := listSet(" Kira ; konut,, İŞ KANUNU ")
+	for _, item := range []string{"kira", "konut", "is kanunu"} {
+		if !set[item] {
+			t.Errorf("listSet is missing %q: %v", item, set)
+		}
+	}
+	if len(set) != 3 {
+		t.Errorf("listSet = %v, want 3 items", set)
+	}
+	if got := sharedCount(listSet("a, b, c, d, e"), "e, d, c, b, a"); got != relatedMaxShared {
+		t.Errorf("sharedCount = %d, want the cap %d", got, relatedMaxShared)
+	}
+}
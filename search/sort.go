@@ -0,0 +1,135 @@
+package search
+
+import (
+	"bytes"
+	"time"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"legal-documents-api/utils"
+)
+
+// SortOrder selects how search results are ordered
+type SortOrder string
+
+const (
+	SortRelevance SortOrder = "relevance" // best match first (default)
+	SortDateDesc  SortOrder = "date_desc" // newest first
+	SortDateAsc   SortOrder = "date_asc"  // oldest first
+	SortTitle     SortOrder = "title"     // Turkish alphabetical order of pdf_adi
+)
+
+// SortOrders lists every supported sort order
+var SortOrders = []SortOrder{SortRelevance, SortDateDesc, SortDateAsc, SortTitle}
+
+// DateField names a document date that results can be bounded and sorted by
+type DateField string
+
+const (
+	DatePublished DateField = "belge_yayin_tarihi"
+	DateUploaded  DateField = "yukleme_tarihi"
+)
+
+// dateKeyLayout renders dates as byte-comparable day keys
+const dateKeyLayout = "20060102"
+
+// dateKey returns the comparable day key of t, or nil for the zero time
+func dateKey(t time.Time) []byte {
+	if t.IsZero() {
+		return nil
+	}
+	return []byte(t.UTC().Format(dateKeyLayout))
+}
+
+// documentDateKey returns the day key of a document date, parsing the string
+// form when the typed date is not set
+func documentDateKey(typed *time.Time, raw string) []byte {
+	if typed != nil {
+		return dateKey(*typed)
+	}
+	if parsed, ok := utils.ParseDate(raw); ok {
+		return dateKey(parsed)
+	}
+	return nil
+}
+
+// newTitleCollator returns a collator ordering titles by the Turkish alphabet
+// (c < ç < d, ı < i), ignoring case
+func newTitleCollator() *collate.Collator {
+	return collate.New(language.Turkish, collate.IgnoreCase)
+}
+
+// rankKey is everything needed to place a hit in a sorted result list
+type rankKey struct {
+	score float64
+	key   []byte // date or title key for non-relevance orders
+	id    string
+}
+
+// before is the total order of search results under o. Ties are broken by
+// score and finally document id so every page boundary is deterministic;
+// documents without a date sort last in both date orders.
+func (o SortOrder) before(a, b rankKey) bool {
+	switch o {
+	case SortDateDesc, SortDateAsc, SortTitle:
+		if (a.key == nil) != (b.key == nil) {
+			return a.key != nil
+		}
+		if c := bytes.Compare(a.key, b.key); c != 0 {
+			if o == SortDateDesc {
+				return c > 0
+			}
+			return c < 0
+		}
+	}
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	return a.id < b.id
+}
+
+// sortKey returns the key entry is ordered by under q
+func (q Query) sortKey(entry *indexedDoc) []byte {
+	switch q.Sort {
+	case SortDateDesc, SortDateAsc:
+		return entry.dateKey(q.dateField())
+	case SortTitle:
+		return entry.titleKey
+	}
+	return nil
+}
+
+// dateField returns the date the query filters and sorts by
+func (q Query) dateField() DateField {
+	if q.DateField == "" {
+		return DatePublished
+	}
+	return q.DateField
+}
+
+// inDateRange reports whether entry falls within the query's date bounds.
+// Documents without a recognisable date are excluded once a bound is set.
+func (q Query) inDateRange(entry *indexedDoc) bool {
+	if q.From.IsZero() && q.To.IsZero() {
+		return true
+	}
+	key := entry.dateKey(q.dateField())
+	if key == nil {
+		return false
+	}
+	if from := dateKey(q.From); from != nil && bytes.Compare(key, from) < 0 {
+		return false
+	}
+	if to := dateKey(q.To); to != nil && bytes.Compare(key, to) > 0 {
+		return false
+	}
+	return true
+}
+
+func (d *indexedDoc) dateKey(field DateField) []byte {
+	if field == DateUploaded {
+		return d.uploaded
+	}
+	return d.published
+}
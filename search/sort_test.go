@@ -0,0 +1,79 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func datedDocument(n int, title, yayin, yukleme string) Document {
+	doc := testDocument(n, title, "kira")
+	doc.Metadata.BelgeYayinTarihi = yayin
+	doc.Metadata.YuklemeTarihi = yukleme
+	return doc
+}
+
+func TestSortOrders(t *testing.T) {
+	typed := datedDocument(5, "Çevre", "tarih yok", "")
+	published := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	typed.Metadata.BelgeYayinDate = &published
+	idx := newTestIndex(
+		datedDocument(1, "ılık", "15.03.2022", "2023-01-05"),
+		datedDocument(2, "Izgara", "2020-01-10", "2023-01-04"),
+		datedDocument(3, "çay", "", "2023-01-03"),
+		datedDocument(4, "İmar", "01.01.2023", ""),
+		typed,
+		datedDocument(6, "Ceza", "2021-06-01", "2023-01-01"),
+	)
+
+	tests := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		// Undated documents sort last in both directions; equal dates keep
+		// the relevance tie-break by id
+		{"date_desc", Query{Sort: SortDateDesc}, []int{4, 1, 5, 6, 2, 3}},
+		{"date_asc", Query{Sort: SortDateAsc}, []int{2, 5, 6, 1, 4, 3}},
+		{"date_desc uploaded", Query{Sort: SortDateDesc, DateField: DateUploaded}, []int{1, 2, 3, 6, 4, 5}},
+		// Turkish alphabet, ignoring case: c < ç < ı = I < i = İ
+		{"title", Query{Sort: SortTitle}, []int{6, 3, 5, 1, 2, 4}},
+	}
+	for _, tt := range tests {
+		tt.q.Text = "kira"
+		if got := rankedIDs(t, idx, tt.q); !equalInts(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	idx := newTestIndex(
+		datedDocument(1, "Kira", "31.12.2020", "2021-01-02"),
+		datedDocument(2, "Kira", "2021-01-01", "2021-01-02"),
+		datedDocument(3, "Kira", "2021-12-31", "2022-01-02"),
+		datedDocument(4, "Kira", "", "2021-06-01"),
+	)
+	day := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		// Bounds are whole days and inclusive; undated documents drop out
+		{"year", Query{From: day("2021-01-01"), To: day("2021-12-31")}, []int{2, 3}},
+		{"from only", Query{From: day("2021-06-01")}, []int{3}},
+		{"to only", Query{To: day("2021-01-01")}, []int{1, 2}},
+		{"uploaded", Query{DateField: DateUploaded, To: day("2021-06-01")}, []int{1, 2, 4}},
+		{"unbounded", Query{}, []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		tt.q.Text = "kira"
+		if got := searchIDs(t, idx, tt.q); !equalInts(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
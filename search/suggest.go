@@ -0,0 +1,435 @@
+package search
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"legal-documents-api/textnorm"
+)
+
+const (
+	// suggestBlockSize is the number of dictionary entries per block. Blocks
+	// keep their most frequent entries precomputed, so a prefix spanning many
+	// blocks is answered without scanning them.
+	suggestBlockSize = 256
+	// MaxSuggestions caps how many completions Suggest returns
+	MaxSuggestions = 50
+	// maxSuggestPhraseWords is the longest tag or keyword stored as one completion
+	maxSuggestPhraseWords = 6
+)
+
+// suggestEntry is one completion of the suggestion dictionary
+type suggestEntry struct {
+	key   string // normalized text, the lookup key
+	text  string // display form
+	field Field  // highest priority field it occurs in
+	count int    // documents containing it
+}
+
+// before orders entries by document frequency, then field priority, then text
+func (e *suggestEntry) before(o *suggestEntry) bool {
+	if e.count != o.count {
+		return e.count > o.count
+	}
+	if e.field != o.field {
+		return e.field < o.field
+	}
+	return e.text < o.text
+}
+
+// suggestDict is an immutable dictionary of completions sorted by key
+type suggestDict struct {
+	entries []suggestEntry
+	blocks  [][]int32 // per block, its entries best first (at most MaxSuggestions)
+}
+
+// suggesters holds the dictionary over all documents and one per institution
+type suggesters struct {
+	all     *suggestDict
+	byKurum map[string]*suggestDict
+}
+
+// suggestSource is the part of a document the dictionary is built from
+type suggestSource struct {
+	title    string
+	tags     string
+	keywords string
+	kurumID  string
+	kurumAdi string
+}
+
+// RefreshSuggestions rebuilds the suggestion dictionary when documents changed
+// since the last build. The build works on a snapshot, so searches and
+// suggestions keep being served from the previous dictionary meanwhile.
+func (idx *Index) RefreshSuggestions() {
+	idx.mu.RLock()
+	if idx.suggest != nil && idx.suggestGeneration == idx.generation {
+		idx.mu.RUnlock()
+		return
+	}
+	generation := idx.generation
+	sources := make([]suggestSource, 0, idx.live)
+	for _, entry := range idx.docs {
+		if entry == nil {
+			continue
+		}
+		meta := &entry.doc.Metadata
+		sources = append(sources, suggestSource{title: meta.PdfAdi, tags: meta.Etiketler, keywords: meta.AnahtarKelimeler, kurumID: meta.KurumID, kurumAdi: entry.doc.KurumAdi})
+	}
+	idx.mu.RUnlock()
+
+	built := buildSuggesters(sources)
+
+	idx.mu.Lock()
+	if idx.suggestGeneration <= generation {
+		idx.suggest = built
+		idx.suggestGeneration = generation
+	}
+	idx.mu.Unlock()
+}
+
+// buildSuggesters collects the completions of every document: title words,
+// word pairs and whole titles, tags and keywords with their words, and
+// institution names reachable from any of their words
+func buildSuggesters(sources []suggestSource) *suggesters {
+	all := make(map[string]*suggestEntry)
+	byKurum := make(map[string]map[string]*suggestEntry)
+	kurumDocs := make(map[string]int)
+	kurumNames := make(map[string]string)
+
+	for _, source := range sources {
+		keys := make(map[string]*suggestEntry)
+		add := func(key, text string, field Field) {
+			if key == "" {
+				return
+			}
+			if existing, ok := keys[key]; ok {
+				if field < existing.field {
+					existing.field = field
+					existing.text = text
+				}
+				return
+			}
+			keys[key] = &suggestEntry{key: key, text: text, field: field}
+		}
+
+		addWords(textnorm.NewText(source.title), FieldTitle, 2, add)
+		if title := textnorm.Normalize(source.title); strings.Count(title, " ") >= 2 {
+			add(title, textnorm.ToLower(strings.Join(strings.Fields(source.title), " ")), FieldTitle)
+		}
+		for _, list := range []struct {
+			text  string
+			field Field
+		}{{source.tags, FieldTags}, {source.keywords, FieldKeywords}} {
+			for _, item := range strings.FieldsFunc(list.text, func(r rune) bool { return r == ',' || r == ';' }) {
+				text := textnorm.NewText(item)
+				words := text.Words()
+				addWords(text, list.field, 1, add)
+				if len(words) > 1 && len(words) <= maxSuggestPhraseWords {
+					add(text.Normalized, textnorm.ToLower(item[words[0].Start:words[len(words)-1].End]), list.field)
+				}
+			}
+		}
+
+		if source.kurumID != "" {
+			kurumDocs[source.kurumID]++
+			kurumNames[source.kurumID] = source.kurumAdi
+			if byKurum[source.kurumID] == nil {
+				byKurum[source.kurumID] = make(map[string]*suggestEntry)
+			}
+		}
+		for key, entry := range keys {
+			mergeSuggestEntry(all, key, entry)
+			if source.kurumID != "" {
+				mergeSuggestEntry(byKurum[source.kurumID], key, entry)
+			}
+		}
+	}
+
+	// Institution names complete from any of their words ("güvenlik" ->
+	// "Sosyal Güvenlik Kurumu"), counted by the institution's documents
+	for kurumID, count := range kurumDocs {
+		name := kurumNames[kurumID]
+		words := textnorm.Tokens(name)
+		for i := range words {
+			key := strings.Join(words[i:], " ")
+			if existing, ok := all[key]; ok && existing.count >= count {
+				continue
+			}
+			all[key] = &suggestEntry{key: key, text: name, field: FieldInstitution, count: count}
+		}
+	}
+
+	s := &suggesters{all: newSuggestDict(all), byKurum: make(map[string]*suggestDict, len(byKurum))}
+	for kurumID, entries := range byKurum {
+		s.byKurum[kurumID] = newSuggestDict(entries)
+	}
+	return s
+}
+
+// addWords adds every run of 1 to maxWords consecutive words of text that
+// does not end in a short connective
+func addWords(text textnorm.Text, field Field, maxWords int, add func(key, text string, field Field)) {
+	words := text.Words()
+	for i := range words {
+		key := words[i].Term
+		for n := 1; n <= maxWords && i+n <= len(words); n++ {
+			if n > 1 {
+				if utf8.RuneCountInString(words[i+n-1].Term) < 3 {
+					break // "sağlığı ve" is no completion
+				}
+				key += " " + words[i+n-1].Term
+			}
+			add(key, textnorm.ToLower(text.Source[words[i].Start:words[i+n-1].End]), field)
+		}
+	}
+}
+
+// mergeSuggestEntry counts one more document for key in entries
+func mergeSuggestEntry(entries map[string]*suggestEntry, key string, entry *suggestEntry) {
+	existing, ok := entries[key]
+	if !ok {
+		entries[key] = &suggestEntry{key: key, text: entry.text, field: entry.field, count: 1}
+		return
+	}
+	existing.count++
+	if entry.field < existing.field {
+		existing.field = entry.field
+		existing.text = entry.text
+	}
+}
+
+// newSuggestDict sorts the entries and precomputes the best entries per block
+func newSuggestDict(entries map[string]*suggestEntry) *suggestDict {
+	d := &suggestDict{entries: make([]suggestEntry, 0, len(entries))}
+	for _, entry := range entries {
+		d.entries = append(d.entries, *entry)
+	}
+	sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].key < d.entries[j].key })
+
+	for start := 0; start < len(d.entries); start += suggestBlockSize {
+		end := start + suggestBlockSize
+		if end > len(d.entries) {
+			end = len(d.entries)
+		}
+		block := make([]int32, 0, end-start)
+		for i := start; i < end; i++ {
+			block = append(block, int32(i))
+		}
+		d.sortBest(block)
+		if len(block) > MaxSuggestions {
+			block = block[:MaxSuggestions]
+		}
+		d.blocks = append(d.blocks, block)
+	}
+	return d
+}
+
+func (d *suggestDict) sortBest(indexes []int32) {
+	sort.Slice(indexes, func(i, j int) bool {
+		return d.entries[indexes[i]].before(&d.entries[indexes[j]])
+	})
+}
+
+// top returns the most frequent entries whose key starts with prefix. Entries
+// in partially covered blocks are inspected one by one; fully covered blocks
+// contribute their precomputed best entries through a k-way merge.
+func (d *suggestDict) top(prefix string, limit int) []suggestEntry {
+	if d == nil || limit <= 0 {
+		return nil
+	}
+	if limit > MaxSuggestions {
+		limit = MaxSuggestions
+	}
+	lo := sort.Search(len(d.entries), func(i int) bool { return d.entries[i].key >= prefix })
+	hi := lo + sort.Search(len(d.entries)-lo, func(i int) bool { return !strings.HasPrefix(d.entries[lo+i].key, prefix) })
+	if lo == hi {
+		return nil
+	}
+
+	var partial []int32
+	lists := &bestLists{dict: d}
+	firstFull := (lo + suggestBlockSize - 1) / suggestBlockSize
+	lastFull := hi / suggestBlockSize // exclusive
+	if firstFull >= lastFull {
+		for i := lo; i < hi; i++ {
+			partial = append(partial, int32(i))
+		}
+	} else {
+		for i := lo; i < firstFull*suggestBlockSize; i++ {
+			partial = append(partial, int32(i))
+		}
+		for i := lastFull * suggestBlockSize; i < hi; i++ {
+			partial = append(partial, int32(i))
+		}
+		for b := firstFull; b < lastFull; b++ {
+			lists.items = append(lists.items, d.blocks[b])
+		}
+	}
+	// Prefixes covering whole blocks only leave no partial entries
+	if len(partial) > 0 {
+		d.sortBest(partial)
+		lists.items = append(lists.items, partial)
+	}
+	heap.Init(lists)
+
+	results := make([]suggestEntry, 0, limit)
+	for lists.Len() > 0 && len(results) < limit {
+		list := lists.items[0]
+		results = append(results, d.entries[list[0]])
+		if len(list) > 1 {
+			lists.items[0] = list[1:]
+			heap.Fix(lists, 0)
+		} else {
+			heap.Pop(lists)
+		}
+	}
+	return results
+}
+
+// find returns the entry whose key is key
+func (d *suggestDict) find(key string) (suggestEntry, bool) {
+	if d == nil {
+		return suggestEntry{}, false
+	}
+	i := sort.Search(len(d.entries), func(i int) bool { return d.entries[i].key >= key })
+	if i < len(d.entries) && d.entries[i].key == key {
+		return d.entries[i], true
+	}
+	return suggestEntry{}, false
+}
+
+// bestLists is a heap of entry lists, each sorted best first, ordered by
+// their first entry
+type bestLists struct {
+	dict  *suggestDict
+	items [][]int32
+}
+
+func (h *bestLists) Len() int { return len(h.items) }
+func (h *bestLists) Less(i, j int) bool {
+	return h.dict.entries[h.items[i][0]].before(&h.dict.entries[h.items[j][0]])
+}
+func (h *bestLists) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *bestLists) Push(x interface{}) { h.items = append(h.items, x.([]int32)) }
+func (h *bestLists) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// Suggest completes a partially typed query from the suggestion dictionary.
+// The whole query is completed as a prefix of titles, phrases, tags, keywords
+// and institution names. When nothing matches, misspelled words are corrected;
+// failing that, only the last word is completed after the words before it.
+func (idx *Index) Suggest(ctx context.Context, q SuggestQuery) ([]Suggestion, error) {
+	tokens := textnorm.Tokens(q.Text)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	limit := q.Limit
+	if limit <= 0 || limit > MaxSuggestions {
+		limit = MaxSuggestions
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.suggest == nil {
+		return nil, nil // not built yet
+	}
+	dict := idx.suggest.all
+	if q.KurumID != "" {
+		dict = idx.suggest.byKurum[q.KurumID]
+	}
+
+	if suggestions := suggestionsFrom(dict.top(strings.Join(tokens, " "), limit), "", false); len(suggestions) > 0 {
+		return suggestions, nil
+	}
+
+	// Correct unknown leading words and complete close spellings of the last
+	last := tokens[len(tokens)-1]
+	leading := make([]string, len(tokens)-1)
+	corrected := false
+	for i, token := range tokens[:len(tokens)-1] {
+		leading[i] = token
+		if _, ok := idx.terms[token]; !ok {
+			if correction, ok := idx.correctionLocked(token); ok {
+				leading[i] = correction
+				corrected = true
+			}
+		}
+	}
+	candidates := []string{last}
+	for _, m := range idx.fuzzyTermsLocked(last, true) {
+		candidates = append(candidates, m.term)
+	}
+	for i, candidate := range candidates {
+		if i == 0 && !corrected {
+			continue // the query as typed already found nothing
+		}
+		key := strings.Join(append(append([]string(nil), leading...), candidate), " ")
+		if suggestions := suggestionsFrom(dict.top(key, limit), "", true); len(suggestions) > 0 {
+			return suggestions, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Complete the last word on its own after the words typed before it
+	if len(leading) == 0 {
+		return nil, nil
+	}
+	display := make([]string, len(leading))
+	for i, token := range leading {
+		display[i] = idx.displayLocked(token)
+	}
+	var completions []suggestEntry
+	for _, entry := range dict.top(candidates[0], limit*2) {
+		if !strings.Contains(entry.key, " ") && entry.field != FieldInstitution {
+			completions = append(completions, entry)
+		}
+	}
+	return suggestionsFrom(completions, strings.Join(display, " "), corrected), nil
+}
+
+// SuggestionCount implements SearchEngine. It looks the text up in the
+// suggestion dictionary, so it costs a binary search rather than a query.
+func (idx *Index) SuggestionCount(text, kurumID string) int {
+	key := strings.Join(textnorm.Tokens(text), " ")
+	if key == "" {
+		return 0
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.suggest == nil {
+		return 0
+	}
+	dict := idx.suggest.all
+	if kurumID != "" {
+		dict = idx.suggest.byKurum[kurumID]
+	}
+	entry, _ := dict.find(key)
+	return entry.count
+}
+
+// suggestionsFrom converts dictionary entries into suggestions, prepending prefix
+func suggestionsFrom(entries []suggestEntry, prefix string, fuzzy bool) []Suggestion {
+	if len(entries) == 0 {
+		return nil
+	}
+	suggestions := make([]Suggestion, len(entries))
+	for i, entry := range entries {
+		text := entry.text
+		if prefix != "" {
+			text = prefix + " " + text
+		}
+		suggestions[i] = Suggestion{Text: text, Count: entry.count, Field: entry.field, Fuzzy: fuzzy}
+	}
+	return suggestions
+}
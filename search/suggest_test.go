@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"legal-documents-api/models"
+)
+
+func TestSuggestionCount(t *testing.T) {
+	idx := NewIndex()
+	for _, meta := range []models.DocumentMetadata{
+		{PdfAdi: "Kişisel Verilerin Korunması Kanunu", KurumID: "kvkk"},
+		{PdfAdi: "Kişisel Verilerin Korunması Kanunu Uygulama Rehberi", KurumID: "kvkk"},
+		{PdfAdi: "İş Kanunu", KurumID: "csgb", Etiketler: "kişisel veriler"},
+	} {
+		meta.ID = primitive.NewObjectID()
+		idx.Index(Document{Metadata: meta})
+	}
+	idx.RefreshSuggestions()
+
+	tests := []struct {
+		text, kurumID string
+		want          int
+	}{
+		{"Kişisel Verilerin", "", 2},
+		{"KİŞİSEL VERİLERİN", "", 2},
+		{"kişisel verilerin korunması kanunu", "", 1},
+		{"kişisel veriler", "", 1},
+		{"kanunu", "", 3},
+		{"kanunu", "csgb", 1},
+		{"kişisel verilerin", "csgb", 0},
+		{"kanunu", "unknown", 0},
+		{"kişisel verilerin korunması", "", 0}, // not a completion of its own
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := idx.SuggestionCount(tt.text, tt.kurumID); got != tt.want {
+			t.Errorf("SuggestionCount(%q, %q) = %d, want %d", tt.text, tt.kurumID, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestDictTop(t *testing.T) {
+	// Enough entries for prefixes to span whole blocks and partial ones
+	entries := make(map[string]*suggestEntry)
+	for i := 0; i < 5*suggestBlockSize; i++ {
+		key := fmt.Sprintf("%c%04d", 'a'+rune(i%3), i)
+		entries[key] = &suggestEntry{key: key, text: key, field: Field(i % 3), count: (i * 7919) % 97}
+	}
+	d := newSuggestDict(entries)
+
+	for _, tt := range []struct {
+		prefix string
+		limit  int
+	}{
+		{"", MaxSuggestions}, {"a", 10}, {"b0", 25}, {"c01", 5}, {"a0999", 3}, {"c00", 200}, {"z", 10},
+	} {
+		var want []string
+		var matching []*suggestEntry
+		for _, e := range entries {
+			if strings.HasPrefix(e.key, tt.prefix) {
+				matching = append(matching, e)
+			}
+		}
+		sort.Slice(matching, func(i, j int) bool { return matching[i].before(matching[j]) })
+		limit := tt.limit
+		if limit > MaxSuggestions {
+			limit = MaxSuggestions
+		}
+		for i := 0; i < len(matching) && i < limit; i++ {
+			want = append(want, matching[i].key)
+		}
+
+		var got []string
+		for _, e := range d.top(tt.prefix, tt.limit) {
+			got = append(got, e.key)
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("top(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	idx := NewIndex()
+	for _, doc := range []Document{
+		{Metadata: models.DocumentMetadata{PdfAdi: "Prim Borçlarının Yapılandırılması", KurumID: "sgk"}, KurumAdi: "Sosyal Güvenlik Kurumu"},
+		{Metadata: models.DocumentMetadata{PdfAdi: "Prim Teşviki Uygulaması", KurumID: "sgk", Etiketler: "prim teşviki"}, KurumAdi: "Sosyal Güvenlik Kurumu"},
+		{Metadata: models.DocumentMetadata{PdfAdi: "Prim Ödeme Süreleri", KurumID: "gib", Etiketler: "prim teşviki"}, KurumAdi: "Gelir İdaresi Başkanlığı"},
+	} {
+		doc.Metadata.ID = primitive.NewObjectID()
+		idx.Index(doc)
+	}
+	idx.RefreshSuggestions()
+
+	suggest := func(text, kurumID string, limit int) string {
+		t.Helper()
+		suggestions, err := idx.Suggest(context.Background(), SuggestQuery{Text: text, KurumID: kurumID, Limit: limit})
+		if err != nil {
+			t.Fatalf("Suggest(%q) failed: %v", text, err)
+		}
+		parts := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			parts[i] = fmt.Sprintf("%s/%d", s.Text, s.Count)
+			if s.Fuzzy {
+				parts[i] += "~"
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	tests := []struct {
+		text, kurumID string
+		limit         int
+		want          string
+	}{
+		// Most documents first, then titles before tags
+		{"pri", "", 3, "prim/3, prim teşviki/2, prim borçlarının/1"},
+		{"PRİM TEŞ", "", 0, "prim teşviki/2, prim teşviki uygulaması/1"},
+		{"prim teş", "gib", 0, "prim teşviki/1"},
+		{"pri", "gib", 2, "prim/1, prim ödeme/1"},
+		// Institution names complete from any of their words
+		{"güvenl", "", 0, "Sosyal Güvenlik Kurumu/2"},
+		// Misspellings are corrected
+		{"prin teşviki", "", 0, "prim teşviki/2~, prim teşviki uygulaması/1~"},
+		// The last word is completed on its own after the others
+		{"yeni yapılandır", "", 0, "yeni yapılandırılması/1"},
+		{"", "", 0, ""},
+		{"prim", "yok", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := suggest(tt.text, tt.kurumID, tt.limit); got != tt.want {
+			t.Errorf("Suggest(%q, %q) = %q, want %q", tt.text, tt.kurumID, got, tt.want)
+		}
+	}
+}
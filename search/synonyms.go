@@ -0,0 +1,104 @@
+package search
+
+import (
+	"strings"
+
+	"legal-documents-api/textnorm"
+	"legal-documents-api/utils"
+)
+
+// ExpandSynonyms rewrites a parsed query so that words and phrases listed in
+// the synonym dictionary also match their other spellings: "kvkk" becomes
+// (kvkk OR "kisisel verilerin korunmasi kanunu"). Runs of plain words are
+// matched as multi-word forms, longest first. Prefix terms are left alone.
+func ExpandSynonyms(node Node) Node {
+	maxWords := utils.SynonymMaxWords()
+	if maxWords == 0 {
+		return node
+	}
+	return expandNode(node, maxWords)
+}
+
+func expandNode(node Node, maxWords int) Node {
+	switch n := node.(type) {
+	case *TermNode:
+		if n.Prefix {
+			return n
+		}
+		return withSynonyms(n, n.Field, []string{n.Term})
+	case *PhraseNode:
+		return withSynonyms(n, n.Field, n.Terms)
+	case *NotNode:
+		return &NotNode{Child: expandNode(n.Child, maxWords)}
+	case *OrNode:
+		children := make([]Node, len(n.Children))
+		for i, child := range n.Children {
+			children[i] = expandNode(child, maxWords)
+		}
+		return &OrNode{Children: children}
+	case *AndNode:
+		return combineAnd(expandRuns(n.Children, maxWords))
+	}
+	return node
+}
+
+// expandRuns expands the children of an AndNode, replacing the longest run of
+// consecutive plain words that forms a dictionary entry with its alternatives
+func expandRuns(children []Node, maxWords int) []Node {
+	expanded := make([]Node, 0, len(children))
+	for i := 0; i < len(children); {
+		run := plainRun(children[i:], maxWords)
+		found := false
+		for n := len(run); n >= 2; n-- {
+			terms := make([]string, n)
+			for k, term := range run[:n] {
+				terms[k] = term.Term
+			}
+			if len(utils.GetSynonyms(strings.Join(terms, " "))) == 0 {
+				continue
+			}
+			group := make([]Node, n)
+			copy(group, children[i:i+n])
+			expanded = append(expanded, withSynonyms(&AndNode{Children: group}, run[0].Field, terms))
+			i += n
+			found = true
+			break
+		}
+		if !found {
+			expanded = append(expanded, expandNode(children[i], maxWords))
+			i++
+		}
+	}
+	return expanded
+}
+
+// plainRun returns up to max leading non-prefix terms sharing a field
+func plainRun(nodes []Node, max int) []*TermNode {
+	var run []*TermNode
+	for _, node := range nodes {
+		term, ok := node.(*TermNode)
+		if !ok || term.Prefix || len(run) == max || (len(run) > 0 && term.Field != run[0].Field) {
+			break
+		}
+		run = append(run, term)
+	}
+	return run
+}
+
+// withSynonyms returns original OR'ed with every dictionary alternative of terms
+func withSynonyms(original Node, field Field, terms []string) Node {
+	alternatives := utils.GetSynonyms(strings.Join(terms, " "))
+	if len(alternatives) == 0 {
+		return original
+	}
+	children := []Node{original}
+	for _, alternative := range alternatives {
+		if tokens := textnorm.Tokens(alternative); len(tokens) > 0 {
+			children = append(children, phraseOrTerm(field, tokens))
+		}
+	}
+	if len(children) == 1 {
+		return original
+	}
+	return &OrNode{Children: children}
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"testing"
+
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+func TestExpandSynonyms(t *testing.T) {
+	utils.SetSynonyms([]models.Synonym{
+		{Term: "Kişisel Verilerin Korunması Kanunu", Synonyms: []string{"KVKK"}},
+		{Term: "Sosyal Güvenlik Kurumu", Synonyms: []string{"SGK"}},
+		{Term: "yönetmelik", Synonyms: []string{"yön."}, OneWay: true},
+	})
+	defer utils.SetSynonyms(nil)
+
+	tests := []struct {
+		query, want string
+	}{
+		{"kvkk", `(kvkk OR "kisisel verilerin korunmasi kanunu")`},
+		{"KVKK ihlali", `((kvkk OR "kisisel verilerin korunmasi kanunu") AND ihlali)`},
+		// Multi-word forms are matched over runs of plain words
+		{"sosyal güvenlik kurumu genelgesi", `(((sosyal AND guvenlik AND kurumu) OR sgk) AND genelgesi)`},
+		{`"sosyal güvenlik kurumu"`, `("sosyal guvenlik kurumu" OR sgk)`},
+		{"baslik:sgk", `(title:sgk OR title:"sosyal guvenlik kurumu")`},
+		{"kvkk -sgk", `((kvkk OR "kisisel verilerin korunmasi kanunu") AND NOT (sgk OR "sosyal guvenlik kurumu"))`},
+		// One-way entries expand the abbreviation only
+		{"yön", "(yon OR yonetmelik)"},
+		{"yönetmelik", "yonetmelik"},
+		// Prefix terms and unknown words are left alone
+		{"sgk*", "sgk*"},
+		{"iş kanunu", "(is AND kanunu)"},
+	}
+	for _, tt := range tests {
+		node, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) failed: %v", tt.query, err)
+		}
+		if got := ExpandSynonyms(node).String(); got != tt.want {
+			t.Errorf("ExpandSynonyms(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchWithSynonyms(t *testing.T) {
+	utils.SetSynonyms([]models.Synonym{{Term: "Sosyal Güvenlik Kurumu", Synonyms: []string{"SGK"}}})
+	defer utils.SetSynonyms(nil)
+
+	idx := newTestIndex(
+		testDocument(1, "SGK genelgesi", "prim borçları"),
+		testDocument(2, "Sosyal Güvenlik Kurumu duyurusu", "prim borçları"),
+		testDocument(3, "Sosyal politika", "kurumu güvenlik"),
+	)
+	node, _ := ParseQuery("sgk")
+	if got := searchIDs(t, idx, Query{Node: ExpandSynonyms(node)}); !equalInts(got, []int{1, 2}) {
+		t.Errorf("sgk = %v, want [1 2]", got)
+	}
+}
@@ -0,0 +1,53 @@
+package semantic
+
+import (
+	"strings"
+
+	"legal-documents-api/textnorm"
+)
+
+const (
+	// ChunkWords is the number of words per chunk: long enough to carry the
+	// context of an article, short enough that one topic dominates the vector
+	ChunkWords = 150
+	// ChunkOverlap is how many words consecutive chunks share, so a passage cut
+	// at a chunk boundary is still whole in one of them
+	ChunkOverlap = 30
+)
+
+// Chunk is a passage of a document's content
+type Chunk struct {
+	Index int    // position of the chunk in the document
+	Text  string // source text of the passage
+}
+
+// SplitChunks splits text into overlapping passages of about size words.
+// Chunks start at a paragraph break when one falls within the overlap window.
+func SplitChunks(text string, size, overlap int) []Chunk {
+	if overlap >= size {
+		overlap = size / 4
+	}
+	words := textnorm.NewText(text).Words()
+	var chunks []Chunk
+	for start := 0; start < len(words); {
+		end := start + size
+		if end > len(words) {
+			end = len(words)
+		}
+		passage := strings.TrimSpace(text[words[start].Start:words[end-1].End])
+		chunks = append(chunks, Chunk{Index: len(chunks), Text: passage})
+		if end == len(words) {
+			break
+		}
+
+		next := end - overlap
+		for i := next; i < end; i++ {
+			if strings.Contains(text[words[i-1].End:words[i].Start], "\n") {
+				next = i
+				break
+			}
+		}
+		start = next
+	}
+	return chunks
+}
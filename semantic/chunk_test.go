@@ -0,0 +1,51 @@
+package semantic
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func numbered(from, to int) string {
+	words := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		words = append(words, "k"+strconv.Itoa(i))
+	}
+	return strings.Join(words, " ")
+}
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		size, overlap int
+		want          []string
+	}{
+		{"short text", "Tek paragraf.", 10, 2, []string{"Tek paragraf"}},
+		{"empty", "  \n ", 10, 2, nil},
+		// Consecutive chunks share overlap words
+		{"overlap", numbered(0, 10), 4, 1, []string{numbered(0, 4), numbered(3, 7), numbered(6, 10)}},
+		// A paragraph break inside the overlap window starts the next chunk
+		{
+			"paragraph break",
+			numbered(0, 4) + "\n" + numbered(4, 8),
+			6, 3,
+			[]string{numbered(0, 4) + "\n" + numbered(4, 6), numbered(4, 8)},
+		},
+		// An overlap as large as the chunk falls back to a quarter of it
+		{"overlap too large", numbered(0, 12), 8, 8, []string{numbered(0, 8), numbered(6, 12)}},
+	}
+	for _, tt := range tests {
+		chunks := SplitChunks(tt.text, tt.size, tt.overlap)
+		var got []string
+		for i, chunk := range chunks {
+			if chunk.Index != i {
+				t.Errorf("%s: chunk %d has index %d", tt.name, i, chunk.Index)
+			}
+			got = append(got, chunk.Text)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("%s: chunks = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package semantic provides vector search over document content: text is
+// split into chunks, embedded with an Embedder and looked up by approximate
+// nearest neighbours
+package semantic
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+
+	"legal-documents-api/textnorm"
+)
+
+// Embedder turns text into a fixed-size vector whose cosine similarity to
+// other vectors reflects semantic similarity
+type Embedder interface {
+	// Name identifies the model; stored vectors are only comparable within a model
+	Name() string
+	// Dimensions is the length of every vector Embed returns
+	Dimensions() int
+	// Embed returns the L2-normalized vector of text; empty text yields a zero vector
+	Embed(text string) []float32
+}
+
+// HashEmbedder is a deterministic, dependency-free Embedder using the hashing
+// trick: words, word pairs and character trigrams are hashed into a fixed
+// number of signed buckets. Trigrams let inflected Turkish forms
+// ("sözleşme", "sözleşmenin") land close together. It needs no model files,
+// so it runs offline and always produces the same vectors.
+type HashEmbedder struct {
+	dims int
+}
+
+// Feature weights of the hash embedder
+const (
+	hashWordWeight    = 1.0
+	hashBigramWeight  = 0.7
+	hashTrigramWeight = 0.4
+)
+
+// NewHashEmbedder creates a hash embedder producing vectors of dims dimensions
+func NewHashEmbedder(dims int) *HashEmbedder {
+	return &HashEmbedder{dims: dims}
+}
+
+// Name implements Embedder
+func (e *HashEmbedder) Name() string {
+	return "hash-ngram-" + strconv.Itoa(e.dims)
+}
+
+// Dimensions implements Embedder
+func (e *HashEmbedder) Dimensions() int {
+	return e.dims
+}
+
+// Embed implements Embedder
+func (e *HashEmbedder) Embed(text string) []float32 {
+	counts := make(map[string]float64)
+	tokens := textnorm.Tokens(text)
+	for i, token := range tokens {
+		counts["w:"+token] += hashWordWeight
+		if i > 0 {
+			counts["b:"+tokens[i-1]+" "+token] += hashBigramWeight
+		}
+		runes := []rune("#" + token + "#")
+		for j := 0; j+3 <= len(runes); j++ {
+			counts["t:"+string(runes[j:j+3])] += hashTrigramWeight
+		}
+	}
+
+	// Features are added in sorted order: summing in map order would make the
+	// rounding, and so the vector, differ between calls
+	features := make([]string, 0, len(counts))
+	for feature := range counts {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	vector := make([]float64, e.dims)
+	for _, feature := range features {
+		count := counts[feature]
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		// Sublinear term frequency, so repeated words do not dominate
+		weight := 1 + math.Log(count)
+		if count < 1 {
+			weight = count
+		}
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[sum%uint64(e.dims)] += weight
+	}
+	return normalize(vector)
+}
+
+// normalize scales v to unit length
+func normalize(v []float64) []float32 {
+	norm := 0.0
+	for _, x := range v {
+		norm += x * x
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = float32(x / norm)
+	}
+	return out
+}
+
+// dot returns the dot product of two vectors of equal length, which is their
+// cosine similarity when both are normalized
+func dot(a, b []float32) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
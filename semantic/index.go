@@ -0,0 +1,92 @@
+package semantic
+
+import (
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chunksPerResult is how many chunk neighbours are fetched per requested
+// document, since several chunks of one document often rank close together
+const chunksPerResult = 5
+
+// DocumentMatch is a document with a passage similar to the query
+type DocumentMatch struct {
+	DocumentID  string
+	Score       float64            // cosine similarity of the best passage
+	EmbeddingID primitive.ObjectID // the best passage
+	Chunk       int
+}
+
+// Index answers semantic queries: it embeds the query with the same Embedder
+// used for the stored chunks and looks up the nearest chunk vectors
+type Index struct {
+	embedder Embedder
+	vectors  *VectorIndex
+	ready    int32
+}
+
+// NewIndex creates an empty semantic index using embedder
+func NewIndex(embedder Embedder) *Index {
+	return &Index{
+		embedder: embedder,
+		vectors:  NewVectorIndex(embedder.Dimensions()),
+	}
+}
+
+// Embedder returns the embedder of the index
+func (idx *Index) Embedder() Embedder {
+	return idx.embedder
+}
+
+// Ready reports whether the stored vectors have been loaded
+func (idx *Index) Ready() bool {
+	return atomic.LoadInt32(&idx.ready) == 1
+}
+
+// MarkReady flags the initial load as complete
+func (idx *Index) MarkReady() {
+	atomic.StoreInt32(&idx.ready, 1)
+}
+
+// Len returns the number of indexed chunks
+func (idx *Index) Len() int {
+	return idx.vectors.Len()
+}
+
+// Search returns up to limit documents whose passages are most similar to
+// text, best first. Each document appears once, scored by its best passage.
+// When keep is not nil only documents it accepts are returned, and fewer than
+// limit only when no other document is accepted.
+func (idx *Index) Search(text string, limit int, keep func(documentID string) bool) []DocumentMatch {
+	vector := idx.embedder.Embed(text)
+	if dot(vector, vector) == 0 || limit <= 0 {
+		return nil // no words to compare
+	}
+
+	// Several chunks of a document may take the places of others; fetch more
+	// until limit documents are found or every chunk was returned
+	for k := limit * chunksPerResult; ; k *= 4 {
+		chunks := idx.vectors.Search(vector, k, keep)
+		var matches []DocumentMatch
+		seen := make(map[string]bool)
+		for _, chunk := range chunks {
+			if seen[chunk.DocumentID] {
+				continue
+			}
+			seen[chunk.DocumentID] = true
+			matches = append(matches, DocumentMatch{
+				DocumentID:  chunk.DocumentID,
+				Score:       chunk.Score,
+				EmbeddingID: chunk.EmbeddingID,
+				Chunk:       chunk.Chunk,
+			})
+			if len(matches) == limit {
+				return matches
+			}
+		}
+		if len(chunks) < k {
+			return matches
+		}
+	}
+}
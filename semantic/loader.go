@@ -0,0 +1,262 @@
+package semantic
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/editorial"
+	"legal-documents-api/models"
+)
+
+// contentBatchSize is how many documents are embedded per round trip
+const contentBatchSize = 50
+
+// EnsureIndexes creates the content_embeddings indexes
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetContentEmbeddingsCollection(client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "model", Value: 1}, {Key: "metadata_id", Value: 1}, {Key: "chunk", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// LoadFromMongo loads the stored vectors of the index's embedder, embeds
+// active documents that have none yet and marks the index ready
+func LoadFromMongo(ctx context.Context, client *mongo.Client, idx *Index) error {
+	start := time.Now()
+	if err := EnsureIndexes(ctx, client); err != nil {
+		return err
+	}
+	if err := loadStored(ctx, client, idx); err != nil {
+		return err
+	}
+	if err := Refresh(ctx, client, idx); err != nil {
+		return err
+	}
+	idx.MarkReady()
+	log.Printf("Semantic index loaded with %d chunks in %s", idx.Len(), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// loadStored reads every stored vector produced by the index's embedder
+func loadStored(ctx context.Context, client *mongo.Client, idx *Index) error {
+	findOptions := options.Find().
+		SetProjection(bson.M{"metadata_id": 1, "chunk": 1, "vector": 1}).
+		SetSort(bson.D{{Key: "metadata_id", Value: 1}, {Key: "chunk", Value: 1}})
+	cursor, err := config.GetContentEmbeddingsCollection(client).Find(ctx, bson.M{"model": idx.embedder.Name()}, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var current primitive.ObjectID
+	var ids []primitive.ObjectID
+	var vectors [][]float32
+	flush := func() {
+		if len(ids) > 0 {
+			idx.vectors.Add(current.Hex(), ids, vectors)
+		}
+		ids, vectors = nil, nil
+	}
+	for cursor.Next(ctx) {
+		var embedding models.ContentEmbedding
+		if err := cursor.Decode(&embedding); err != nil {
+			continue
+		}
+		if embedding.MetadataID != current {
+			flush()
+			current = embedding.MetadataID
+		}
+		ids = append(ids, embedding.ID)
+		vectors = append(vectors, embedding.Vector)
+	}
+	flush()
+	return cursor.Err()
+}
+
+// Refresh embeds active documents that have no vectors yet and drops the
+// vectors of documents that are gone or no longer active. Documents whose
+// content changes keep their vectors until Rebuild, unless the writer calls
+// Forget.
+func Refresh(ctx context.Context, client *mongo.Client, idx *Index) error {
+	cursor, err := config.GetMetadataCollection(client).Find(ctx, bson.M{"status": editorial.ListedFilter()},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	active := make(map[string]bool)
+	var missing []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var metadata struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&metadata); err != nil {
+			continue
+		}
+		active[metadata.ID.Hex()] = true
+		if !idx.vectors.HasDocument(metadata.ID.Hex()) {
+			missing = append(missing, metadata.ID)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	var removed []string
+	var removedIDs []primitive.ObjectID
+	for _, doc := range idx.vectors.Documents() {
+		if !active[doc] {
+			removed = append(removed, doc)
+			if id, err := primitive.ObjectIDFromHex(doc); err == nil {
+				removedIDs = append(removedIDs, id)
+			}
+		}
+	}
+	if len(removedIDs) > 0 {
+		if _, err := config.GetContentEmbeddingsCollection(client).DeleteMany(ctx, bson.M{"metadata_id": bson.M{"$in": removedIDs}}); err != nil {
+			return err
+		}
+		idx.vectors.Remove(removed...)
+	}
+
+	embedded := 0
+	for start := 0; start < len(missing); start += contentBatchSize {
+		end := start + contentBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		n, err := embedBatch(ctx, client, idx, missing[start:end])
+		if err != nil {
+			return err
+		}
+		embedded += n
+	}
+
+	if embedded > 0 || len(removed) > 0 {
+		log.Printf("Semantic index refreshed: %d documents embedded, %d removed", embedded, len(removed))
+	}
+	return nil
+}
+
+// embedBatch chunks, embeds and stores the content of the given documents,
+// returning how many had content
+func embedBatch(ctx context.Context, client *mongo.Client, idx *Index, ids []primitive.ObjectID) (int, error) {
+	findOptions := options.Find().SetProjection(bson.M{"metadata_id": 1, "icerik": 1})
+	cursor, err := config.GetContentCollection(client).Find(ctx, bson.M{"metadata_id": bson.M{"$in": ids}}, findOptions)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	collection := config.GetContentEmbeddingsCollection(client)
+	model := idx.embedder.Name()
+	embedded := 0
+	for cursor.Next(ctx) {
+		var content models.DocumentContent
+		if err := cursor.Decode(&content); err != nil {
+			continue
+		}
+		chunks := SplitChunks(content.Icerik, ChunkWords, ChunkOverlap)
+		if len(chunks) == 0 {
+			continue
+		}
+
+		now := time.Now().UTC()
+		rows := make([]interface{}, len(chunks))
+		chunkIDs := make([]primitive.ObjectID, len(chunks))
+		vectors := make([][]float32, len(chunks))
+		for i, chunk := range chunks {
+			chunkIDs[i] = primitive.NewObjectID()
+			vectors[i] = idx.embedder.Embed(chunk.Text)
+			rows[i] = models.ContentEmbedding{
+				ID:         chunkIDs[i],
+				MetadataID: content.MetadataID,
+				Chunk:      chunk.Index,
+				Text:       chunk.Text,
+				Model:      model,
+				Vector:     vectors[i],
+				CreatedAt:  now,
+			}
+		}
+
+		// Replace whatever an interrupted earlier run left behind
+		if _, err := collection.DeleteMany(ctx, bson.M{"model": model, "metadata_id": content.MetadataID}); err != nil {
+			return embedded, err
+		}
+		if _, err := collection.InsertMany(ctx, rows); err != nil {
+			return embedded, err
+		}
+		idx.vectors.Add(content.MetadataID.Hex(), chunkIDs, vectors)
+		embedded++
+	}
+	return embedded, cursor.Err()
+}
+
+// Rebuild discards the stored vectors of the index's embedder and embeds
+// every active document again, picking up changed content
+func Rebuild(ctx context.Context, client *mongo.Client, idx *Index) error {
+	if _, err := config.GetContentEmbeddingsCollection(client).DeleteMany(ctx, bson.M{"model": idx.embedder.Name()}); err != nil {
+		return err
+	}
+	idx.vectors.Remove(idx.vectors.Documents()...)
+	return Refresh(ctx, client, idx)
+}
+
+// Forget drops the vectors of a document whose content changed, so the next
+// refresh embeds it again
+func Forget(ctx context.Context, client *mongo.Client, idx *Index, id primitive.ObjectID) error {
+	if _, err := config.GetContentEmbeddingsCollection(client).DeleteMany(ctx, bson.M{"metadata_id": id}); err != nil {
+		return err
+	}
+	idx.vectors.Remove(id.Hex())
+	return nil
+}
+
+// Passages returns the stored text of the given chunks by embedding id
+func Passages(ctx context.Context, client *mongo.Client, ids []primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+	passages := make(map[primitive.ObjectID]string, len(ids))
+	if len(ids) == 0 {
+		return passages, nil
+	}
+	findOptions := options.Find().SetProjection(bson.M{"text": 1})
+	cursor, err := config.GetContentEmbeddingsCollection(client).Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var embedding models.ContentEmbedding
+		if err := cursor.Decode(&embedding); err != nil {
+			continue
+		}
+		passages[embedding.ID] = embedding.Text
+	}
+	return passages, cursor.Err()
+}
+
+// StartRefresher periodically embeds new documents in the background
+func StartRefresher(client *mongo.Client, idx *Index, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := Refresh(ctx, client, idx); err != nil {
+				log.Printf("Warning: Semantic index refresh failed: %v", err)
+			} else if !idx.Ready() {
+				idx.MarkReady()
+			}
+			cancel()
+		}
+	}()
+}
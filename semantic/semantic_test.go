@@ -0,0 +1,191 @@
+package semantic
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testDims = 256
+
+	passage   = "İşveren, işçinin iş sözleşmesini bildirim süresine uymadan feshederse, bildirim süresine ilişkin ücret tutarında tazminat ödemek zorundadır. Bildirim süreleri işçinin işyerindeki kıdemine göre belirlenir."
+	nearCopy  = "İşveren, işçinin iş sözleşmesini bildirim sürelerine uymadan feshederse, bildirim süresine ilişkin ücret tutarında tazminat öder. Bildirim süreleri işçinin kıdemine göre belirlenir."
+	unrelated = "Gelir vergisi beyannamesi mart ayının son gününe kadar vergi dairesine verilir; kira geliri elde eden mükellefler de beyanname vermekle yükümlüdür."
+)
+
+func TestHashEmbedderIsDeterministic(t *testing.T) {
+	tests := []string{passage, unrelated, "iş kanunu", "a"}
+	for _, text := range tests {
+		first := NewHashEmbedder(testDims).Embed(text)
+		second := NewHashEmbedder(testDims).Embed(text)
+		if len(first) != testDims {
+			t.Fatalf("Embed(%q) has %d dimensions, want %d", text, len(first), testDims)
+		}
+		for i := range first {
+			if first[i] != second[i] {
+				t.Errorf("Embed(%q) differs between calls at %d: %v and %v", text, i, first[i], second[i])
+				break
+			}
+		}
+		if norm := dot(first, first); math.Abs(norm-1) > 1e-5 {
+			t.Errorf("Embed(%q) has squared norm %v, want 1", text, norm)
+		}
+	}
+}
+
+func TestHashEmbedderSimilarity(t *testing.T) {
+	e := NewHashEmbedder(testDims)
+	tests := []struct {
+		name     string
+		a, b     string
+		min, max float64
+	}{
+		{"case and diacritics", "İŞ SÖZLEŞMESİ", "iş sözleşmesi", 1 - 1e-5, 1 + 1e-5},
+		{"near copy", passage, nearCopy, 0.75, 1},
+		{"unrelated", passage, unrelated, -0.3, 0.3},
+		{"inflected form", "sözleşme", "sözleşmenin", 0.4, 1},
+		{"different words", "sözleşme", "vergi", -0.3, 0.3},
+		{"no words", "", "?!", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := dot(e.Embed(tt.a), e.Embed(tt.b)); got < tt.min || got > tt.max {
+			t.Errorf("%s: similarity = %.3f, want between %.2f and %.2f", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+// randomVectors returns n random unit vectors, enough of them to make Search
+// take its candidates from the LSH buckets instead of scanning every vector
+func randomVectors(n int) [][]float32 {
+	rng := rand.New(rand.NewSource(1))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		v := make([]float64, testDims)
+		for j := range v {
+			v[j] = rng.NormFloat64()
+		}
+		vectors[i] = normalize(v)
+	}
+	return vectors
+}
+
+func TestVectorIndexFindsNearDuplicate(t *testing.T) {
+	e := NewHashEmbedder(testDims)
+	idx := NewIndex(e)
+	add := func(doc string, vectors ...[]float32) {
+		ids := make([]primitive.ObjectID, len(vectors))
+		for i := range ids {
+			ids[i] = primitive.NewObjectID()
+		}
+		idx.vectors.Add(doc, ids, vectors)
+	}
+	for i, vector := range randomVectors(20000) {
+		add("filler"+strconv.Itoa(i), vector)
+	}
+	add("unrelated", e.Embed(unrelated))
+	add("target", e.Embed(unrelated+" ek madde"), e.Embed(passage))
+
+	matches := idx.Search(nearCopy, 3, nil)
+	if len(matches) == 0 {
+		t.Fatal("Search found nothing")
+	}
+	if matches[0].DocumentID != "target" || matches[0].Chunk != 1 {
+		t.Errorf("best match is chunk %d of %s, want chunk 1 of target", matches[0].Chunk, matches[0].DocumentID)
+	}
+	for _, m := range matches[1:] {
+		if m.DocumentID == "target" {
+			t.Errorf("target listed twice")
+		}
+		if m.Score >= matches[0].Score {
+			t.Errorf("%s scores %.3f, not below the near duplicate's %.3f", m.DocumentID, m.Score, matches[0].Score)
+		}
+	}
+
+	// The exact copy ranks ahead of a chunk that extends it
+	matches = idx.Search(unrelated, 2, nil)
+	if len(matches) != 2 || matches[0].DocumentID != "unrelated" || matches[1].DocumentID != "target" {
+		t.Fatalf("Search(unrelated) = %+v, want unrelated, then target", matches)
+	}
+
+	idx.vectors.Remove("target")
+	for _, m := range idx.Search(nearCopy, 3, nil) {
+		if m.DocumentID == "target" {
+			t.Errorf("removed document still found")
+		}
+	}
+	if got := idx.Search("?!", 3, nil); got != nil {
+		t.Errorf("Search without words = %+v, want nil", got)
+	}
+}
+
+func TestVectorIndexCompaction(t *testing.T) {
+	vi := NewVectorIndex(testDims)
+	vectors := randomVectors(8)
+	for i, vector := range vectors {
+		vi.Add("doc"+strconv.Itoa(i), []primitive.ObjectID{primitive.NewObjectID()}, [][]float32{vector})
+	}
+	// Removing three of eight passes the quarter threshold and renumbers
+	// the remaining entries
+	vi.Remove("doc0", "doc1", "doc2")
+	if vi.deleted != 0 || len(vi.entries) != 5 {
+		t.Fatalf("after compaction: %d entries, %d deleted, want 5 and 0", len(vi.entries), vi.deleted)
+	}
+	for i := 3; i < 8; i++ {
+		matches := vi.Search(vectors[i], 1, nil)
+		if len(matches) != 1 || matches[0].DocumentID != "doc"+strconv.Itoa(i) {
+			t.Errorf("Search(vector %d) = %+v, want doc%d", i, matches, i)
+		}
+	}
+	if vi.HasDocument("doc1") || !vi.HasDocument("doc7") {
+		t.Errorf("HasDocument does not reflect the removal")
+	}
+	if got := vi.Search(vectors[3][:10], 1, nil); got != nil {
+		t.Errorf("Search with the wrong dimensions = %+v, want nil", got)
+	}
+}
+
+func TestIndexSearchKeep(t *testing.T) {
+	e := NewHashEmbedder(testDims)
+	idx := NewIndex(e)
+	add := func(doc string, vectors ...[]float32) {
+		ids := make([]primitive.ObjectID, len(vectors))
+		for i := range ids {
+			ids[i] = primitive.NewObjectID()
+		}
+		idx.vectors.Add(doc, ids, vectors)
+	}
+	vectors := randomVectors(5002)
+	for i, vector := range vectors[:5000] {
+		add("filler"+strconv.Itoa(i), vector)
+	}
+	add("target", e.Embed(passage))
+	// Many close chunks of one document fill the first neighbours
+	var chunks [][]float32
+	for i := 0; i < 40; i++ {
+		chunks = append(chunks, e.Embed(nearCopy+" madde "+strconv.Itoa(i)))
+	}
+	add("chatty", chunks...)
+	// Accepted documents far from the query
+	add("far1", vectors[5000])
+	add("far2", vectors[5001])
+
+	keep := func(doc string) bool { return doc == "chatty" || doc == "far1" || doc == "far2" }
+	matches := idx.Search(nearCopy, 3, keep)
+	found := make(map[string]bool)
+	for _, m := range matches {
+		found[m.DocumentID] = true
+	}
+	if len(matches) != 3 || matches[0].DocumentID != "chatty" || !found["far1"] || !found["far2"] {
+		t.Errorf("Search with keep = %+v, want chatty, far1 and far2", matches)
+	}
+
+	// Fewer accepted documents than the limit return all of them
+	only := func(doc string) bool { return doc == "far2" }
+	if matches := idx.Search(nearCopy, 5, only); len(matches) != 1 || matches[0].DocumentID != "far2" {
+		t.Errorf("Search keeping far2 = %+v, want far2 only", matches)
+	}
+}
@@ -0,0 +1,251 @@
+package semantic
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// lshTables and lshBits shape the random hyperplane index: each table
+	// hashes a vector to lshBits sign bits, so vectors at a small angle tend to
+	// share a bucket in at least one table
+	lshTables = 8
+	lshBits   = 12
+	// lshSeed makes the hyperplanes, and so the buckets, reproducible
+	lshSeed = 20240601
+	// bruteForceBelow is the candidate count under which every vector is
+	// scanned instead; small corpora get exact results
+	bruteForceBelow = 200
+)
+
+// vectorEntry is one stored chunk vector
+type vectorEntry struct {
+	id      primitive.ObjectID // content_embeddings id
+	doc     string             // metadata id
+	chunk   int
+	vector  []float32
+	deleted bool
+}
+
+// ChunkMatch is a chunk similar to the query vector
+type ChunkMatch struct {
+	EmbeddingID primitive.ObjectID
+	DocumentID  string
+	Chunk       int
+	Score       float64 // cosine similarity
+}
+
+// VectorIndex is an in-memory approximate nearest neighbour index using
+// random hyperplane locality sensitive hashing. Candidates from the query's
+// buckets, and the buckets one bit away, are re-ranked by exact cosine
+// similarity. It is safe for concurrent use.
+type VectorIndex struct {
+	mu      sync.RWMutex
+	dims    int
+	planes  [lshTables][lshBits][]float32
+	buckets [lshTables]map[uint32][]int32
+	entries []vectorEntry
+	byDoc   map[string][]int32
+	deleted int
+}
+
+// NewVectorIndex creates an empty index for vectors of dims dimensions
+func NewVectorIndex(dims int) *VectorIndex {
+	vi := &VectorIndex{dims: dims, byDoc: make(map[string][]int32)}
+	rng := rand.New(rand.NewSource(lshSeed))
+	for t := range vi.planes {
+		for b := range vi.planes[t] {
+			plane := make([]float32, dims)
+			for i := range plane {
+				plane[i] = float32(rng.NormFloat64())
+			}
+			vi.planes[t][b] = plane
+		}
+		vi.buckets[t] = make(map[uint32][]int32)
+	}
+	return vi
+}
+
+// Len returns the number of stored chunk vectors
+func (vi *VectorIndex) Len() int {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	return len(vi.entries) - vi.deleted
+}
+
+// HasDocument reports whether any chunk of the document is stored
+func (vi *VectorIndex) HasDocument(doc string) bool {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	return len(vi.byDoc[doc]) > 0
+}
+
+// Documents returns the metadata ids of every stored document
+func (vi *VectorIndex) Documents() []string {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	docs := make([]string, 0, len(vi.byDoc))
+	for doc := range vi.byDoc {
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// Add stores the chunk vectors of a document, replacing any it had
+func (vi *VectorIndex) Add(doc string, ids []primitive.ObjectID, vectors [][]float32) {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+
+	vi.removeLocked(doc)
+	for i, vector := range vectors {
+		if len(vector) != vi.dims {
+			continue
+		}
+		internal := int32(len(vi.entries))
+		vi.entries = append(vi.entries, vectorEntry{id: ids[i], doc: doc, chunk: i, vector: vector})
+		vi.byDoc[doc] = append(vi.byDoc[doc], internal)
+		for t := range vi.buckets {
+			key := vi.hashLocked(t, vector)
+			vi.buckets[t][key] = append(vi.buckets[t][key], internal)
+		}
+	}
+}
+
+// Remove deletes every chunk of the given documents
+func (vi *VectorIndex) Remove(docs ...string) {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+
+	for _, doc := range docs {
+		vi.removeLocked(doc)
+	}
+	// Rebuild once tombstones make up a quarter of the entries
+	if vi.deleted > 0 && vi.deleted*4 > len(vi.entries) {
+		vi.compactLocked()
+	}
+}
+
+func (vi *VectorIndex) removeLocked(doc string) {
+	for _, internal := range vi.byDoc[doc] {
+		vi.entries[internal].deleted = true
+		vi.entries[internal].vector = nil
+		vi.deleted++
+	}
+	delete(vi.byDoc, doc)
+}
+
+// compactLocked drops deleted entries and rebuilds the buckets
+func (vi *VectorIndex) compactLocked() {
+	live := make([]vectorEntry, 0, len(vi.entries)-vi.deleted)
+	for _, entry := range vi.entries {
+		if !entry.deleted {
+			live = append(live, entry)
+		}
+	}
+	vi.entries = live
+	vi.deleted = 0
+	vi.byDoc = make(map[string][]int32)
+	for t := range vi.buckets {
+		vi.buckets[t] = make(map[uint32][]int32)
+	}
+	for i, entry := range vi.entries {
+		internal := int32(i)
+		vi.byDoc[entry.doc] = append(vi.byDoc[entry.doc], internal)
+		for t := range vi.buckets {
+			key := vi.hashLocked(t, entry.vector)
+			vi.buckets[t][key] = append(vi.buckets[t][key], internal)
+		}
+	}
+}
+
+// hashLocked returns the bucket of vector in table t: one bit per hyperplane,
+// set when the vector lies on its positive side
+func (vi *VectorIndex) hashLocked(t int, vector []float32) uint32 {
+	var key uint32
+	for b, plane := range vi.planes[t] {
+		if dot(plane, vector) >= 0 {
+			key |= 1 << uint(b)
+		}
+	}
+	return key
+}
+
+// Search returns up to k chunks most similar to vector, best first. When keep
+// is not nil only chunks of documents it accepts are returned; they are
+// selected before the k best are taken, so a selective keep still finds k
+// chunks when there are that many.
+func (vi *VectorIndex) Search(vector []float32, k int, keep func(doc string) bool) []ChunkMatch {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+
+	if len(vector) != vi.dims || k <= 0 {
+		return nil
+	}
+
+	kept := make(map[string]bool)
+	accept := func(entry *vectorEntry) bool {
+		if entry.deleted {
+			return false
+		}
+		if keep == nil {
+			return true
+		}
+		ok, known := kept[entry.doc]
+		if !known {
+			ok = keep(entry.doc)
+			kept[entry.doc] = ok
+		}
+		return ok
+	}
+
+	seen := make(map[int32]bool)
+	var candidates []int32
+	collect := func(internals []int32) {
+		for _, internal := range internals {
+			if !seen[internal] && accept(&vi.entries[internal]) {
+				seen[internal] = true
+				candidates = append(candidates, internal)
+			}
+		}
+	}
+	for t := range vi.buckets {
+		key := vi.hashLocked(t, vector)
+		collect(vi.buckets[t][key])
+		// Multi-probe: neighbouring buckets differ in one hyperplane
+		for b := 0; b < lshBits; b++ {
+			collect(vi.buckets[t][key^(1<<uint(b))])
+		}
+	}
+	if len(candidates) < bruteForceBelow || len(candidates) < k {
+		candidates = candidates[:0]
+		for i := range vi.entries {
+			if accept(&vi.entries[i]) {
+				candidates = append(candidates, int32(i))
+			}
+		}
+	}
+
+	matches := make([]ChunkMatch, 0, len(candidates))
+	for _, internal := range candidates {
+		entry := &vi.entries[internal]
+		matches = append(matches, ChunkMatch{
+			EmbeddingID: entry.id,
+			DocumentID:  entry.doc,
+			Chunk:       entry.chunk,
+			Score:       dot(vector, entry.vector),
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].EmbeddingID.Hex() < matches[j].EmbeddingID.Hex()
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
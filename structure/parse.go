@@ -0,0 +1,296 @@
+// Package structure parses the article structure of legislative texts
+package structure
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"legal-documents-api/models"
+)
+
+// Version is bumped whenever parsing changes, so stored structures produced by
+// an older parser are parsed again
+const Version = 1
+
+// Node types
+const (
+	TypeKisim         = "kisim"
+	TypeBolum         = "bolum"
+	TypeMadde         = "madde"
+	TypeGeciciMadde   = "gecici_madde"
+	TypeEkMadde       = "ek_madde"
+	TypeEkGeciciMadde = "ek_gecici_madde"
+	TypeFikra         = "fikra"
+	TypeBent          = "bent"
+)
+
+// maxHeadingLength is the longest line taken as a heading, in runes
+const maxHeadingLength = 120
+
+var (
+	// Headings are matched against the Turkish upper-cased line, so
+	// "Birinci Bölüm" and "BİRİNCİ BÖLÜM" are alike
+	divisionPattern = regexp.MustCompile(`^(\p{Lu}+)\s+(KISIM|BÖLÜM)$`)
+	articlePattern  = regexp.MustCompile(`^(EK GEÇİCİ MADDE|GEÇİCİ MADDE|EK MADDE|MADDE)\s+(\d+(?:\s*/\s*\p{Lu})?)\s*(?:[-–—.:]|$)`)
+	// A paragraph starts a line or follows the dash after the article number
+	paragraphPattern = regexp.MustCompile(`(?m)(?:^|[-–—]\s*)[ \t]*\((\d{1,2})\)\s`)
+	// A subparagraph starts a line with a letter and a closing parenthesis
+	subparagraphPattern = regexp.MustCompile(`(?m)^[ \t]*(\p{Ll})\)\s`)
+)
+
+// articleTypes maps the upper-cased article keyword to its node type
+var articleTypes = map[string]string{
+	"MADDE":           TypeMadde,
+	"GEÇİCİ MADDE":    TypeGeciciMadde,
+	"EK MADDE":        TypeEkMadde,
+	"EK GEÇİCİ MADDE": TypeEkGeciciMadde,
+}
+
+// anchorPrefixes is the anchor prefix of each node type
+var anchorPrefixes = map[string]string{
+	TypeKisim:         "kisim",
+	TypeBolum:         "bolum",
+	TypeMadde:         "madde",
+	TypeGeciciMadde:   "gecici-madde",
+	TypeEkMadde:       "ek-madde",
+	TypeEkGeciciMadde: "ek-gecici-madde",
+}
+
+// turkishAlphabet orders subparagraph letters: a) b) c) ç) d) ...
+const turkishAlphabet = "abcçdefgğhıijklmnoöprsştuüvyz"
+
+// line is one line of the text with its byte range
+type line struct {
+	text       string // trimmed
+	start, end int
+}
+
+// division is a heading found in the text, before nesting
+type division struct {
+	node  models.StructureNode
+	level int // 0 kısım, 1 bölüm, 2 madde
+	body  int // offset of the article line, after its title
+}
+
+// Parse extracts the kısım, bölüm and madde headings of text together with the
+// fıkra and bent of every madde. Articles nest in the closest preceding bölüm,
+// chapters in the closest preceding kısım. Text that has no recognisable
+// article headings yields no nodes.
+func Parse(text string) []models.StructureNode {
+	lines := splitLines(text)
+	consumed := make([]bool, len(lines)) // lines already used as a heading
+	var divisions []division
+	counts := make(map[string]int)
+
+	for i, l := range lines {
+		if l.text == "" || consumed[i] {
+			continue
+		}
+		upper := strings.ToUpperSpecial(unicode.TurkishCase, l.text)
+
+		if m := divisionPattern.FindStringSubmatch(upper); m != nil {
+			typ, level := TypeKisim, 0
+			if m[2] == "BÖLÜM" {
+				typ, level = TypeBolum, 1
+			}
+			counts[typ]++
+			node := models.StructureNode{
+				Type:   typ,
+				Number: m[1],
+				Anchor: anchorPrefixes[typ] + "-" + strconv.Itoa(counts[typ]),
+				Start:  l.start,
+			}
+			// The name of the division follows on the next line
+			if next := nextLine(lines, i); next >= 0 && isHeading(lines[next].text) && !isStructureLine(lines[next].text) {
+				node.Title = lines[next].text
+				consumed[next] = true
+			}
+			divisions = append(divisions, division{node: node, level: level})
+			continue
+		}
+
+		if m := articlePattern.FindStringSubmatch(upper); m != nil {
+			typ := articleTypes[m[1]]
+			number := strings.Join(strings.Fields(m[2]), "")
+			node := models.StructureNode{
+				Type:   typ,
+				Number: number,
+				Anchor: anchorPrefixes[typ] + "-" + anchorNumber(number),
+				Start:  l.start,
+			}
+			// The article title precedes it on a line of its own
+			if prev := prevLine(lines, i); prev >= 0 && !consumed[prev] && isHeading(lines[prev].text) && !isStructureLine(lines[prev].text) {
+				node.Title = lines[prev].text
+				node.Start = lines[prev].start
+				consumed[prev] = true
+			}
+			divisions = append(divisions, division{node: node, level: 2, body: l.start})
+		}
+	}
+
+	// A division runs until the next one at the same or a higher level
+	for i := range divisions {
+		divisions[i].node.End = len(text)
+		for j := i + 1; j < len(divisions); j++ {
+			if divisions[j].level <= divisions[i].level {
+				divisions[i].node.End = divisions[j].node.Start
+				break
+			}
+		}
+	}
+	for i := range divisions {
+		if divisions[i].level == 2 {
+			divisions[i].node.Children = parseParagraphs(text, divisions[i].body, divisions[i].node.End)
+		}
+	}
+
+	nodes, _ := nest(divisions, 0, -1)
+	return nodes
+}
+
+// nest builds the tree of divisions[from:] whose level is deeper than parent,
+// returning it and the index of the first division that is not part of it
+func nest(divisions []division, from, parent int) ([]models.StructureNode, int) {
+	var nodes []models.StructureNode
+	i := from
+	for i < len(divisions) && divisions[i].level > parent {
+		node := divisions[i].node
+		level := divisions[i].level
+		i++
+		if level < 2 {
+			var children []models.StructureNode
+			children, i = nest(divisions, i, level)
+			node.Children = children
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, i
+}
+
+// parseParagraphs finds the numbered fıkra of the article between start and
+// end and the lettered bent within each. Markers must be consecutive, so a
+// "(5)" in the middle of a sentence is not taken for a paragraph.
+func parseParagraphs(text string, start, end int) []models.StructureNode {
+	var paragraphs []models.StructureNode
+	expected := 1
+	for _, m := range paragraphPattern.FindAllStringSubmatchIndex(text[start:end], -1) {
+		n, _ := strconv.Atoi(text[start+m[2] : start+m[3]])
+		if n != expected {
+			continue
+		}
+		expected++
+		paragraphs = append(paragraphs, models.StructureNode{
+			Type:   TypeFikra,
+			Number: strconv.Itoa(n),
+			Anchor: "fikra-" + strconv.Itoa(n),
+			Start:  start + m[2] - 1, // the opening parenthesis
+		})
+	}
+	// Articles without numbered paragraphs may still list subparagraphs
+	if len(paragraphs) == 0 {
+		return parseSubparagraphs(text, start, end, "")
+	}
+
+	for i := range paragraphs {
+		paragraphs[i].End = end
+		if i+1 < len(paragraphs) {
+			paragraphs[i].End = paragraphs[i+1].Start
+		}
+		paragraphs[i].Children = parseSubparagraphs(text, paragraphs[i].Start, paragraphs[i].End, paragraphs[i].Anchor+"-")
+	}
+	return paragraphs
+}
+
+// parseSubparagraphs finds the consecutive lettered bent between start and
+// end, anchored below anchorPrefix
+func parseSubparagraphs(text string, start, end int, anchorPrefix string) []models.StructureNode {
+	var subparagraphs []models.StructureNode
+	next := 0 // position of the expected letter in turkishAlphabet
+	for _, m := range subparagraphPattern.FindAllStringSubmatchIndex(text[start:end], -1) {
+		letter := text[start+m[2] : start+m[3]]
+		if next >= len(turkishAlphabet) || !strings.HasPrefix(turkishAlphabet[next:], letter) {
+			continue
+		}
+		next += len(letter)
+		subparagraphs = append(subparagraphs, models.StructureNode{
+			Type:   TypeBent,
+			Number: letter,
+			Anchor: anchorPrefix + "bent-" + letter,
+			Start:  start + m[2],
+		})
+	}
+	for i := range subparagraphs {
+		subparagraphs[i].End = end
+		if i+1 < len(subparagraphs) {
+			subparagraphs[i].End = subparagraphs[i+1].Start
+		}
+	}
+	return subparagraphs
+}
+
+func splitLines(text string) []line {
+	var lines []line
+	start := 0
+	for start <= len(text) {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		raw := text[start:end]
+		trimmed := strings.TrimSpace(raw)
+		offset := start + strings.Index(raw, trimmed)
+		if trimmed == "" {
+			offset = start
+		}
+		lines = append(lines, line{text: trimmed, start: offset, end: end})
+		start = end + 1
+	}
+	return lines
+}
+
+func nextLine(lines []line, i int) int {
+	for j := i + 1; j < len(lines); j++ {
+		if lines[j].text != "" {
+			return j
+		}
+	}
+	return -1
+}
+
+func prevLine(lines []line, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if lines[j].text != "" {
+			return j
+		}
+	}
+	return -1
+}
+
+// isHeading reports whether a line looks like a title: short, capitalised and
+// not the end of a sentence or the start of a list item
+func isHeading(text string) bool {
+	if text == "" || utf8.RuneCountInString(text) > maxHeadingLength {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(text)
+	if !unicode.IsUpper(first) {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return !strings.ContainsRune(".,;:", last)
+}
+
+func isStructureLine(text string) bool {
+	upper := strings.ToUpperSpecial(unicode.TurkishCase, text)
+	return divisionPattern.MatchString(upper) || articlePattern.MatchString(upper)
+}
+
+// anchorNumber turns an article number into its anchor form: "5/A" -> "5a"
+func anchorNumber(number string) string {
+	return strings.ToLowerSpecial(unicode.TurkishCase, strings.ReplaceAll(number, "/", ""))
+}
@@ -0,0 +1,112 @@
+package structure
+
+import (
+	"strings"
+	"testing"
+
+	"legal-documents-api/models"
+)
+
+// outline lists the anchor and title of every node, children indented below
+// their parent
+func outline(nodes []models.StructureNode, depth int) []string {
+	var lines []string
+	for _, n := range nodes {
+		line := strings.Repeat("  ", depth) + n.Anchor
+		if n.Title != "" {
+			line += " " + n.Title
+		}
+		lines = append(lines, line)
+		lines = append(lines, outline(n.Children, depth+1)...)
+	}
+	return lines
+}
+
+func TestParse(t *testing.T) {
+	const text = `BİRİNCİ KISIM
+Genel Hükümler
+
+Birinci Bölüm
+Amaç ve Kapsam
+
+Amaç
+MADDE 1 - (1) Bu Kanunun amacı sosyal güvenliği düzenlemektir.
+(2) Bu Kanun kapsamında:
+a) Sigortalılar,
+b) İşverenler,
+c) Kurumlar,
+ç) Diğer kişiler,
+yer alır.
+
+Kapsam
+MADDE 2 - Bu Kanun;
+a) Kamu idarelerini,
+b) Özel kesimi
+kapsar. (5) numaralı fıkra yoktur.
+
+İKİNCİ BÖLÜM
+Sigortalılık
+
+MADDE 5/A - (1) Sigortalılık çalışmaya başlamakla başlar.
+
+GEÇİCİ MADDE 1 - (1) Bu Kanun yürürlüğe girdiğinde uygulanır.
+
+EK MADDE 3 - Ek hüküm.
+`
+	got := outline(Parse(text), 0)
+	want := []string{
+		"kisim-1 Genel Hükümler",
+		"  bolum-1 Amaç ve Kapsam",
+		"    madde-1 Amaç",
+		"      fikra-1",
+		"      fikra-2",
+		"        fikra-2-bent-a",
+		"        fikra-2-bent-b",
+		"        fikra-2-bent-c",
+		"        fikra-2-bent-ç",
+		"    madde-2 Kapsam",
+		"      bent-a",
+		"      bent-b",
+		"  bolum-2 Sigortalılık",
+		"    madde-5a",
+		"      fikra-1",
+		"    gecici-madde-1",
+		"      fikra-1",
+		"    ek-madde-3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Parse outline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Offsets cut the text of every node out of the source
+	nodes := Parse(text)
+	article := nodes[0].Children[0].Children[0]
+	if got := text[article.Start:article.End]; !strings.HasPrefix(got, "Amaç\nMADDE 1") || strings.Contains(got, "MADDE 2") {
+		t.Errorf("madde-1 text = %q", got)
+	}
+	if article.Number != "1" || article.Type != TypeMadde {
+		t.Errorf("madde-1 = %s %q", article.Type, article.Number)
+	}
+	paragraph := article.Children[1]
+	if got := text[paragraph.Start:paragraph.End]; !strings.HasPrefix(got, "(2) Bu Kanun kapsamında") || !strings.Contains(got, "yer alır.") {
+		t.Errorf("fikra-2 text = %q", got)
+	}
+	if got := text[paragraph.Children[3].Start:paragraph.Children[3].End]; !strings.HasPrefix(got, "ç) Diğer kişiler") {
+		t.Errorf("bent-ç text = %q", got)
+	}
+	if n := nodes[0].Children[1].Children[0]; n.Number != "5/A" {
+		t.Errorf("madde-5a number = %q, want 5/A", n.Number)
+	}
+}
+
+func TestParseWithoutStructure(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"Bu bir duyurudur. Madde işaretleri içermez.",
+		"(1) Tek başına bir fıkra\na) ve bir bent",
+	} {
+		if nodes := Parse(text); len(nodes) != 0 {
+			t.Errorf("Parse(%q) = %v, want no nodes", text, outline(nodes, 0))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package structure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+// backfillBatchSize is how many structures are written per bulk write
+const backfillBatchSize = 100
+
+// Build parses icerik into a structure ready to be stored
+func Build(icerik string) *models.DocumentStructure {
+	return &models.DocumentStructure{
+		Version:     Version,
+		ContentHash: utils.ContentHash(icerik),
+		Nodes:       Parse(icerik),
+		ParsedAt:    time.Now().UTC(),
+	}
+}
+
+// Current reports whether the stored structure of content was parsed from its
+// current icerik by the current parser
+func Current(content *models.DocumentContent) bool {
+	return content.Yapi != nil && content.Yapi.Version == Version && content.Yapi.ContentHash == utils.ContentHash(content.Icerik)
+}
+
+// Ensure returns the structure of content, parsing and storing it first when
+// it is missing or outdated
+func Ensure(ctx context.Context, client *mongo.Client, content *models.DocumentContent) (*models.DocumentStructure, error) {
+	if Current(content) {
+		return content.Yapi, nil
+	}
+	content.Yapi = Build(content.Icerik)
+	_, err := config.GetContentCollection(client).UpdateOne(ctx, bson.M{"_id": content.ID}, bson.M{"$set": bson.M{"yapi": content.Yapi}})
+	return content.Yapi, err
+}
+
+// BackfillStats summarises a structure backfill run
+type BackfillStats struct {
+	Scanned      int
+	Parsed       int
+	Unstructured int // content without recognisable article headings
+}
+
+// Backfill parses and stores the structure of every content document whose
+// structure is missing or outdated, or of every document with force set
+func Backfill(ctx context.Context, client *mongo.Client, force bool) (BackfillStats, error) {
+	var stats BackfillStats
+	collection := config.GetContentCollection(client)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"icerik": 1, "yapi.version": 1, "yapi.content_hash": 1}))
+	if err != nil {
+		return stats, err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		_, err := collection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+		updates = updates[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var content models.DocumentContent
+		if err := cursor.Decode(&content); err != nil {
+			continue
+		}
+		stats.Scanned++
+		if !force && Current(&content) {
+			continue
+		}
+
+		yapi := Build(content.Icerik)
+		stats.Parsed++
+		if len(yapi.Nodes) == 0 {
+			stats.Unstructured++
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": content.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"yapi": yapi}}))
+		if len(updates) >= backfillBatchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return stats, err
+	}
+	if err := flush(); err != nil {
+		return stats, err
+	}
+
+	log.Printf("Structure backfill: %d scanned, %d parsed, %d without articles", stats.Scanned, stats.Parsed, stats.Unstructured)
+	return stats, nil
+}
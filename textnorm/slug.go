@@ -0,0 +1,36 @@
+package textnorm
+
+import "strings"
+
+// maxSlugLength is the longest slug Slug returns, in bytes
+const maxSlugLength = 80
+
+// Slug turns s into a URL slug: folded, lowercase ASCII words joined by
+// hyphens, cut at a word boundary. "İş Sağlığı ve Güvenliği Kanunu" becomes
+// "is-sagligi-ve-guvenligi-kanunu". Letters without an ASCII form are dropped.
+func Slug(s string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(Normalize(s)) {
+		word = strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, word)
+		if word == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			if b.Len()+1+len(word) > maxSlugLength {
+				break
+			}
+			b.WriteByte('-')
+		}
+		b.WriteString(word)
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+	return slug
+}
@@ -0,0 +1,262 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldMap maps Turkish and common Latin diacritics to their plain ASCII letter.
+// Lookups happen after Turkish lowercasing, so only lowercase forms are needed.
+var foldMap = map[rune]rune{
+	'ı': 'i', 'î': 'i', 'í': 'i', 'ì': 'i', 'ï': 'i',
+	'ö': 'o', 'ô': 'o', 'ó': 'o', 'ò': 'o',
+	'ü': 'u', 'û': 'u', 'ú': 'u', 'ù': 'u',
+	'ş': 's', 'ç': 'c', 'ğ': 'g',
+	'â': 'a', 'á': 'a', 'à': 'a', 'ä': 'a',
+	'ê': 'e', 'é': 'e', 'è': 'e', 'ë': 'e',
+}
+
+// variantMap lists every spelling a folded letter can have in source text.
+// It is used to build regex patterns that match regardless of casing/diacritics.
+var variantMap = map[rune]string{
+	'a': "aAâÂáÁàÀäÄ",
+	'c': "cCçÇ",
+	'e': "eEêÊéÉèÈëË",
+	'g': "gGğĞ",
+	'i': "iIıİîÎíÍìÌïÏ",
+	'o': "oOöÖôÔóÓòÒ",
+	's': "sSşŞ",
+	'u': "uUüÜûÛúÚùÙ",
+}
+
+// separatorPattern matches a run of non letter/digit characters. It is placed
+// between query tokens so punctuation and spacing differences do not prevent a match.
+const separatorPattern = `[^0-9A-Za-zÇĞİÖŞÜçğıöşüÂÎÛâîû]+`
+
+// ToLower lowercases s using Turkish casing rules (I -> ı, İ -> i)
+func ToLower(s string) string {
+	return strings.ToLowerSpecial(unicode.TurkishCase, s)
+}
+
+// foldRune lowercases and folds a single rune. It returns -1 for runes that
+// should be dropped entirely (combining marks such as the dot in "i̇").
+func foldRune(r rune) rune {
+	r = unicode.TurkishCase.ToLower(r)
+	if unicode.Is(unicode.Mn, r) {
+		return -1
+	}
+	if folded, ok := foldMap[r]; ok {
+		return folded
+	}
+	return r
+}
+
+// Fold applies Turkish case folding and diacritic folding, keeping punctuation
+// and spacing intact. "SÖZLEŞME" and "sozlesme" fold to the same string.
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if folded := foldRune(r); folded >= 0 {
+			b.WriteRune(folded)
+		}
+	}
+	return b.String()
+}
+
+// Normalize folds s and strips punctuation, collapsing any run of non
+// letter/digit characters into a single space. Turkish suffixes separated by
+// an apostrophe ("Kanun'un") are dropped so they match the bare word.
+func Normalize(s string) string {
+	return NewText(s).Normalized
+}
+
+// Tokens returns the normalized words of s
+func Tokens(s string) []string {
+	return strings.Fields(Normalize(s))
+}
+
+// Contains reports whether query occurs in text after normalizing both
+func Contains(text, query string) bool {
+	q := Normalize(query)
+	if q == "" {
+		return false
+	}
+	return strings.Contains(Normalize(text), q)
+}
+
+// Count returns the number of non-overlapping occurrences of query in text
+// after normalizing both
+func Count(text, query string) int {
+	q := Normalize(query)
+	if q == "" {
+		return 0
+	}
+	return strings.Count(Normalize(text), q)
+}
+
+// Text is a normalized string that remembers which byte of the original input
+// each normalized byte came from, so matches can be mapped back for previews.
+type Text struct {
+	Source     string
+	Normalized string
+	offsets    []int // offsets[i] is the source byte offset of Normalized[i]; one sentinel entry at the end
+}
+
+// NewText normalizes s while recording source offsets
+func NewText(s string) Text {
+	var b strings.Builder
+	b.Grow(len(s))
+	offsets := make([]int, 0, len(s)+1)
+
+	var buf [utf8.UTFMax]byte
+	lastSpace := true // suppress leading separators
+	skipSuffix := false
+
+	for i, r := range s {
+		folded := foldRune(r)
+		if folded < 0 {
+			continue
+		}
+
+		isWord := unicode.IsLetter(folded) || unicode.IsDigit(folded)
+		if skipSuffix {
+			if unicode.IsLetter(folded) {
+				continue
+			}
+			skipSuffix = false
+		}
+
+		if isWord {
+			n := utf8.EncodeRune(buf[:], folded)
+			b.Write(buf[:n])
+			for k := 0; k < n; k++ {
+				offsets = append(offsets, i)
+			}
+			lastSpace = false
+			continue
+		}
+
+		// Apostrophe between letters starts a Turkish suffix: Kanun'un -> kanun
+		if (r == '\'' || r == '’') && !lastSpace {
+			next, _ := utf8.DecodeRuneInString(s[i+utf8.RuneLen(r):])
+			if unicode.IsLetter(next) {
+				skipSuffix = true
+				continue
+			}
+		}
+
+		if !lastSpace {
+			b.WriteByte(' ')
+			offsets = append(offsets, i)
+			lastSpace = true
+		}
+	}
+
+	normalized := b.String()
+	if strings.HasSuffix(normalized, " ") {
+		normalized = normalized[:len(normalized)-1]
+		offsets = offsets[:len(offsets)-1]
+	}
+	offsets = append(offsets, len(s))
+
+	return Text{Source: s, Normalized: normalized, offsets: offsets}
+}
+
+// SourceOffset converts a byte offset in Normalized to a byte offset in Source
+func (t Text) SourceOffset(i int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= len(t.offsets) {
+		return len(t.Source)
+	}
+	return t.offsets[i]
+}
+
+// SourceRange converts a [start, end) range in Normalized to the matching
+// range in Source. The end is extended to cover the whole last rune.
+func (t Text) SourceRange(start, end int) (int, int) {
+	srcStart := t.SourceOffset(start)
+	if end <= start {
+		return srcStart, srcStart
+	}
+	srcEnd := t.SourceOffset(end - 1)
+	_, size := utf8.DecodeRuneInString(t.Source[srcEnd:])
+	return srcStart, srcEnd + size
+}
+
+// Index returns the source byte range of the first occurrence of query, or
+// (-1, -1) when it does not occur
+func (t Text) Index(query string) (int, int) {
+	q := Normalize(query)
+	if q == "" {
+		return -1, -1
+	}
+	idx := strings.Index(t.Normalized, q)
+	if idx < 0 {
+		return -1, -1
+	}
+	return t.SourceRange(idx, idx+len(q))
+}
+
+// RegexPattern builds a regular expression that matches query in raw source
+// text regardless of casing, diacritics or punctuation between words. Every
+// character outside the letter/digit classes is escaped, so the result is safe
+// to pass to MongoDB's $regex.
+func RegexPattern(query string) string {
+	tokens := Tokens(query)
+	parts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		var b strings.Builder
+		for _, r := range token {
+			b.WriteString(runeClass(r))
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, separatorPattern)
+}
+
+// runeClass returns a character class matching every spelling of a folded rune
+func runeClass(r rune) string {
+	if r == 'i' {
+		// "i̇" is i followed by a combining dot above, which Fold drops
+		return "[" + variantMap[r] + "]\u0307?"
+	}
+	if variants, ok := variantMap[r]; ok {
+		return "[" + variants + "]"
+	}
+	if unicode.IsDigit(r) {
+		return string(r)
+	}
+	upper := unicode.ToUpper(r)
+	if upper == r {
+		return string(r)
+	}
+	return "[" + string(r) + string(upper) + "]"
+}
+
+// Word is a normalized token together with its byte range in the source text
+type Word struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Words splits the normalized text into tokens, keeping each token's source range
+func (t Text) Words() []Word {
+	var words []Word
+	start := 0
+	for i := 0; i <= len(t.Normalized); i++ {
+		if i < len(t.Normalized) && t.Normalized[i] != ' ' {
+			continue
+		}
+		if i > start {
+			srcStart, srcEnd := t.SourceRange(start, i)
+			words = append(words, Word{Term: t.Normalized[start:i], Start: srcStart, End: srcEnd})
+		}
+		start = i + 1
+	}
+	return words
+}
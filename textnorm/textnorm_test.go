@@ -0,0 +1,189 @@
+package textnorm
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"SÖZLEŞME", "sozlesme"},
+		{"sözleşme", "sozlesme"},
+		{"IĞDIR", "igdir"},
+		{"İSTANBUL", "istanbul"},
+		{"ıiİI", "iiii"},
+		{"Çalışma Süresi", "calisma suresi"},
+		{"i̇stanbul", "istanbul"}, // i with a combining dot above
+		{"Kâğıt, Hâkim!", "kagit, hakim!"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"İş Kanunu", "is kanunu"},
+		{"  İŞ   KANUNU  ", "is kanunu"},
+		{"5510 sayılı Kanun'un 4/a maddesi", "5510 sayili kanun 4 a maddesi"},
+		{"Yargıtay’ın kararı", "yargitay karari"},
+		{"'tırnak' içinde", "tirnak icinde"},
+		{"Madde 12-(1)", "madde 12 1"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContainsAndCount(t *testing.T) {
+	tests := []struct {
+		text, query string
+		contains    bool
+		count       int
+	}{
+		{"İŞ SAĞLIĞI VE GÜVENLİĞİ KANUNU", "is sagligi", true, 1},
+		{"Kanun'un 3. maddesi ve kanunun 4. maddesi", "kanun", true, 2},
+		{"Sosyal Güvenlik Kurumu", "guvenlik kurumu", true, 1},
+		{"Sosyal Güvenlik Kurumu", "kurum başkanı", false, 0},
+		{"anything", "", false, 0},
+		{"anything", "!?", false, 0},
+	}
+	for _, tt := range tests {
+		if got := Contains(tt.text, tt.query); got != tt.contains {
+			t.Errorf("Contains(%q, %q) = %v, want %v", tt.text, tt.query, got, tt.contains)
+		}
+		if got := Count(tt.text, tt.query); got != tt.count {
+			t.Errorf("Count(%q, %q) = %d, want %d", tt.text, tt.query, got, tt.count)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"İş Sağlığı ve Güvenliği Kanunu", "is-sagligi-ve-guvenligi-kanunu"},
+		{"5510 Sayılı Kanun'un Uygulanması", "5510-sayili-kanun-uygulanmasi"},
+		{"  Çalışma -- Yönetmeliği (2023/15)  ", "calisma-yonetmeligi-2023-15"},
+		{"Ω Ψ", ""}, // no ASCII form
+		{"Résumé Ω Kanun", "resume-kanun"},
+		{"", ""},
+		{strings.Repeat("uzunkelime ", 20), strings.TrimSuffix(strings.Repeat("uzunkelime-", 7), "-")},
+		{strings.Repeat("a", 100), strings.Repeat("a", maxSlugLength)},
+	}
+	for _, tt := range tests {
+		got := Slug(tt.in)
+		if got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if len(got) > maxSlugLength {
+			t.Errorf("Slug(%q) is %d bytes, longer than %d", tt.in, len(got), maxSlugLength)
+		}
+	}
+}
+
+func TestTextIndex(t *testing.T) {
+	tests := []struct {
+		source, query, want string
+	}{
+		{"Bu İŞ KANUNU kapsamında", "is kanunu", "İŞ KANUNU"},
+		{"Kanun'un 3. maddesi", "kanun 3", "Kanun'un 3"},
+		{"Sosyal  Güvenlik,Kurumu", "guvenlik kurumu", "Güvenlik,Kurumu"},
+		{"Bir şey", "yok", ""},
+	}
+	for _, tt := range tests {
+		start, end := NewText(tt.source).Index(tt.query)
+		got := ""
+		if start >= 0 {
+			got = tt.source[start:end]
+		}
+		if got != tt.want {
+			t.Errorf("NewText(%q).Index(%q) = %q, want %q", tt.source, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	source := "Kanun'un  İKİNCİ maddesi"
+	want := []string{"Kanun", "İKİNCİ", "maddesi"} // the suffix is dropped
+	words := NewText(source).Words()
+	if len(words) != len(want) {
+		t.Fatalf("Words() returned %d words, want %d", len(words), len(want))
+	}
+	for i, word := range words {
+		if got := source[word.Start:word.End]; got != want[i] {
+			t.Errorf("word %d covers %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestRegexPattern(t *testing.T) {
+	tests := []struct {
+		query, text string
+		match       bool
+	}{
+		{"is kanunu", "İŞ KANUNU", true},
+		{"is kanunu", "iş-kanunu", true},
+		{"sozlesme", "SÖZLEŞME", true},
+		{"sozlesme", "sözleşmeler", true},
+		{"is kanunu", "iş hukuku", false},
+		{"a.b*(c)", "A B C", true}, // punctuation is never a metacharacter
+	}
+	for _, tt := range tests {
+		re, err := regexp.Compile(RegexPattern(tt.query))
+		if err != nil {
+			t.Fatalf("RegexPattern(%q) does not compile: %v", tt.query, err)
+		}
+		if got := re.MatchString(tt.text); got != tt.match {
+			t.Errorf("RegexPattern(%q) matching %q = %v, want %v", tt.query, tt.text, got, tt.match)
+		}
+	}
+}
+
+// Folding changes byte lengths (İ is two bytes, i one; "i̇" drops a combining
+// mark), so every word must still map back to exactly the source text it
+// came from
+func TestWordsMapBackToSource(t *testing.T) {
+	sources := []string{
+		"İŞÇİ ve İŞVEREN’in HAKLARI",
+		"i̇stanbul İli, Iğdır ilçesi",
+		"5510 sayılı Kanun'un 4/a-(ı) bendi",
+		"  ÂDİL  yargılanma\thakkı\n(Madde 36)  ",
+	}
+	for _, source := range sources {
+		text := NewText(source)
+		words := text.Words()
+		tokens := Tokens(source)
+		if len(words) != len(tokens) {
+			t.Errorf("NewText(%q).Words() has %d words, Tokens has %d", source, len(words), len(tokens))
+			continue
+		}
+		for i, word := range words {
+			if word.Term != tokens[i] {
+				t.Errorf("word %d of %q is %q, token is %q", i, source, word.Term, tokens[i])
+			}
+			covered := source[word.Start:word.End]
+			if !utf8.ValidString(covered) {
+				t.Errorf("word %q of %q covers a partial rune %q", word.Term, source, covered)
+			}
+			if got := Normalize(covered); got != word.Term {
+				t.Errorf("word %q of %q covers %q, which normalizes to %q", word.Term, source, covered, got)
+			}
+			if !regexp.MustCompile(RegexPattern(word.Term)).MatchString(covered) {
+				t.Errorf("RegexPattern(%q) does not match its source %q", word.Term, covered)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+        "regexp"
+        "strconv"
+        "strings"
+        "time"
+
+        "legal-documents-api/models"
+        "legal-documents-api/textnorm"
+)
+
+// dateLayouts are the numeric date formats found in document metadata, tried in order
+var dateLayouts = []string{
+        "02.01.2006",
+        "2.1.2006",
+        "02/01/2006",
+        "2/1/2006",
+        "02-01-2006",
+        "02.01.2006 15:04:05",
+        "02.01.2006 15:04",
+        "2006-01-02",
+        "2006-01-02 15:04:05",
+        "2006-01-02T15:04:05",
+        time.RFC3339,
+        time.RFC3339Nano,
+        "2006.01.02",
+        "2006/01/02",
+        "02.01.06",
+}
+
+// monthNames are the Turkish month names in calendar order. Their first three
+// letters are the abbreviations used on Turkish pages: "Oca", "Şub", "Ağu".
+var monthNames = []string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"}
+
+// turkishMonths maps folded Turkish month names and abbreviations, and English
+// month names, to months
+var turkishMonths = func() map[string]time.Month {
+        months := make(map[string]time.Month)
+        for i, name := range monthNames {
+                month := time.Month(i + 1)
+                months[textnorm.Fold(name)] = month
+                months[textnorm.Fold(string([]rune(name)[:3]))] = month
+                months[strings.ToLower(month.String())] = month
+        }
+        return months
+}()
+
+// englishMonths turns Turkish month names and abbreviations into the English
+// ones Go layouts use. Full names come first so they are not cut to
+// abbreviations.
+var englishMonths = func() *strings.Replacer {
+        var pairs []string
+        for i, name := range monthNames {
+                pairs = append(pairs, name, time.Month(i+1).String())
+        }
+        for i, name := range monthNames {
+                pairs = append(pairs, string([]rune(name)[:3]), time.Month(i+1).String()[:3])
+        }
+        return strings.NewReplacer(pairs...)
+}()
+
+// EnglishMonths replaces the Turkish month names and abbreviations in s with
+// the English ones, so that Go time layouts and values may be written in
+// Turkish: "2 Ocak 2006" becomes "2 January 2006", "2 Oca 2006" "2 Jan 2006"
+func EnglishMonths(s string) string {
+        return englishMonths.Replace(s)
+}
+
+var (
+        // "23 Eylül 2025" or "23 Eyl 2025", optionally followed by a weekday
+        textualDatePattern = regexp.MustCompile(`^(\d{1,2})\s+(\pL+)\s+(\d{4})`)
+        // The same anywhere in a longer text
+        monthDatePattern = regexp.MustCompile(`(\d{1,2})\s+(\pL+)\s+(\d{4})`)
+        // First date-like fragment inside a longer string, e.g. "R.G. Tarihi: 12.05.2020"
+        embeddedDatePattern = regexp.MustCompile(`\d{1,2}[./-]\d{1,2}[./-]\d{4}|\d{4}-\d{1,2}-\d{1,2}|\d{1,2}\s+\pL+\s+\d{4}`)
+)
+
+// ParseDate parses the date formats used in belge_yayin_tarihi, yukleme_tarihi
+// and olusturulma_tarihi: dd.mm.yyyy (and / or - separated), ISO 8601 and
+// Turkish month names or their abbreviations such as "23 Eylül 2025" and
+// "23 Eyl 2025". Dates without a zone are returned in UTC. The second result
+// is false when no date can be recognised.
+func ParseDate(value string) (time.Time, bool) {
+        value = strings.TrimSpace(value)
+        if value == "" {
+                return time.Time{}, false
+        }
+
+        if t, ok := parseDateExact(value); ok {
+                return t, true
+        }
+
+        // Fall back to the first date inside surrounding text
+        if match := embeddedDatePattern.FindString(value); match != "" && match != value {
+                return parseDateExact(match)
+        }
+        return time.Time{}, false
+}
+
+func parseDateExact(value string) (time.Time, bool) {
+        for _, layout := range dateLayouts {
+                if t, err := time.Parse(layout, value); err == nil {
+                        return t, true
+                }
+        }
+
+        if m := textualDatePattern.FindStringSubmatch(value); m != nil {
+                return monthDate(m[1], m[2], m[3])
+        }
+        return time.Time{}, false
+}
+
+// FindMonthDate returns the first date in text written with a month name,
+// such as "23 Eylül 2025" or "11 Ağu 2025". Numbers followed by words that
+// are not month names are skipped.
+func FindMonthDate(text string) (time.Time, bool) {
+        for _, m := range monthDatePattern.FindAllStringSubmatch(text, -1) {
+                if t, ok := monthDate(m[1], m[2], m[3]); ok {
+                        return t, true
+                }
+        }
+        return time.Time{}, false
+}
+
+// monthDate builds the date of a day, month name and year, rejecting unknown
+// months and days the month does not have
+func monthDate(dayText, monthText, yearText string) (time.Time, bool) {
+        month, ok := turkishMonths[textnorm.Fold(monthText)]
+        if !ok {
+                return time.Time{}, false
+        }
+        day, _ := strconv.Atoi(dayText)
+        year, _ := strconv.Atoi(yearText)
+        t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+        if t.Day() != day {
+                return time.Time{}, false // e.g. 31 Şubat
+        }
+        return t, true
+}
+
+// ParseDatePtr is ParseDate returning nil for unrecognised dates, for the
+// optional typed date fields of models.DocumentMetadata
+func ParseDatePtr(value string) *time.Time {
+        if t, ok := ParseDate(value); ok {
+                return &t
+        }
+        return nil
+}
+
+// SetParsedDates fills the typed date fields of metadata from their string
+// counterparts
+func SetParsedDates(metadata *models.DocumentMetadata) {
+        metadata.BelgeYayinDate = ParseDatePtr(metadata.BelgeYayinTarihi)
+        metadata.YuklemeDate = ParseDatePtr(metadata.YuklemeTarihi)
+        metadata.OlusturulmaDate = ParseDatePtr(metadata.OlusturulmaTarihi)
+}
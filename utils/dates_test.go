@@ -0,0 +1,135 @@
+package utils
+
+import (
+        "testing"
+        "time"
+
+        "legal-documents-api/models"
+)
+
+func TestParseDate(t *testing.T) {
+        tests := []struct {
+                value string
+                want  string // yyyy-mm-dd hh:mm, empty when not a date
+        }{
+                {"23 Eylül 2025", "2025-09-23 00:00"},
+                {"23 EYLÜL 2025", "2025-09-23 00:00"},
+                {"23 eylul 2025", "2025-09-23 00:00"},
+                {"3 Ağustos 2024 Cumartesi", "2024-08-03 00:00"},
+                {"1 Şubat 2024", "2024-02-01 00:00"},
+                {"23 September 2025", "2025-09-23 00:00"},
+                // Abbreviated month names
+                {"23 Eyl 2025", "2025-09-23 00:00"},
+                {"11 Ağu 2025", "2025-08-11 00:00"},
+                {"5 Şub 2024", "2024-02-05 00:00"},
+                {"7 Oca 2024", "2024-01-07 00:00"},
+                {"30 Ara 2023", "2023-12-30 00:00"},
+                // Numeric forms
+                {"23.09.2025", "2025-09-23 00:00"},
+                {"3.9.2025", "2025-09-03 00:00"},
+                {"23/09/2025", "2025-09-23 00:00"},
+                {"23-09-2025", "2025-09-23 00:00"},
+                {"23.09.25", "2025-09-23 00:00"},
+                {"23.09.2025 14:30", "2025-09-23 14:30"},
+                // ISO forms
+                {"2025-09-23", "2025-09-23 00:00"},
+                {"2025-09-23T14:30:00", "2025-09-23 14:30"},
+                {"2025-09-23T14:30:00Z", "2025-09-23 14:30"},
+                {"2025-09-23T14:30:00+03:00", "2025-09-23 14:30"},
+                // A date inside other text
+                {"R.G. Tarihi: 12.05.2020", "2020-05-12 00:00"},
+                {"Yayın tarihi 23 Eylül 2025, Salı", "2025-09-23 00:00"},
+                {"  23.09.2025  ", "2025-09-23 00:00"},
+                // Dates that do not exist
+                {"31 Şubat 2025", ""},
+                {"29 Şub 2025", ""},
+                {"31.04.2025", ""},
+                {"2025-02-30", ""},
+                {"32 Ocak 2025", ""},
+                // Not dates at all
+                {"", ""},
+                {"   ", ""},
+                {"belirsiz", ""},
+                {"23 Eylül", ""},
+                {"23 Foo 2025", ""},
+                {"2025", ""},
+        }
+        for _, tt := range tests {
+                got, ok := ParseDate(tt.value)
+                if tt.want == "" {
+                        if ok {
+                                t.Errorf("ParseDate(%q) = %v, want no date", tt.value, got)
+                        }
+                        continue
+                }
+                if !ok || got.Format("2006-01-02 15:04") != tt.want {
+                        t.Errorf("ParseDate(%q) = %v, %v, want %s", tt.value, got, ok, tt.want)
+                }
+        }
+
+        // Zoned times keep their zone; the rest are UTC
+        if got, _ := ParseDate("23.09.2025"); got.Location() != time.UTC {
+                t.Errorf("ParseDate without a zone is in %v, want UTC", got.Location())
+        }
+        if got, _ := ParseDate("2025-09-23T14:30:00+03:00"); !got.Equal(time.Date(2025, time.September, 23, 11, 30, 0, 0, time.UTC)) {
+                t.Errorf("ParseDate with a zone = %v", got)
+        }
+}
+
+func TestSetParsedDates(t *testing.T) {
+        old := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+        metadata := models.DocumentMetadata{
+                BelgeYayinTarihi:  "23 Eylül 2025",
+                YuklemeTarihi:     "2025-09-24T10:00:00",
+                OlusturulmaTarihi: "belirsiz",
+                OlusturulmaDate:   &old, // stale: the string no longer parses
+        }
+        SetParsedDates(&metadata)
+
+        if metadata.BelgeYayinDate == nil || !metadata.BelgeYayinDate.Equal(time.Date(2025, time.September, 23, 0, 0, 0, 0, time.UTC)) {
+                t.Errorf("BelgeYayinDate = %v", metadata.BelgeYayinDate)
+        }
+        if metadata.YuklemeDate == nil || !metadata.YuklemeDate.Equal(time.Date(2025, time.September, 24, 10, 0, 0, 0, time.UTC)) {
+                t.Errorf("YuklemeDate = %v", metadata.YuklemeDate)
+        }
+        if metadata.OlusturulmaDate != nil {
+                t.Errorf("OlusturulmaDate = %v, want nil", metadata.OlusturulmaDate)
+        }
+}
+
+func TestEnglishMonths(t *testing.T) {
+        tests := []struct {
+                value, want string
+        }{
+                {"2 Ocak 2006", "2 January 2006"},
+                {"2 Oca 2006", "2 Jan 2006"},
+                {"30 Ağustos 2024", "30 August 2024"},
+                {"30 Ağu 2024", "30 Aug 2024"},
+                {"5 Mayıs 2024", "5 May 2024"},
+                {"02.01.2006", "02.01.2006"},
+        }
+        for _, tt := range tests {
+                if got := EnglishMonths(tt.value); got != tt.want {
+                        t.Errorf("EnglishMonths(%q) = %q, want %q", tt.value, got, tt.want)
+                }
+        }
+}
+
+func TestFindMonthDate(t *testing.T) {
+        tests := []struct {
+                text string
+                want string // "" when no date is found
+        }{
+                {"Yayın tarihi: 23 Eylül 2025, Pazartesi", "2025-09-23"},
+                {"11 Ağu 2025 tarihli duyuru", "2025-08-11"},
+                {"12 adet 2024 yılı, 4 Mart 2024", "2024-03-04"},
+                {"31 Şubat 2025", ""},
+                {"23.09.2025", ""},
+        }
+        for _, tt := range tests {
+                got, ok := FindMonthDate(tt.text)
+                if ok != (tt.want != "") || (ok && got.Format("2006-01-02") != tt.want) {
+                        t.Errorf("FindMonthDate(%q) = %v, %v, want %q", tt.text, got, ok, tt.want)
+                }
+        }
+}
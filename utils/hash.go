@@ -0,0 +1,14 @@
+package utils
+
+import (
+        "fmt"
+        "hash/fnv"
+)
+
+// ContentHash returns a short fingerprint of a document's icerik, stored with
+// data derived from it so edits can be noticed
+func ContentHash(icerik string) string {
+        h := fnv.New64a()
+        h.Write([]byte(icerik))
+        return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -3,12 +3,14 @@ package utils
 import (
         "context"
         "log"
+        "sort"
         "sync"
 
         "go.mongodb.org/mongo-driver/bson"
         "go.mongodb.org/mongo-driver/mongo"
         "legal-documents-api/config"
         "legal-documents-api/models"
+        "legal-documents-api/textnorm"
 )
 
 // KurumCache holds institution data in memory for fast access
@@ -83,6 +85,34 @@ func GetKurumAciklamaByID(kurumID string) string {
         return ""
 }
 
+// FindKurumIDByName returns the kurum_id of the institution named name
+// (Turkish case and diacritic insensitive), or "". When no name is equal to
+// it, the institution with the lowest kurum_id whose name contains it is
+// returned, so a partial name always resolves to the same institution.
+func FindKurumIDByName(name string) string {
+        cache.mutex.RLock()
+        defer cache.mutex.RUnlock()
+
+        normalized := textnorm.Normalize(name)
+        if normalized == "" {
+                return ""
+        }
+        ids := make([]string, 0, len(cache.kurumlar))
+        for id, kurum := range cache.kurumlar {
+                if textnorm.Normalize(kurum.KurumAdi) == normalized {
+                        return id
+                }
+                ids = append(ids, id)
+        }
+        sort.Strings(ids)
+        for _, id := range ids {
+                if textnorm.Contains(cache.kurumlar[id].KurumAdi, name) {
+                        return id
+                }
+        }
+        return ""
+}
+
 // GetAllKurumlar returns all institutions from cache
 func GetAllKurumlar() []models.Kurum {
         cache.mutex.RLock()
@@ -0,0 +1,48 @@
+package utils
+
+import (
+        "testing"
+
+        "go.mongodb.org/mongo-driver/bson/primitive"
+
+        "legal-documents-api/models"
+)
+
+func TestFindKurumIDByName(t *testing.T) {
+        saved := cache.kurumlar
+        defer func() { cache.kurumlar = saved }()
+
+        cache.kurumlar = make(map[string]models.Kurum)
+        for _, kurum := range []struct{ id, name string }{
+                {"000000000000000000000003", "Sosyal Güvenlik Kurumu"},
+                {"000000000000000000000002", "Sosyal Güvenlik Kurumu Başkanlığı"},
+                {"000000000000000000000001", "Gelir İdaresi Başkanlığı"},
+                {"000000000000000000000004", "Enerji Piyasası Düzenleme Kurumu"},
+        } {
+                id, _ := primitive.ObjectIDFromHex(kurum.id)
+                cache.kurumlar[kurum.id] = models.Kurum{ID: id, KurumAdi: kurum.name}
+        }
+
+        tests := []struct {
+                name, want string
+        }{
+                // An equal name wins over a lower id that merely contains it
+                {"sosyal guvenlik kurumu", "000000000000000000000003"},
+                {"SOSYAL GÜVENLİK KURUMU BAŞKANLIĞI", "000000000000000000000002"},
+                // Partial names resolve to the lowest id containing them
+                {"başkanlığı", "000000000000000000000001"},
+                {"kurumu", "000000000000000000000002"},
+                {"piyasası", "000000000000000000000004"},
+                {"tapu", ""},
+                {"  ", ""},
+        }
+        for _, tt := range tests {
+                // Map order varies between runs, so look each name up repeatedly
+                for i := 0; i < 20; i++ {
+                        if got := FindKurumIDByName(tt.name); got != tt.want {
+                                t.Errorf("FindKurumIDByName(%q) = %q, want %q", tt.name, got, tt.want)
+                                break
+                        }
+                }
+        }
+}
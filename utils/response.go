@@ -40,3 +40,19 @@ func SendSuccessResponse(w http.ResponseWriter, data interface{}, message string
                 SendErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
         }
 }
+
+// SendCreatedResponse sends a standardized response for a newly created resource
+func SendCreatedResponse(w http.ResponseWriter, data interface{}, message string) {
+        response := models.APIResponse{
+                Success: true,
+                Data:    data,
+                Message: message,
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusCreated)
+
+        if err := json.NewEncoder(w).Encode(response); err != nil {
+                log.Printf("Error encoding created response: %v", err)
+        }
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+        "context"
+        "log"
+        "strings"
+        "sync"
+
+        "go.mongodb.org/mongo-driver/bson"
+        "go.mongodb.org/mongo-driver/mongo"
+        "legal-documents-api/config"
+        "legal-documents-api/models"
+        "legal-documents-api/textnorm"
+)
+
+// SynonymCache holds the synonym dictionary in memory for query expansion
+type SynonymCache struct {
+        expansions map[string][]string // normalized form -> other spellings
+        maxWords   int                 // longest normalized form, in words
+        mutex      sync.RWMutex
+}
+
+var synonymCache = &SynonymCache{
+        expansions: make(map[string][]string),
+}
+
+// LoadSynonymsToCache loads the synonym dictionary into memory cache
+func LoadSynonymsToCache(mongoClient *mongo.Client) error {
+        ctx := context.Background()
+        collection := config.GetSynonymsCollection(mongoClient)
+
+        cursor, err := collection.Find(ctx, bson.M{})
+        if err != nil {
+                return err
+        }
+        defer cursor.Close(ctx)
+
+        var synonyms []models.Synonym
+        if err := cursor.All(ctx, &synonyms); err != nil {
+                return err
+        }
+
+        SetSynonyms(synonyms)
+
+        log.Printf("Loaded %d synonym entries into cache", len(synonyms))
+        return nil
+}
+
+// SetSynonyms replaces the cached dictionary with synonyms
+func SetSynonyms(synonyms []models.Synonym) {
+        expansions, maxWords := buildSynonymExpansions(synonyms)
+
+        synonymCache.mutex.Lock()
+        defer synonymCache.mutex.Unlock()
+
+        synonymCache.expansions = expansions
+        synonymCache.maxWords = maxWords
+}
+
+// buildSynonymExpansions maps each normalized form to the spellings it expands
+// to and returns the length, in words, of the longest form
+func buildSynonymExpansions(synonyms []models.Synonym) (map[string][]string, int) {
+        expansions := make(map[string][]string)
+        maxWords := 0
+        add := func(from, to string) {
+                key := textnorm.Normalize(from)
+                if key == "" || key == textnorm.Normalize(to) {
+                        return
+                }
+                for _, existing := range expansions[key] {
+                        if textnorm.Normalize(existing) == textnorm.Normalize(to) {
+                                return
+                        }
+                }
+                expansions[key] = append(expansions[key], to)
+                if words := len(strings.Fields(key)); words > maxWords {
+                        maxWords = words
+                }
+        }
+        for _, synonym := range synonyms {
+                forms := append([]string{synonym.Term}, synonym.Synonyms...)
+                for i, from := range forms {
+                        // One-way entries only expand their synonyms to the term
+                        if synonym.OneWay && i == 0 {
+                                continue
+                        }
+                        for j, to := range forms {
+                                if i == j || (synonym.OneWay && j != 0) {
+                                        continue
+                                }
+                                add(from, to)
+                        }
+                }
+        }
+        return expansions, maxWords
+}
+
+// RefreshSynonymsCache reloads the synonyms cache
+func RefreshSynonymsCache(mongoClient *mongo.Client) error {
+        return LoadSynonymsToCache(mongoClient)
+}
+
+// GetSynonyms returns the spellings a word or phrase expands to, matched
+// Turkish case and diacritic insensitively ("kvkk" -> "Kişisel Verilerin Korunması Kanunu")
+func GetSynonyms(text string) []string {
+        synonymCache.mutex.RLock()
+        defer synonymCache.mutex.RUnlock()
+
+        return synonymCache.expansions[textnorm.Normalize(text)]
+}
+
+// SynonymMaxWords returns the number of words in the longest dictionary form
+func SynonymMaxWords() int {
+        synonymCache.mutex.RLock()
+        defer synonymCache.mutex.RUnlock()
+
+        return synonymCache.maxWords
+}
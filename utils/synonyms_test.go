@@ -0,0 +1,53 @@
+package utils
+
+import (
+        "reflect"
+        "testing"
+
+        "legal-documents-api/models"
+)
+
+func TestBuildSynonymExpansions(t *testing.T) {
+        expansions, maxWords := buildSynonymExpansions([]models.Synonym{
+                {Term: "Kişisel Verilerin Korunması Kanunu", Synonyms: []string{"KVKK", "kvkk", "6698 sayılı Kanun"}},
+                {Term: "yönetmelik", Synonyms: []string{"yön.", "Yönetmelik"}, OneWay: true},
+                {Term: "KVKK", Synonyms: []string{"Kişisel Verilerin Korunması Kanunu"}}, // repeats the first entry
+        })
+
+        tests := []struct {
+                key  string
+                want []string
+        }{
+                {"kisisel verilerin korunmasi kanunu", []string{"KVKK", "6698 sayılı Kanun"}},
+                {"kvkk", []string{"Kişisel Verilerin Korunması Kanunu", "6698 sayılı Kanun"}},
+                {"6698 sayili kanun", []string{"Kişisel Verilerin Korunması Kanunu", "KVKK"}},
+                // One-way: the abbreviation expands, the term does not
+                {"yon", []string{"yönetmelik"}},
+                {"yonetmelik", nil},
+        }
+        for _, tt := range tests {
+                if got := expansions[tt.key]; !reflect.DeepEqual(got, tt.want) {
+                        t.Errorf("expansions[%q] = %q, want %q", tt.key, got, tt.want)
+                }
+        }
+        if maxWords != 4 {
+                t.Errorf("maxWords = %d, want 4", maxWords)
+        }
+}
+
+func TestGetSynonyms(t *testing.T) {
+        SetSynonyms([]models.Synonym{{Term: "Sosyal Güvenlik Kurumu", Synonyms: []string{"SGK"}}})
+        defer SetSynonyms(nil)
+
+        for _, text := range []string{"SGK", "sgk", " Sgk "} {
+                if got := GetSynonyms(text); !reflect.DeepEqual(got, []string{"Sosyal Güvenlik Kurumu"}) {
+                        t.Errorf("GetSynonyms(%q) = %q", text, got)
+                }
+        }
+        if got := GetSynonyms("SOSYAL GÜVENLİK KURUMU"); !reflect.DeepEqual(got, []string{"SGK"}) {
+                t.Errorf("GetSynonyms of the term = %q, want [SGK]", got)
+        }
+        if SynonymMaxWords() != 3 {
+                t.Errorf("SynonymMaxWords = %d, want 3", SynonymMaxWords())
+        }
+}
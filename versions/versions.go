@@ -0,0 +1,317 @@
+// Package versions keeps the version history of documents: snapshots of
+// their metadata and content, each valid for a period of time
+package versions
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"legal-documents-api/config"
+	"legal-documents-api/models"
+	"legal-documents-api/utils"
+)
+
+// ErrNotFound is returned when no version matches the request
+var ErrNotFound = errors.New("version not found")
+
+// EnsureIndexes creates the document_versions indexes
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	_, err := config.GetDocumentVersionsCollection(client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "document_id", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "document_id", Value: 1}, {Key: "valid_from", Value: 1}}},
+	})
+	return err
+}
+
+// List returns the versions of a document, oldest first, without their content
+func List(ctx context.Context, client *mongo.Client, documentID primitive.ObjectID) ([]models.DocumentVersion, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "version", Value: 1}}).
+		SetProjection(bson.M{"icerik": 0})
+	cursor, err := config.GetDocumentVersionsCollection(client).Find(ctx, bson.M{"document_id": documentID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var list []models.DocumentVersion
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// Get returns version n of a document with its content
+func Get(ctx context.Context, client *mongo.Client, documentID primitive.ObjectID, n int) (*models.DocumentVersion, error) {
+	return findOne(ctx, client, bson.M{"document_id": documentID, "version": n}, nil)
+}
+
+// AsOf returns the version of a document in force at t: the last version
+// that took effect on or before t
+func AsOf(ctx context.Context, client *mongo.Client, documentID primitive.ObjectID, t time.Time) (*models.DocumentVersion, error) {
+	return findOne(ctx, client,
+		bson.M{"document_id": documentID, "valid_from": bson.M{"$lte": t}},
+		options.FindOne().SetSort(bson.D{{Key: "valid_from", Value: -1}, {Key: "version", Value: -1}}))
+}
+
+// latest returns the newest version of a document, or nil when it has none
+func latest(ctx context.Context, client *mongo.Client, documentID primitive.ObjectID) (*models.DocumentVersion, error) {
+	version, err := findOne(ctx, client, bson.M{"document_id": documentID},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"icerik": 0}))
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return version, err
+}
+
+func findOne(ctx context.Context, client *mongo.Client, filter bson.M, findOptions *options.FindOneOptions) (*models.DocumentVersion, error) {
+	if findOptions == nil {
+		findOptions = options.FindOne()
+	}
+	var version models.DocumentVersion
+	err := config.GetDocumentVersionsCollection(client).FindOne(ctx, filter, findOptions).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Capture records the current metadata and content of a document as a new
+// version when its content differs from the latest version. Writers call it
+// after changing a document; the snapshotter catches changes made elsewhere.
+// The first version of a document takes effect on its publication date, later
+// ones when captured unless a later publication date says otherwise.
+func Capture(ctx context.Context, client *mongo.Client, metadata models.DocumentMetadata, content models.DocumentContent, note string) (*models.DocumentVersion, bool, error) {
+	previous, err := latest(ctx, client, metadata.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	hash := utils.ContentHash(content.Icerik)
+	if previous != nil && previous.ContentHash == hash {
+		return previous, false, nil
+	}
+
+	now := time.Now().UTC()
+	version := newVersion(metadata, content, note, now)
+	version.DocumentID = metadata.ID
+	if previous == nil {
+		version.Version = 1
+		version.ValidFrom = firstValidFrom(metadata, now)
+	} else {
+		version.Version = previous.Version + 1
+		version.ValidFrom = now
+		if published := metadata.BelgeYayinDate; published != nil && published.After(previous.ValidFrom) && published.Before(now) {
+			version.ValidFrom = *published
+		}
+	}
+
+	collection := config.GetDocumentVersionsCollection(client)
+	if _, err := collection.InsertOne(ctx, version); err != nil {
+		return nil, false, err
+	}
+	if previous != nil {
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": previous.ID}, bson.M{"$set": bson.M{"valid_to": version.ValidFrom}}); err != nil {
+			return nil, false, err
+		}
+	}
+	return &version, true, nil
+}
+
+// Link makes older, a separately created record of the same document, the
+// history of newer: the versions of older are followed by those of newer
+// under newer's id, and older points to newer through guncel_surum_id.
+// The merged history is written under negative version numbers first, so the
+// existing versions are only removed once their replacements are stored; a
+// failure before that leaves both histories as they were.
+func Link(ctx context.Context, client *mongo.Client, older, newer models.DocumentMetadata, note string) ([]models.DocumentVersion, error) {
+	if older.ID == newer.ID {
+		return nil, errors.New("a document cannot be linked to itself")
+	}
+	if older.GuncelSurumID != nil {
+		return nil, errors.New("the previous document is already linked to " + older.GuncelSurumID.Hex())
+	}
+	var merged []models.DocumentVersion
+	for _, metadata := range []models.DocumentMetadata{older, newer} {
+		if err := CaptureCurrent(ctx, client, metadata); err != nil {
+			return nil, err
+		}
+		cursor, err := config.GetDocumentVersionsCollection(client).Find(ctx, bson.M{"document_id": metadata.ID},
+			options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+		if err != nil {
+			return nil, err
+		}
+		var list []models.DocumentVersion
+		if err := cursor.All(ctx, &list); err != nil {
+			return nil, err
+		}
+		merged = append(merged, list...)
+	}
+
+	chain(merged, older.ID, newer.ID, note)
+
+	collection := config.GetDocumentVersionsCollection(client)
+	previous := make([]primitive.ObjectID, len(merged))
+	rows := make([]interface{}, len(merged))
+	staged := make([]primitive.ObjectID, len(merged))
+	for i := range merged {
+		previous[i] = merged[i].ID
+		merged[i].ID = primitive.NewObjectID()
+		staged[i] = merged[i].ID
+		row := merged[i]
+		row.Version = -row.Version
+		rows[i] = row
+	}
+	if _, err := collection.InsertMany(ctx, rows); err != nil {
+		removeStaged(collection, staged)
+		return nil, err
+	}
+	if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": previous}}); err != nil {
+		// Staged versions are the only copy of any version already deleted
+		countCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if kept, countErr := collection.CountDocuments(countCtx, bson.M{"_id": bson.M{"$in": previous}}); countErr == nil && int(kept) == len(previous) {
+			removeStaged(collection, staged)
+		} else {
+			log.Printf("Warning: Versions of document %s are left under negative numbers after a failed link", newer.ID.Hex())
+		}
+		return nil, err
+	}
+	if _, err := collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": staged}}, bson.M{"$mul": bson.M{"version": -1}}); err != nil {
+		return nil, err
+	}
+
+	_, err := config.GetMetadataCollection(client).UpdateOne(ctx, bson.M{"_id": older.ID}, bson.M{"$set": bson.M{"guncel_surum_id": newer.ID}})
+	return merged, err
+}
+
+// chain renumbers the merged versions of older followed by those of newer as
+// one history of newer, each version ending where the next takes effect. The
+// first version of newer that follows older is given note if it has none.
+func chain(merged []models.DocumentVersion, older, newer primitive.ObjectID, note string) {
+	for i := range merged {
+		merged[i].DocumentID = newer
+		merged[i].Version = i + 1
+		merged[i].ValidTo = nil
+		if i == 0 {
+			continue
+		}
+		// A version never takes effect before the one it replaces
+		if merged[i].ValidFrom.Before(merged[i-1].ValidFrom) {
+			merged[i].ValidFrom = merged[i-1].ValidFrom
+		}
+		validTo := merged[i].ValidFrom
+		merged[i-1].ValidTo = &validTo
+		if merged[i].SourceID == newer && merged[i-1].SourceID == older && merged[i].Note == "" {
+			merged[i].Note = note
+		}
+	}
+}
+
+// removeStaged deletes the versions Link staged before it failed. It runs
+// on its own context, as the failure may be the expiry of the caller's.
+func removeStaged(collection *mongo.Collection, ids []primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		log.Printf("Warning: Failed to remove staged versions: %v", err)
+	}
+}
+
+// CaptureCurrent loads the content of a document and captures it
+func CaptureCurrent(ctx context.Context, client *mongo.Client, metadata models.DocumentMetadata) error {
+	var content models.DocumentContent
+	err := config.GetContentCollection(client).FindOne(ctx, bson.M{"metadata_id": metadata.ID}).Decode(&content)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	_, _, err = Capture(ctx, client, metadata, content, "")
+	return err
+}
+
+// Snapshot captures every document whose content changed since its latest
+// version, giving documents without history their first version
+func Snapshot(ctx context.Context, client *mongo.Client) error {
+	cursor, err := config.GetMetadataCollection(client).Find(ctx, bson.M{"guncel_surum_id": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	captured := 0
+	for cursor.Next(ctx) {
+		var metadata models.DocumentMetadata
+		if err := cursor.Decode(&metadata); err != nil {
+			continue
+		}
+		var content models.DocumentContent
+		err := config.GetContentCollection(client).FindOne(ctx, bson.M{"metadata_id": metadata.ID}).Decode(&content)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, created, err := Capture(ctx, client, metadata, content, "")
+		if err != nil {
+			return err
+		}
+		if created {
+			captured++
+		}
+	}
+	if captured > 0 {
+		log.Printf("Document versions: %d snapshots captured", captured)
+	}
+	return cursor.Err()
+}
+
+// StartSnapshotter periodically captures documents changed outside the API
+func StartSnapshotter(client *mongo.Client, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := Snapshot(ctx, client); err != nil {
+				log.Printf("Warning: Document version snapshot failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+func newVersion(metadata models.DocumentMetadata, content models.DocumentContent, note string, now time.Time) models.DocumentVersion {
+	return models.DocumentVersion{
+		ID:               primitive.NewObjectID(),
+		SourceID:         metadata.ID,
+		PdfAdi:           metadata.PdfAdi,
+		BelgeDurumu:      metadata.BelgeDurumu,
+		BelgeYayinTarihi: metadata.BelgeYayinTarihi,
+		Icerik:           content.Icerik,
+		ContentHash:      utils.ContentHash(content.Icerik),
+		Note:             note,
+		CreatedAt:        now,
+	}
+}
+
+// firstValidFrom is when the first known version of a document took effect
+func firstValidFrom(metadata models.DocumentMetadata, now time.Time) time.Time {
+	for _, date := range []*time.Time{metadata.BelgeYayinDate, metadata.YuklemeDate, metadata.OlusturulmaDate} {
+		if date != nil && date.Before(now) {
+			return *date
+		}
+	}
+	if t, ok := utils.ParseDate(metadata.BelgeYayinTarihi); ok && t.Before(now) {
+		return t
+	}
+	return now
+}
@@ -0,0 +1,72 @@
+package versions
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"legal-documents-api/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFirstValidFrom(t *testing.T) {
+	now := date(2025, time.June, 1)
+	published, uploaded, created := date(2020, time.March, 5), date(2021, time.April, 6), date(2022, time.May, 7)
+	future := date(2026, time.January, 1)
+
+	tests := []struct {
+		name     string
+		metadata models.DocumentMetadata
+		want     time.Time
+	}{
+		{"publication date first", models.DocumentMetadata{BelgeYayinDate: &published, YuklemeDate: &uploaded, OlusturulmaDate: &created}, published},
+		{"upload date without a publication date", models.DocumentMetadata{YuklemeDate: &uploaded, OlusturulmaDate: &created}, uploaded},
+		{"creation date last", models.DocumentMetadata{OlusturulmaDate: &created}, created},
+		{"future dates are skipped", models.DocumentMetadata{BelgeYayinDate: &future, OlusturulmaDate: &created}, created},
+		{"string publication date", models.DocumentMetadata{BelgeYayinTarihi: "23.09.2024"}, date(2024, time.September, 23)},
+		{"future string date", models.DocumentMetadata{BelgeYayinTarihi: "23.09.2030"}, now},
+		{"no dates", models.DocumentMetadata{BelgeYayinTarihi: "belirsiz"}, now},
+	}
+	for _, tt := range tests {
+		if got := firstValidFrom(tt.metadata, now); !got.Equal(tt.want) {
+			t.Errorf("%s: firstValidFrom = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	older, newer := primitive.NewObjectID(), primitive.NewObjectID()
+	merged := []models.DocumentVersion{
+		{SourceID: older, ValidFrom: date(2020, time.January, 1)},
+		{SourceID: older, ValidFrom: date(2022, time.January, 1), Note: "Değişiklik"},
+		// The new record was published before the old one last changed
+		{SourceID: newer, ValidFrom: date(2021, time.January, 1)},
+		{SourceID: newer, ValidFrom: date(2024, time.January, 1)},
+	}
+	chain(merged, older, newer, "Yeni yönetmelik")
+
+	wantFrom := []time.Time{date(2020, time.January, 1), date(2022, time.January, 1), date(2022, time.January, 1), date(2024, time.January, 1)}
+	wantNotes := []string{"", "Değişiklik", "Yeni yönetmelik", ""}
+	for i, v := range merged {
+		if v.DocumentID != newer || v.Version != i+1 {
+			t.Errorf("version %d: document %s number %d, want %s %d", i, v.DocumentID.Hex(), v.Version, newer.Hex(), i+1)
+		}
+		if !v.ValidFrom.Equal(wantFrom[i]) {
+			t.Errorf("version %d: valid_from %v, want %v", i+1, v.ValidFrom, wantFrom[i])
+		}
+		if v.Note != wantNotes[i] {
+			t.Errorf("version %d: note %q, want %q", i+1, v.Note, wantNotes[i])
+		}
+		if i+1 < len(merged) {
+			if v.ValidTo == nil || !v.ValidTo.Equal(merged[i+1].ValidFrom) {
+				t.Errorf("version %d: valid_to %v, want %v", i+1, v.ValidTo, merged[i+1].ValidFrom)
+			}
+		} else if v.ValidTo != nil {
+			t.Errorf("last version: valid_to %v, want none", v.ValidTo)
+		}
+	}
+}